@@ -2,15 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime/debug"
+	"strings"
 	"syscall"
 
 	"customvpn/client/internal/app"
 	"customvpn/client/internal/config"
+	"customvpn/client/internal/i18n"
 	"customvpn/client/internal/logging"
 )
 
@@ -29,15 +32,35 @@ func run() error {
 	}
 	defaultConfig := config.DefaultPath(appDir)
 	configPath := flag.String("config", defaultConfig, "path to config.yaml")
+	headless := flag.Bool("headless", false, "log in and connect automatically without windows (tray only)")
+	cleanup := flag.Bool("cleanup", false, "remove routes, kill switch rules and DNS settings left by the client, then exit")
 	flag.Parse()
 
 	cfg, err := config.Load(*configPath, appDir)
 	if err != nil {
 		return err
 	}
+	i18n.SetLanguage(cfg.Language)
+	// второй экземпляр менял бы те же маршруты и брандмауэр: просим первый показать окно и выходим
+	lock, err := app.AcquireSingleInstanceLock(cfg.AppDir)
+	if errors.Is(err, app.ErrAlreadyRunning) {
+		if !*cleanup {
+			if activateErr := app.ActivateRunningInstance(cfg.AppDir); activateErr != nil {
+				fmt.Fprintf(os.Stderr, "show running instance: %v\n", activateErr)
+			}
+		}
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("single instance lock: %w", err)
+	}
+	defer lock.Release()
 
 	logLevel := logging.ParseLevel(cfg.LogLevel)
-	logger, err := logging.New(cfg.LogFile, logLevel)
+	logger, err := logging.New(cfg.LogFile, logLevel, logging.Options{
+		MaxSizeBytes: int64(cfg.LogMaxSizeMB) * 1024 * 1024,
+		MaxBackups:   cfg.LogMaxBackups,
+	})
 	if err != nil {
 		return fmt.Errorf("initialize logger: %w", err)
 	}
@@ -53,18 +76,47 @@ func run() error {
 	ctx, stop := signal.NotifyContext(baseCtx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 	logger.Infof("CustomVPN client starting (config: %s)", *configPath)
+	if cfg.DataDir != cfg.AppDir {
+		logger.Infof("app directory %s is not writable, logs and state are kept in %s", cfg.AppDir, cfg.DataDir)
+	} else {
+		logger.Infof("data directory: %s", cfg.DataDir)
+	}
+	if cfg.Headless.PasswordInFile {
+		logger.Infof("warning: headless.password in %s is ignored, the password must not be stored in plain text: set %s or save credentials at login", *configPath, config.EnvHeadlessPassword)
+	}
 	logger.Debugf("core binary: %s", cfg.CorePath)
 	logger.Debugf("core log file: %s", cfg.CoreLogFile)
 
-	return startApp(ctx, cfg)
+	if *cleanup {
+		return runCleanup(cfg, logger)
+	}
+	return startApp(ctx, cfg, *configPath, appDir, lock, app.Options{Headless: *headless, NewUI: fyneUI(logger)})
+}
+
+// runCleanup снимает системные изменения клиента без окон; ошибка, если какой-то шаг не удался.
+func runCleanup(cfg *config.Config, logger *logging.Logger) error {
+	logger.Infof("cleanup mode: removing system changes")
+	application, err := app.New(cfg, logger, app.Options{})
+	if err != nil {
+		return err
+	}
+	result := application.Cleanup()
+	logger.Infof("cleanup summary: routes removed=%d, firewall rules removed=%d, errors=%d", result.RoutesRemoved, result.FirewallRulesRemoved, len(result.Errors))
+	if len(result.Errors) > 0 {
+		for _, msg := range result.Errors {
+			logger.Errorf("cleanup error: %s", msg)
+		}
+		return fmt.Errorf("cleanup finished with %d errors: %s", len(result.Errors), strings.Join(result.Errors, "; "))
+	}
+	return nil
 }
 
-func startApp(ctx context.Context, cfg *config.Config) error {
+func startApp(ctx context.Context, cfg *config.Config, configPath, appDir string, lock *app.InstanceLock, opts app.Options) error {
 	logger, ok := logging.FromContext(ctx)
 	if !ok {
 		return fmt.Errorf("logger not found in context")
 	}
-	application, err := app.New(cfg, logger)
+	application, err := app.New(cfg, logger, opts)
 	if err != nil {
 		return err
 	}
@@ -72,7 +124,34 @@ func startApp(ctx context.Context, cfg *config.Config) error {
 		return err
 	}
 	logger.Infof("state machine launched, entering UI loop")
-	done := make(chan struct{})
+	if err := lock.ListenActivation(func() { _ = application.ShowWindow() }); err != nil {
+		logger.Errorf("single instance activation unavailable: %v", err)
+	}
+	go watchConfigReload(ctx, configPath, func() {
+		reloaded, err := config.Load(configPath, appDir)
+		if err != nil {
+			logger.Errorf("config reload failed: %v", err)
+			return
+		}
+		logger.Infof("config reloaded from %s", configPath)
+		application.ApplyConfig(reloaded)
+	})
+	runUntilStopped(ctx, logger, application)
+	return nil
+}
+
+// uiRunner — часть Application, участвующая в остановке.
+type uiRunner interface {
+	RunUILoop()
+	Stop()
+	Done() <-chan struct{}
+}
+
+// runUntilStopped крутит цикл UI на главной goroutine и возвращается после полной остановки.
+// Сигнал ОС вызывает Stop, который закрывает окна и завершает цикл UI; выход из цикла
+// по другой причине тоже заканчивается Stop. Stop выполняется один раз, поэтому порядок
+// этих путей не важен, а ожидание Done не зависит от того, какой из них сработал первым.
+func runUntilStopped(ctx context.Context, logger *logging.Logger, application uiRunner) {
 	go func() {
 		select {
 		case <-ctx.Done():
@@ -81,11 +160,9 @@ func startApp(ctx context.Context, cfg *config.Config) error {
 		case <-application.Done():
 			logger.Infof("application requested shutdown")
 		}
-		close(done)
 	}()
 	application.RunUILoop()
 	logger.Infof("UI loop exited, stopping application")
 	application.Stop()
-	<-done
-	return nil
+	<-application.Done()
 }
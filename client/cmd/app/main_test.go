@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/logging"
+)
+
+// fakeRunner повторяет контракт Application: RunUILoop блокируется до Stop,
+// Stop выполняется один раз и закрывает Done.
+type fakeRunner struct {
+	stopOnce sync.Once
+	quitOnce sync.Once
+	quit     chan struct{}
+	done     chan struct{}
+	stops    atomic.Int32
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{quit: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (r *fakeRunner) RunUILoop() {
+	<-r.quit
+}
+
+func (r *fakeRunner) Stop() {
+	r.stops.Add(1)
+	r.stopOnce.Do(func() {
+		r.quitOnce.Do(func() { close(r.quit) })
+		close(r.done)
+	})
+}
+
+func (r *fakeRunner) Done() <-chan struct{} {
+	return r.done
+}
+
+// exitLoop имитирует выход из цикла Fyne без Stop (Quit трея).
+func (r *fakeRunner) exitLoop() {
+	r.quitOnce.Do(func() { close(r.quit) })
+}
+
+func newMainTestLogger(t *testing.T) *logging.Logger {
+	t.Helper()
+	logger, err := logging.New(filepath.Join(t.TempDir(), "client.log"), logging.LevelDebug, logging.Options{})
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	t.Cleanup(func() { _ = logger.Close() })
+	return logger
+}
+
+func runUntilStoppedAsync(ctx context.Context, logger *logging.Logger, runner uiRunner) <-chan struct{} {
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		runUntilStopped(ctx, logger, runner)
+	}()
+	return returned
+}
+
+func TestRunUntilStoppedOnSignal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := newFakeRunner()
+	returned := runUntilStoppedAsync(ctx, newMainTestLogger(t), runner)
+
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("runUntilStopped did not return after signal")
+	}
+	// Stop из goroutine сигнала и после выхода из цикла UI
+	if got := runner.stops.Load(); got != 2 {
+		t.Fatalf("Stop called %d times, want 2", got)
+	}
+}
+
+func TestRunUntilStoppedOnUILoopExit(t *testing.T) {
+	runner := newFakeRunner()
+	returned := runUntilStoppedAsync(context.Background(), newMainTestLogger(t), runner)
+
+	runner.exitLoop()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("runUntilStopped did not return after UI loop exit")
+	}
+	select {
+	case <-runner.Done():
+	default:
+		t.Fatalf("application is not stopped after UI loop exit")
+	}
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// configPollInterval — период проверки времени изменения config.yaml.
+const configPollInterval = 2 * time.Second
+
+// watchConfigReload вызывает reload, когда config.yaml изменяется на диске.
+// На Windows нет SIGHUP, поэтому файл опрашивается по времени изменения.
+func watchConfigReload(ctx context.Context, path string, reload func()) {
+	lastMod := modTime(path)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		mod := modTime(path)
+		if mod.IsZero() || mod.Equal(lastMod) {
+			continue
+		}
+		lastMod = mod
+		reload()
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"customvpn/client/internal/app"
+	"customvpn/client/internal/logging"
+	"customvpn/client/internal/ui"
+)
+
+var _ app.UI = (*ui.Manager)(nil)
+
+// fyneUI возвращает фабрику окон Fyne для app.Options.NewUI.
+func fyneUI(logger *logging.Logger) app.NewUIFunc {
+	return func(hooks app.UIHooks) app.UI {
+		return ui.NewManager(ui.Options{
+			AppID:              "customvpn.client",
+			AppName:            "CustomVPN",
+			Logger:             logger,
+			Dispatch:           hooks.Dispatch,
+			SaveSettings:       hooks.SaveSettings,
+			Theme:              hooks.Theme,
+			CoreLogFile:        hooks.CoreLogFile,
+			ConfirmDisconnect:  hooks.ConfirmDisconnect,
+			CollectDiagnostics: hooks.CollectDiagnostics,
+			Headless:           hooks.Headless,
+			PreviewConnect:     hooks.PreviewConnect,
+			CoreConfig:         hooks.CoreConfig,
+			ExportConnectPlan:  hooks.ExportConnectPlan,
+			Favorites:          hooks.Favorites,
+			SaveFavorites:      hooks.SaveFavorites,
+			SessionHistory:     hooks.SessionHistory,
+		})
+	}
+}
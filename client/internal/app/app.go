@@ -2,85 +2,203 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"customvpn/client/internal/config"
 	"customvpn/client/internal/controlclient"
+	"customvpn/client/internal/diagnostics"
 	"customvpn/client/internal/dns"
 	"customvpn/client/internal/firewall"
 	"customvpn/client/internal/logging"
+	"customvpn/client/internal/netstats"
 	"customvpn/client/internal/process"
 	"customvpn/client/internal/routes"
 	"customvpn/client/internal/state"
-	"customvpn/client/internal/ui"
 )
 
 // Application связывает state machine и контрольный сервер.
 type Application struct {
 	cfg        *config.Config
 	logger     *logging.Logger
+	// auditLog — журнал аудита audit.log; nil, если его не удалось открыть.
+	auditLog   *logging.Logger
 	control    *controlclient.Client
 	machine    *state.Machine
 	ctx        *state.AppContext
 	routes     *routes.Manager
-	firewall   *firewall.Manager
+	// gateways определяет шлюзы системы; в тестах заменяется фейком.
+	gateways   GatewayDetector
+	// firewall управляет правилами Kill Switch; в тестах заменяется фейком.
+	firewall   Firewall
 	dns        *dns.Manager
 	launcher   *process.Launcher
 	controlIP4 net.IP
-	ui         *ui.Manager
+	ui         UI
+	// current — последнее состояние state machine для State(); пишется из OnStateChanged.
+	current    atomic.Value
 	cleanupOnce sync.Once
 	shutdown   chan struct{}
 	runCtx     context.Context
 	runCancel  context.CancelFunc
 	stopOnce   sync.Once
+	connectMu     sync.Mutex
+	connectCtx    context.Context
+	connectCancel context.CancelFunc
+	// connectDone закрывается по завершении сценария подключения; connectClosed запрещает новые (Stop).
+	connectDone   chan struct{}
+	connectClosed bool
+	// teardownCtx — контекст текущего шага выхода (см. runExitCleanup); защищён connectMu.
+	teardownCtx context.Context
+	coreLog    *coreLogThrottle
+	credsMu    sync.Mutex
+	login      string
+	password   string
+	// staleRoutes — маршруты из routes.json, оставшиеся после предыдущего запуска.
+	staleRoutes []state.RouteRecord
+	headless    headlessRun
+	history     *sessionHistory
+}
+
+// Options задаёт параметры запуска Application.
+type Options struct {
+	// Headless — запуск без окон: вход и подключение выполняются автоматически, доступен только трей.
+	Headless bool
+	// NewUI создаёт UI приложения; nil — NoopUI без окон (управление через Login/Connect/Disconnect).
+	NewUI NewUIFunc
+	// ControlDoer подменяет HTTP-транспорт запросов к Control-серверу (тесты); nil — обычный клиент
+	// с TLS и прокси из config.yaml.
+	ControlDoer controlclient.Doer
 }
 
 // New создаёт Application и настраивает state machine callbacks.
-func New(cfg *config.Config, logger *logging.Logger) (*Application, error) {
+func New(cfg *config.Config, logger *logging.Logger, opts Options) (*Application, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config is nil")
 	}
 	if logger == nil {
 		return nil, fmt.Errorf("logger is nil")
 	}
-	client, err := controlclient.New(cfg.ControlServerURL, controlclient.Options{Logger: logger})
+	stateCtx := state.NewAppContext(cfg)
+	// маршруты прошлого запуска попадают в реестр до включения записи, чтобы файл их не потерял
+	routesPath := cfg.RoutesPath()
+	staleRoutes, err := state.LoadPersistedRoutes(routesPath)
 	if err != nil {
-		return nil, fmt.Errorf("init control client: %w", err)
+		logger.Errorf("load persisted routes failed: %v", err)
+	}
+	for _, record := range staleRoutes {
+		stateCtx.RoutesRegistry.Upsert(record)
+	}
+	if err := stateCtx.RoutesRegistry.EnablePersistence(routesPath, func(err error) {
+		logger.Errorf("persist routes failed: %v", err)
+	}); err != nil {
+		logger.Errorf("persist routes failed: %v", err)
 	}
-	stateCtx := state.NewAppContext(cfg)
 	runCtx, runCancel := context.WithCancel(context.Background())
 	app := &Application{
 		cfg:      cfg,
 		logger:   logger,
-		control:  client,
+		auditLog: openAuditLog(cfg, logger),
 		ctx:      stateCtx,
-		routes:   routes.NewManager(logger),
+		routes:   routes.NewManager(logger, routes.Options{VerifyRoutes: cfg.VerifyRoutes}),
+		gateways: systemGateways{},
 		firewall: firewall.NewManager(logger),
 		dns:      dns.NewManager(logger),
 		launcher: process.NewLauncher(logger),
 		shutdown: make(chan struct{}),
 		runCtx:   runCtx,
 		runCancel: runCancel,
+		staleRoutes: staleRoutes,
+	}
+	tlsConfig, err := loadControlTLSConfig(cfg.ControlServerCAFile)
+	if err != nil {
+		runCancel()
+		return nil, fmt.Errorf("init control client: %w", err)
+	}
+	controlOpts := controlclient.Options{
+		Logger:           logger,
+		TLSConfig:        tlsConfig,
+		PinnedCertSHA256: cfg.ControlServerCertSHA256,
+		ProxyURL:         cfg.ControlProxyURL,
+		NoEnvProxy:       !cfg.UseEnvProxyEnabled(),
+		ReauthFunc:       app.reauthenticate,
+		OnTokenRefreshed: app.onTokenRefreshed,
+		Timeouts: controlclient.Timeouts{
+			Health:  cfg.ControlTimeouts.Health,
+			Auth:    cfg.ControlTimeouts.Auth,
+			Sync:    cfg.ControlTimeouts.Sync,
+			Profile: cfg.ControlTimeouts.Profile,
+		},
+		Retry: controlclient.RetryPolicy{
+			Attempts: cfg.ControlRetry.Attempts,
+			Backoff:  cfg.ControlRetry.Backoff,
+		},
 	}
+	var client *controlclient.Client
+	if opts.ControlDoer != nil {
+		client, err = controlclient.NewWithDoer(cfg.ControlServerURL, opts.ControlDoer, controlOpts)
+	} else {
+		client, err = controlclient.New(cfg.ControlServerURL, controlOpts)
+	}
+	if err != nil {
+		runCancel()
+		return nil, fmt.Errorf("init control client: %w", err)
+	}
+	app.control = client
 	app.launcher.SetExitCallback(app.onProcessExit)
-	uiManager := ui.NewManager(ui.Options{
-		AppID:    "customvpn.client",
-		AppName:  "CustomVPN",
-		Logger:   logger,
-		Dispatch: app.dispatch,
-	})
+	app.coreLog = newCoreLogThrottle(coreLogInterval, app.sendCoreLog)
+	var previewConnect, coreConfig func() (string, error)
+	if logger.Level() == logging.LevelDebug {
+		// предпросмотр подключения и выгрузка конфигурации Core — отладочные действия,
+		// видны только при log_level: debug
+		previewConnect = app.previewConnectText
+		coreConfig = app.selectedCoreConfigText
+	}
+	favorites, err := loadFavorites(cfg.FavoritesPath())
+	if err != nil {
+		logger.Errorf("load favorites failed: %v", err)
+	}
+	app.history, err = loadSessionHistory(cfg.HistoryPath())
+	if err != nil {
+		logger.Errorf("load session history failed: %v", err)
+	}
+	var uiManager UI = NewNoopUI()
+	if opts.NewUI != nil {
+		uiManager = opts.NewUI(UIHooks{
+			Dispatch:           app.dispatch,
+			SaveSettings:       app.saveSettings,
+			Theme:              cfg.Theme,
+			CoreLogFile:        cfg.CoreLogFile,
+			ConfirmDisconnect:  cfg.ConfirmDisconnectEnabled(),
+			CollectDiagnostics: app.collectDiagnostics,
+			Headless:           opts.Headless,
+			PreviewConnect:     previewConnect,
+			CoreConfig:         coreConfig,
+			ExportConnectPlan:  app.connectPlanJSON,
+			Favorites:          favorites,
+			SaveFavorites:      app.saveFavoriteProfiles,
+			SessionHistory:     app.history.snapshot,
+		})
+	}
 	uiManager.SetOnStopped(app.onAppStopped)
 	app.ui = uiManager
+	app.current.Store(stateCtx.State)
 	callbacks := state.Callbacks{
 		StartPreflight:      app.startPreflight,
+		TestConnection:      app.testConnection,
 		StartAuth:           app.startAuth,
 		StartSync:           app.startSync,
 		StartPrepareEnv:     app.startPrepareEnv,
 		StartConnecting:     app.startConnecting,
 		StartDisconnecting:  app.startDisconnecting,
+		CancelConnecting:    app.cancelConnecting,
 		ForceCleanup:        app.forceCleanup,
 		CleanupAndExit:      app.cleanupAndExit,
 		ShowLoginWindow:     uiManager.ShowLoginWindow,
@@ -91,6 +209,26 @@ func New(cfg *config.Config, logger *logging.Logger) (*Application, error) {
 		ShowTransientNotice: uiManager.ShowTransientNotice,
 		ShowCleanupStarted:  uiManager.ShowCleanupStarted,
 		ShowCleanupDone:     uiManager.ShowCleanupDone,
+		ShowSettings:        uiManager.ShowSettings,
+		RememberLogin:       app.rememberLogin,
+		DetectGateways:      app.gateways.DefaultGateways,
+		ReadInterfaceCounters: netstats.Read,
+		StoreCredentials:    app.storeCredentials,
+		OnStateChanged:      app.onStateChanged,
+		ProbeTunnel:         app.probeTunnel,
+		OnSessionStarted:    app.onSessionStarted,
+		OnSessionEnded:      app.onSessionEnded,
+	}
+	if cfg.RememberLoginEnabled() {
+		login, err := loadLastLogin(cfg.LastLoginPath())
+		if err != nil {
+			logger.Errorf("load remembered login failed: %v", err)
+		}
+		stateCtx.UI.LoginInput = login
+	}
+	app.loadStoredCredentials(stateCtx)
+	if opts.Headless {
+		app.enableHeadless(&callbacks)
 	}
 	app.machine = state.NewMachine(stateCtx, logger, callbacks)
 	return app, nil
@@ -105,10 +243,22 @@ func (a *Application) Run() error {
 		a.ui.Start()
 		a.ui.UpdateUI(a.ctx)
 	}
+	go a.logCoreVersion()
+	a.reconcileStaleRoutes()
 	a.machine.Start()
 	return a.dispatch(state.Event{Type: state.EventUILaunch, TS: time.Now()})
 }
 
+// Cleanup выполняет ту же очистку, что кнопка «Починка», без state machine и UI (флаг --cleanup)
+// и останавливает Application. Вызывается вместо Run.
+func (a *Application) Cleanup() state.CleanupResultPayload {
+	var result state.CleanupResultPayload
+	// teardown выхода не нужен: очистка уже сняла всё, что он снимает
+	a.cleanupOnce.Do(func() { result = a.cleanup(a.ctx) })
+	a.Stop()
+	return result
+}
+
 // RunUILoop запускает главный цикл Fyne и блокирует вызывающую горутину до выхода.
 func (a *Application) RunUILoop() {
 	if a.ui == nil {
@@ -117,13 +267,22 @@ func (a *Application) RunUILoop() {
 	a.ui.RunMainLoop()
 }
 
-// Stop останавливает state machine.
+// Stop снимает сетевые настройки сессии, затем останавливает Core, UI и state machine.
+// Teardown выполняется синхронно и не более одного раза (см. cleanupOnce), даже если выход
+// уже запущен событием Exit или остановкой Fyne. Вызов безопасен из любой goroutine, кроме
+// главного потока UI: повторные вызовы ждут первого, а все ожидания внутри ограничены по времени.
 func (a *Application) Stop() {
 	a.stopOnce.Do(func() {
+		// сценарий подключения прерываем и ждём его отката, чтобы он не менял маршруты во время teardown
+		if !a.closeConnect(exitConnectWaitTimeout) && a.logger != nil {
+			a.logger.Errorf("connecting scenario did not finish rollback before timeout")
+		}
+		a.cleanupOnce.Do(func() { a.runExitCleanup() })
 		if a.runCancel != nil {
 			a.runCancel()
 		}
 		if a.launcher != nil {
+			// Core уже остановлен teardown; повтор — страховка на случай его сбоя
 			_ = a.launcher.Stop(state.ProcessCore, 2*time.Second)
 		}
 		if a.ui != nil {
@@ -138,10 +297,17 @@ func (a *Application) Stop() {
 				a.logger.Errorf("state machine background tasks did not finish before timeout")
 			}
 		}
+		_ = a.auditLog.Close()
 		close(a.shutdown)
 	})
 }
 
+// onStateChanged запоминает состояние для State() и передаёт смену в UI.
+func (a *Application) onStateChanged(prev, next state.State) {
+	a.current.Store(next)
+	a.ui.OnStateChanged(prev, next)
+}
+
 func (a *Application) dispatch(evt state.Event) error {
 	if err := a.machine.Dispatch(evt); err != nil {
 		a.logger.Errorf("dispatch %s failed: %v", evt.Type, err)
@@ -188,25 +354,172 @@ func (a *Application) onProcessExit(name state.ProcessName, exitCode int, reason
 	}
 }
 
+// onAppStopped вызывается Fyne после выхода из цикла UI (в том числе по пункту Quit трея).
+// Stop здесь безопасен: цикл UI уже не ждёт, а параллельный вызов из main дождётся этого.
 func (a *Application) onAppStopped() {
-	a.cleanupOnce.Do(func() { a.runExitCleanup() })
+	a.Stop()
 }
 
+// runExitCleanup выполняет упорядоченный teardown при выходе: маршруты, Kill Switch, DNS и
+// только затем Core, чтобы маршруты не указывали на остановленный туннель. Ошибка шага
+// логируется и не прерывает следующие шаги.
 func (a *Application) runExitCleanup() {
 	if a.ctx == nil {
 		return
 	}
-	if err := a.executeDisconnecting(a.ctx); err != nil {
-		a.logger.Errorf("exit cleanup failed: %v", err)
-	}
-	if a.firewall != nil {
-		firewallCtx, cancel := a.requestContext(routeOpTimeout)
-		if err := a.firewall.RemoveKillSwitchGroup(firewallCtx); err != nil {
-			a.logger.Errorf("exit cleanup firewall group failed: %v", err)
+	a.logger.Infof("exit teardown started")
+	// хук нужен только если сессия успела поднять маршруты; откат подключения их уже снял
+	connected := len(a.ctx.RoutesRegistry.ListByKinds(state.RouteKindDirect, state.RouteKindTunnel)) > 0
+	_ = a.teardownSession(a.ctx, connected, a.exitStep)
+	_ = a.exitStep("kill switch group", exitStepTimeout, func() error {
+		if a.firewall == nil {
+			return nil
 		}
+		firewallCtx, cancel := a.requestContext(routeOpTimeout)
+		defer cancel()
+		_, err := a.firewall.RemoveKillSwitchGroup(firewallCtx)
+		return err
+	})
+	a.logout()
+	_ = a.deleteCleanupState()
+	a.logger.Infof("exit teardown finished")
+}
+
+// exitStep выполняет шаг teardown с собственным таймаутом, независимым от контекста приложения.
+func (a *Application) exitStep(name string, timeout time.Duration, fn func() error) error {
+	stepCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	a.connectMu.Lock()
+	a.teardownCtx = stepCtx
+	a.connectMu.Unlock()
+	defer func() {
+		a.connectMu.Lock()
+		a.teardownCtx = nil
+		a.connectMu.Unlock()
 		cancel()
+	}()
+	start := time.Now()
+	a.logger.Debugf("exit teardown step %s started", name)
+	if err := fn(); err != nil {
+		a.logger.Errorf("exit teardown step %s failed after %s: %v", name, time.Since(start).Round(time.Millisecond), err)
+		return err
 	}
-	_ = a.deleteCleanupState()
+	a.logger.Infof("exit teardown step %s done in %s", name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// logout аннулирует токен на Control-сервере. Ошибка только логируется:
+// выход из приложения не должен зависеть от доступности сервера.
+func (a *Application) logout() {
+	if a.control == nil || a.ctx == nil {
+		return
+	}
+	token := strings.TrimSpace(a.ctx.AuthToken())
+	if token == "" {
+		return
+	}
+	// контекст приложения к этому моменту может быть уже отменён
+	logoutCtx, cancel := context.WithTimeout(context.Background(), logoutTimeout)
+	defer cancel()
+	if err := a.control.Logout(logoutCtx, token); err != nil {
+		a.logger.Errorf("logout failed: %v", err)
+		a.audit(auditLogout, "result", "failure")
+		return
+	}
+	a.logger.Infof("logged out from control server")
+	a.audit(auditLogout, "result", "success")
+}
+
+// ApplyConfig применяет перечитанный config.yaml без перезапуска: уровень логов и
+// подтверждение разрыва подключения. Остальные изменения требуют перезапуска и только логируются.
+func (a *Application) ApplyConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	level := logging.ParseLevel(cfg.LogLevel)
+	if level != a.logger.Level() {
+		a.logger.SetLevel(level)
+		a.logger.Infof("log level changed to %s", level)
+	}
+	if a.ui != nil {
+		a.ui.SetConfirmDisconnect(cfg.ConfirmDisconnectEnabled())
+	}
+	if changed := restartRequiredChanges(a.cfg, cfg); len(changed) > 0 {
+		a.logger.Infof("config changes take effect after restart: %s", strings.Join(changed, ", "))
+	}
+}
+
+// restartRequiredChanges перечисляет изменённые ключи, которые применяются только при запуске.
+func restartRequiredChanges(current, next *config.Config) []string {
+	var changed []string
+	if current.ControlServerURL != next.ControlServerURL {
+		changed = append(changed, "control_server_url")
+	}
+	if current.ControlProxyURL != next.ControlProxyURL {
+		changed = append(changed, "control_proxy_url")
+	}
+	if current.UseEnvProxyEnabled() != next.UseEnvProxyEnabled() {
+		changed = append(changed, "use_env_proxy")
+	}
+	if current.CorePath != next.CorePath {
+		changed = append(changed, "core_path")
+	}
+	if current.LogFile != next.LogFile {
+		changed = append(changed, "log_file")
+	}
+	if current.EnableIPv6 != next.EnableIPv6 {
+		changed = append(changed, "enable_ipv6")
+	}
+	if current.VerifyRoutes != next.VerifyRoutes {
+		changed = append(changed, "verify_routes")
+	}
+	if current.Headless != next.Headless {
+		changed = append(changed, "headless")
+	}
+	if current.Language != next.Language {
+		changed = append(changed, "language")
+	}
+	return changed
+}
+
+// collectDiagnostics собирает архив диагностики и показывает его в файловом менеджере.
+func (a *Application) collectDiagnostics() (string, error) {
+	path, err := diagnostics.Collect(a.ctx, a.cfg)
+	if err != nil {
+		a.logger.Errorf("collect diagnostics failed: %v", err)
+		return "", err
+	}
+	a.logger.Infof("diagnostics bundle written to %s", path)
+	if err := diagnostics.Reveal(path); err != nil {
+		a.logger.Errorf("reveal diagnostics bundle failed: %v", err)
+	}
+	return path, nil
+}
+
+// saveSettings проверяет и записывает пользовательские настройки в config.yaml.
+// Изменения вступают в силу после перезапуска приложения.
+func (a *Application) saveSettings(settings config.Settings) error {
+	if err := config.Save(a.cfg.Path, a.cfg.AppDir, settings); err != nil {
+		a.logger.Errorf("save settings failed: %v", err)
+		return err
+	}
+	a.logger.Infof("settings saved to %s", a.cfg.Path)
+	return nil
+}
+
+// loadControlTLSConfig строит TLS-конфигурацию с корнями из PEM-файла частного CA.
+func loadControlTLSConfig(caFile string) (*tls.Config, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read control server CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("control server CA %s contains no certificates", caFile)
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
 }
 
 func intPtr(v int) *int {
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/controlclient"
+	"customvpn/client/internal/state"
+)
+
+const testWaitTimeout = 5 * time.Second
+
+// fakeControl — Control-сервер в памяти для Options.ControlDoer: запросы обрабатывает
+// handler без сети, число запросов считается по пути.
+type fakeControl struct {
+	handler http.HandlerFunc
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newFakeControl(handler http.HandlerFunc) *fakeControl {
+	return &fakeControl{handler: handler, requests: make(map[string]int)}
+}
+
+func (f *fakeControl) Do(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.requests[req.URL.Path]++
+	f.mu.Unlock()
+	rec := httptest.NewRecorder()
+	f.handler(rec, req)
+	return rec.Result(), nil
+}
+
+func (f *fakeControl) count(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+// controlServer отвечает как рабочий Control-сервер с профилями profiles.
+func controlServer(token string, profiles ...controlclient.ProfileDTO) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" && r.URL.Path != "/auth" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/health":
+			_, _ = w.Write([]byte("OK"))
+		case r.URL.Path == "/auth":
+			var req controlclient.AuthRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(controlclient.AuthErrorDTO{Error: "bad_credentials"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(controlclient.AuthResponse{AuthToken: token})
+		case r.URL.Path == "/sync/profiles":
+			summaries := make([]controlclient.ProfileSummaryDTO, 0, len(profiles))
+			for _, p := range profiles {
+				summaries = append(summaries, controlclient.ProfileSummaryDTO{ID: p.ID, Name: p.Name, Country: p.Country})
+			}
+			_ = json.NewEncoder(w).Encode(summaries)
+		case strings.HasPrefix(r.URL.Path, "/profiles/"):
+			id := strings.TrimPrefix(r.URL.Path, "/profiles/")
+			for _, p := range profiles {
+				if p.ID == id {
+					_ = json.NewEncoder(w).Encode(p)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/logout":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+// newTestApplication создаёт Application из config.yaml во временном каталоге приложения
+// с Control-сервером control. extraYAML дописывается в конфигурацию.
+func newTestApplication(t *testing.T, control controlclient.Doer, extraYAML string) *Application {
+	t.Helper()
+	appDir := t.TempDir()
+	corePath := filepath.Join(appDir, "bin", "core")
+	if err := os.MkdirAll(filepath.Dir(corePath), 0o755); err != nil {
+		t.Fatalf("create bin: %v", err)
+	}
+	if err := os.WriteFile(corePath, []byte("#!/bin/sh\necho 'sing-box version 1.0.0'\n"), 0o755); err != nil {
+		t.Fatalf("write core: %v", err)
+	}
+	data := `control_server_url: "https://control.test"
+core_path: "bin/core"
+log_level: "debug"
+log_file: "logs/app.log"
+remember_login: false
+preflight_base_delay: "1ms"
+preflight_max_delay: "4ms"
+control_retry:
+  attempts: 1
+` + extraYAML
+	path := filepath.Join(appDir, "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.Load(path, appDir)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	a, err := New(cfg, newTestLogger(t), Options{ControlDoer: control})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// правила брандмауэра системы тесты не трогают
+	a.firewall = nil
+	t.Cleanup(a.Stop)
+	return a
+}
+
+func waitForState(t *testing.T, a *Application, want state.State) {
+	t.Helper()
+	deadline := time.Now().Add(testWaitTimeout)
+	for a.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state %s not reached, got %s", want, a.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
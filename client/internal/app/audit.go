@@ -0,0 +1,57 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/logging"
+)
+
+// Действия, которые пишутся в журнал аудита.
+const (
+	auditLogin      = "login"
+	auditLogout     = "logout"
+	auditConnect    = "connect"
+	auditDisconnect = "disconnect"
+	auditKillSwitch = "kill_switch"
+	auditCleanup    = "cleanup"
+)
+
+// openAuditLog открывает журнал аудита audit.log рядом с основным логом.
+// Журнал не обязателен: при ошибке приложение работает без него.
+// Журнал только дополняется: log_max_size_mb к нему не применяется, иначе ротация удаляла бы старые записи.
+func openAuditLog(cfg *config.Config, logger *logging.Logger) *logging.Logger {
+	audit, err := logging.New(cfg.AuditLogFile, logging.LevelInfo, logging.Options{})
+	if err != nil {
+		logger.Errorf("open audit log failed: %v", err)
+		return nil
+	}
+	return audit
+}
+
+// audit пишет в журнал аудита одну строку «action=... key=value ...».
+// Пароли и токены сюда не передаются: только логин, ID профиля, результат и счётчики.
+func (a *Application) audit(action string, fields ...string) {
+	if a.auditLog == nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("action=")
+	b.WriteString(action)
+	for i := 0; i+1 < len(fields); i += 2 {
+		b.WriteByte(' ')
+		b.WriteString(fields[i])
+		b.WriteByte('=')
+		b.WriteString(auditValue(fields[i+1]))
+	}
+	a.auditLog.Infof("%s", b.String())
+}
+
+// auditValue заключает значение в кавычки, если в нём есть пробелы или кавычки, чтобы строку можно было разобрать.
+func auditValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
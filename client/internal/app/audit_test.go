@@ -0,0 +1,41 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"customvpn/client/internal/config"
+)
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	cfg := &config.Config{AuditLogFile: path, LogMaxSizeMB: 1, LogMaxBackups: 1}
+	logger := newTestLogger(t)
+
+	// две сессии приложения: вторая дописывает журнал, а не начинает новый
+	for session := 0; session < 2; session++ {
+		a := &Application{cfg: cfg, logger: logger, auditLog: openAuditLog(cfg, logger)}
+		if a.auditLog == nil {
+			t.Fatalf("audit log was not opened")
+		}
+		// больше log_max_size_mb: основной лог уже ротировался бы
+		for i := 0; i < 12000; i++ {
+			a.audit(auditConnect, "result", "success", "profile", "profile-with-a-long-identifier")
+		}
+		_ = a.auditLog.Close()
+	}
+
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("audit log was rotated: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 24000 {
+		t.Fatalf("audit log has %d lines, want 24000", lines)
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// serverBindPlaceholder — плейсхолдер конфигурации Core для локального адреса исходящего сокета.
+const serverBindPlaceholder = "${SERVER_BIND_ADDRESS}"
+
+// resolveServerBindAddress возвращает IPv4-адрес физического интерфейса, через который достижим сервер.
+// Core привязывает к нему исходящий сокет: на машинах с несколькими интерфейсами (и при частично
+// поднятом туннеле) иначе соединение с сервером может уйти не через прямой маршрут.
+func (a *Application) resolveServerBindAddress(server string) (string, error) {
+	serverIP, err := a.resolveServerIPv4(server)
+	if err != nil {
+		return "", err
+	}
+	// сервер в локальной сети достижим напрямую, иначе — через шлюз по умолчанию
+	peer := serverIP
+	gateway, err := a.gateways.GatewayForIP(serverIP)
+	if err != nil {
+		defaults, defErr := a.gateways.DefaultGateways()
+		if defErr != nil {
+			return "", fmt.Errorf("detect default gateway: %w", defErr)
+		}
+		if len(defaults) == 0 || defaults[0] == nil {
+			return "", fmt.Errorf("default gateway not found")
+		}
+		peer = net.ParseIP(defaults[0].IP)
+		if peer == nil || peer.To4() == nil {
+			return "", fmt.Errorf("default gateway %q is not IPv4", defaults[0].IP)
+		}
+		gateway, err = a.gateways.GatewayForIP(peer)
+		if err != nil {
+			return "", fmt.Errorf("detect interface for gateway %s: %w", peer, err)
+		}
+	}
+	iface, err := net.InterfaceByIndex(gateway.InterfaceIndex)
+	if err != nil {
+		return "", fmt.Errorf("interface %s (index %d): %w", gateway.InterfaceName, gateway.InterfaceIndex, err)
+	}
+	bindIP, err := interfaceAddressFor(iface, peer)
+	if err != nil {
+		return "", err
+	}
+	if a.logger != nil {
+		a.logger.Infof("core bind address: server=%s via %s (%s) source=%s", serverIP, peer, iface.Name, bindIP)
+	}
+	return bindIP.String(), nil
+}
+
+// resolveServerIPv4 возвращает первый IPv4-адрес сервера.
+func (a *Application) resolveServerIPv4(server string) (net.IP, error) {
+	server = strings.TrimSpace(server)
+	if ip := net.ParseIP(server); ip != nil {
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("server %s is not IPv4", server)
+		}
+		return ip.To4(), nil
+	}
+	lookupCtx, cancel := a.requestContext(routeOpTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIP(lookupCtx, "ip4", server)
+	if err != nil {
+		return nil, fmt.Errorf("resolve server %s: %w", server, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("server %s has no IPv4 address", server)
+	}
+	return ips[0].To4(), nil
+}
+
+// resolveServerIPs возвращает все адреса сервера, IPv4 и IPv6: Core может подключиться к любому из них.
+func (a *Application) resolveServerIPs(server string) ([]string, error) {
+	server = strings.TrimSpace(server)
+	if ip := net.ParseIP(server); ip != nil {
+		return []string{ip.String()}, nil
+	}
+	lookupCtx, cancel := a.requestContext(routeOpTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIP(lookupCtx, "ip", server)
+	if err != nil {
+		return nil, fmt.Errorf("resolve server %s: %w", server, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("server %s has no addresses", server)
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	return addrs, nil
+}
+
+// interfaceAddressFor выбирает адрес интерфейса из одной подсети с peer.
+func interfaceAddressFor(iface *net.Interface, peer net.IP) (net.IP, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("list addresses of %s: %w", iface.Name, err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil {
+			continue
+		}
+		if ipNet.Contains(peer) {
+			return ipNet.IP.To4(), nil
+		}
+	}
+	return nil, fmt.Errorf("interface %s has no IPv4 address in the subnet of %s", iface.Name, peer)
+}
@@ -2,6 +2,27 @@
 
 package app
 
-import "os/exec"
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
 
 func applyCommandAttributes(_ *exec.Cmd) {}
+
+// shellCommand выполняет строку команды через /bin/sh.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// applyKillProcessTree запускает команду в отдельной группе процессов и при отмене
+// контекста завершает всю группу.
+func applyKillProcessTree(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
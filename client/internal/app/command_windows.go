@@ -3,10 +3,34 @@
 package app
 
 import (
+	"context"
 	"os/exec"
+	"strconv"
 	"syscall"
 )
 
 func applyCommandAttributes(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 }
+
+// shellCommand выполняет строку команды через cmd.exe без окна консоли.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	applyCommandAttributes(cmd)
+	// строка передаётся cmd.exe как есть: экранирование аргументов Go ломает кавычки внутри команды
+	cmd.SysProcAttr.CmdLine = `cmd.exe /S /C "` + command + `"`
+	return cmd
+}
+
+// applyKillProcessTree при отмене контекста завершает процесс вместе с дочерними через taskkill /T.
+// Атрибуты запуска (HideWindow) не меняются.
+func applyKillProcessTree(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		applyCommandAttributes(kill)
+		if err := kill.Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
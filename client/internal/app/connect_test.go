@@ -0,0 +1,80 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/state"
+)
+
+func TestBeginConnectDeadline(t *testing.T) {
+	cases := []struct {
+		name        string
+		timeout     time.Duration
+		hasDeadline bool
+	}{
+		{name: "with timeout", timeout: time.Minute, hasDeadline: true},
+		{name: "without timeout", timeout: 0, hasDeadline: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Application{cfg: &config.Config{ConnectTimeout: tc.timeout}, logger: newTestLogger(t)}
+			ctx, _ := a.beginConnect()
+			if _, ok := ctx.Deadline(); ok != tc.hasDeadline {
+				t.Fatalf("deadline set = %v, want %v", ok, tc.hasDeadline)
+			}
+			a.endConnect()
+			select {
+			case <-ctx.Done():
+			default:
+				t.Fatalf("endConnect did not cancel the connect context")
+			}
+		})
+	}
+}
+
+func TestStopWaitsForConnectRollback(t *testing.T) {
+	a := &Application{cfg: &config.Config{}, logger: newTestLogger(t), ui: NewNoopUI(), shutdown: make(chan struct{})}
+	connectCtx, done := a.beginConnect()
+	var rolledBack atomic.Bool
+	go func() {
+		<-connectCtx.Done()
+		// откат занимает время: Stop не должен начинать teardown раньше
+		time.Sleep(50 * time.Millisecond)
+		rolledBack.Store(true)
+		close(done)
+	}()
+
+	a.Stop()
+	if !rolledBack.Load() {
+		t.Fatalf("Stop returned before the connecting scenario finished its rollback")
+	}
+	if ctx, _ := a.beginConnect(); ctx != nil {
+		t.Fatalf("a new connecting scenario started after Stop")
+	}
+}
+
+func TestStopRunsPostDisconnectHook(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{AppDir: dir, DataDir: dir, AllowProfileHooks: true}
+	ctx := state.NewAppContext(cfg)
+	ctx.SetProfiles([]state.Profile{{ID: "p1", Name: "First", PostDisconnectCmd: "echo post > hook.txt"}})
+	ctx.SetSelectedProfileID("p1")
+	a := &Application{cfg: cfg, logger: newTestLogger(t), ctx: ctx, ui: NewNoopUI(), shutdown: make(chan struct{})}
+
+	// без маршрутов сессии подключения не было: хук при выходе не нужен
+	a.runExitCleanup()
+	if _, err := os.Stat(filepath.Join(dir, "hook.txt")); !os.IsNotExist(err) {
+		t.Fatalf("post-disconnect hook ran without a session: %v", err)
+	}
+
+	ctx.RoutesRegistry.Upsert(state.RouteRecord{ID: "r1", Destination: "0.0.0.0/1", Kind: state.RouteKindTunnel})
+	a.Stop()
+	if _, err := os.Stat(filepath.Join(dir, "hook.txt")); err != nil {
+		t.Fatalf("post-disconnect hook did not run on exit: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+// State возвращает текущее состояние state machine. Безопасен из любой goroutine.
+func (a *Application) State() state.State {
+	current, _ := a.current.Load().(state.State)
+	return current
+}
+
+// Profiles возвращает копию загруженного списка профилей.
+func (a *Application) Profiles() []state.Profile {
+	return a.ctx.Profiles()
+}
+
+// Login отправляет вход с указанными учётными данными, как кнопка «Войти».
+func (a *Application) Login(login, password string) error {
+	if strings.TrimSpace(login) == "" || password == "" {
+		return fmt.Errorf("login and password are required")
+	}
+	payload := state.CredentialsPayload{Login: strings.TrimSpace(login), Password: password}
+	return a.dispatch(state.Event{Type: state.EventUIClickLogin, Payload: payload, TS: time.Now()})
+}
+
+// Connect выбирает профиль и запускает подключение, как выбор в списке и кнопка «Подключиться».
+// Результат приходит сменой State; события обрабатываются по порядку.
+func (a *Application) Connect(profileID string) error {
+	profile, ok := a.ctx.FindProfile(profileID)
+	if !ok {
+		return fmt.Errorf("profile %s not found", profileID)
+	}
+	if !profile.Enabled() {
+		return fmt.Errorf("profile %s is disabled", profileID)
+	}
+	if err := a.dispatch(state.Event{Type: state.EventUISelectProfile, Payload: state.SelectionPayload{ID: profileID}, TS: time.Now()}); err != nil {
+		return err
+	}
+	return a.dispatch(state.Event{Type: state.EventUIClickConnect, TS: time.Now()})
+}
+
+// ShowWindow показывает окно приложения, как пункт «Показать» в трее.
+func (a *Application) ShowWindow() error {
+	return a.dispatch(state.Event{Type: state.EventTrayShowWindow, TS: time.Now()})
+}
+
+// Disconnect разрывает подключение или прерывает идущее подключение, как кнопка «Отключиться».
+func (a *Application) Disconnect() error {
+	return a.dispatch(state.Event{Type: state.EventUIClickDisconnect, TS: time.Now()})
+}
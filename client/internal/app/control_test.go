@@ -0,0 +1,159 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/controlclient"
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+)
+
+var (
+	testProfileNL = controlclient.ProfileDTO{ID: "nl-1", Name: "Amsterdam", Country: "NL", Host: "nl.example.com", Port: 443}
+	testProfileDE = controlclient.ProfileDTO{ID: "de-1", Name: "Frankfurt", Country: "DE", Host: "de.example.com", Port: 443}
+)
+
+// failingDoer обрывает запросы к пути path ошибкой транспорта, остальные передаёт next.
+type failingDoer struct {
+	path string
+	err  error
+	next *fakeControl
+}
+
+func (d *failingDoer) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == d.path {
+		return nil, d.err
+	}
+	return d.next.Do(req)
+}
+
+// transitionsUI — NoopUI, запоминающий переходы state machine.
+type transitionsUI struct {
+	*NoopUI
+	mu          sync.Mutex
+	transitions [][2]state.State
+}
+
+func (u *transitionsUI) OnStateChanged(prev, next state.State) {
+	u.mu.Lock()
+	u.transitions = append(u.transitions, [2]state.State{prev, next})
+	u.mu.Unlock()
+}
+
+func (u *transitionsUI) seen(prev, next state.State) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Contains(u.transitions, [2]state.State{prev, next})
+}
+
+// recordTransitions подменяет UI приложения до Run.
+func recordTransitions(a *Application) *transitionsUI {
+	ui := &transitionsUI{NoopUI: a.ui.(*NoopUI)}
+	a.ui = ui
+	return ui
+}
+
+func waitForTransition(t *testing.T, ui *transitionsUI, prev, next state.State) {
+	t.Helper()
+	deadline := time.Now().Add(testWaitTimeout)
+	for !ui.seen(prev, next) {
+		if time.Now().After(deadline) {
+			t.Fatalf("transition %s -> %s not observed", prev, next)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoginAuthenticatesAndSyncsProfiles(t *testing.T) {
+	control := newFakeControl(controlServer("token", testProfileNL, testProfileDE))
+	a := newTestApplication(t, control, "")
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitForState(t, a, state.StateWaitingLogin)
+
+	if err := a.Login("alice", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	waitForState(t, a, state.StateReadyDisconnected)
+
+	if got := control.count("/auth"); got != 1 {
+		t.Fatalf("/auth requests = %d, want 1", got)
+	}
+	if got := control.count("/sync/profiles"); got != 1 {
+		t.Fatalf("/sync/profiles requests = %d, want 1", got)
+	}
+	if got, want := profileIDs(a.Profiles()), []string{"de-1", "nl-1"}; !slices.Equal(got, want) {
+		t.Fatalf("profiles = %q, want %q", got, want)
+	}
+}
+
+func TestLoginWithWrongPasswordSkipsSync(t *testing.T) {
+	control := newFakeControl(controlServer("token", testProfileNL))
+	a := newTestApplication(t, control, "")
+	ui := recordTransitions(a)
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitForState(t, a, state.StateWaitingLogin)
+
+	if err := a.Login("alice", "wrong"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	waitForTransition(t, ui, state.StateAuthInProgress, state.StateError)
+	if got := control.count("/auth"); got != 1 {
+		t.Fatalf("/auth requests = %d, want 1", got)
+	}
+	if got := control.count("/sync/profiles"); got != 0 {
+		t.Fatalf("/sync/profiles requests = %d after failed auth, want 0", got)
+	}
+	if len(a.Profiles()) != 0 {
+		t.Fatalf("profiles loaded after failed auth: %v", a.Profiles())
+	}
+}
+
+func TestLoginTransportErrorEntersError(t *testing.T) {
+	control := newFakeControl(controlServer("token", testProfileNL))
+	doer := &failingDoer{path: "/auth", err: errors.New("connection reset by peer"), next: control}
+	a := newTestApplication(t, doer, "")
+	ui := recordTransitions(a)
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitForState(t, a, state.StateWaitingLogin)
+
+	if err := a.Login("alice", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	waitForTransition(t, ui, state.StateAuthInProgress, state.StateError)
+	if got := control.count("/sync/profiles"); got != 0 {
+		t.Fatalf("/sync/profiles requests = %d after transport error, want 0", got)
+	}
+}
+
+func TestSyncFailureAfterReauthKeepsAuthKind(t *testing.T) {
+	i18n.SetLanguage(i18n.LangRU)
+	cases := []struct {
+		name        string
+		err         error
+		wantKind    state.ErrorKind
+		wantMessage string
+	}{
+		{name: "session expired", err: &controlclient.Error{Op: "SyncProfileList", Kind: state.ErrorKindAuthFailed}, wantKind: state.ErrorKindAuthFailed, wantMessage: i18n.T("status.session_expired")},
+		{name: "account locked", err: &controlclient.Error{Op: "SyncProfileList", Kind: state.ErrorKindAccountLocked}, wantKind: state.ErrorKindAccountLocked, wantMessage: i18n.T("error.account_locked")},
+		{name: "server error", err: &controlclient.Error{Op: "SyncProfileList", Kind: state.ErrorKindSyncFailed, Status: http.StatusBadGateway}, wantKind: state.ErrorKindSyncFailed, wantMessage: i18n.Tf("error.with_status", "fallback", http.StatusBadGateway)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := buildSyncFailurePayload(tc.err, "fallback")
+			if payload.Kind != tc.wantKind || payload.Message != tc.wantMessage {
+				t.Fatalf("payload = %s %q, want %s %q", payload.Kind, payload.Message, tc.wantKind, tc.wantMessage)
+			}
+		})
+	}
+}
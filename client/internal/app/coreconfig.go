@@ -0,0 +1,83 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"customvpn/client/internal/state"
+)
+
+// coreConfigPlaceholder находит ${NAME} вместе с окружающими кавычками, если они есть:
+// "${SERVER_PORT}" целиком заменяется JSON-значением, ${...} внутри строки — экранированным текстом.
+var coreConfigPlaceholder = regexp.MustCompile(`("?)\$\{([A-Za-z0-9_]+)\}("?)`)
+
+// coreConfigValue — значение плейсхолдера: literal подставляется вместо строки "${NAME}" целиком,
+// text — внутрь строки JSON.
+type coreConfigValue struct {
+	literal string
+	text    string
+}
+
+// expandCoreConfig подставляет адрес сервера профиля в конфигурацию Core:
+// ${SERVER_HOST} и ${SERVER_PORT}, а также ${SERVER_BIND_ADDRESS}, если bindAddress задан.
+// Неизвестные плейсхолдеры остаются как есть и возвращаются списком.
+func expandCoreConfig(raw []byte, profile *state.Profile, bindAddress string) ([]byte, []string) {
+	values := map[string]coreConfigValue{
+		"SERVER_HOST": jsonStringValue(profile.Host),
+		"SERVER_PORT": {literal: strconv.Itoa(profile.Port), text: strconv.Itoa(profile.Port)},
+	}
+	if bindAddress != "" {
+		values["SERVER_BIND_ADDRESS"] = jsonStringValue(bindAddress)
+	}
+	unknown := make(map[string]struct{})
+	expanded := coreConfigPlaceholder.ReplaceAllFunc(raw, func(match []byte) []byte {
+		parts := coreConfigPlaceholder.FindSubmatch(match)
+		openQuote, name, closeQuote := string(parts[1]), string(parts[2]), string(parts[3])
+		value, ok := values[name]
+		if !ok {
+			unknown[name] = struct{}{}
+			return match
+		}
+		if openQuote != "" && closeQuote != "" {
+			return []byte(value.literal)
+		}
+		return []byte(openQuote + value.text + closeQuote)
+	})
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return expanded, names
+}
+
+// jsonStringValue экранирует строку для JSON: адрес может попасть внутрь строки в кавычках.
+func jsonStringValue(s string) coreConfigValue {
+	quoted, _ := json.Marshal(s)
+	literal := string(quoted)
+	return coreConfigValue{literal: literal, text: literal[1 : len(literal)-1]}
+}
+
+// applyCoreLogLevel задаёт log.level в конфигурации Core (sing-box), сохраняя остальные поля секции log.
+func applyCoreLogLevel(raw []byte, level string) ([]byte, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("parse core config: %w", err)
+	}
+	logSection := make(map[string]json.RawMessage)
+	if existing, ok := root["log"]; ok {
+		if err := json.Unmarshal(existing, &logSection); err != nil {
+			return nil, fmt.Errorf("parse core config log section: %w", err)
+		}
+	}
+	logSection["level"], _ = json.Marshal(level)
+	encoded, err := json.Marshal(logSection)
+	if err != nil {
+		return nil, err
+	}
+	root["log"] = encoded
+	return json.MarshalIndent(root, "", "  ")
+}
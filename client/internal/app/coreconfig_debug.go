@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// redactedValue заменяет значения секретных полей в выгрузке конфигурации Core.
+const redactedValue = "***"
+
+// secretKeyMarkers — части имён полей, значения которых скрываются при выгрузке.
+var secretKeyMarkers = []string{"password", "secret", "token"}
+
+// selectedCoreConfigText возвращает конфигурацию Core выбранного профиля
+// в читаемом виде со скрытыми секретами. Отладочное действие для поддержки.
+func (a *Application) selectedCoreConfigText() (string, error) {
+	ctx := a.ctx
+	if ctx == nil {
+		return "", fmt.Errorf("app context is nil")
+	}
+	selectedID := ctx.SelectedProfileID()
+	if selectedID == "" {
+		return "", fmt.Errorf("no profile selected")
+	}
+	profile, ok := ctx.FindProfile(selectedID)
+	if !ok {
+		return "", fmt.Errorf("profile %s not found", selectedID)
+	}
+	if len(profile.CoreConfigRaw) == 0 {
+		// список профилей приходит без core_config, загружаем профиль целиком
+		profileCtx, cancel := a.controlContext()
+		full, err := a.control.SyncProfile(profileCtx, ctx.AuthToken(), selectedID)
+		cancel()
+		if err != nil {
+			return "", fmt.Errorf("load profile %s: %w", selectedID, err)
+		}
+		profile = full
+	}
+	text, err := redactCoreConfig(profile.CoreConfigRaw)
+	if err != nil {
+		return "", fmt.Errorf("profile %s: %w", selectedID, err)
+	}
+	a.logger.Debugf("core config of profile %s exported (%d bytes)", selectedID, len(text))
+	return text, nil
+}
+
+// redactCoreConfig форматирует JSON с отступами, заменяя значения секретных полей на redactedValue.
+func redactCoreConfig(raw []byte) (string, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return "", fmt.Errorf("core_config is empty")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// числа сохраняются как в исходном JSON, без округления через float64
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return "", fmt.Errorf("decode core_config: %w", err)
+	}
+	encoded, err := json.MarshalIndent(redactSecrets(doc), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode core_config: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// redactSecrets обходит декодированный JSON и скрывает значения полей из secretKeyMarkers.
+// Пустые значения оставляются: по ним видно, что секрет не задан.
+func redactSecrets(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if isSecretKey(key) && !isEmptyJSONValue(item) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactSecrets(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactSecrets(item)
+		}
+		return v
+	}
+	return value
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyJSONValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+// coreLogInterval — минимальный интервал между строками Core, отправляемыми в state machine.
+const coreLogInterval = 300 * time.Millisecond
+
+// coreLogThrottle пропускает не чаще одной строки за интервал; последняя отброшенная
+// строка отправляется по таймеру, чтобы в статусе оставалось актуальное сообщение.
+type coreLogThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+	pending  string
+	timer    *time.Timer
+	send     func(line string)
+}
+
+func newCoreLogThrottle(interval time.Duration, send func(line string)) *coreLogThrottle {
+	return &coreLogThrottle{interval: interval, send: send}
+}
+
+func (t *coreLogThrottle) push(line string) {
+	t.mu.Lock()
+	elapsed := time.Since(t.last)
+	if elapsed >= t.interval && t.timer == nil {
+		t.last = time.Now()
+		t.mu.Unlock()
+		t.send(line)
+		return
+	}
+	t.pending = line
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.interval-elapsed, t.flush)
+	}
+	t.mu.Unlock()
+}
+
+func (t *coreLogThrottle) flush() {
+	t.mu.Lock()
+	line := t.pending
+	t.pending = ""
+	t.timer = nil
+	t.last = time.Now()
+	t.mu.Unlock()
+	if line != "" {
+		t.send(line)
+	}
+}
+
+// onCoreLine пересылает вывод Core в state machine, пока выполняется подключение.
+func (a *Application) onCoreLine(_ state.ProcessName, line string) {
+	a.connectMu.Lock()
+	connecting := a.connectCtx != nil
+	a.connectMu.Unlock()
+	if !connecting || a.coreLog == nil {
+		return
+	}
+	a.coreLog.push(line)
+}
+
+func (a *Application) sendCoreLog(line string) {
+	if a.machine == nil || a.isStopping() {
+		return
+	}
+	_ = a.machine.Dispatch(state.Event{Type: state.EventSysCoreLog, Payload: state.CoreLogPayload{Line: line}})
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// favoriteProfiles — содержимое favorites.json: ID избранных профилей в порядке добавления.
+type favoriteProfiles struct {
+	Profiles []string `json:"profiles"`
+}
+
+// loadFavorites читает избранные профили. Отсутствующий файл означает пустой список.
+func loadFavorites(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var payload favoriteProfiles
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	ids := make([]string, 0, len(payload.Profiles))
+	for _, id := range payload.Profiles {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// saveFavorites атомарно перезаписывает favorites.json.
+func saveFavorites(path string, ids []string) error {
+	data, err := json.MarshalIndent(favoriteProfiles{Profiles: ids}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// saveFavoriteProfiles вызывается UI при изменении избранного.
+func (a *Application) saveFavoriteProfiles(ids []string) error {
+	if err := saveFavorites(a.cfg.FavoritesPath(), ids); err != nil {
+		a.logger.Errorf("save favorites failed: %v", err)
+		return err
+	}
+	a.logger.Debugf("favorites saved: %v", ids)
+	return nil
+}
@@ -0,0 +1,22 @@
+package app
+
+import "context"
+
+// Firewall управляет правилами Kill Switch и блокировки IPv6. Реализация — *firewall.Manager;
+// в тестах подставляется фейк, чтобы проверять сценарии без изменения правил системы.
+type Firewall interface {
+	// CheckAvailable проверяет, что правила для интерфейса iface можно применить.
+	CheckAvailable(ctx context.Context, iface string) error
+	// EnableLocalPolicyMerge разрешает локальные правила, если их запрещает политика.
+	EnableLocalPolicyMerge(ctx context.Context) error
+	// BlockDNSOnInterface запрещает DNS мимо туннеля на iface и возвращает созданные правила.
+	BlockDNSOnInterface(ctx context.Context, iface string, allowed []string, corePath string) ([]string, error)
+	// BlockAllOnInterface (строгий Kill Switch) запрещает весь трафик через iface, кроме адресов allowed.
+	BlockAllOnInterface(ctx context.Context, iface string, allowed []string) ([]string, error)
+	// BlockIPv6OnInterface запрещает исходящий IPv6 на iface и возвращает созданные правила.
+	BlockIPv6OnInterface(ctx context.Context, iface string) ([]string, error)
+	// RemoveRules удаляет правила по именам.
+	RemoveRules(ctx context.Context, rules []string) error
+	// RemoveKillSwitchGroup удаляет все правила клиента и возвращает их число.
+	RemoveKillSwitchGroup(ctx context.Context) (int, error)
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"net"
+
+	"customvpn/client/internal/routes"
+	"customvpn/client/internal/state"
+)
+
+// GatewayDetector определяет шлюзы системы. Сценарии обращаются к сети только через него,
+// чтобы в тестах можно было подставить заранее заданные шлюзы.
+type GatewayDetector interface {
+	// DefaultGateways возвращает маршруты по умолчанию IPv4, лучший первым.
+	DefaultGateways() ([]*state.GatewayInfo, error)
+	// DefaultGatewayV6 возвращает маршрут по умолчанию IPv6.
+	DefaultGatewayV6() (*state.GatewayInfo, error)
+	// GatewayForIP возвращает шлюз и интерфейс, через которые достижим ip.
+	GatewayForIP(ip net.IP) (*state.GatewayInfo, error)
+}
+
+// systemGateways читает шлюзы из таблицы маршрутизации ОС.
+type systemGateways struct{}
+
+func (systemGateways) DefaultGateways() ([]*state.GatewayInfo, error) {
+	return routes.DetectDefaultGateways()
+}
+
+func (systemGateways) DefaultGatewayV6() (*state.GatewayInfo, error) {
+	return routes.DetectDefaultGatewayV6()
+}
+
+func (systemGateways) GatewayForIP(ip net.IP) (*state.GatewayInfo, error) {
+	return routes.DetectGatewayForIP(ip)
+}
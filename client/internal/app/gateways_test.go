@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/logging"
+	"customvpn/client/internal/routes"
+	"customvpn/client/internal/state"
+)
+
+// fakeGateways — заранее заданная таблица маршрутов по умолчанию.
+type fakeGateways struct {
+	mu    sync.Mutex
+	v4    []*state.GatewayInfo
+	v6    *state.GatewayInfo
+	v6Err error
+	v4Err error
+}
+
+func (f *fakeGateways) set(gateways ...*state.GatewayInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.v4 = gateways
+}
+
+func (f *fakeGateways) DefaultGateways() ([]*state.GatewayInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*state.GatewayInfo(nil), f.v4...), f.v4Err
+}
+
+func (f *fakeGateways) DefaultGatewayV6() (*state.GatewayInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.v6, f.v6Err
+}
+
+func (f *fakeGateways) GatewayForIP(net.IP) (*state.GatewayInfo, error) {
+	return nil, errors.New("not implemented")
+}
+
+// chooserUI — UI без окон, который выбирает шлюз по имени интерфейса и считает показы диалога.
+type chooserUI struct {
+	*NoopUI
+	mu     sync.Mutex
+	pick   string
+	prompt int
+}
+
+func (u *chooserUI) ChooseGateway(_ context.Context, gateways []*state.GatewayInfo) (*state.GatewayInfo, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.prompt++
+	for _, gw := range gateways {
+		if gw.InterfaceName == u.pick {
+			return gw, true
+		}
+	}
+	return nil, false
+}
+
+func (u *chooserUI) prompts() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.prompt
+}
+
+func newTestLogger(t *testing.T) *logging.Logger {
+	t.Helper()
+	logger, err := logging.New(filepath.Join(t.TempDir(), "client.log"), logging.LevelDebug, logging.Options{})
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	t.Cleanup(func() { _ = logger.Close() })
+	return logger
+}
+
+var (
+	testWiFi     = &state.GatewayInfo{IP: "192.168.1.1", InterfaceName: "Wi-Fi", InterfaceIndex: 12, Metric: 25}
+	testEthernet = &state.GatewayInfo{IP: "10.0.0.1", InterfaceName: "Ethernet", InterfaceIndex: 7, Metric: 35}
+	testLTE      = &state.GatewayInfo{IP: "10.64.0.1", InterfaceName: "LTE", InterfaceIndex: 21, Metric: 50}
+)
+
+func TestSelectDefaultGatewayRemembersChoice(t *testing.T) {
+	gateways := &fakeGateways{}
+	gateways.set(testWiFi, testEthernet)
+	ui := &chooserUI{NoopUI: NewNoopUI(), pick: "Ethernet"}
+	a := &Application{logger: newTestLogger(t), gateways: gateways, ui: ui}
+	ctx := state.NewAppContext(nil)
+
+	gw, scErr := a.selectDefaultGateway(ctx, true)
+	if scErr != nil || gw.InterfaceName != "Ethernet" {
+		t.Fatalf("first select = %v, %v; want Ethernet", gw, scErr)
+	}
+	if ctx.GatewayChoice() != "Ethernet" {
+		t.Fatalf("choice = %q, want Ethernet", ctx.GatewayChoice())
+	}
+
+	// автопереподключение: выбранный интерфейс на месте, диалог не показывается
+	gateways.set(testEthernet, testWiFi, testLTE)
+	gw, scErr = a.selectDefaultGateway(ctx, true)
+	if scErr != nil || gw.InterfaceName != "Ethernet" {
+		t.Fatalf("reconnect select = %v, %v; want Ethernet", gw, scErr)
+	}
+	if ui.prompts() != 1 {
+		t.Fatalf("chooser shown %d times, want 1", ui.prompts())
+	}
+
+	// интерфейс пропал: выбор спрашивается заново
+	gateways.set(testWiFi, testLTE)
+	ui.pick = "LTE"
+	gw, scErr = a.selectDefaultGateway(ctx, true)
+	if scErr != nil || gw.InterfaceName != "LTE" {
+		t.Fatalf("select after interface loss = %v, %v; want LTE", gw, scErr)
+	}
+	if ui.prompts() != 2 || ctx.GatewayChoice() != "LTE" {
+		t.Fatalf("prompts = %d choice = %q, want 2 and LTE", ui.prompts(), ctx.GatewayChoice())
+	}
+}
+
+func TestSelectDefaultGatewaySingleSkipsChooser(t *testing.T) {
+	gateways := &fakeGateways{}
+	gateways.set(testWiFi)
+	ui := &chooserUI{NoopUI: NewNoopUI()}
+	a := &Application{logger: newTestLogger(t), gateways: gateways, ui: ui}
+
+	gw, scErr := a.selectDefaultGateway(state.NewAppContext(nil), true)
+	if scErr != nil || gw != testWiFi {
+		t.Fatalf("select = %v, %v; want the only gateway", gw, scErr)
+	}
+	if ui.prompts() != 0 {
+		t.Fatalf("chooser shown for a single gateway")
+	}
+}
+
+func TestResolveConnectTargetUsesGatewayDetector(t *testing.T) {
+	testV6 := &state.GatewayInfo{IP: "fe80::1", InterfaceName: "Wi-Fi", InterfaceIndex: 12}
+	errDetect := errors.New("route table unavailable")
+	cases := []struct {
+		name       string
+		enableIPv6 bool
+		directV6   bool
+		v4Err      error
+		v6         *state.GatewayInfo
+		v6Err      error
+		wantKind   state.ErrorKind
+		wantV6     bool
+	}{
+		{name: "ipv4 only", v6Err: errDetect},
+		{name: "ipv4 detection fails", v4Err: errDetect, wantKind: state.ErrorKindRoutingFailed},
+		{name: "ipv6 gateway unused", enableIPv6: true, v6Err: errDetect},
+		{name: "ipv6 gateway needed", enableIPv6: true, directV6: true, v6Err: errDetect, wantKind: state.ErrorKindRoutingFailed},
+		{name: "ipv6 gateway found", enableIPv6: true, directV6: true, v6: testV6, wantV6: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateways := &fakeGateways{v6: tc.v6, v6Err: tc.v6Err, v4Err: tc.v4Err}
+			gateways.set(testWiFi)
+			a := &Application{
+				cfg:      &config.Config{EnableIPv6: tc.enableIPv6},
+				logger:   newTestLogger(t),
+				gateways: gateways,
+				ui:       NewNoopUI(),
+			}
+			ctx := newPreviewContext(state.StateReadyDisconnected)
+			if tc.directV6 {
+				profile, _ := ctx.FindProfile("p1")
+				profile.DirectRoutes = append(profile.DirectRoutes, "2001:db8::/32")
+				ctx.UpdateProfile(profile)
+			}
+
+			target, scErr := a.resolveConnectTarget(ctx, true)
+			if tc.wantKind != "" {
+				if scErr == nil || scErr.kind != tc.wantKind {
+					t.Fatalf("error = %v, want kind %s", scErr, tc.wantKind)
+				}
+				return
+			}
+			if scErr != nil {
+				t.Fatalf("resolve: %v", scErr)
+			}
+			if target.gateway != testWiFi {
+				t.Fatalf("gateway = %+v, want Wi-Fi", target.gateway)
+			}
+			if (target.gatewayV6 != nil) != tc.wantV6 {
+				t.Fatalf("gatewayV6 = %+v, want set %v", target.gatewayV6, tc.wantV6)
+			}
+		})
+	}
+}
+
+func TestExecuteConnectingGatewayFailureLeavesNothing(t *testing.T) {
+	gateways := &fakeGateways{v4Err: errors.New("route table unavailable")}
+	logger := newTestLogger(t)
+	a := &Application{
+		cfg:      &config.Config{},
+		logger:   logger,
+		gateways: gateways,
+		routes:   routes.NewManager(logger, routes.Options{}),
+		ui:       NewNoopUI(),
+	}
+	ctx := newPreviewContext(state.StateConnecting)
+	artifacts := newConnectArtifacts(a, ctx)
+
+	scErr := a.executeConnecting(ctx, artifacts)
+	if scErr == nil || scErr.kind != state.ErrorKindRoutingFailed {
+		t.Fatalf("error = %v, want %s", scErr, state.ErrorKindRoutingFailed)
+	}
+	if len(artifacts.routes) != 0 || artifacts.coreStarted || artifacts.gateway != nil {
+		t.Fatalf("artifacts after failed gateway detection = %+v", artifacts)
+	}
+	if routes := ctx.RoutesRegistry.ListByKinds(state.RouteKindDirect, state.RouteKindTunnel); len(routes) != 0 {
+		t.Fatalf("routes registered: %+v", routes)
+	}
+}
+
+func TestPrepareGatewayErrorMessage(t *testing.T) {
+	i18n.SetLanguage(i18n.LangRU)
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "multiple gateways", err: errors.New("multiple default gateways detected"), want: "Обнаружено несколько шлюзов по умолчанию"},
+		{name: "detect failed", err: errors.New("default gateway not found"), want: "Не удалось определить шлюз по умолчанию"},
+		{name: "nil", err: nil, want: "Не удалось определить шлюз по умолчанию"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := prepareGatewayErrorMessage(tc.err); got != tc.want {
+				t.Fatalf("prepareGatewayErrorMessage(%v) = %q, want %q", tc.err, got, tc.want)
+			}
+		})
+	}
+}
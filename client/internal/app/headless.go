@@ -0,0 +1,121 @@
+package app
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+// headlessRun хранит состояние автоматического входа и подключения в режиме --headless.
+type headlessRun struct {
+	loginOnce   sync.Once
+	connectOnce sync.Once
+}
+
+// enableHeadless подменяет показ окон автоматическим входом и подключением.
+// Окна в этом режиме не создаются, поэтому исходные callbacks только обновляют флаги UI.
+func (a *Application) enableHeadless(callbacks *state.Callbacks) {
+	showLogin := callbacks.ShowLoginWindow
+	showMain := callbacks.ShowMainWindow
+	callbacks.ShowLoginWindow = func(ctx *state.AppContext) {
+		if showLogin != nil {
+			showLogin(ctx)
+		}
+		a.headlessLogin(ctx)
+	}
+	callbacks.ShowMainWindow = func(ctx *state.AppContext) {
+		if showMain != nil {
+			showMain(ctx)
+		}
+		a.headlessConnect(ctx)
+	}
+	a.logger.Infof("headless mode: windows disabled, login and connect are automatic")
+}
+
+// headlessLogin выполняет вход один раз за запуск: после отказа сервера повтор не делается,
+// чтобы не заблокировать учётную запись. Вызывается из goroutine state machine.
+func (a *Application) headlessLogin(ctx *state.AppContext) {
+	if ctx == nil || ctx.State != state.StateWaitingLogin {
+		return
+	}
+	a.headless.loginOnce.Do(func() {
+		creds, ok := a.headlessCredentials(ctx)
+		if !ok {
+			a.logger.Errorf("headless login skipped: no credentials in config, environment or credential store")
+			return
+		}
+		a.logger.Infof("headless login as %s", creds.Login)
+		// Dispatch из callback state machine может заблокироваться на полной очереди
+		go a.dispatchHeadless(state.Event{Type: state.EventUIClickLogin, Payload: creds, TS: time.Now()})
+	})
+}
+
+// headlessCredentials берёт логин из секции headless config.yaml и пароль из CUSTOMVPN_HEADLESS_PASSWORD,
+// иначе — сохранённые в хранилище ОС (загружены в ctx.UI при старте).
+func (a *Application) headlessCredentials(ctx *state.AppContext) (state.CredentialsPayload, bool) {
+	login := strings.TrimSpace(a.cfg.Headless.Login)
+	if login != "" && a.cfg.Headless.Password != "" {
+		return state.CredentialsPayload{Login: login, Password: a.cfg.Headless.Password}, true
+	}
+	if strings.TrimSpace(ctx.UI.LoginInput) == "" || ctx.UI.PasswordInput == "" {
+		return state.CredentialsPayload{}, false
+	}
+	return state.CredentialsPayload{
+		Login:    ctx.UI.LoginInput,
+		Password: ctx.UI.PasswordInput,
+		Remember: ctx.UI.RememberCredentials,
+	}, true
+}
+
+// headlessConnect подключается к выбранному профилю при первом переходе в ReadyDisconnected.
+// Дальнейшие подключения — только из трея. Вызывается из goroutine state machine.
+func (a *Application) headlessConnect(ctx *state.AppContext) {
+	if ctx == nil || ctx.State != state.StateReadyDisconnected {
+		return
+	}
+	a.headless.connectOnce.Do(func() {
+		profiles := ctx.Profiles()
+		profile, ok := headlessProfile(profiles, a.cfg.Headless.Profile)
+		if !ok {
+			a.logger.Errorf("headless connect skipped: profile %q not found among %d profiles", a.cfg.Headless.Profile, len(profiles))
+			return
+		}
+		a.logger.Infof("headless connect to profile %s (%s)", profile.Name, profile.ID)
+		go func() {
+			a.dispatchHeadless(state.Event{Type: state.EventUISelectProfile, Payload: state.SelectionPayload{ID: profile.ID}, TS: time.Now()})
+			a.dispatchHeadless(state.Event{Type: state.EventUIClickConnect, TS: time.Now()})
+		}()
+	})
+}
+
+// headlessProfile ищет профиль по ID или имени; пустой запрос выбирает первый включённый профиль.
+func headlessProfile(profiles []state.Profile, query string) (state.Profile, bool) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		for _, profile := range profiles {
+			if profile.Enabled() {
+				return profile, true
+			}
+		}
+		return state.Profile{}, false
+	}
+	for _, profile := range profiles {
+		if profile.ID == query {
+			return profile, true
+		}
+	}
+	for _, profile := range profiles {
+		if strings.EqualFold(profile.Name, query) {
+			return profile, true
+		}
+	}
+	return state.Profile{}, false
+}
+
+func (a *Application) dispatchHeadless(evt state.Event) {
+	if err := a.dispatch(evt); err != nil {
+		a.logger.Errorf("headless dispatch %s failed: %v", evt.Type, err)
+	}
+}
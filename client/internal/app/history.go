@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+// sessionHistoryLimit — сколько последних сессий хранит history.json.
+const sessionHistoryLimit = 100
+
+// sessionHistory — история подключений в history.json; записи идут от старых к новым.
+type sessionHistory struct {
+	mu      sync.Mutex
+	path    string
+	entries []state.SessionRecord
+}
+
+// loadSessionHistory читает историю подключений. Отсутствующий файл означает пустую историю;
+// при повреждённом файле история начинается заново и возвращается ошибка.
+func loadSessionHistory(path string) (*sessionHistory, error) {
+	history := &sessionHistory{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return history, fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &history.entries); err != nil {
+		return history, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// start добавляет открытую запись о новой сессии.
+func (h *sessionHistory) start(profileID, profileName string, at time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, state.SessionRecord{ProfileID: profileID, ProfileName: profileName, ConnectedAt: at})
+	if extra := len(h.entries) - sessionHistoryLimit; extra > 0 {
+		h.entries = append([]state.SessionRecord(nil), h.entries[extra:]...)
+	}
+	return h.saveLocked()
+}
+
+// finish закрывает последнюю запись, если она ещё открыта.
+func (h *sessionHistory) finish(reason state.SessionEndReason, at time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.entries) == 0 || h.entries[len(h.entries)-1].DisconnectedAt != nil {
+		return nil
+	}
+	last := &h.entries[len(h.entries)-1]
+	last.DisconnectedAt = &at
+	last.Duration = at.Sub(last.ConnectedAt).Round(time.Second)
+	last.Reason = reason
+	return h.saveLocked()
+}
+
+// snapshot возвращает копию истории, новые сессии первыми.
+func (h *sessionHistory) snapshot() []state.SessionRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make([]state.SessionRecord, 0, len(h.entries))
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		result = append(result, h.entries[i])
+	}
+	return result
+}
+
+// saveLocked атомарно перезаписывает history.json. Вызывается под h.mu.
+func (h *sessionHistory) saveLocked() error {
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// onSessionStarted записывает начало сессии. Вызывается из goroutine state machine.
+func (a *Application) onSessionStarted(ctx *state.AppContext) {
+	profileID, profileName := ctx.SelectedProfileID(), ""
+	if profile, ok := ctx.FindProfile(profileID); ok {
+		profileName = profile.Name
+	}
+	if err := a.history.start(profileID, profileName, time.Now()); err != nil {
+		a.logger.Errorf("save session history failed: %v", err)
+	}
+}
+
+// onSessionEnded записывает конец сессии. Вызывается из goroutine state machine.
+func (a *Application) onSessionEnded(_ *state.AppContext, reason state.SessionEndReason) {
+	if err := a.history.finish(reason, time.Now()); err != nil {
+		a.logger.Errorf("save session history failed: %v", err)
+	}
+}
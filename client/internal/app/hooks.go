@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+)
+
+// profileHookTimeout ограничивает команду профиля до подключения и после отключения.
+const profileHookTimeout = 30 * time.Second
+
+// runPreConnectHook выполняет pre_connect_cmd профиля; ошибка прерывает подключение.
+func (a *Application) runPreConnectHook(profile *state.Profile) *scenarioError {
+	if !a.profileHooksAllowed(profile.ID, profile.PreConnectCmd) {
+		return nil
+	}
+	if err := a.runProfileHook("pre-connect", profile.PreConnectCmd); err != nil {
+		if scErr := a.checkConnectCanceled(); scErr != nil {
+			return scErr
+		}
+		return newScenarioError(state.ErrorKindProcessFailed, i18n.T("error.pre_connect_hook"), err)
+	}
+	return nil
+}
+
+// runPostDisconnectHook выполняет post_disconnect_cmd профиля; ошибка только пишется в лог.
+func (a *Application) runPostDisconnectHook(profile *state.Profile) {
+	if profile == nil || !a.profileHooksAllowed(profile.ID, profile.PostDisconnectCmd) {
+		return
+	}
+	if err := a.runProfileHook("post-disconnect", profile.PostDisconnectCmd); err != nil {
+		a.logger.Errorf("post-disconnect hook for profile %s failed: %v", profile.ID, err)
+	}
+}
+
+func (a *Application) profileHooksAllowed(profileID, command string) bool {
+	if strings.TrimSpace(command) == "" {
+		return false
+	}
+	if a.cfg == nil || !a.cfg.AllowProfileHooks {
+		a.logger.Infof("profile %s has hook commands, but allow_profile_hooks is off: skipped", profileID)
+		return false
+	}
+	return true
+}
+
+// runProfileHook запускает команду через оболочку ОС (cmd.exe или sh) без окна консоли.
+// По таймауту завершается вся группа процессов команды.
+func (a *Application) runProfileHook(kind, command string) error {
+	ctx, cancel := context.WithTimeout(a.parentContext(), profileHookTimeout)
+	defer cancel()
+	a.logger.Infof("running %s hook: %s", kind, command)
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = a.cfg.AppDir
+	applyKillProcessTree(cmd)
+	cmd.WaitDelay = processStopTimeout
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s hook timed out after %s", kind, profileHookTimeout)
+	}
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	a.logger.Infof("%s hook finished in %s", kind, time.Since(start).Round(time.Millisecond))
+	return nil
+}
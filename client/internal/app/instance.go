@@ -0,0 +1,155 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"customvpn/client/internal/config"
+)
+
+// ErrAlreadyRunning — клиент уже запущен: второй экземпляр менял бы те же маршруты и правила брандмауэра.
+var ErrAlreadyRunning = errors.New("another CustomVPN client instance is already running")
+
+const (
+	// instanceAddrFile хранит адрес, по которому запущенный экземпляр принимает просьбу показать окно.
+	instanceAddrFile = "instance.addr"
+	// instanceOwnerFile описывает владельца блокировки для сообщения второму экземпляру.
+	instanceOwnerFile = "instance.owner"
+	// instanceActivateCommand — единственная команда локального канала.
+	instanceActivateCommand = "show"
+	instanceIPCTimeout      = 2 * time.Second
+)
+
+// InstanceLock удерживает блокировку единственного экземпляра до Release.
+type InstanceLock struct {
+	dir    string
+	unlock func() error
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+// AcquireSingleInstanceLock захватывает блокировку единственного экземпляра: на Windows — именованный
+// mutex, на остальных ОС — flock на файле в config.InstanceDir(appDir). На Windows и при записываемом
+// appDir блокировка общая для всех пользователей: маршруты и брандмауэр общие для системы.
+// Если клиент уже запущен, возвращает ErrAlreadyRunning с описанием владельца.
+// Вызывается до создания UI и Application.
+func AcquireSingleInstanceLock(appDir string) (*InstanceLock, error) {
+	dir, err := config.InstanceDir(appDir)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lockInstance(dir)
+	if errors.Is(err, ErrAlreadyRunning) {
+		if owner := readInstanceOwner(dir); owner != "" {
+			return nil, fmt.Errorf("%w (%s)", ErrAlreadyRunning, owner)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	// без описания владельца второй экземпляр выведет сообщение без подробностей
+	_ = os.WriteFile(filepath.Join(dir, instanceOwnerFile), []byte(describeInstanceOwner()), 0o600)
+	return &InstanceLock{dir: dir, unlock: unlock}, nil
+}
+
+// describeInstanceOwner описывает текущий процесс: PID, пользователь и время запуска.
+func describeInstanceOwner() string {
+	owner := fmt.Sprintf("pid %d", os.Getpid())
+	if current, err := user.Current(); err == nil {
+		owner += ", user " + current.Username
+	}
+	return owner + ", started " + time.Now().Format(time.RFC3339)
+}
+
+func readInstanceOwner(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, instanceOwnerFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ListenActivation принимает от второго экземпляра просьбу показать окно и вызывает onActivate.
+// Канал слушает только 127.0.0.1; адрес записывается в каталог блокировки для ActivateRunningInstance.
+func (l *InstanceLock) ListenActivation(onActivate func()) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("listen activation: %w", err)
+	}
+	// адрес читает только владелец: другие пользователи не должны управлять чужим окном
+	if err := os.WriteFile(filepath.Join(l.dir, instanceAddrFile), []byte(listener.Addr().String()), 0o600); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("write instance address: %w", err)
+	}
+	l.mu.Lock()
+	l.listener = listener
+	l.mu.Unlock()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.SetDeadline(time.Now().Add(instanceIPCTimeout))
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			_ = conn.Close()
+			if strings.TrimSpace(line) == instanceActivateCommand && onActivate != nil {
+				onActivate()
+			}
+		}
+	}()
+	return nil
+}
+
+// Release закрывает канал активации и снимает блокировку. Повторный вызов безопасен.
+func (l *InstanceLock) Release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	listener := l.listener
+	l.listener = nil
+	unlock := l.unlock
+	l.unlock = nil
+	l.mu.Unlock()
+	if listener != nil {
+		_ = listener.Close()
+		_ = os.Remove(filepath.Join(l.dir, instanceAddrFile))
+	}
+	if unlock != nil {
+		_ = os.Remove(filepath.Join(l.dir, instanceOwnerFile))
+		_ = unlock()
+	}
+}
+
+// ActivateRunningInstance просит уже запущенный экземпляр показать окно.
+func ActivateRunningInstance(appDir string) error {
+	dir, err := config.InstanceDir(appDir)
+	if err != nil {
+		return err
+	}
+	addr, err := os.ReadFile(filepath.Join(dir, instanceAddrFile))
+	if err != nil {
+		return fmt.Errorf("read instance address: %w", err)
+	}
+	conn, err := net.DialTimeout("tcp", strings.TrimSpace(string(addr)), instanceIPCTimeout)
+	if err != nil {
+		return fmt.Errorf("connect to running instance: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(instanceIPCTimeout))
+	if _, err := conn.Write([]byte(instanceActivateCommand + "\n")); err != nil {
+		return fmt.Errorf("send activation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockInstance берёт flock на instance.lock в dir; блокировка снимается и при падении процесса.
+func lockInstance(dir string) (func() error, error) {
+	path := filepath.Join(dir, "instance.lock")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if os.IsPermission(err) {
+		// файл создан другим пользователем: для flock хватает чтения
+		file, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open instance lock: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrAlreadyRunning
+		}
+		return nil, fmt.Errorf("lock instance: %w", err)
+	}
+	return file.Close, nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSingleInstanceLockRefusesSecondInstance(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := AcquireSingleInstanceLock(dir)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	_, err = AcquireSingleInstanceLock(dir)
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("second lock error = %v, want ErrAlreadyRunning", err)
+	}
+	// сообщение называет владельца блокировки
+	if !strings.Contains(err.Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Fatalf("error %q does not name the running instance", err)
+	}
+
+	lock.Release()
+	lock, err = AcquireSingleInstanceLock(dir)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	lock.Release()
+}
+
+func TestActivateRunningInstance(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := AcquireSingleInstanceLock(dir)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer lock.Release()
+	activated := make(chan struct{}, 1)
+	if err := lock.ListenActivation(func() { activated <- struct{}{} }); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, instanceAddrFile))
+		if err != nil {
+			t.Fatalf("stat address file: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Fatalf("address file mode = %o, want 600", perm)
+		}
+	}
+
+	if err := ActivateRunningInstance(dir); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	<-activated
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// instanceMutexName — общий для всех сессий mutex: маршруты и брандмауэр общие для системы.
+const instanceMutexName = `Global\CustomVPN.Client.Instance`
+
+// lockInstance создаёт именованный mutex; Windows освобождает его и при падении процесса.
+func lockInstance(_ string) (func() error, error) {
+	name, err := windows.UTF16PtrFromString(instanceMutexName)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.CreateMutex(nil, false, name)
+	if errors.Is(err, windows.ERROR_ALREADY_EXISTS) {
+		if handle != 0 {
+			_ = windows.CloseHandle(handle)
+		}
+		return nil, ErrAlreadyRunning
+	}
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		// mutex создан процессом другого пользователя с более строгими правами
+		return nil, ErrAlreadyRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create instance mutex: %w", err)
+	}
+	return func() error { return windows.CloseHandle(handle) }, nil
+}
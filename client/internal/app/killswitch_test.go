@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/firewall"
+	"customvpn/client/internal/routes"
+	"customvpn/client/internal/state"
+)
+
+// fakeFirewall записывает вызовы вместо изменения правил системы.
+// strictUnsupported имитирует платформу без строгого Kill Switch, checkErr — недоступный брандмауэр.
+type fakeFirewall struct {
+	mu                sync.Mutex
+	calls             []string
+	strictUnsupported bool
+	checkErr          error
+}
+
+func (f *fakeFirewall) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeFirewall) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeFirewall) CheckAvailable(_ context.Context, iface string) error {
+	f.record("check " + iface)
+	return f.checkErr
+}
+
+func (f *fakeFirewall) EnableLocalPolicyMerge(context.Context) error {
+	f.record("merge")
+	return nil
+}
+
+func (f *fakeFirewall) BlockDNSOnInterface(_ context.Context, iface string, _ []string, _ string) ([]string, error) {
+	f.record("block dns " + iface)
+	return []string{"CustomVPN KillSwitch DNS " + iface}, nil
+}
+
+func (f *fakeFirewall) BlockAllOnInterface(_ context.Context, iface string, allowed []string) ([]string, error) {
+	f.record("block all " + iface + " " + strings.Join(allowed, ","))
+	if f.strictUnsupported {
+		return nil, firewall.ErrStrictKillSwitchUnsupported
+	}
+	return []string{"CustomVPN Block All " + iface}, nil
+}
+
+func (f *fakeFirewall) BlockIPv6OnInterface(_ context.Context, iface string) ([]string, error) {
+	f.record("block ipv6 " + iface)
+	return nil, nil
+}
+
+func (f *fakeFirewall) RemoveRules(_ context.Context, rules []string) error {
+	f.record("remove rules")
+	return nil
+}
+
+func (f *fakeFirewall) RemoveKillSwitchGroup(context.Context) (int, error) {
+	f.record("remove group")
+	return 0, nil
+}
+
+func TestKillSwitchFollowsProfileFlag(t *testing.T) {
+	cases := []struct {
+		name       string
+		killSwitch bool
+		wantBlock  bool
+	}{
+		{name: "off", killSwitch: false, wantBlock: false},
+		{name: "on", killSwitch: true, wantBlock: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fw := &fakeFirewall{}
+			artifacts := runConnectWithFirewall(t, fw, tc.killSwitch, false)
+			calls := fw.recorded()
+			blocked := false
+			for _, call := range calls {
+				if call == "block dns Wi-Fi" {
+					blocked = true
+				}
+			}
+			if blocked != tc.wantBlock {
+				t.Fatalf("firewall calls = %q, want DNS block %v", calls, tc.wantBlock)
+			}
+			if !tc.killSwitch && len(calls) != 0 {
+				t.Fatalf("firewall called for a profile with kill switch off: %q", calls)
+			}
+			if tc.killSwitch && len(artifacts.killSwitchRules) == 0 {
+				t.Fatalf("kill switch rules are not tracked for rollback")
+			}
+		})
+	}
+}
+
+// runConnectWithFirewall выполняет сценарий подключения с фейковым брандмауэром до запуска Core.
+func runConnectWithFirewall(t *testing.T, fw *fakeFirewall, killSwitch, strict bool) *connectArtifacts {
+	t.Helper()
+	dir := t.TempDir()
+	gateways := &fakeGateways{}
+	gateways.set(testWiFi)
+	logger := newTestLogger(t)
+	a := &Application{
+		cfg:      &config.Config{AppDir: dir, DataDir: dir, CorePath: "core", TunnelGateway: "172.19.0.1", KillSwitchStrict: strict},
+		logger:   logger,
+		gateways: gateways,
+		firewall: fw,
+		routes:   routes.NewManager(logger, routes.Options{}),
+		ui:       NewNoopUI(),
+	}
+	ctx := newPreviewContext(state.StateConnecting)
+	profile, _ := ctx.FindProfile("p1")
+	// без прямых маршрутов сценарий не трогает таблицу маршрутизации до запуска Core
+	profile.DirectRoutes = nil
+	profile.KillSwitchEnabled = killSwitch
+	ctx.UpdateProfile(profile)
+	artifacts := newConnectArtifacts(a, ctx)
+
+	// Core не запускается (launcher не задан): сценарий завершается ошибкой после шага Kill Switch
+	if scErr := a.executeConnecting(ctx, artifacts); scErr == nil {
+		t.Fatalf("connect succeeded without Core")
+	}
+	return artifacts
+}
+
+func TestStrictKillSwitch(t *testing.T) {
+	cases := []struct {
+		name        string
+		unsupported bool
+		wantRules   []string
+	}{
+		{name: "supported", wantRules: []string{"CustomVPN KillSwitch DNS Wi-Fi", "CustomVPN Block All Wi-Fi"}},
+		// платформа без строгого режима: остаётся блокировка DNS, подключение не прерывается
+		{name: "unsupported", unsupported: true, wantRules: []string{"CustomVPN KillSwitch DNS Wi-Fi"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fw := &fakeFirewall{strictUnsupported: tc.unsupported}
+			artifacts := runConnectWithFirewall(t, fw, true, true)
+
+			calls := fw.recorded()
+			dnsAt := slices.Index(calls, "block dns Wi-Fi")
+			// сервер профиля задан адресом 203.0.113.10: он единственный разрешён
+			allAt := slices.Index(calls, "block all Wi-Fi 203.0.113.10")
+			if dnsAt < 0 || allAt < 0 || dnsAt > allAt {
+				t.Fatalf("firewall calls = %q, want DNS block before strict block", calls)
+			}
+			if !slices.Equal(artifacts.killSwitchRules, tc.wantRules) {
+				t.Fatalf("tracked rules = %q, want %q", artifacts.killSwitchRules, tc.wantRules)
+			}
+		})
+	}
+}
+
+func TestStrictKillSwitchIgnoredWithoutProfileKillSwitch(t *testing.T) {
+	fw := &fakeFirewall{}
+	runConnectWithFirewall(t, fw, false, true)
+	if calls := fw.recorded(); len(calls) != 0 {
+		t.Fatalf("firewall called for a profile with kill switch off: %q", calls)
+	}
+}
+
+func TestBlockIPv6WithUnavailableFirewall(t *testing.T) {
+	gatewayV6 := &state.GatewayInfo{IP: "fe80::1", InterfaceName: "Wi-Fi", InterfaceIndex: 12}
+	cases := []struct {
+		name       string
+		killSwitch bool
+		wantErr    bool
+	}{
+		// без Kill Switch подключение продолжается с предупреждением в логе
+		{name: "kill switch off", killSwitch: false, wantErr: false},
+		{name: "kill switch on", killSwitch: true, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fw := &fakeFirewall{checkErr: errors.New("netsh is not available")}
+			a := &Application{cfg: &config.Config{}, logger: newTestLogger(t), firewall: fw}
+			ctx := newPreviewContext(state.StateConnecting)
+			profile, _ := ctx.FindProfile("p1")
+			profile.KillSwitchEnabled = tc.killSwitch
+
+			scErr := a.blockIPv6(ctx, &profile, gatewayV6, nil)
+			if (scErr != nil) != tc.wantErr {
+				t.Fatalf("blockIPv6 error = %v, want error %v", scErr, tc.wantErr)
+			}
+			if slices.Contains(fw.recorded(), "block ipv6 Wi-Fi") {
+				t.Fatalf("IPv6 block rules added although the firewall is unavailable")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"context"
+	"net"
+)
+
+// probeTunnel выполняет одну проверку liveness_probe: TCP-подключение к адресу за туннелем.
+// Вызывается из goroutine state machine.
+func (a *Application) probeTunnel() error {
+	settings := a.cfg.LivenessProbe
+	ctx, cancel := context.WithTimeout(a.parentContext(), settings.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", settings.Target)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"customvpn/client/internal/credstore"
+	"customvpn/client/internal/state"
+)
+
+// lastLogin — содержимое файла с логином последнего успешного входа. Пароль не хранится.
+type lastLogin struct {
+	Login string `json:"login"`
+}
+
+// loadLastLogin читает запомненный логин. Отсутствующий файл не считается ошибкой;
+// при повреждённом файле возвращается пустой логин и ошибка.
+func loadLastLogin(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	var payload lastLogin
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", fmt.Errorf("parse %s: %w", path, err)
+	}
+	return strings.TrimSpace(payload.Login), nil
+}
+
+func saveLastLogin(path, login string) error {
+	data, err := json.Marshal(lastLogin{Login: strings.TrimSpace(login)})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (a *Application) rememberLogin(login string) {
+	if a.cfg == nil || !a.cfg.RememberLoginEnabled() {
+		return
+	}
+	if err := saveLastLogin(a.cfg.LastLoginPath(), login); err != nil {
+		a.logger.Errorf("save remembered login failed: %v", err)
+	}
+}
+
+// storeCredentials сохраняет учётные данные в хранилище ОС, если отмечено «Запомнить меня»,
+// и удаляет ранее сохранённые, если отметку сняли.
+func (a *Application) storeCredentials(login, password string, remember bool) {
+	if !remember {
+		if err := credstore.Delete(); err != nil && !errors.Is(err, credstore.ErrNotSupported) {
+			a.logger.Errorf("delete stored credentials failed: %v", err)
+		}
+		return
+	}
+	if err := credstore.Save(login, password); err != nil {
+		a.logger.Errorf("store credentials failed: %v", err)
+		return
+	}
+	a.logger.Infof("credentials stored in OS credential store")
+}
+
+// loadStoredCredentials заполняет форму входа учётными данными из хранилища ОС.
+func (a *Application) loadStoredCredentials(ctx *state.AppContext) {
+	login, password, err := credstore.Load()
+	if err != nil {
+		if !errors.Is(err, credstore.ErrNotFound) && !errors.Is(err, credstore.ErrNotSupported) {
+			a.logger.Errorf("load stored credentials failed: %v", err)
+		}
+		return
+	}
+	ctx.UI.LoginInput = login
+	ctx.UI.PasswordInput = password
+	ctx.UI.RememberCredentials = true
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"customvpn/client/internal/config"
+)
+
+func TestLoadLastLogin(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name    string
+		content *string
+		want    string
+		wantErr bool
+	}{
+		{name: "absent", content: nil, want: ""},
+		{name: "valid", content: stringPtr(`{"login":" alice "}`), want: "alice"},
+		{name: "corrupt", content: stringPtr(`{"login":`), want: "", wantErr: true},
+		{name: "empty", content: stringPtr(""), want: "", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".json")
+			if tc.content != nil {
+				if err := os.WriteFile(path, []byte(*tc.content), 0o600); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			got, err := loadLastLogin(path)
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Fatalf("loadLastLogin = %q, %v; want %q, error %v", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRememberLoginStoresOnlyLogin(t *testing.T) {
+	dir := t.TempDir()
+	enabled, disabled := true, false
+	a := &Application{cfg: &config.Config{DataDir: dir, RememberLogin: &enabled}, logger: newTestLogger(t)}
+
+	a.rememberLogin("alice")
+	data, err := os.ReadFile(a.cfg.LastLoginPath())
+	if err != nil {
+		t.Fatalf("read remembered login: %v", err)
+	}
+	if strings.Contains(string(data), "password") {
+		t.Fatalf("remembered login file has a password field: %s", data)
+	}
+	if login, err := loadLastLogin(a.cfg.LastLoginPath()); err != nil || login != "alice" {
+		t.Fatalf("loadLastLogin = %q, %v; want alice", login, err)
+	}
+
+	// remember_login: false — файл не перезаписывается
+	a.cfg.RememberLogin = &disabled
+	a.rememberLogin("bob")
+	if login, _ := loadLastLogin(a.cfg.LastLoginPath()); login != "alice" {
+		t.Fatalf("login saved with remember_login off: %q", login)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
@@ -1,58 +1,90 @@
 ﻿package app
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"net"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"customvpn/client/internal/controlclient"
+	"customvpn/client/internal/dns"
 	"customvpn/client/internal/firewall"
+	"customvpn/client/internal/i18n"
 	"customvpn/client/internal/routes"
 	"customvpn/client/internal/state"
 )
 
 const (
-	preflightAttempts      = 3
-	preflightDelay         = 2 * time.Second
 	requestTimeout         = 15 * time.Second
 	routeOpTimeout         = 5 * time.Second
 	processStopTimeout     = 5 * time.Second
 	connectionCheckTimeout = 5 * time.Second
 	tunnelDetectTimeout    = 10 * time.Second
+	logoutTimeout          = 3 * time.Second
+	exitRoutesTimeout      = 30 * time.Second
+	exitStepTimeout        = 10 * time.Second
+	exitConnectWaitTimeout = 30 * time.Second
 	tunnelDetectDelay      = 500 * time.Millisecond
+	coreVersionTimeout     = 5 * time.Second
+	dnsOpTimeout           = 15 * time.Second
 	killSwitchCheckAttempts = 3
 	killSwitchCheckDelay    = 500 * time.Millisecond
 )
 
+// errTunnelDetectCanceled — ожидание интерфейса туннеля прервано отменой подключения или выходом.
+var errTunnelDetectCanceled = errors.New("tunnel detection canceled")
+
+// errCoreCheckTimeout — `core check` не завершился за core_check_timeout.
+var errCoreCheckTimeout = errors.New("core config check timed out")
+
+// errCoreBindAddress — не удалось вычислить адрес для ${SERVER_BIND_ADDRESS}.
+var errCoreBindAddress = errors.New("resolve core bind address")
+
 func (a *Application) startPreflight(_ *state.AppContext) {
+	attempts := a.cfg.PreflightAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
 	var lastErr error
-	for attempt := 1; attempt <= preflightAttempts; attempt++ {
+	for attempt := 1; attempt <= attempts; attempt++ {
 		if a.isStopping() {
 			return
 		}
-		ctx, cancel := a.requestContext(10 * time.Second)
-		err := a.control.CheckHealth(ctx)
+		ctx, cancel := a.controlContext()
+		info, err := a.control.CheckHealth(ctx)
 		cancel()
 		if err == nil {
-			a.logger.Infof("preflight succeeded on attempt %d", attempt)
-			a.dispatch(state.Event{Type: state.EventSysPreflightSuccess})
+			a.logger.Infof("preflight succeeded on attempt %d, api version %s", attempt, info.APIVersion)
+			if err := controlclient.CheckAPIVersion(info); err != nil {
+				a.logger.Errorf("preflight: %v", err)
+				a.dispatch(state.Event{Type: state.EventSysPreflightFailure, Payload: buildPreflightFailurePayload(err)})
+				return
+			}
+			a.dispatch(state.Event{Type: state.EventSysPreflightSuccess, Payload: state.PreflightSuccessPayload{Server: info}})
 			return
 		}
 		lastErr = err
-		a.logger.Errorf("preflight attempt %d/%d failed: %v", attempt, preflightAttempts, err)
-		if attempt < preflightAttempts {
-			if a.isStopping() {
+		a.logger.Errorf("preflight attempt %d/%d failed: %v", attempt, attempts, err)
+		if attempt < attempts {
+			// сервер под нагрузкой может попросить подождать дольше обычной паузы
+			delay := max(backoffDelay(attempt, a.cfg.PreflightBaseDelay, a.cfg.PreflightMaxDelay), controlclient.RetryAfter(err))
+			a.logger.Debugf("preflight retry in %s", delay)
+			if !a.sleep(delay) {
 				return
 			}
-			time.Sleep(preflightDelay)
 		}
 	}
 	payload := buildPreflightFailurePayload(lastErr)
@@ -63,23 +95,54 @@ func (a *Application) startAuth(_ *state.AppContext, login, password string) {
 	if a.isStopping() {
 		return
 	}
-	ctx, cancel := a.requestContext(requestTimeout)
+	ctx, cancel := a.controlContext()
 	defer cancel()
 	token, err := a.control.Auth(ctx, login, password)
 	if err != nil {
 		a.logger.Errorf("auth request failed: %v", err)
 		payload := buildAuthFailurePayload(err)
+		a.audit(auditLogin, "result", "failure", "login", login, "kind", string(payload.Kind))
 		a.dispatch(state.Event{Type: state.EventSysAuthFailure, Payload: payload})
 		return
 	}
 	a.logger.Infof("auth succeeded, token length %d", len(token))
+	a.audit(auditLogin, "result", "success", "login", login)
+	a.rememberCredentials(login, password)
 	a.dispatch(state.Event{Type: state.EventSysAuthSuccess, Payload: state.AuthSuccessPayload{Token: token}})
 }
 
+func (a *Application) rememberCredentials(login, password string) {
+	a.credsMu.Lock()
+	a.login = login
+	a.password = password
+	a.credsMu.Unlock()
+}
+
+// reauthenticate повторно вызывает /auth с учётными данными последнего успешного входа.
+func (a *Application) reauthenticate(ctx context.Context) (string, error) {
+	a.credsMu.Lock()
+	login, password := a.login, a.password
+	a.credsMu.Unlock()
+	if strings.TrimSpace(login) == "" {
+		return "", errors.New("no stored credentials for re-authentication")
+	}
+	token, err := a.control.Auth(ctx, login, password)
+	if err != nil {
+		a.logger.Errorf("re-authentication failed: %v", err)
+		return "", err
+	}
+	a.logger.Infof("re-authentication succeeded, token length %d", len(token))
+	return token, nil
+}
+
+func (a *Application) onTokenRefreshed(token string) {
+	a.dispatch(state.Event{Type: state.EventSysTokenRefreshed, Payload: state.AuthSuccessPayload{Token: token}})
+}
+
 func buildAuthFailurePayload(err error) state.ScenarioResultPayload {
 	payload := state.ScenarioResultPayload{
 		Kind:             state.ErrorKindAuthFailed,
-		Message:          "Ошибка авторизации",
+		Message:          i18n.T("error.auth_failed"),
 		TechnicalMessage: "",
 	}
 	if err == nil {
@@ -88,7 +151,7 @@ func buildAuthFailurePayload(err error) state.ScenarioResultPayload {
 	payload.TechnicalMessage = err.Error()
 	if errors.Is(err, context.DeadlineExceeded) {
 		payload.Kind = state.ErrorKindNetworkUnavailable
-		payload.Message = "Истекло время ожидания ответа сервера авторизации"
+		payload.Message = i18n.T("error.auth_timeout")
 		return payload
 	}
 	var cErr *controlclient.Error
@@ -98,30 +161,73 @@ func buildAuthFailurePayload(err error) state.ScenarioResultPayload {
 		}
 		switch cErr.Kind {
 		case state.ErrorKindAuthFailed:
-			payload.Message = "Неверный логин или пароль"
+			payload.Message = i18n.T("error.bad_credentials")
+		case state.ErrorKindAccountLocked:
+			payload.Message = i18n.T("error.account_locked")
+		case state.ErrorKindTooManyAttempts:
+			payload.Message = i18n.T("error.too_many_attempts")
+			if cErr.RetryAfter > 0 {
+				minutes := int(math.Ceil(cErr.RetryAfter.Minutes()))
+				payload.Message = i18n.Tf("error.too_many_attempts_minutes", minutes)
+			}
 		case state.ErrorKindNetworkUnavailable:
-			payload.Message = "Не удалось подключиться к серверу авторизации"
+			payload.Message = i18n.T("error.auth_unreachable")
 		default:
 			if cErr.Status > 0 {
-				payload.Message = fmt.Sprintf("Ошибка авторизации (код %d)", cErr.Status)
+				payload.Message = i18n.Tf("error.auth_status", cErr.Status)
 			}
 		}
 	}
 	return payload
 }
 
+// testConnection выполняет разовую проверку /health по кнопке в окне входа, без повторов preflight.
+func (a *Application) testConnection(_ *state.AppContext) {
+	if a.isStopping() {
+		return
+	}
+	ctx, cancel := a.controlContext()
+	info, err := a.control.CheckHealth(ctx)
+	cancel()
+	if err == nil {
+		err = controlclient.CheckAPIVersion(info)
+	}
+	payload := state.TestConnectionPayload{OK: err == nil, Message: i18n.T("notice.server_available")}
+	if err != nil {
+		a.logger.Infof("test connection failed: %v", err)
+		payload.Message = buildPreflightFailurePayload(err).Message
+		if payload.Message == i18n.T("error.preflight_unavailable") {
+			payload.Message = i18n.T("error.control_unreachable")
+		}
+	} else {
+		a.logger.Infof("test connection succeeded, api version %s", info.APIVersion)
+	}
+	a.dispatch(state.Event{Type: state.EventSysTestConnectionDone, Payload: payload})
+}
+
 func buildPreflightFailurePayload(err error) state.ScenarioResultPayload {
 	payload := state.ScenarioResultPayload{
 		Kind:             state.ErrorKindNetworkUnavailable,
-		Message:          "Нет связи с управляющим сервером. Повторим через 5 секунд",
+		// сообщение по умолчанию, когда причина сбоя не уточнена
+		Message:          i18n.T("error.preflight_unavailable"),
 		TechnicalMessage: "",
 	}
 	if err == nil {
 		return payload
 	}
 	payload.TechnicalMessage = err.Error()
+	var versionErr *controlclient.APIVersionError
+	if errors.As(err, &versionErr) {
+		payload.Kind = state.ErrorKindIncompatibleServer
+		payload.Message = i18n.Tf("error.api_version", versionErr.Version)
+		return payload
+	}
+	if errors.Is(err, controlclient.ErrCertificatePinMismatch) {
+		payload.Message = i18n.T("error.cert_pin")
+		return payload
+	}
 	if errors.Is(err, context.DeadlineExceeded) {
-		payload.Message = "Истекло время ожидания ответа управляющего сервера"
+		payload.Message = i18n.T("error.control_timeout")
 		return payload
 	}
 	var cErr *controlclient.Error
@@ -130,7 +236,10 @@ func buildPreflightFailurePayload(err error) state.ScenarioResultPayload {
 			payload.Kind = cErr.Kind
 		}
 		if cErr.Status > 0 {
-			payload.Message = fmt.Sprintf("Управляющий сервер недоступен (код %d)", cErr.Status)
+			payload.Message = i18n.Tf("error.control_status", cErr.Status)
+		}
+		if payload.RetryAfter = controlclient.RetryAfter(err); payload.RetryAfter > 0 {
+			payload.Message += i18n.Tf("error.retry_in", int(math.Ceil(max(payload.RetryAfter, 5*time.Second).Seconds())))
 		}
 	}
 	return payload
@@ -148,7 +257,7 @@ func buildSyncFailurePayload(err error, fallback string) state.ScenarioResultPay
 	payload.TechnicalMessage = err.Error()
 	if errors.Is(err, context.DeadlineExceeded) {
 		payload.Kind = state.ErrorKindNetworkUnavailable
-		payload.Message = "Истекло время ожидания ответа сервера"
+		payload.Message = i18n.T("error.server_timeout")
 		return payload
 	}
 	var cErr *controlclient.Error
@@ -156,8 +265,14 @@ func buildSyncFailurePayload(err error, fallback string) state.ScenarioResultPay
 		if cErr.Kind != "" {
 			payload.Kind = cErr.Kind
 		}
-		if cErr.Status > 0 {
-			payload.Message = fmt.Sprintf("%s (код %d)", fallback, cErr.Status)
+		// повторный вход после истечения токена не удался: нужен ввод логина, а не повтор загрузки
+		switch {
+		case cErr.Kind == state.ErrorKindAuthFailed:
+			payload.Message = i18n.T("status.session_expired")
+		case cErr.Kind == state.ErrorKindAccountLocked:
+			payload.Message = i18n.T("error.account_locked")
+		case cErr.Status > 0:
+			payload.Message = i18n.Tf("error.with_status", fallback, cErr.Status)
 		}
 	}
 	return payload
@@ -166,7 +281,7 @@ func buildSyncFailurePayload(err error, fallback string) state.ScenarioResultPay
 func buildPrepareEnvFailurePayload(err error) state.ScenarioResultPayload {
 	payload := state.ScenarioResultPayload{
 		Kind:             state.ErrorKindRoutingFailed,
-		Message:          "Не удалось подготовить маршруты",
+		Message:          i18n.T("error.prepare_routes"),
 		TechnicalMessage: "",
 	}
 	if err == nil {
@@ -176,54 +291,50 @@ func buildPrepareEnvFailurePayload(err error) state.ScenarioResultPayload {
 	msg := strings.TrimSpace(err.Error())
 	lower := strings.ToLower(msg)
 	if strings.Contains(lower, "requires elevation") || strings.Contains(lower, "привил") {
-		payload.Message = "Недостаточно прав. Запустите приложение от имени администратора"
+		payload.Message = i18n.T("error.elevation_required")
 		return payload
 	}
 	if strings.Contains(lower, "multiple default gateways") {
-		payload.Message = "Обнаружено несколько шлюзов по умолчанию"
+		payload.Message = i18n.T("error.multiple_gateways")
 		return payload
 	}
 	if errors.Is(err, context.DeadlineExceeded) {
-		payload.Message = "Истекло время ожидания при подготовке маршрутов"
+		payload.Message = i18n.T("error.prepare_routes_timeout")
 		return payload
 	}
 	return payload
 }
 
 func prepareGatewayErrorMessage(err error) string {
-	if err == nil {
-		return "РќРµ СѓРґР°Р»РѕСЃСЊ РѕРїСЂРµРґРµР»РёС‚СЊ С€Р»СЋР· РїРѕ СѓРјРѕР»С‡Р°РЅРёСЋ"
-	}
-	msg := strings.ToLower(err.Error())
-	if strings.Contains(msg, "multiple default gateways") {
-		return "РћР±РЅР°СЂСѓР¶РµРЅРѕ РЅРµСЃРєРѕР»СЊРєРѕ С€Р»СЋР·РѕРІ РїРѕ СѓРјРѕР»С‡Р°РЅРёСЋ"
-	}
-	if strings.Contains(msg, "default gateway not found") {
-		return "РќРµ СѓРґР°Р»РѕСЃСЊ РѕРїСЂРµРґРµР»РёС‚СЊ С€Р»СЋР· РїРѕ СѓРјРѕР»С‡Р°РЅРёСЋ"
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "multiple default gateways") {
+		return i18n.T("error.multiple_gateways")
 	}
-	return "РќРµ СѓРґР°Р»РѕСЃСЊ РѕРїСЂРµРґРµР»РёС‚СЊ С€Р»СЋР· РїРѕ СѓРјРѕР»С‡Р°РЅРёСЋ"
+	return i18n.T("error.gateway_detect")
 }
 
 func (a *Application) startSync(appCtx *state.AppContext) {
 	if a.isStopping() {
 		return
 	}
-	authToken := strings.TrimSpace(appCtx.AuthToken)
+	authToken := strings.TrimSpace(appCtx.AuthToken())
 	if authToken == "" {
 		a.logger.Errorf("sync requested without auth token")
-		payload := buildSyncFailurePayload(errors.New("auth token is empty"), "Не удалось загрузить данные")
+		payload := buildSyncFailurePayload(errors.New("auth token is empty"), i18n.T("error.load_data"))
 		a.dispatch(state.Event{Type: state.EventSysSyncFailure, Payload: payload})
 		return
 	}
-	profilesCtx, cancelProfiles := a.requestContext(requestTimeout)
+	profilesCtx, cancelProfiles := a.controlContext()
 	profiles, err := a.control.SyncProfileList(profilesCtx, authToken)
 	cancelProfiles()
 	if err != nil {
 		a.logger.Errorf("sync profiles failed: %v", err)
-		payload := buildSyncFailurePayload(err, "Не удалось загрузить список профилей")
+		payload := buildSyncFailurePayload(err, i18n.T("error.load_profiles"))
 		a.dispatch(state.Event{Type: state.EventSysSyncFailure, Payload: payload})
 		return
 	}
+	// сервер не обязан сохранять порядок: без сортировки список и выбор «прыгают» при обновлении
+	sortProfiles(profiles)
+	profiles = a.loadProfileDetails(authToken, profiles)
 	if a.logger != nil {
 		for _, profile := range profiles {
 			a.logger.Infof("sync profiles: id=%s", profile.ID)
@@ -235,6 +346,46 @@ func (a *Application) startSync(appCtx *state.AppContext) {
 	}
 }
 
+// syncDetailConcurrency — сколько полных профилей загружается одновременно при синхронизации.
+const syncDetailConcurrency = 4
+
+// loadProfileDetails загружает полные профили (с core_config) для списка из /sync/profiles,
+// сообщая прогресс событиями EventSysSyncProgress. При ошибке остаётся список без core_config:
+// полный профиль загрузится при подключении.
+func (a *Application) loadProfileDetails(authToken string, profiles []state.Profile) []state.Profile {
+	if len(profiles) == 0 {
+		return profiles
+	}
+	ids := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		ids = append(ids, profile.ID)
+	}
+	detailsCtx, cancel := a.controlContext()
+	detailed, err := a.control.SyncProfilesDetailedProgress(detailsCtx, authToken, ids, syncDetailConcurrency, func(done, total int) {
+		a.dispatch(state.Event{Type: state.EventSysSyncProgress, Payload: state.SyncProgressPayload{Done: done, Total: total}})
+	})
+	cancel()
+	if err != nil {
+		a.logger.Errorf("sync profile details failed, keeping the profile list: %v", err)
+		return profiles
+	}
+	return detailed
+}
+
+// sortProfiles упорядочивает профили по стране, имени (без учёта регистра) и ID.
+func sortProfiles(profiles []state.Profile) {
+	sort.SliceStable(profiles, func(i, j int) bool {
+		left, right := profiles[i], profiles[j]
+		if c := strings.Compare(strings.ToLower(left.Country), strings.ToLower(right.Country)); c != 0 {
+			return c < 0
+		}
+		if c := strings.Compare(strings.ToLower(left.Name), strings.ToLower(right.Name)); c != 0 {
+			return c < 0
+		}
+		return left.ID < right.ID
+	})
+}
+
 func (a *Application) startPrepareEnv(appCtx *state.AppContext) {
 	if a.isStopping() {
 		return
@@ -251,16 +402,39 @@ func (a *Application) startConnecting(ctx *state.AppContext) {
 	if a.isStopping() {
 		return
 	}
+	connectCtx, done := a.beginConnect()
+	if connectCtx == nil {
+		return
+	}
+	// Stop ждёт done: откат и итоговое событие должны завершиться до teardown выхода
+	defer close(done)
 	artifacts := newConnectArtifacts(a, ctx)
-	if err := a.executeConnecting(ctx, artifacts); err != nil {
+	err := a.executeConnecting(ctx, artifacts)
+	timedOut := errors.Is(connectCtx.Err(), context.DeadlineExceeded)
+	canceled := connectCtx.Err() != nil && !timedOut && !a.isStopping()
+	// откат выполняется уже вне отменённого контекста
+	a.endConnect()
+	profileID := ctx.SelectedProfileID()
+	if err != nil {
 		artifacts.rollback()
+		if canceled {
+			a.logger.Infof("connecting scenario canceled by user")
+			a.audit(auditConnect, "result", "canceled", "profile", profileID)
+			a.dispatch(state.Event{Type: state.EventSysConnectingCanceled})
+			return
+		}
 		kind := err.kind
 		if kind == "" {
 			kind = state.ErrorKindProcessFailed
 		}
 		message := err.message
+		if timedOut {
+			// шаг, прерванный по сроку, сообщает своё; пользователю важна общая причина
+			message = i18n.T("error.connect_timeout")
+			a.logger.Errorf("connecting scenario exceeded connect_timeout %s", a.cfg.ConnectTimeout)
+		}
 		if message == "" {
-			message = "РќРµ СѓРґР°Р»РѕСЃСЊ РїРѕРґРєР»СЋС‡РёС‚СЊСЃСЏ"
+			message = i18n.T("error.connect_failed")
 		}
 		if err.err != nil {
 			a.logger.Errorf("connecting scenario failed: %v", err.err)
@@ -268,11 +442,17 @@ func (a *Application) startConnecting(ctx *state.AppContext) {
 			a.logger.Errorf("connecting scenario failed: %s", message)
 		}
 		payload := state.ScenarioResultPayload{Kind: kind, Message: message}
+		a.audit(auditConnect, "result", "failure", "profile", profileID, "kind", string(kind))
 		a.dispatch(state.Event{Type: state.EventSysConnectingFailure, Payload: payload})
 		return
 	}
 	a.logger.Infof("connecting scenario completed")
-	a.dispatch(state.Event{Type: state.EventSysConnectingSuccess})
+	a.audit(auditConnect, "result", "success", "profile", profileID)
+	payload := state.ConnectingSuccessPayload{Server: artifacts.server, Gateway: artifacts.gateway, GatewayV6: artifacts.gatewayV6}
+	if artifacts.tunnel != nil {
+		payload.Tunnel = *artifacts.tunnel
+	}
+	a.dispatch(state.Event{Type: state.EventSysConnectingSuccess, Payload: payload})
 }
 
 func (a *Application) startDisconnecting(ctx *state.AppContext) {
@@ -284,8 +464,10 @@ func (a *Application) startDisconnecting(ctx *state.AppContext) {
 	}
 	if err := a.executeDisconnecting(ctx); err != nil {
 		a.logger.Errorf("disconnecting scenario completed with errors: %v", err)
+		a.audit(auditDisconnect, "result", "partial", "profile", ctx.SelectedProfileID())
 	} else {
 		a.logger.Infof("disconnecting scenario completed")
+		a.audit(auditDisconnect, "result", "success", "profile", ctx.SelectedProfileID())
 	}
 	a.dispatch(state.Event{Type: state.EventSysDisconnectingDone})
 }
@@ -294,14 +476,45 @@ func (a *Application) forceCleanup(ctx *state.AppContext) {
 	if a == nil {
 		return
 	}
+	result := a.cleanup(ctx)
+	if a.machine != nil {
+		_ = a.dispatch(state.Event{Type: state.EventSysCleanupDone, Payload: result})
+	}
+}
+
+// cleanup снимает маршруты, правила Kill Switch и DNS сессии, в том числе сохранённые
+// предыдущим запуском, и останавливает Core. Ошибки шагов собираются в result.Errors.
+func (a *Application) cleanup(ctx *state.AppContext) state.CleanupResultPayload {
 	if a.logger != nil {
 		a.logger.Debugf("cleanup requested")
 	}
 	var errs []string
+	var result state.CleanupResultPayload
 	saved, savedErr := a.loadCleanupState()
 	if savedErr != nil && a.logger != nil {
 		a.logger.Errorf("cleanup: load state failed: %v", savedErr)
 	}
+	restored := make(map[string]struct{})
+	if ctx != nil {
+		for _, record := range ctx.DNSRegistry.List() {
+			restored[record.Interface] = struct{}{}
+		}
+		errs = append(errs, a.restoreDNS(ctx)...)
+	}
+	if saved != nil && len(saved.DNSInterfaces) > 0 {
+		var pending []string
+		for _, iface := range saved.DNSInterfaces {
+			if _, ok := restored[iface]; !ok {
+				pending = append(pending, iface)
+			}
+		}
+		if len(pending) > 0 {
+			if a.logger != nil {
+				a.logger.Debugf("cleanup: resetting DNS from saved state: %v", pending)
+			}
+			errs = append(errs, a.resetDNSInterfaces(pending)...)
+		}
+	}
 	if saved != nil && saved.CorePID > 0 {
 		if a.logger != nil {
 			a.logger.Debugf("cleanup: stopping core pid=%d", saved.CorePID)
@@ -332,7 +545,9 @@ func (a *Application) forceCleanup(ctx *state.AppContext) {
 			a.logger.Debugf("cleanup: removing kill switch group")
 		}
 		firewallCtx, cancel := a.requestContext(routeOpTimeout)
-		if err := a.firewall.RemoveKillSwitchGroup(firewallCtx); err != nil {
+		removed, err := a.firewall.RemoveKillSwitchGroup(firewallCtx)
+		result.FirewallRulesRemoved = removed
+		if err != nil {
 			errs = append(errs, err.Error())
 			if a.logger != nil {
 				a.logger.Errorf("cleanup firewall group failed: %v", err)
@@ -351,16 +566,22 @@ func (a *Application) forceCleanup(ctx *state.AppContext) {
 				if a.logger != nil {
 					a.logger.Errorf("cleanup route %s failed: %v", record.Destination, err)
 				}
+				continue
 			}
+			result.RoutesRemoved++
 		}
 	}
 	if saved != nil {
-		a.cleanupRoutesFromState(saved, &errs)
+		result.RoutesRemoved += a.cleanupRoutesFromState(saved, &errs)
 	}
-	if a.machine != nil {
-		_ = a.dispatch(state.Event{Type: state.EventSysCleanupDone, Payload: state.CleanupResultPayload{Errors: errs}})
+	result.RoutesRemoved += a.cleanupTunnelRoutes(&errs)
+	if a.logger != nil {
+		a.logger.Infof("cleanup done: routes=%d firewall_rules=%d errors=%d", result.RoutesRemoved, result.FirewallRulesRemoved, len(errs))
 	}
+	a.audit(auditCleanup, "routes", strconv.Itoa(result.RoutesRemoved), "firewall_rules", strconv.Itoa(result.FirewallRulesRemoved), "errors", strconv.Itoa(len(errs)))
+	result.Errors = errs
 	_ = a.deleteCleanupState()
+	return result
 }
 
 func (a *Application) launchProcess(name state.ProcessName, binary, logFile string, args []string) (*state.ProcessRecord, error) {
@@ -378,7 +599,7 @@ func (a *Application) launchProcess(name state.ProcessName, binary, logFile stri
 		Status:    state.ProcessStarting,
 	}
 	a.ctx.ProcessRegistry.Update(startRecord)
-	record, err := a.launcher.Start(name, binary, args, logFile)
+	record, err := a.launcher.Start(name, binary, args, logFile, a.onCoreLine)
 	if err != nil {
 		exitTime := time.Now()
 		startRecord.ExitedAt = &exitTime
@@ -405,11 +626,156 @@ func (a *Application) requestContext(timeout time.Duration) (context.Context, co
 	if timeout <= 0 {
 		timeout = requestTimeout
 	}
+	return context.WithTimeout(a.parentContext(), timeout)
+}
+
+// controlContext возвращает контекст для запросов к Control-серверу.
+// Таймауты отдельных эндпоинтов применяет controlclient.
+func (a *Application) controlContext() (context.Context, context.CancelFunc) {
+	return context.WithCancel(a.parentContext())
+}
+
+// parentContext возвращает контекст текущего сценария подключения, если он выполняется,
+// иначе контекст жизни приложения.
+func (a *Application) parentContext() context.Context {
+	if a == nil {
+		return context.Background()
+	}
+	a.connectMu.Lock()
+	connectCtx := a.connectCtx
+	teardownCtx := a.teardownCtx
+	a.connectMu.Unlock()
+	// во время выхода контекст приложения уже может быть отменён: шаги ограничены своим таймаутом
+	if teardownCtx != nil {
+		return teardownCtx
+	}
+	if connectCtx != nil {
+		return connectCtx
+	}
+	if a.runCtx != nil {
+		return a.runCtx
+	}
+	return context.Background()
+}
+
+// beginConnect создаёт отменяемый контекст для сценария подключения со сроком connect_timeout
+// и канал, который сценарий закрывает по завершении. После Stop возвращает nil: новый сценарий не начинается.
+func (a *Application) beginConnect() (context.Context, chan struct{}) {
 	parent := context.Background()
-	if a != nil && a.runCtx != nil {
+	if a.runCtx != nil {
 		parent = a.runCtx
 	}
-	return context.WithTimeout(parent, timeout)
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if a.cfg != nil && a.cfg.ConnectTimeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, a.cfg.ConnectTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	a.connectMu.Lock()
+	defer a.connectMu.Unlock()
+	if a.connectClosed {
+		cancel()
+		return nil, nil
+	}
+	done := make(chan struct{})
+	a.connectCtx = ctx
+	a.connectCancel = cancel
+	a.connectDone = done
+	return ctx, done
+}
+
+// closeConnect запрещает новые сценарии подключения, прерывает текущий и ждёт
+// завершения его отката не дольше timeout.
+func (a *Application) closeConnect(timeout time.Duration) bool {
+	a.connectMu.Lock()
+	a.connectClosed = true
+	done := a.connectDone
+	a.connectMu.Unlock()
+	a.cancelConnecting(nil)
+	if done == nil {
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (a *Application) endConnect() {
+	a.connectMu.Lock()
+	cancel := a.connectCancel
+	a.connectCtx = nil
+	a.connectCancel = nil
+	a.connectMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// checkConnectCanceled прерывает сценарий между шагами, если подключение отменено.
+func (a *Application) checkConnectCanceled() *scenarioError {
+	if err := a.parentContext().Err(); err != nil {
+		return newScenarioError(state.ErrorKindProcessFailed, i18n.T("status.connect_cancelled"), err)
+	}
+	return nil
+}
+
+// cancelConnecting прерывает сценарий подключения по нажатию «Отключиться».
+func (a *Application) cancelConnecting(_ *state.AppContext) {
+	a.connectMu.Lock()
+	cancel := a.connectCancel
+	a.connectMu.Unlock()
+	if cancel == nil {
+		return
+	}
+	a.logger.Infof("canceling connecting scenario")
+	cancel()
+}
+
+// backoffDelay возвращает паузу перед следующей попыткой: экспоненциальный рост от base
+// с ограничением max и полным джиттером (равномерно в [0, предел]).
+func backoffDelay(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	if max < base {
+		max = base
+	}
+	limit := base
+	for i := 1; i < attempt && limit < max; i++ {
+		limit *= 2
+	}
+	if limit > max {
+		limit = max
+	}
+	return rand.N(limit + 1)
+}
+
+// sleep ждёт указанное время и возвращает false, если приложение останавливается.
+func (a *Application) sleep(d time.Duration) bool {
+	if a.isStopping() {
+		return false
+	}
+	if d <= 0 {
+		return true
+	}
+	if a == nil || a.runCtx == nil {
+		time.Sleep(d)
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-a.runCtx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
 
 func (a *Application) isStopping() bool {
@@ -449,90 +815,321 @@ func (a *Application) resolveControlIPv4() (net.IP, error) {
 	return nil, fmt.Errorf("no IPv4 records for %s", host)
 }
 
-func (a *Application) executeConnecting(ctx *state.AppContext, artifacts *connectArtifacts) *scenarioError {
-	if a.cfg == nil {
-		return newScenarioError(state.ErrorKindConfigFailed, "Конфигурация приложения не загружена", fmt.Errorf("config is nil"))
+// selectDefaultGateway определяет шлюз по умолчанию. Если шлюзов несколько, берётся выбранный
+// ранее интерфейс; пока он на месте, диалог не показывается и при автопереподключении.
+// Иначе пользователь выбирает сеть в диалоге; отказ от выбора отменяет подключение.
+// Без interactive (предпросмотр) диалог не показывается и берётся лучший шлюз.
+func (a *Application) selectDefaultGateway(ctx *state.AppContext, interactive bool) (*state.GatewayInfo, *scenarioError) {
+	gateways, err := a.gateways.DefaultGateways()
+	if err != nil {
+		return nil, newScenarioError(state.ErrorKindRoutingFailed, prepareGatewayErrorMessage(err), err)
 	}
-	if a.routes == nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Маршрутизатор не инициализирован", fmt.Errorf("route manager is nil"))
+	if len(gateways) == 1 {
+		return gateways[0], nil
 	}
-	gateway, err := routes.DetectDefaultGateway()
-	if err != nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, prepareGatewayErrorMessage(err), err)
+	if choice := ctx.GatewayChoice(); choice != "" {
+		for _, gw := range gateways {
+			if gw.InterfaceName == choice {
+				a.logger.Debugf("default gateway remembered for the session: %s via %s", gw.InterfaceName, gw.IP)
+				return gw, nil
+			}
+		}
+		a.logger.Infof("remembered gateway interface %s is gone", choice)
+		if interactive {
+			ctx.SetGatewayChoice("")
+		}
 	}
-	ctx.DefaultGateway = gateway
-	profile := ctx.FindProfile(ctx.SelectedProfileID)
-	if profile == nil {
-		return newScenarioError(state.ErrorKindConfigFailed, "Не удалось найти выбранный профиль", fmt.Errorf("profile %s not found", ctx.SelectedProfileID))
+	names := make([]string, 0, len(gateways))
+	for _, gw := range gateways {
+		names = append(names, fmt.Sprintf("%s via %s metric %d", gw.InterfaceName, gw.IP, gw.Metric))
 	}
-	if len(profile.CoreConfigRaw) == 0 {
-		profileCtx, cancel := a.requestContext(requestTimeout)
-		fullProfile, err := a.control.SyncProfile(profileCtx, ctx.AuthToken, profile.ID)
+	a.logger.Infof("multiple default gateways detected: %s", strings.Join(names, "; "))
+	if !interactive {
+		return gateways[0], nil
+	}
+	if a.ui == nil {
+		err := fmt.Errorf("multiple default gateways detected")
+		return nil, newScenarioError(state.ErrorKindRoutingFailed, prepareGatewayErrorMessage(err), err)
+	}
+	gateway, ok := a.ui.ChooseGateway(a.parentContext(), gateways)
+	if !ok {
+		a.cancelConnecting(ctx)
+		if scErr := a.checkConnectCanceled(); scErr != nil {
+			return nil, scErr
+		}
+		return nil, newScenarioError(state.ErrorKindProcessFailed, i18n.T("status.connect_cancelled"), context.Canceled)
+	}
+	a.logger.Infof("default gateway selected by user: %s via %s", gateway.InterfaceName, gateway.IP)
+	ctx.SetGatewayChoice(gateway.InterfaceName)
+	return gateway, nil
+}
+
+// connectTarget — профиль и шлюзы, выбранные для подключения, с маршрутами по семействам адресов.
+type connectTarget struct {
+	// profile — копия выбранного профиля, дополненная полной версией с сервера при необходимости.
+	profile   state.Profile
+	fetched   bool
+	gateway   *state.GatewayInfo
+	gatewayV6 *state.GatewayInfo
+	ipv6      bool
+	directV4  []string
+	directV6  []string
+	tunnelV4  []string
+	tunnelV6  []string
+}
+
+// resolveConnectTarget выбирает шлюзы и загружает выбранный профиль, не меняя систему.
+// Общий шаг для executeConnecting и previewConnect; interactive разрешает диалог выбора шлюза.
+func (a *Application) resolveConnectTarget(ctx *state.AppContext, interactive bool) (*connectTarget, *scenarioError) {
+	selectedID := ctx.SelectedProfileID()
+	selected, ok := ctx.FindProfile(selectedID)
+	if !ok {
+		return nil, newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.profile_not_found"), fmt.Errorf("profile %s not found", selectedID))
+	}
+	// отключённый профиль отклоняется до выбора шлюза, чтобы не спрашивать пользователя зря
+	if scErr := checkProfileEnabled(selected); scErr != nil {
+		return nil, scErr
+	}
+	gateway, scErr := a.selectDefaultGateway(ctx, interactive)
+	if scErr != nil {
+		return nil, scErr
+	}
+	// шлюз IPv6 нужен только прямым IPv6-маршрутам: ошибка определения не мешает подключению без них
+	gatewayV6, gatewayV6Err := a.gateways.DefaultGatewayV6()
+	if gatewayV6Err != nil {
+		if a.logger != nil {
+			a.logger.Debugf("ipv6 default gateway not detected: %v", gatewayV6Err)
+		}
+		gatewayV6 = nil
+	}
+	target := &connectTarget{profile: selected, gateway: gateway, gatewayV6: gatewayV6}
+	if len(selected.CoreConfigRaw) == 0 {
+		profileCtx, cancel := a.controlContext()
+		fullProfile, err := a.control.SyncProfile(profileCtx, ctx.AuthToken(), selected.ID)
 		cancel()
 		if err != nil {
-			return newScenarioError(state.ErrorKindSyncFailed, "Не удалось загрузить профиль", err)
+			return nil, newScenarioError(state.ErrorKindSyncFailed, i18n.T("error.load_profile"), err)
+		}
+		target.profile = fullProfile
+		target.fetched = true
+		// профиль могли отключить после синхронизации списка
+		if scErr := checkProfileEnabled(fullProfile); scErr != nil {
+			return nil, scErr
 		}
-		*profile = fullProfile
 	}
+	profile := &target.profile
 	if strings.TrimSpace(profile.Host) == "" {
-		return newScenarioError(state.ErrorKindConfigFailed, "Профиль не содержит адрес", fmt.Errorf("profile host is empty"))
+		return nil, newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.profile_no_host"), fmt.Errorf("profile host is empty"))
 	}
 	if profile.Port <= 0 {
-		return newScenarioError(state.ErrorKindConfigFailed, "Профиль не содержит корректный порт", fmt.Errorf("profile port %d invalid", profile.Port))
+		return nil, newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.profile_bad_port"), fmt.Errorf("profile port %d invalid", profile.Port))
+	}
+	target.ipv6 = a.ipv6Enabled(profile)
+	target.directV4, target.directV6 = splitRoutesByFamily(profile.DirectRoutes)
+	target.tunnelV4, target.tunnelV6 = splitRoutesByFamily(profile.TunnelRoutes)
+	if gatewayV6Err != nil && target.ipv6 && len(target.directV6) > 0 {
+		return nil, newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.ipv6_gateway"), gatewayV6Err)
+	}
+	return target, nil
+}
+
+// checkProfileEnabled запрещает подключение к профилю, отключённому на Control-сервере.
+func checkProfileEnabled(profile state.Profile) *scenarioError {
+	if profile.Enabled() {
+		return nil
+	}
+	return newScenarioError(state.ErrorKindConfigFailed, i18n.Tf("error.profile_disabled", profile.Name), fmt.Errorf("profile %s is disabled", profile.ID))
+}
+
+func (a *Application) executeConnecting(ctx *state.AppContext, artifacts *connectArtifacts) *scenarioError {
+	if a.cfg == nil {
+		return newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.config_not_loaded"), fmt.Errorf("config is nil"))
 	}
-	if err := a.addProfileRoutes(ctx, profile.DirectRoutes, state.RouteKindDirect, ctx.DefaultGateway, artifacts); err != nil {
+	if a.routes == nil {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.router_not_ready"), fmt.Errorf("route manager is nil"))
+	}
+	target, scErr := a.resolveConnectTarget(ctx, true)
+	if scErr != nil {
+		return scErr
+	}
+	// шлюзы попадут в ctx из цикла событий вместе с EventSysConnectingSuccess
+	artifacts.gateway, artifacts.gatewayV6 = target.gateway, target.gatewayV6
+	// сценарий работает с копией профиля: список в ctx может смениться из цикла событий
+	selected, ok := ctx.FindProfile(target.profile.ID)
+	if !ok {
+		return newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.profile_not_found"), fmt.Errorf("profile %s not found", target.profile.ID))
+	}
+	if target.fetched {
+		// полная версия профиля кэшируется до следующей синхронизации
+		selected = target.profile
+		ctx.UpdateProfile(selected)
+	}
+	profile := &selected
+	if err := a.runPreConnectHook(profile); err != nil {
 		return err
 	}
-	if err := a.applyKillSwitch(ctx, profile, artifacts); err != nil {
+	artifacts.server = net.JoinHostPort(profile.Host, strconv.Itoa(profile.Port))
+	ipv6 := target.ipv6
+	directV4, directV6 := target.directV4, target.directV6
+	tunnelV4, tunnelV6 := target.tunnelV4, target.tunnelV6
+	if !ipv6 && len(directV6)+len(tunnelV6) > 0 && a.logger != nil {
+		a.logger.Infof("ipv6 disabled: skip IPv6 routes direct=%v tunnel=%v", directV6, tunnelV6)
+	}
+	if err := a.addProfileRoutes(ctx, directV4, state.RouteKindDirect, target.gateway, profile.RouteMetric, artifacts); err != nil {
 		return err
 	}
+	if ipv6 {
+		if err := a.addProfileRoutes(ctx, directV6, state.RouteKindDirect, target.gatewayV6, profile.RouteMetric, artifacts); err != nil {
+			return err
+		}
+	}
+	// профиль без Kill Switch не трогает брандмауэр (кроме блокировки IPv6 ниже)
+	if profile.KillSwitchEnabled {
+		// строгий Kill Switch пропускает прямые маршруты профиля мимо туннеля
+		direct := directV4
+		if ipv6 {
+			direct = append(append([]string(nil), directV4...), directV6...)
+		}
+		if err := a.applyKillSwitch(ctx, profile, target.gateway, direct, artifacts); err != nil {
+			return err
+		}
+	} else if a.logger != nil {
+		a.logger.Infof("kill switch disabled for profile %s: skip DNS block", profile.ID)
+	}
+	if !ipv6 {
+		if err := a.blockIPv6(ctx, profile, target.gatewayV6, artifacts); err != nil {
+			return err
+		}
+	}
 	a.saveCleanupState(ctx)
+	if err := a.checkConnectCanceled(); err != nil {
+		return err
+	}
 	configPath, err := a.writeCoreConfig(profile)
 	if err != nil {
-		return newScenarioError(state.ErrorKindConfigFailed, "Не удалось записать конфигурацию Core", err)
+		if errors.Is(err, errCoreBindAddress) {
+			return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.core_bind_address"), err)
+		}
+		return newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.core_config_write"), err)
 	}
+	ctx.SetCoreConfigFilePath(profile.ID, configPath)
 	if err := a.checkCoreConfig(configPath); err != nil {
-		return newScenarioError(state.ErrorKindConfigFailed, "Проверка конфигурации Core не прошла", err)
+		if scErr := a.checkConnectCanceled(); scErr != nil {
+			return scErr
+		}
+		if errors.Is(err, errCoreCheckTimeout) {
+			return newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.core_check_timeout"), err)
+		}
+		return newScenarioError(state.ErrorKindConfigFailed, i18n.T("error.core_check_failed"), err)
 	}
 	coreArgs := []string{"run", "-c", configPath}
 	if _, err := a.launchProcess(state.ProcessCore, a.cfg.CorePath, a.cfg.CoreLogFile, coreArgs); err != nil {
-		return newScenarioError(state.ErrorKindProcessFailed, "Не удалось запустить Core", err)
+		return newScenarioError(state.ErrorKindProcessFailed, i18n.T("error.core_start"), err)
 	}
 	artifacts.coreStarted = true
 	a.saveCleanupState(ctx)
-	tunnelGateway, err := a.waitForTunnelGateway(tunnelDetectTimeout)
+	tunnelGateway, err := a.waitForTunnelGateway(a.parentContext(), tunnelDetectTimeout)
+	if errors.Is(err, errTunnelDetectCanceled) {
+		return newScenarioError(state.ErrorKindProcessFailed, i18n.T("status.connect_cancelled"), err)
+	}
 	if err != nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Не удалось определить интерфейс туннеля", err)
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.tunnel_interface"), err)
 	}
+	artifacts.tunnel = tunnelGateway
 	if err := deleteCoreConfigFile(profile.CoreConfigFilePath); err != nil {
 		a.logger.Errorf("cleanup core config failed: %v", err)
 	} else {
 		profile.CoreConfigFilePath = ""
+		ctx.SetCoreConfigFilePath(profile.ID, "")
 	}
-	if err := a.applyTunnelDNS(ctx, tunnelGateway, artifacts); err != nil {
+	if err := a.checkConnectCanceled(); err != nil {
 		return err
 	}
-	if err := a.addProfileRoutes(ctx, profile.TunnelRoutes, state.RouteKindTunnel, tunnelGateway, artifacts); err != nil {
+	if err := a.applyTunnelDNS(ctx, profile, tunnelGateway, artifacts); err != nil {
 		return err
 	}
+	if err := a.addProfileRoutes(ctx, tunnelV4, state.RouteKindTunnel, tunnelGateway, profile.RouteMetric, artifacts); err != nil {
+		return err
+	}
+	if ipv6 {
+		// IPv6-маршруты в туннель — on-link через интерфейс TUN
+		tunnelGatewayV6 := &state.GatewayInfo{
+			IP:             "::",
+			InterfaceIndex: tunnelGateway.InterfaceIndex,
+			InterfaceName:  tunnelGateway.InterfaceName,
+			Metric:         tunnelGateway.Metric,
+		}
+		if err := a.addProfileRoutes(ctx, tunnelV6, state.RouteKindTunnel, tunnelGatewayV6, profile.RouteMetric, artifacts); err != nil {
+			return err
+		}
+	}
 	a.saveCleanupState(ctx)
 	return nil
 }
 
 func (a *Application) executeDisconnecting(ctx *state.AppContext) error {
-	a.stopProcess(state.ProcessCore, processStopTimeout)
-	if ctx != nil {
-		if profile := ctx.FindProfile(ctx.SelectedProfileID); profile != nil && profile.CoreConfigFilePath != "" {
-			if err := deleteCoreConfigFile(profile.CoreConfigFilePath); err != nil {
-				a.logger.Errorf("cleanup core config failed: %v", err)
-			} else {
-				profile.CoreConfigFilePath = ""
-			}
-		}
+	if ctx == nil {
+		a.stopProcess(state.ProcessCore, processStopTimeout)
+		_ = a.deleteCleanupState()
+		return nil
+	}
+	return a.teardownSession(ctx, true, func(_ string, _ time.Duration, fn func() error) error {
+		return fn()
+	})
+}
+
+// teardownStep выполняет один шаг teardownSession с указанным таймаутом.
+type teardownStep func(name string, timeout time.Duration, fn func() error) error
+
+// teardownSession снимает сетевое состояние сессии: маршруты, kill switch, DNS, затем Core.
+// Общий путь для «Отключиться» и выхода из приложения; runHook включает post-disconnect хук.
+func (a *Application) teardownSession(ctx *state.AppContext, runHook bool, step teardownStep) error {
+	// маршруты снимаются до остановки Core, чтобы не указывать в мёртвый туннель
+	routesErr := step("routes", exitRoutesTimeout, func() error {
+		return a.removeSessionRoutes(ctx)
+	})
+	_ = step("kill switch", exitStepTimeout, func() error {
 		a.removeKillSwitch(ctx, nil)
+		return nil
+	})
+	// DNS сбрасываем до остановки Core, пока интерфейс туннеля ещё существует
+	_ = step("dns", exitStepTimeout, func() error {
+		if errs := a.restoreDNS(ctx); len(errs) > 0 {
+			return errors.New(strings.Join(errs, "; "))
+		}
+		return nil
+	})
+	_ = step("core", exitStepTimeout, func() error {
+		a.stopProcess(state.ProcessCore, processStopTimeout)
+		a.deleteSessionCoreConfig(ctx)
+		return nil
+	})
+	if routesErr == nil {
+		_ = a.deleteCleanupState()
+	}
+	// хук выполняется после снятия маршрутов: команда видит обычную сеть
+	if profile, ok := ctx.SelectedProfile(); ok && runHook {
+		a.runPostDisconnectHook(&profile)
+	}
+	return routesErr
+}
+
+// deleteSessionCoreConfig удаляет временный конфиг Core выбранного профиля, если он остался.
+func (a *Application) deleteSessionCoreConfig(ctx *state.AppContext) {
+	profile, ok := ctx.SelectedProfile()
+	if !ok || profile.CoreConfigFilePath == "" {
+		return
+	}
+	if err := deleteCoreConfigFile(profile.CoreConfigFilePath); err != nil {
+		a.logger.Errorf("cleanup core config failed: %v", err)
+		return
 	}
+	ctx.SetCoreConfigFilePath(profile.ID, "")
+}
+
+// removeSessionRoutes удаляет маршруты Direct и Tunnel из реестра и системы.
+func (a *Application) removeSessionRoutes(ctx *state.AppContext) error {
 	if a.routes == nil || ctx == nil {
-		_ = a.deleteCleanupState()
 		return nil
 	}
 	routes := ctx.RoutesRegistry.ListByKinds(state.RouteKindDirect, state.RouteKindTunnel)
@@ -544,9 +1141,8 @@ func (a *Application) executeDisconnecting(ctx *state.AppContext) error {
 		}
 	}
 	if len(errs) > 0 {
-		return fmt.Errorf(strings.Join(errs, "; "))
+		return errors.New(strings.Join(errs, "; "))
 	}
-	_ = a.deleteCleanupState()
 	return nil
 }
 
@@ -557,13 +1153,37 @@ func (a *Application) writeCoreConfig(profile *state.Profile) (string, error) {
 	if len(profile.CoreConfigRaw) == 0 {
 		return "", fmt.Errorf("core config for profile %s is empty", profile.ID)
 	}
+	// адрес физического интерфейса нужен только конфигурациям, которые его используют
+	var bindAddress string
+	if bytes.Contains(profile.CoreConfigRaw, []byte(serverBindPlaceholder)) {
+		address, err := a.resolveServerBindAddress(profile.Host)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", errCoreBindAddress, err)
+		}
+		bindAddress = address
+	}
+	coreConfig, unknown := expandCoreConfig(profile.CoreConfigRaw, profile, bindAddress)
+	if len(unknown) > 0 && a.logger != nil {
+		a.logger.Infof("warning: core config for profile %s has unknown placeholders left as is: %v", profile.ID, unknown)
+	}
+	// core_log_level меняет подробность журнала Core на время сессии без правки профиля
+	if level := a.cfg.CoreLogLevel; level != "" {
+		leveled, err := applyCoreLogLevel(coreConfig, level)
+		if err != nil {
+			return "", fmt.Errorf("set core log level: %w", err)
+		}
+		coreConfig = leveled
+		if a.logger != nil {
+			a.logger.Infof("core log level set to %s", level)
+		}
+	}
 	prefix := fmt.Sprintf("customvpn_core_%s_", sanitizeFileName(profile.Name, profile.ID))
 	file, err := os.CreateTemp("", prefix+"*.json")
 	if err != nil {
 		return "", fmt.Errorf("create core config temp file: %w", err)
 	}
 	fullPath := file.Name()
-	if _, err := file.Write(profile.CoreConfigRaw); err != nil {
+	if _, err := file.Write(coreConfig); err != nil {
 		_ = file.Close()
 		_ = os.Remove(fullPath)
 		return "", fmt.Errorf("write core config: %w", err)
@@ -576,6 +1196,22 @@ func (a *Application) writeCoreConfig(profile *state.Profile) (string, error) {
 	return fullPath, nil
 }
 
+// logCoreVersion пишет в лог версию Core, чтобы поддержка видела развёрнутую сборку.
+func (a *Application) logCoreVersion() {
+	ctx, cancel := context.WithTimeout(a.parentContext(), coreVersionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, a.cfg.CorePath, "version")
+	cmd.Dir = filepath.Dir(a.cfg.CorePath)
+	applyCommandAttributes(cmd)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		a.logger.Errorf("core version check failed: %v", err)
+		return
+	}
+	version, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	a.logger.Infof("core version: %s", strings.TrimSpace(version))
+}
+
 func (a *Application) checkCoreConfig(path string) error {
 	if a.cfg == nil || strings.TrimSpace(a.cfg.CorePath) == "" {
 		return fmt.Errorf("core path is not configured")
@@ -583,10 +1219,20 @@ func (a *Application) checkCoreConfig(path string) error {
 	if strings.TrimSpace(path) == "" {
 		return fmt.Errorf("core config path is empty")
 	}
-	cmd := exec.Command(a.cfg.CorePath, "check", "-c", path)
+	// значение по умолчанию подставляет config при загрузке
+	timeout := a.cfg.CoreCheckTimeout
+	ctx, cancel := context.WithTimeout(a.parentContext(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, a.cfg.CorePath, "check", "-c", path)
 	cmd.Dir = filepath.Dir(a.cfg.CorePath)
 	applyCommandAttributes(cmd)
+	// при таймауте завершается вся группа процессов: дочерние процессы Core могут держать вывод
+	applyKillProcessTree(cmd)
+	cmd.WaitDelay = processStopTimeout
 	output, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", errCoreCheckTimeout, timeout)
+	}
 	if err != nil {
 		out := strings.TrimSpace(string(output))
 		if out != "" {
@@ -603,15 +1249,23 @@ func (a *Application) removeRouteRecord(ctx *state.AppContext, record state.Rout
 	routeCtx, cancel := a.requestContext(routeOpTimeout)
 	defer cancel()
 	if err := a.routes.RemoveRoute(routeCtx, record); err != nil {
-		return err
+		if !errors.Is(err, routes.ErrRouteNotFound) {
+			return err
+		}
+		// маршрута уже нет: цель очистки достигнута
+		if a.logger != nil {
+			a.logger.Debugf("route %s already removed", record.Destination)
+		}
 	}
 	ctx.RoutesRegistry.Remove(record.ID)
 	return nil
 }
 
-func (a *Application) addProfileRoutes(ctx *state.AppContext, cidrs []string, kind state.RouteKind, gateway *state.GatewayInfo, artifacts *connectArtifacts) *scenarioError {
+// addProfileRoutes добавляет маршруты профиля через gateway;
+// metric больше 0 (Profile.RouteMetric) заменяет метрику шлюза.
+func (a *Application) addProfileRoutes(ctx *state.AppContext, cidrs []string, kind state.RouteKind, gateway *state.GatewayInfo, metric int, artifacts *connectArtifacts) *scenarioError {
 	if a.routes == nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Маршрутизатор не инициализирован", fmt.Errorf("route manager is nil"))
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.router_not_ready"), fmt.Errorf("route manager is nil"))
 	}
 	hasRoutes := false
 	for _, cidr := range cidrs {
@@ -624,7 +1278,10 @@ func (a *Application) addProfileRoutes(ctx *state.AppContext, cidrs []string, ki
 		return nil
 	}
 	if gateway == nil || strings.TrimSpace(gateway.IP) == "" {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Маршрутный шлюз не задан", fmt.Errorf("route gateway is nil"))
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.route_gateway_missing"), fmt.Errorf("route gateway is nil"))
+	}
+	if a.cfg != nil && a.cfg.RouteConcurrency > 1 {
+		return a.addProfileRoutesConcurrent(ctx, cidrs, kind, gateway, metric, artifacts, a.cfg.RouteConcurrency)
 	}
 	for _, cidr := range cidrs {
 		cidr = strings.TrimSpace(cidr)
@@ -632,10 +1289,10 @@ func (a *Application) addProfileRoutes(ctx *state.AppContext, cidrs []string, ki
 			continue
 		}
 		routeCtx, cancel := a.requestContext(routeOpTimeout)
-		record, err := a.routes.AddCIDRRoute(routeCtx, cidr, gateway, kind)
+		record, err := a.routes.AddCIDRRoute(routeCtx, cidr, gateway, kind, metric)
 		cancel()
 		if err != nil {
-			return newScenarioError(state.ErrorKindRoutingFailed, fmt.Sprintf("Не удалось добавить маршрут %s", cidr), err)
+			return newScenarioError(state.ErrorKindRoutingFailed, i18n.Tf("error.route_add", cidr), err)
 		}
 		ctx.RoutesRegistry.Upsert(record)
 		if artifacts != nil {
@@ -645,50 +1302,165 @@ func (a *Application) addProfileRoutes(ctx *state.AppContext, cidrs []string, ki
 	return nil
 }
 
-func (a *Application) applyTunnelDNS(ctx *state.AppContext, gateway *state.GatewayInfo, artifacts *connectArtifacts) *scenarioError {
+// addProfileRoutesConcurrent добавляет маршруты пулом из concurrency воркеров.
+// Успешно добавленные записи попадают в реестр и artifacts, чтобы откат удалил ровно их;
+// после первой ошибки оставшиеся добавления отменяются.
+func (a *Application) addProfileRoutesConcurrent(ctx *state.AppContext, cidrs []string, kind state.RouteKind, gateway *state.GatewayInfo, metric int, artifacts *connectArtifacts, concurrency int) *scenarioError {
+	pending := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			pending = append(pending, cidr)
+		}
+	}
+	if concurrency > len(pending) {
+		concurrency = len(pending)
+	}
+	groupCtx, cancel := context.WithCancel(a.parentContext())
+	defer cancel()
+	jobs := make(chan string)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr *scenarioError
+	)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cidr := range jobs {
+				routeCtx, routeCancel := context.WithTimeout(groupCtx, routeOpTimeout)
+				record, err := a.routes.AddCIDRRoute(routeCtx, cidr, gateway, kind, metric)
+				routeCancel()
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = newScenarioError(state.ErrorKindRoutingFailed, i18n.Tf("error.route_add", cidr), err)
+						cancel()
+					}
+				} else {
+					ctx.RoutesRegistry.Upsert(record)
+					if artifacts != nil {
+						artifacts.addRoute(record)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, cidr := range pending {
+		select {
+		case jobs <- cidr:
+		case <-groupCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := groupCtx.Err(); err != nil {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.routes_interrupted"), err)
+	}
+	return nil
+}
+
+func (a *Application) applyTunnelDNS(ctx *state.AppContext, profile *state.Profile, gateway *state.GatewayInfo, artifacts *connectArtifacts) *scenarioError {
 	if a.dns == nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "DNS менеджер не инициализирован", fmt.Errorf("dns manager is nil"))
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.dns_not_ready"), fmt.Errorf("dns manager is nil"))
 	}
 	if gateway == nil || strings.TrimSpace(gateway.InterfaceName) == "" {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Не удалось определить интерфейс туннеля", fmt.Errorf("tunnel interface name is empty"))
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.tunnel_interface"), fmt.Errorf("tunnel interface name is empty"))
 	}
-	dnsCtx, cancel := a.requestContext(routeOpTimeout)
+	servers := a.tunnelDNSServers(profile)
+	dnsCtx, cancel := a.requestContext(dnsOpTimeout)
 	defer cancel()
-	if err := a.dns.SetInterfaceDNS(dnsCtx, gateway.InterfaceName, []string{"100.64.127.2"}); err != nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Не удалось настроить DNS туннеля", err)
+	// регистрируем интерфейс до вызова: частично применённые настройки тоже нужно сбросить
+	ctx.DNSRegistry.Upsert(state.DNSRecord{Interface: gateway.InterfaceName, Servers: servers})
+	if artifacts != nil {
+		artifacts.dnsInterfaces = append(artifacts.dnsInterfaces, gateway.InterfaceName)
+	}
+	if err := a.dns.SetInterfaceDNS(dnsCtx, gateway.InterfaceName, servers); err != nil {
+		if errors.Is(err, dns.ErrTimeout) {
+			return newScenarioError(state.ErrorKindRoutingFailed, i18n.Tf("error.tunnel_dns_timeout", dnsOpTimeout), err)
+		}
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.tunnel_dns"), err)
 	}
 	if a.logger != nil {
-		a.logger.Infof("tunnel DNS set: interface=%s servers=%v", gateway.InterfaceName, []string{"100.64.127.2"})
+		a.logger.Infof("tunnel DNS set: interface=%s servers=%v", gateway.InterfaceName, servers)
 	}
+	a.saveCleanupState(ctx)
 	return nil
 }
 
-func (a *Application) applyKillSwitch(ctx *state.AppContext, profile *state.Profile, artifacts *connectArtifacts) *scenarioError {
-	if profile == nil || !profile.KillSwitchEnabled {
+// restoreDNS сбрасывает DNS на всех интерфейсах из реестра и очищает реестр.
+func (a *Application) restoreDNS(ctx *state.AppContext) []string {
+	if ctx == nil {
+		return nil
+	}
+	var ifaces []string
+	for _, record := range ctx.DNSRegistry.List() {
+		ifaces = append(ifaces, record.Interface)
+	}
+	errs := a.resetDNSInterfaces(ifaces)
+	for _, iface := range ifaces {
+		ctx.DNSRegistry.Remove(iface)
+	}
+	return errs
+}
+
+func (a *Application) resetDNSInterfaces(ifaces []string) []string {
+	if a.dns == nil {
+		return nil
+	}
+	var errs []string
+	for _, iface := range ifaces {
+		if strings.TrimSpace(iface) == "" {
+			continue
+		}
+		dnsCtx, cancel := a.requestContext(dnsOpTimeout)
+		err := a.dns.ResetInterfaceDNS(dnsCtx, iface)
+		cancel()
+		if err != nil {
+			// интерфейс туннеля мог уже исчезнуть вместе с Core
+			if a.logger != nil {
+				a.logger.Errorf("reset DNS on %s failed: %v", iface, err)
+			}
+			errs = append(errs, err.Error())
+			continue
+		}
 		if a.logger != nil {
-			a.logger.Infof("kill switch disabled: skip DNS block")
+			a.logger.Infof("DNS restored: interface=%s", iface)
 		}
+	}
+	return errs
+}
+
+func (a *Application) applyKillSwitch(ctx *state.AppContext, profile *state.Profile, gateway *state.GatewayInfo, direct []string, artifacts *connectArtifacts) *scenarioError {
+	if profile == nil {
 		return nil
 	}
 	if a.logger != nil {
 		a.logger.Debugf("kill switch start: profile=%s", profile.ID)
 	}
 	if a.firewall == nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch не инициализирован", fmt.Errorf("firewall manager is nil"))
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_not_ready"), fmt.Errorf("firewall manager is nil"))
 	}
-	if ctx == nil || ctx.DefaultGateway == nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch не может определить основной интерфейс", fmt.Errorf("default gateway is nil"))
+	if gateway == nil {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_no_interface"), fmt.Errorf("default gateway is nil"))
 	}
-	if strings.TrimSpace(ctx.DefaultGateway.InterfaceName) == "" {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch не может определить основной интерфейс", fmt.Errorf("default gateway interface name is empty"))
+	iface := gateway.InterfaceName
+	if strings.TrimSpace(iface) == "" {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_no_interface"), fmt.Errorf("default gateway interface name is empty"))
 	}
 	if a.logger != nil {
-		a.logger.Debugf("kill switch interface: %s", ctx.DefaultGateway.InterfaceName)
+		a.logger.Debugf("kill switch interface: %s", iface)
 	}
 	var checkErr error
 	for attempt := 1; attempt <= killSwitchCheckAttempts; attempt++ {
 		firewallCtx, cancel := a.requestContext(routeOpTimeout)
-		checkErr = a.firewall.CheckAvailable(firewallCtx, ctx.DefaultGateway.InterfaceName)
+		checkErr = a.firewall.CheckAvailable(firewallCtx, iface)
 		cancel()
 		if checkErr == nil {
 			if a.logger != nil {
@@ -703,9 +1475,6 @@ func (a *Application) applyKillSwitch(ctx *state.AppContext, profile *state.Prof
 			return nil
 		}
 		if errors.Is(checkErr, firewall.ErrLocalPolicyMergeDisabled) {
-			if a.logger != nil {
-				a.logger.Debugf("kill switch check requires local policy merge")
-			}
 			break
 		}
 		if a.logger != nil {
@@ -716,59 +1485,127 @@ func (a *Application) applyKillSwitch(ctx *state.AppContext, profile *state.Prof
 		}
 	}
 	if checkErr != nil {
-		if errors.Is(checkErr, firewall.ErrLocalPolicyMergeDisabled) {
-			if a.ui != nil && a.ui.ConfirmEnableLocalPolicyMerge() {
-				if a.logger != nil {
-					a.logger.Infof("attempting to enable local firewall rules")
-				}
-				enableCtx, enableCancel := a.requestContext(routeOpTimeout)
-				enableErr := a.firewall.EnableLocalPolicyMerge(enableCtx)
-				enableCancel()
-				if enableErr != nil {
-					if a.logger != nil {
-						a.logger.Debugf("kill switch enable local rules failed: %v", enableErr)
-					}
-					if errors.Is(enableErr, firewall.ErrLocalPolicyMergeUnsupported) {
-						return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch недоступен: AllowLocalPolicyMerge не поддерживается в системе", enableErr)
-					}
-					return newScenarioError(state.ErrorKindRoutingFailed, "Не удалось включить локальные правила брандмауэра", enableErr)
-				}
-				recheckCtx, recheckCancel := a.requestContext(routeOpTimeout)
-				recheckErr := a.firewall.CheckAvailable(recheckCtx, ctx.DefaultGateway.InterfaceName)
-				recheckCancel()
-				if recheckErr != nil {
-					return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch недоступен", recheckErr)
-				}
-				checkErr = nil
-			} else {
-				return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch недоступен: локальные правила брандмауэра запрещены", checkErr)
-			}
-		} else {
-			return newScenarioError(state.ErrorKindRoutingFailed, "Kill Switch недоступен", checkErr)
+		if !errors.Is(checkErr, firewall.ErrLocalPolicyMergeDisabled) {
+			return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_unavailable"), checkErr)
+		}
+		if scErr := a.enableLocalPolicyMerge(iface, checkErr); scErr != nil {
+			return scErr
 		}
 	}
 	firewallCtx, cancel := a.requestContext(routeOpTimeout)
 	defer cancel()
-	rules, err := a.firewall.BlockDNSOnInterface(firewallCtx, ctx.DefaultGateway.InterfaceName, nil, a.cfg.CorePath)
+	rules, err := a.firewall.BlockDNSOnInterface(firewallCtx, iface, nil, a.cfg.CorePath)
 	if err != nil {
-		return newScenarioError(state.ErrorKindRoutingFailed, "Не удалось применить Kill Switch", err)
+		// при ошибке возвращаются только правила, которые не удалось откатить: их снимет rollback
+		if len(rules) > 0 && artifacts != nil {
+			artifacts.killSwitchRules = append(artifacts.killSwitchRules, rules...)
+		}
+		if errors.Is(err, firewall.ErrPartialApply) {
+			return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_partial"), err)
+		}
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_apply"), err)
+	}
+	if artifacts != nil {
+		artifacts.killSwitchRules = append(artifacts.killSwitchRules, rules...)
+	}
+	if a.cfg.KillSwitchStrict {
+		strictRules, scErr := a.applyStrictKillSwitch(iface, profile, direct, artifacts)
+		if scErr != nil {
+			return scErr
+		}
+		rules = append(rules, strictRules...)
 	}
 	if a.logger != nil {
-		a.logger.Infof("kill switch enabled: interface=%s rules=%v", ctx.DefaultGateway.InterfaceName, rules)
+		a.logger.Infof("kill switch enabled: interface=%s rules=%v", iface, rules)
 	}
-	ctx.KillSwitchRules = append([]string{}, rules...)
-	if artifacts != nil {
+	a.audit(auditKillSwitch, "result", "enabled", "profile", profile.ID, "interface", iface, "rules", strconv.Itoa(len(rules)))
+	ctx.SetKillSwitch(rules)
+	return nil
+}
+
+// applyStrictKillSwitch добавляет к блокировке DNS запрет остального трафика через iface, кроме
+// адресов VPN-сервера и прямых маршрутов direct. Если платформа строгий режим не поддерживает,
+// остаётся только блокировка DNS.
+func (a *Application) applyStrictKillSwitch(iface string, profile *state.Profile, direct []string, artifacts *connectArtifacts) ([]string, *scenarioError) {
+	serverIPs, err := a.resolveServerIPs(profile.Host)
+	if err != nil {
+		return nil, newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_apply"), err)
+	}
+	allowed := append(serverIPs, direct...)
+	firewallCtx, cancel := a.requestContext(routeOpTimeout)
+	defer cancel()
+	rules, err := a.firewall.BlockAllOnInterface(firewallCtx, iface, allowed)
+	if errors.Is(err, firewall.ErrStrictKillSwitchUnsupported) {
+		if a.logger != nil {
+			a.logger.Infof("warning: strict kill switch is not supported on this platform, only DNS is blocked")
+		}
+		return nil, nil
+	}
+	if len(rules) > 0 && artifacts != nil {
 		artifacts.killSwitchRules = append(artifacts.killSwitchRules, rules...)
 	}
+	if err != nil {
+		if errors.Is(err, firewall.ErrPartialApply) {
+			return nil, newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_partial"), err)
+		}
+		return nil, newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_apply"), err)
+	}
+	if a.logger != nil {
+		a.logger.Debugf("strict kill switch enabled: interface=%s allowed=%v", iface, allowed)
+	}
+	return rules, nil
+}
+
+// enableLocalPolicyMerge спрашивает пользователя о включении локальных правил брандмауэра,
+// включает их и повторяет проверку один раз. Отказ прерывает подключение.
+func (a *Application) enableLocalPolicyMerge(iface string, checkErr error) *scenarioError {
+	if a.logger != nil {
+		a.logger.Debugf("kill switch check requires local policy merge: %v", checkErr)
+	}
+	confirmed := a.ui != nil && a.ui.ConfirmEnableLocalPolicyMerge()
+	// Пока открыт диалог, пользователь мог нажать «Отключиться».
+	if scErr := a.checkConnectCanceled(); scErr != nil {
+		return scErr
+	}
+	if !confirmed {
+		if a.logger != nil {
+			a.logger.Infof("local firewall rules not allowed by user: kill switch not applied")
+		}
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_policy_denied"), checkErr)
+	}
+	if a.logger != nil {
+		a.logger.Infof("attempting to enable local firewall rules")
+	}
+	enableCtx, enableCancel := a.requestContext(routeOpTimeout)
+	enableErr := a.firewall.EnableLocalPolicyMerge(enableCtx)
+	enableCancel()
+	if enableErr != nil {
+		if a.logger != nil {
+			a.logger.Debugf("kill switch enable local rules failed: %v", enableErr)
+		}
+		if errors.Is(enableErr, firewall.ErrLocalPolicyMergeUnsupported) {
+			return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_merge_unsupported"), enableErr)
+		}
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.local_policy_enable"), enableErr)
+	}
+	recheckCtx, recheckCancel := a.requestContext(routeOpTimeout)
+	recheckErr := a.firewall.CheckAvailable(recheckCtx, iface)
+	recheckCancel()
+	if recheckErr != nil {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.killswitch_after_merge"), recheckErr)
+	}
 	return nil
 }
 
 func (a *Application) removeKillSwitch(ctx *state.AppContext, rules []string) {
+	if ctx != nil {
+		ctx.SetKillSwitchActive(false)
+	}
 	if a.firewall == nil {
 		return
 	}
 	if len(rules) == 0 && ctx != nil {
-		rules = ctx.KillSwitchRules
+		rules, _ = ctx.KillSwitch()
 	}
 	if len(rules) == 0 {
 		return
@@ -784,27 +1621,40 @@ func (a *Application) removeKillSwitch(ctx *state.AppContext, rules []string) {
 	if a.logger != nil {
 		a.logger.Infof("kill switch disabled: rules=%v", rules)
 	}
+	a.audit(auditKillSwitch, "result", "disabled", "rules", strconv.Itoa(len(rules)))
 	if ctx != nil {
-		ctx.KillSwitchRules = nil
+		ctx.SetKillSwitch(nil)
 	}
 }
 
-func tunnelGatewayInfo() (*state.GatewayInfo, error) {
-	ip := net.ParseIP("100.64.127.1")
+func (a *Application) tunnelGatewayInfo() (*state.GatewayInfo, error) {
+	ip := net.ParseIP(a.cfg.TunnelGateway)
 	if ip == nil {
-		return nil, fmt.Errorf("invalid tunnel gateway ip")
+		return nil, fmt.Errorf("invalid tunnel gateway ip %q", a.cfg.TunnelGateway)
+	}
+	return a.gateways.GatewayForIP(ip)
+}
+
+// tunnelDNSServers возвращает DNS туннеля профиля, а без них — tunnel_dns из config.yaml.
+func (a *Application) tunnelDNSServers(profile *state.Profile) []string {
+	if profile != nil && len(profile.TunnelDNS) > 0 {
+		return profile.TunnelDNS
 	}
-	return routes.DetectGatewayForIP(ip)
+	return a.cfg.TunnelDNS
 }
 
-func (a *Application) waitForTunnelGateway(timeout time.Duration) (*state.GatewayInfo, error) {
+// waitForTunnelGateway ждёт появления интерфейса туннеля после запуска Core.
+// Отмена ctx прерывает ожидание сразу и возвращает errTunnelDetectCanceled.
+func (a *Application) waitForTunnelGateway(ctx context.Context, timeout time.Duration) (*state.GatewayInfo, error) {
 	deadline := time.Now().Add(timeout)
 	var lastErr error
+	timer := time.NewTimer(tunnelDetectDelay)
+	defer timer.Stop()
 	for attempt := 1; ; attempt++ {
-		if a.isStopping() {
-			return nil, fmt.Errorf("tunnel detection canceled")
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %w", errTunnelDetectCanceled, err)
 		}
-		gateway, err := tunnelGatewayInfo()
+		gateway, err := a.tunnelGatewayInfo()
 		if err == nil {
 			if attempt > 1 && a.logger != nil {
 				a.logger.Infof("tunnel interface detected after %d attempts", attempt)
@@ -812,10 +1662,16 @@ func (a *Application) waitForTunnelGateway(timeout time.Duration) (*state.Gatewa
 			return gateway, nil
 		}
 		lastErr = err
-		if time.Now().After(deadline) {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
 			return nil, lastErr
 		}
-		time.Sleep(tunnelDetectDelay)
+		timer.Reset(min(tunnelDetectDelay, remaining))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", errTunnelDetectCanceled, ctx.Err())
+		case <-timer.C:
+		}
 	}
 }
 
@@ -853,6 +1709,7 @@ type cleanupState struct {
 	CorePID         int                `json:"core_pid"`
 	KillSwitchRules []string           `json:"kill_switch_rules"`
 	Routes          []state.RouteRecord `json:"routes"`
+	DNSInterfaces   []string           `json:"dns_interfaces"`
 }
 
 func (a *Application) saveCleanupState(ctx *state.AppContext) {
@@ -873,11 +1730,15 @@ func (a *Application) saveCleanupState(ctx *state.AppContext) {
 	if record, ok := ctx.ProcessRegistry.Get(state.ProcessCore); ok {
 		corePID = record.PID
 	}
+	killSwitchRules, _ := ctx.KillSwitch()
 	payload := cleanupState{
 		CorePID:         corePID,
-		KillSwitchRules: append([]string{}, ctx.KillSwitchRules...),
+		KillSwitchRules: killSwitchRules,
 		Routes:          ctx.RoutesRegistry.ListByKinds(state.RouteKindDirect, state.RouteKindTunnel),
 	}
+	for _, record := range ctx.DNSRegistry.List() {
+		payload.DNSInterfaces = append(payload.DNSInterfaces, record.Interface)
+	}
 	data, err := json.Marshal(payload)
 	if err != nil {
 		if a.logger != nil {
@@ -932,28 +1793,107 @@ func (a *Application) cleanupStatePath() string {
 	if a == nil || a.cfg == nil {
 		return ""
 	}
-	return filepath.Join(a.cfg.AppDir, "temp", "cleanup_state.json")
+	return a.cfg.CleanupStatePath()
 }
 
-func (a *Application) cleanupRoutesFromState(saved *cleanupState, errs *[]string) {
+func (a *Application) cleanupRoutesFromState(saved *cleanupState, errs *[]string) int {
 	if a == nil || a.routes == nil || saved == nil {
-		return
+		return 0
 	}
 	if a.logger != nil {
 		a.logger.Debugf("cleanup: removing routes from saved state")
 	}
+	removed := 0
 	for _, record := range saved.Routes {
 		routeCtx, cancel := a.requestContext(routeOpTimeout)
-		if err := a.routes.RemoveRoute(routeCtx, record); err != nil {
+		if err := a.routes.RemoveRoute(routeCtx, record); errors.Is(err, routes.ErrRouteNotFound) {
+			if a.logger != nil {
+				a.logger.Debugf("cleanup: saved route %s already removed", record.Destination)
+			}
+		} else if err != nil {
 			if errs != nil {
 				*errs = append(*errs, err.Error())
 			}
 			if a.logger != nil {
 				a.logger.Errorf("cleanup saved route %s failed: %v", record.Destination, err)
 			}
+		} else {
+			removed++
+		}
+		cancel()
+	}
+	return removed
+}
+
+// reconcileStaleRoutes удаляет маршруты из routes.json, которые предыдущий запуск не успел снять
+// (аварийное завершение). Записи, которые удалить не удалось, остаются в реестре для «Починки».
+func (a *Application) reconcileStaleRoutes() {
+	records := a.staleRoutes
+	a.staleRoutes = nil
+	if len(records) == 0 || a.routes == nil || a.ctx == nil {
+		return
+	}
+	a.logger.Infof("found %d routes left by previous run, reconciling", len(records))
+	removed, failed := 0, 0
+	for _, record := range records {
+		checkCtx, cancel := a.requestContext(routeOpTimeout)
+		err := a.routes.VerifyRoute(checkCtx, record)
+		cancel()
+		if errors.Is(err, routes.ErrRouteNotFound) {
+			a.ctx.RoutesRegistry.Remove(record.ID)
+			continue
+		}
+		// маршрут есть либо проверка на платформе недоступна: пробуем удалить
+		if err := a.removeRouteRecord(a.ctx, record); err != nil {
+			a.logger.Errorf("remove stale route %s failed: %v", record.Destination, err)
+			failed++
+			continue
+		}
+		removed++
+	}
+	a.logger.Infof("stale routes reconciled: removed=%d failed=%d", removed, failed)
+}
+
+// cleanupTunnelRoutes ищет в системной таблице маршруты через шлюз туннеля и удаляет их.
+// Покрывает случай, когда приложение упало и ни реестр, ни cleanup_state.json не сохранились.
+func (a *Application) cleanupTunnelRoutes(errs *[]string) int {
+	if a == nil || a.routes == nil {
+		return 0
+	}
+	if a.logger != nil {
+		a.logger.Debugf("cleanup: scanning routes via %s", a.cfg.TunnelGateway)
+	}
+	scanCtx, cancel := a.requestContext(routeOpTimeout)
+	records, err := a.routes.RoutesViaGateway(scanCtx, a.cfg.TunnelGateway, state.RouteKindTunnel)
+	cancel()
+	if err != nil {
+		// Сканирование доступно не на всех платформах: это не ошибка очистки.
+		if a.logger != nil {
+			a.logger.Debugf("cleanup: route scan skipped: %v", err)
+		}
+		return 0
+	}
+	removed := 0
+	for _, record := range records {
+		routeCtx, cancel := a.requestContext(routeOpTimeout)
+		if err := a.routes.RemoveRoute(routeCtx, record); errors.Is(err, routes.ErrRouteNotFound) {
+			// маршрут пропал между сканированием и удалением
+			if a.logger != nil {
+				a.logger.Debugf("cleanup: stale route %s already removed", record.Destination)
+			}
+		} else if err != nil {
+			if errs != nil {
+				*errs = append(*errs, err.Error())
+			}
+			if a.logger != nil {
+				a.logger.Errorf("cleanup stale route %s failed: %v", record.Destination, err)
+			}
+		} else {
+			removed++
 		}
 		cancel()
 	}
+	return removed
 }
 
 type scenarioError struct {
@@ -966,12 +1906,93 @@ func newScenarioError(kind state.ErrorKind, message string, err error) *scenario
 	return &scenarioError{kind: kind, message: message, err: err}
 }
 
+// ipv6Enabled сообщает, разрешён ли IPv6 в сессии: через config.yaml или профиль.
+func (a *Application) ipv6Enabled(profile *state.Profile) bool {
+	if a.cfg != nil && a.cfg.EnableIPv6 {
+		return true
+	}
+	return profile != nil && profile.IPv6Enabled
+}
+
+// blockIPv6 запрещает исходящий IPv6 на основном интерфейсе, чтобы трафик не шёл мимо туннеля.
+// Правила добавляются к правилам Kill Switch и снимаются вместе с ними.
+// Без Kill Switch профиля недоступный брандмауэр не прерывает подключение.
+func (a *Application) blockIPv6(ctx *state.AppContext, profile *state.Profile, gatewayV6 *state.GatewayInfo, artifacts *connectArtifacts) *scenarioError {
+	if gatewayV6 == nil {
+		if a.logger != nil {
+			a.logger.Debugf("ipv6 block skipped: no IPv6 default gateway")
+		}
+		return nil
+	}
+	if a.firewall == nil {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.firewall_not_ready"), fmt.Errorf("firewall manager is nil"))
+	}
+	iface := gatewayV6.InterfaceName
+	firewallCtx, cancel := a.requestContext(routeOpTimeout)
+	defer cancel()
+	if err := a.firewall.CheckAvailable(firewallCtx, iface); err != nil {
+		if errors.Is(err, firewall.ErrFirewallDisabled) {
+			if a.logger != nil {
+				a.logger.Errorf("ipv6 block skipped: windows firewall is disabled, IPv6 traffic may bypass the tunnel")
+			}
+			return nil
+		}
+		if profile == nil || !profile.KillSwitchEnabled {
+			if a.logger != nil {
+				a.logger.Infof("warning: ipv6 block skipped: firewall unavailable on %s, IPv6 traffic may bypass the tunnel: %v", iface, err)
+			}
+			return nil
+		}
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.ipv6_block_unavailable"), err)
+	}
+	rules, err := a.firewall.BlockIPv6OnInterface(firewallCtx, iface)
+	if err != nil {
+		return newScenarioError(state.ErrorKindRoutingFailed, i18n.T("error.ipv6_block"), err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	if a.logger != nil {
+		a.logger.Infof("ipv6 blocked: interface=%s rules=%v", iface, rules)
+	}
+	ctx.AddKillSwitchRules(rules)
+	if artifacts != nil {
+		artifacts.killSwitchRules = append(artifacts.killSwitchRules, rules...)
+	}
+	return nil
+}
+
+// splitRoutesByFamily разделяет CIDR профиля на IPv4 и IPv6.
+// Неразборчивые значения остаются в IPv4, чтобы ошибку вернул менеджер маршрутов.
+func splitRoutesByFamily(cidrs []string) (v4, v6 []string) {
+	for _, cidr := range cidrs {
+		trimmed := strings.TrimSpace(cidr)
+		if trimmed == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(trimmed); err == nil && network.IP.To4() == nil {
+			v6 = append(v6, trimmed)
+			continue
+		}
+		v4 = append(v4, trimmed)
+	}
+	return v4, v6
+}
+
 type connectArtifacts struct {
 	app             *Application
 	ctx             *state.AppContext
 	routes          []state.RouteRecord
 	coreStarted     bool
 	killSwitchRules []string
+	dnsInterfaces   []string
+	// tunnel — интерфейс туннеля, найденный после запуска Core.
+	tunnel *state.GatewayInfo
+	// gateway и gatewayV6 — основные шлюзы подключения.
+	gateway   *state.GatewayInfo
+	gatewayV6 *state.GatewayInfo
+	// server — адрес сервера профиля host:port.
+	server string
 }
 
 func newConnectArtifacts(app *Application, ctx *state.AppContext) *connectArtifacts {
@@ -986,14 +2007,22 @@ func (c *connectArtifacts) rollback() {
 	if c == nil {
 		return
 	}
+	if len(c.dnsInterfaces) > 0 {
+		c.app.resetDNSInterfaces(c.dnsInterfaces)
+		if c.ctx != nil {
+			for _, iface := range c.dnsInterfaces {
+				c.ctx.DNSRegistry.Remove(iface)
+			}
+		}
+	}
 	if c.coreStarted {
 		c.app.stopProcess(state.ProcessCore, processStopTimeout)
 		if c.ctx != nil {
-			if profile := c.ctx.FindProfile(c.ctx.SelectedProfileID); profile != nil && profile.CoreConfigFilePath != "" {
+			if profile, ok := c.ctx.SelectedProfile(); ok && profile.CoreConfigFilePath != "" {
 				if err := deleteCoreConfigFile(profile.CoreConfigFilePath); err != nil {
 					c.app.logger.Errorf("cleanup core config failed: %v", err)
 				} else {
-					profile.CoreConfigFilePath = ""
+					c.ctx.SetCoreConfigFilePath(profile.ID, "")
 				}
 			}
 		}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+func TestBackoffDelayGrowsAndIsBounded(t *testing.T) {
+	const (
+		base     = 100 * time.Millisecond
+		maxDelay = 700 * time.Millisecond
+	)
+	// предел попытки: base, 2·base, 4·base, затем maxDelay
+	limits := []time.Duration{base, 2 * base, 4 * base, maxDelay, maxDelay}
+	var prevPeak time.Duration
+	for i, limit := range limits {
+		attempt := i + 1
+		var peak time.Duration
+		for n := 0; n < 2000; n++ {
+			delay := backoffDelay(attempt, base, maxDelay)
+			if delay < 0 || delay > limit {
+				t.Fatalf("attempt %d: delay %s outside [0, %s]", attempt, delay, limit)
+			}
+			peak = max(peak, delay)
+		}
+		// полный джиттер: за 2000 выборок пик почти достигает предела
+		if peak < limit*9/10 {
+			t.Fatalf("attempt %d: peak delay %s, want close to %s", attempt, peak, limit)
+		}
+		if peak < prevPeak*9/10 {
+			t.Fatalf("attempt %d: peak delay %s shrank from %s", attempt, peak, prevPeak)
+		}
+		prevPeak = peak
+	}
+	if delay := backoffDelay(3, 0, maxDelay); delay != 0 {
+		t.Fatalf("zero base: delay = %s, want 0", delay)
+	}
+}
+
+func TestPreflightRetriesUntilHealthy(t *testing.T) {
+	var failures atomic.Int32
+	failures.Store(2)
+	healthy := controlServer("token-1")
+	control := newFakeControl(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		healthy(w, r)
+	})
+	a := newTestApplication(t, control, "")
+
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitForState(t, a, state.StateWaitingLogin)
+	if got := control.count("/health"); got != 3 {
+		t.Fatalf("health requests = %d, want 3", got)
+	}
+}
+
+func TestPreflightGivesUpAfterAttempts(t *testing.T) {
+	control := newFakeControl(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	a := newTestApplication(t, control, "preflight_attempts: 2\n")
+
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	deadline := time.Now().Add(testWaitTimeout)
+	for control.count("/health") < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("health requests = %d, want 2", control.count("/health"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// после preflight_attempts попыток сценарий сдаётся: следующий повтор — по таймеру state machine
+	time.Sleep(100 * time.Millisecond)
+	if got := control.count("/health"); got != 2 {
+		t.Fatalf("health requests = %d, want 2", got)
+	}
+	if current := a.State(); current != state.StatePreflightCheck {
+		t.Fatalf("state = %s, want %s", current, state.StatePreflightCheck)
+	}
+}
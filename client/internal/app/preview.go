@@ -0,0 +1,266 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+)
+
+// PlannedRoute — маршрут, который будет добавлен при подключении.
+type PlannedRoute struct {
+	CIDR      string
+	Kind      state.RouteKind
+	Gateway   string
+	Interface string
+	Metric    int
+}
+
+// ConnectPlan описывает, что подключение к профилю изменит в системе.
+type ConnectPlan struct {
+	ProfileID   string
+	ProfileName string
+	Server      string
+	IPv6        bool
+	KillSwitch  bool
+	Routes      []PlannedRoute
+	// SkippedRoutes — IPv6-маршруты профиля, пропускаемые при выключенном IPv6.
+	SkippedRoutes []string
+	TunnelDNS     []string
+	// Gateway и GatewayV6 — основные шлюзы, через которые пойдут прямые маршруты; GatewayV6 может быть nil.
+	Gateway   *state.GatewayInfo
+	GatewayV6 *state.GatewayInfo
+	// FirewallRules — правила брандмауэра, которые подключение создаст (Kill Switch и блокировка IPv6).
+	FirewallRules []PlannedFirewallRule
+}
+
+// PlannedFirewallRule — правило брандмауэра из плана подключения: запрет исходящего трафика
+// через интерфейс. Port 0 означает любой порт.
+type PlannedFirewallRule struct {
+	Purpose   string
+	Interface string
+	Family    string
+	Protocols []string
+	Port      int
+}
+
+// Назначения правил брандмауэра в плане подключения.
+const (
+	firewallPurposeKillSwitch = "kill_switch_dns"
+	firewallPurposeIPv6Block  = "ipv6_block"
+)
+
+// previewConnect строит план подключения к выбранному профилю без запуска Core
+// и без изменения маршрутов, брандмауэра и DNS. Вызывается не из цикла событий, поэтому
+// читает ctx только через снимок Session и методы под mutex и не показывает диалог выбора шлюза.
+func (a *Application) previewConnect(ctx *state.AppContext) (*ConnectPlan, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("app context is nil")
+	}
+	if current := ctx.Session().State; current != state.StateReadyDisconnected {
+		return nil, fmt.Errorf("preview is available only while disconnected (state %s)", current)
+	}
+	target, scErr := a.resolveConnectTarget(ctx, false)
+	if scErr != nil {
+		return nil, fmt.Errorf("%s: %w", scErr.message, scErr.err)
+	}
+	profile := target.profile
+	plan := &ConnectPlan{
+		ProfileID:   profile.ID,
+		ProfileName: profile.Name,
+		Server:      fmt.Sprintf("%s:%d", profile.Host, profile.Port),
+		IPv6:        target.ipv6,
+		KillSwitch:  profile.KillSwitchEnabled,
+		TunnelDNS:   a.tunnelDNSServers(&profile),
+		Gateway:     target.gateway,
+		GatewayV6:   target.gatewayV6,
+	}
+	// интерфейс туннеля появляется только после запуска Core, поэтому известен лишь адрес шлюза
+	tunnel := &state.GatewayInfo{IP: a.cfg.TunnelGateway, InterfaceName: "tun"}
+	plan.addRoutes(target.directV4, state.RouteKindDirect, target.gateway, profile.RouteMetric)
+	if target.ipv6 {
+		plan.addRoutes(target.directV6, state.RouteKindDirect, target.gatewayV6, profile.RouteMetric)
+	}
+	plan.addRoutes(target.tunnelV4, state.RouteKindTunnel, tunnel, profile.RouteMetric)
+	if target.ipv6 {
+		plan.addRoutes(target.tunnelV6, state.RouteKindTunnel, &state.GatewayInfo{IP: "::", InterfaceName: tunnel.InterfaceName}, profile.RouteMetric)
+	} else {
+		plan.SkippedRoutes = append(append(plan.SkippedRoutes, target.directV6...), target.tunnelV6...)
+	}
+	// правила повторяют executeConnecting: DNS блокируется на основном интерфейсе,
+	// IPv6 — при выключенном IPv6 и наличии IPv6-шлюза
+	if profile.KillSwitchEnabled && target.gateway != nil {
+		plan.FirewallRules = append(plan.FirewallRules, PlannedFirewallRule{
+			Purpose:   firewallPurposeKillSwitch,
+			Interface: target.gateway.InterfaceName,
+			Family:    "any",
+			Protocols: []string{"udp", "tcp"},
+			Port:      53,
+		})
+	}
+	if !target.ipv6 && target.gatewayV6 != nil {
+		plan.FirewallRules = append(plan.FirewallRules, PlannedFirewallRule{
+			Purpose:   firewallPurposeIPv6Block,
+			Interface: target.gatewayV6.InterfaceName,
+			Family:    "ipv6",
+			Protocols: []string{"any"},
+		})
+	}
+	a.logConnectPlan(plan)
+	return plan, nil
+}
+
+// previewConnectText — previewConnect для UI: план в виде текста.
+func (a *Application) previewConnectText() (string, error) {
+	plan, err := a.previewConnect(a.ctx)
+	if err != nil {
+		return "", err
+	}
+	return plan.String(), nil
+}
+
+// connectPlanJSON — previewConnect для экспорта: план в виде JSON-документа.
+func (a *Application) connectPlanJSON() ([]byte, error) {
+	plan, err := a.previewConnect(a.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(plan, "", "  ")
+}
+
+// addRoutes повторяет выбор метрики routes.Manager: metric больше 0 заменяет метрику шлюза.
+func (p *ConnectPlan) addRoutes(cidrs []string, kind state.RouteKind, gateway *state.GatewayInfo, metric int) {
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		route := PlannedRoute{CIDR: cidr, Kind: kind, Metric: 1}
+		if gateway != nil {
+			route.Gateway = gateway.IP
+			route.Interface = gateway.InterfaceName
+			if gateway.Metric > 0 {
+				route.Metric = gateway.Metric
+			}
+		}
+		if metric > 0 {
+			route.Metric = metric
+		}
+		p.Routes = append(p.Routes, route)
+	}
+}
+
+func (a *Application) logConnectPlan(plan *ConnectPlan) {
+	a.logger.Infof("connect preview: profile=%s (%s) server=%s ipv6=%t kill_switch=%t", plan.ProfileName, plan.ProfileID, plan.Server, plan.IPv6, plan.KillSwitch)
+	for _, route := range plan.Routes {
+		a.logger.Infof("connect preview: route %s kind=%s via %s dev %s metric %d", route.CIDR, route.Kind, route.Gateway, route.Interface, route.Metric)
+	}
+	if len(plan.SkippedRoutes) > 0 {
+		a.logger.Infof("connect preview: ipv6 disabled, skipped routes %v", plan.SkippedRoutes)
+	}
+	a.logger.Infof("connect preview: tunnel dns %v", plan.TunnelDNS)
+}
+
+// String форматирует план для окна предпросмотра.
+func (p *ConnectPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, i18n.T("preview.profile"), p.ProfileName, p.ProfileID)
+	fmt.Fprintf(&b, i18n.T("preview.server"), p.Server)
+	fmt.Fprintf(&b, "IPv6: %s, Kill Switch: %s\n", onOff(p.IPv6), onOff(p.KillSwitch))
+	fmt.Fprintf(&b, i18n.T("preview.tunnel_dns"), strings.Join(p.TunnelDNS, ", "))
+	fmt.Fprintf(&b, i18n.T("preview.routes"), len(p.Routes))
+	for _, route := range p.Routes {
+		fmt.Fprintf(&b, "%-8s %-20s via %s dev %s metric %d\n", route.Kind, route.CIDR, route.Gateway, route.Interface, route.Metric)
+	}
+	if len(p.SkippedRoutes) > 0 {
+		fmt.Fprintf(&b, i18n.T("preview.skipped"), strings.Join(p.SkippedRoutes, ", "))
+	}
+	return b.String()
+}
+
+// MarshalJSON описывает план для аудита: сервер, шлюзы, прямые и туннельные маршруты,
+// DNS туннеля и правила брандмауэра. Поля в snake_case, пустые списки — [], а не null.
+func (p *ConnectPlan) MarshalJSON() ([]byte, error) {
+	type gatewayJSON struct {
+		IP        string `json:"ip"`
+		Interface string `json:"interface"`
+		Metric    int    `json:"metric"`
+	}
+	type routeJSON struct {
+		CIDR      string `json:"cidr"`
+		Gateway   string `json:"gateway"`
+		Interface string `json:"interface"`
+		Metric    int    `json:"metric"`
+	}
+	type firewallRuleJSON struct {
+		Purpose   string   `json:"purpose"`
+		Action    string   `json:"action"`
+		Direction string   `json:"direction"`
+		Interface string   `json:"interface"`
+		Family    string   `json:"family"`
+		Protocols []string `json:"protocols"`
+		Port      int      `json:"port,omitempty"`
+	}
+	gateway := func(info *state.GatewayInfo) *gatewayJSON {
+		if info == nil {
+			return nil
+		}
+		return &gatewayJSON{IP: info.IP, Interface: info.InterfaceName, Metric: info.Metric}
+	}
+	doc := struct {
+		ProfileID     string             `json:"profile_id"`
+		ProfileName   string             `json:"profile_name"`
+		Server        string             `json:"server"`
+		IPv6          bool               `json:"ipv6"`
+		KillSwitch    bool               `json:"kill_switch"`
+		Gateway       *gatewayJSON       `json:"gateway"`
+		GatewayV6     *gatewayJSON       `json:"gateway_v6"`
+		DirectRoutes  []routeJSON        `json:"direct_routes"`
+		TunnelRoutes  []routeJSON        `json:"tunnel_routes"`
+		SkippedRoutes []string           `json:"skipped_routes"`
+		DNSServers    []string           `json:"dns_servers"`
+		FirewallRules []firewallRuleJSON `json:"firewall_rules"`
+	}{
+		ProfileID:     p.ProfileID,
+		ProfileName:   p.ProfileName,
+		Server:        p.Server,
+		IPv6:          p.IPv6,
+		KillSwitch:    p.KillSwitch,
+		Gateway:       gateway(p.Gateway),
+		GatewayV6:     gateway(p.GatewayV6),
+		DirectRoutes:  []routeJSON{},
+		TunnelRoutes:  []routeJSON{},
+		SkippedRoutes: append([]string{}, p.SkippedRoutes...),
+		DNSServers:    append([]string{}, p.TunnelDNS...),
+		FirewallRules: []firewallRuleJSON{},
+	}
+	for _, route := range p.Routes {
+		item := routeJSON{CIDR: route.CIDR, Gateway: route.Gateway, Interface: route.Interface, Metric: route.Metric}
+		if route.Kind == state.RouteKindTunnel {
+			doc.TunnelRoutes = append(doc.TunnelRoutes, item)
+		} else {
+			doc.DirectRoutes = append(doc.DirectRoutes, item)
+		}
+	}
+	for _, rule := range p.FirewallRules {
+		doc.FirewallRules = append(doc.FirewallRules, firewallRuleJSON{
+			Purpose:   rule.Purpose,
+			Action:    "block",
+			Direction: "out",
+			Interface: rule.Interface,
+			Family:    rule.Family,
+			Protocols: append([]string{}, rule.Protocols...),
+			Port:      rule.Port,
+		})
+	}
+	return json.Marshal(doc)
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return i18n.T("preview.on")
+	}
+	return i18n.T("preview.off")
+}
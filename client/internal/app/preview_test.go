@@ -0,0 +1,75 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/state"
+)
+
+func newPreviewContext(current state.State) *state.AppContext {
+	ctx := state.NewAppContext(nil)
+	ctx.SetProfiles([]state.Profile{{
+		ID:                "p1",
+		Name:              "First",
+		Host:              "203.0.113.10",
+		Port:              443,
+		CoreConfigRaw:     json.RawMessage(`{"outbounds":[]}`),
+		DirectRoutes:      []string{"198.51.100.0/24"},
+		TunnelRoutes:      []string{"0.0.0.0/1"},
+		KillSwitchEnabled: true,
+	}})
+	ctx.SetSelectedProfileID("p1")
+	// так делает цикл событий после обработки события
+	ctx.State = current
+	ctx.PublishSession()
+	return ctx
+}
+
+func TestPreviewConnectDoesNotPromptForGateway(t *testing.T) {
+	gateways := &fakeGateways{}
+	gateways.set(testWiFi, testEthernet)
+	ui := &chooserUI{NoopUI: NewNoopUI(), pick: "Ethernet"}
+	a := &Application{
+		cfg:      &config.Config{TunnelGateway: "172.19.0.1", TunnelDNS: []string{"1.1.1.1"}},
+		logger:   newTestLogger(t),
+		gateways: gateways,
+		ui:       ui,
+	}
+	ctx := newPreviewContext(state.StateReadyDisconnected)
+
+	plan, err := a.previewConnect(ctx)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if ui.prompts() != 0 {
+		t.Fatalf("preview showed the gateway chooser")
+	}
+	if plan.Gateway == nil || plan.Gateway.InterfaceName != "Wi-Fi" {
+		t.Fatalf("preview gateway = %+v, want the best one (Wi-Fi)", plan.Gateway)
+	}
+
+	// после выбора пользователя предпросмотр показывает тот же шлюз, что и подключение
+	ctx.SetGatewayChoice("Ethernet")
+	plan, err = a.previewConnect(ctx)
+	if err != nil {
+		t.Fatalf("preview with choice: %v", err)
+	}
+	if plan.Gateway == nil || plan.Gateway.InterfaceName != "Ethernet" {
+		t.Fatalf("preview gateway = %+v, want remembered Ethernet", plan.Gateway)
+	}
+	if len(plan.FirewallRules) != 1 || plan.FirewallRules[0].Interface != "Ethernet" {
+		t.Fatalf("firewall rules = %+v, want kill switch on Ethernet", plan.FirewallRules)
+	}
+}
+
+func TestPreviewConnectRequiresDisconnectedState(t *testing.T) {
+	gateways := &fakeGateways{}
+	gateways.set(testWiFi)
+	a := &Application{cfg: &config.Config{}, logger: newTestLogger(t), gateways: gateways}
+
+	if _, err := a.previewConnect(newPreviewContext(state.StateConnected)); err == nil {
+		t.Fatalf("preview must be refused while connected")
+	}
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"customvpn/client/internal/state"
+)
+
+func profileIDs(profiles []state.Profile) []string {
+	ids := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestSortProfilesIsDeterministic(t *testing.T) {
+	profiles := []state.Profile{
+		{ID: "nl-2", Country: "NL", Name: "Amsterdam"},
+		{ID: "de-1", Country: "DE", Name: "Frankfurt"},
+		{ID: "nl-1", Country: "NL", Name: "Amsterdam"},
+		{ID: "de-2", Country: "de", Name: "berlin"},
+		{ID: "fi-1", Country: "FI", Name: "Helsinki"},
+		{ID: "nl-3", Country: "nl", Name: "Rotterdam"},
+	}
+	// страна, затем имя без учёта регистра, затем ID
+	want := []string{"de-2", "de-1", "fi-1", "nl-1", "nl-2", "nl-3"}
+
+	// сервер отдаёт профили в порядке обхода map: порядок на входе произвольный
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 100; round++ {
+		shuffled := slices.Clone(profiles)
+		rnd.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		sortProfiles(shuffled)
+		if got := profileIDs(shuffled); !slices.Equal(got, want) {
+			t.Fatalf("round %d: order = %q, want %q", round, got, want)
+		}
+	}
+}
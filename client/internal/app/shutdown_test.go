@@ -0,0 +1,82 @@
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// shutdownCountingUI — NoopUI, считающий вызовы Shutdown.
+type shutdownCountingUI struct {
+	*NoopUI
+	shutdowns atomic.Int32
+}
+
+func (u *shutdownCountingUI) Shutdown() {
+	u.shutdowns.Add(1)
+	u.NoopUI.Shutdown()
+}
+
+func TestStopFromSignalAndUILoopUnblocksRunUILoop(t *testing.T) {
+	a := newTestApplication(t, newFakeControl(controlServer("token")), "")
+	ui := &shutdownCountingUI{NoopUI: a.ui.(*NoopUI)}
+	a.ui = ui
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		// как в main: выход из цикла UI заканчивается Stop, а onAppStopped вызывает его ещё раз
+		a.RunUILoop()
+		a.Stop()
+	}()
+
+	// сигнал ОС и пункт Quit трея останавливают приложение одновременно
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Stop()
+		}()
+	}
+
+	select {
+	case <-loopDone:
+	case <-time.After(testWaitTimeout):
+		t.Fatalf("RunUILoop did not return after Stop")
+	}
+	wg.Wait()
+	select {
+	case <-a.Done():
+	default:
+		t.Fatalf("Done is not closed after Stop")
+	}
+	if got := ui.shutdowns.Load(); got != 1 {
+		t.Fatalf("ui.Shutdown called %d times, want 1", got)
+	}
+}
+
+func TestUIQuitStopsApplication(t *testing.T) {
+	a := newTestApplication(t, newFakeControl(controlServer("token")), "")
+	if err := a.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	loopDone := make(chan struct{})
+	go func() {
+		defer close(loopDone)
+		a.RunUILoop()
+	}()
+
+	// выход из цикла Fyne без сигнала (Quit трея): onAppStopped выполняет Stop
+	a.ui.Quit()
+	select {
+	case <-a.Done():
+	case <-time.After(testWaitTimeout):
+		t.Fatalf("application did not stop after UI quit")
+	}
+	<-loopDone
+}
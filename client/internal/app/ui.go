@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/state"
+)
+
+// UI — окна и уведомления, которые вызывает Application и state machine.
+// Fyne-реализация — ui.Manager; без окон работает NoopUI или своя реализация.
+// Show*/UpdateUI/OnStateChanged вызываются из goroutine event-loop и не должны блокироваться,
+// ChooseGateway и ConfirmEnableLocalPolicyMerge — из сценария подключения и ждут ответа.
+type UI interface {
+	Start()
+	// RunMainLoop блокирует вызывающую goroutine до Quit или Shutdown.
+	RunMainLoop()
+	Quit()
+	Shutdown()
+	WaitAsync(timeout time.Duration) bool
+	// SetOnStopped задаёт функцию, вызываемую после выхода из RunMainLoop.
+	SetOnStopped(fn func())
+	SetConfirmDisconnect(enabled bool)
+
+	ShowLoginWindow(ctx *state.AppContext)
+	ShowMainWindow(ctx *state.AppContext)
+	HideMainWindow(ctx *state.AppContext)
+	UpdateUI(ctx *state.AppContext)
+	ShowModalError(info *state.ErrorInfo)
+	ShowTransientNotice(message string)
+	ShowCleanupStarted()
+	ShowCleanupDone(result state.CleanupResultPayload)
+	ShowSettings(ctx *state.AppContext)
+	OnStateChanged(prev, next state.State)
+
+	ChooseGateway(ctx context.Context, gateways []*state.GatewayInfo) (*state.GatewayInfo, bool)
+	ConfirmEnableLocalPolicyMerge() bool
+}
+
+// UIHooks — действия Application, доступные UI: отправка событий и сохранение данных.
+type UIHooks struct {
+	Dispatch           func(state.Event) error
+	SaveSettings       func(config.Settings) error
+	Theme              string
+	CoreLogFile        string
+	ConfirmDisconnect  bool
+	CollectDiagnostics func() (string, error)
+	Headless           bool
+	// PreviewConnect задан только при log_level: debug.
+	PreviewConnect func() (string, error)
+	// CoreConfig задан только при log_level: debug.
+	CoreConfig func() (string, error)
+	// ExportConnectPlan возвращает план подключения к выбранному профилю в JSON.
+	ExportConnectPlan func() ([]byte, error)
+	Favorites         []string
+	SaveFavorites     func([]string) error
+	SessionHistory    func() []state.SessionRecord
+}
+
+// NewUIFunc создаёт UI; вызывается из New до запуска state machine.
+type NewUIFunc func(hooks UIHooks) UI
+
+// NoopUI — UI без окон для встраивания: уведомления пропускаются, из нескольких шлюзов
+// выбирается первый, включение локальных правил брандмауэра отклоняется.
+type NoopUI struct {
+	stopOnce  sync.Once
+	stop      chan struct{}
+	mu        sync.Mutex
+	onStopped func()
+}
+
+// NewNoopUI создаёт NoopUI.
+func NewNoopUI() *NoopUI {
+	return &NoopUI{stop: make(chan struct{})}
+}
+
+func (u *NoopUI) Start() {}
+
+// RunMainLoop ждёт Quit или Shutdown и вызывает функцию из SetOnStopped.
+func (u *NoopUI) RunMainLoop() {
+	<-u.stop
+	u.mu.Lock()
+	onStopped := u.onStopped
+	u.mu.Unlock()
+	if onStopped != nil {
+		onStopped()
+	}
+}
+
+func (u *NoopUI) Quit() {
+	u.stopOnce.Do(func() { close(u.stop) })
+}
+
+func (u *NoopUI) Shutdown() {
+	u.Quit()
+}
+
+func (u *NoopUI) WaitAsync(_ time.Duration) bool {
+	return true
+}
+
+func (u *NoopUI) SetOnStopped(fn func()) {
+	u.mu.Lock()
+	u.onStopped = fn
+	u.mu.Unlock()
+}
+
+func (u *NoopUI) SetConfirmDisconnect(_ bool)                  {}
+func (u *NoopUI) ShowLoginWindow(_ *state.AppContext)          {}
+func (u *NoopUI) ShowMainWindow(_ *state.AppContext)           {}
+func (u *NoopUI) HideMainWindow(_ *state.AppContext)           {}
+func (u *NoopUI) UpdateUI(_ *state.AppContext)                 {}
+func (u *NoopUI) ShowModalError(_ *state.ErrorInfo)            {}
+func (u *NoopUI) ShowTransientNotice(_ string)                 {}
+func (u *NoopUI) ShowCleanupStarted()                          {}
+func (u *NoopUI) ShowCleanupDone(_ state.CleanupResultPayload) {}
+func (u *NoopUI) ShowSettings(_ *state.AppContext)             {}
+func (u *NoopUI) OnStateChanged(_, _ state.State)              {}
+func (u *NoopUI) ConfirmEnableLocalPolicyMerge() bool          { return false }
+
+func (u *NoopUI) ChooseGateway(_ context.Context, gateways []*state.GatewayInfo) (*state.GatewayInfo, bool) {
+	if len(gateways) == 0 {
+		return nil, false
+	}
+	return gateways[0], true
+}
@@ -3,25 +3,161 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
+
+	"customvpn/client/internal/i18n"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	defaultPreflightAttempts  = 3
+	defaultPreflightBaseDelay = 2 * time.Second
+	defaultPreflightMaxDelay  = 30 * time.Second
+	defaultAutoReconnectDelay = 5 * time.Second
+	defaultCoreCheckTimeout   = 15 * time.Second
+	defaultConnectTimeout     = 30 * time.Second
+	defaultProbeInterval      = 10 * time.Second
+	defaultProbeTimeout       = 5 * time.Second
+	defaultProbeFailures      = 3
+	defaultTunnelGateway      = "100.64.127.1"
+	defaultTunnelDNS          = "100.64.127.2"
+)
+
 // ErrConfigFailed обозначает любую проблему с чтением или разбором config.yaml.
 var ErrConfigFailed = errors.New("config: failed to load")
 
 // Config описывает пользовательские настройки приложения и вычисляемые пути.
 type Config struct {
 	ControlServerURL string `yaml:"control_server_url"`
-	CorePath         string `yaml:"core_path"`
-	LogLevel         string `yaml:"log_level"`
-	LogFile          string `yaml:"log_file"`
+	// ControlServerCAFile — PEM с корневыми сертификатами частного CA Control-сервера.
+	ControlServerCAFile string `yaml:"control_server_ca_file"`
+	// ControlServerCertSHA256 — закреплённые SHA-256 отпечатки сертификата Control-сервера.
+	ControlServerCertSHA256 []string `yaml:"control_server_cert_sha256"`
+	// ControlProxyURL — прокси (http:// или socks5://) для запросов к Control-серверу.
+	ControlProxyURL string `yaml:"control_proxy_url"`
+	// UseEnvProxy берёт прокси из переменных окружения HTTP_PROXY/HTTPS_PROXY, если control_proxy_url пуст;
+	// по умолчанию включено, false — прямое подключение.
+	UseEnvProxy *bool  `yaml:"use_env_proxy"`
+	CorePath    string `yaml:"core_path"`
+	// CoreCheckTimeout ограничивает `core check` перед запуском Core; зависший процесс завершается.
+	CoreCheckTimeout time.Duration `yaml:"core_check_timeout"`
+	// ConnectTimeout ограничивает весь сценарий подключения; по истечении подключение откатывается.
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	// CoreLogLevel подставляется в log.level конфигурации Core; пусто — уровень из конфигурации профиля.
+	CoreLogLevel string `yaml:"core_log_level"`
+	LogLevel     string `yaml:"log_level"`
+	LogFile      string `yaml:"log_file"`
+	// LogMaxSizeMB — размер log_file в мегабайтах, после которого выполняется ротация (0 — без ротации).
+	LogMaxSizeMB  int `yaml:"log_max_size_mb"`
+	LogMaxBackups int `yaml:"log_max_backups"`
+	// Theme — оформление окон: light, dark или system (как в системе); по умолчанию light.
+	Theme string `yaml:"theme"`
+	// Language — язык интерфейса: ru (по умолчанию) или en.
+	Language string `yaml:"language"`
+
+	PreflightAttempts  int           `yaml:"preflight_attempts"`
+	PreflightBaseDelay time.Duration `yaml:"preflight_base_delay"`
+	PreflightMaxDelay  time.Duration `yaml:"preflight_max_delay"`
+
+	ControlTimeouts ControlTimeouts `yaml:"control_timeouts"`
+	// ControlRetry задаёт повторы GET-запросов к Control-серверу при сетевых ошибках и 5xx.
+	ControlRetry ControlRetry `yaml:"control_retry"`
+
+	// AutoReconnectAttempts — число попыток переподключения после неожиданного завершения Core (0 — выключено).
+	AutoReconnectAttempts int           `yaml:"auto_reconnect_attempts"`
+	AutoReconnectDelay    time.Duration `yaml:"auto_reconnect_delay"`
+
+	// LivenessProbe проверяет в состоянии Connected, что туннель пропускает трафик.
+	LivenessProbe LivenessProbe `yaml:"liveness_probe"`
+
+	// TunnelGateway — адрес шлюза туннеля из конфигурации Core: по нему находится интерфейс туннеля
+	// и через него идут маршруты Tunnel.
+	TunnelGateway string `yaml:"tunnel_gateway"`
+	// TunnelDNS — DNS-серверы туннеля для профилей без собственного tunnel_dns.
+	TunnelDNS []string `yaml:"tunnel_dns"`
+
+	// EnableIPv6 разрешает IPv6 во время сессии; по умолчанию исходящий IPv6 на основном интерфейсе блокируется.
+	EnableIPv6 bool `yaml:"enable_ipv6"`
+
+	// KillSwitchStrict включает строгий Kill Switch (только Linux): кроме DNS на основном интерфейсе
+	// блокируется весь трафик, кроме адреса VPN-сервера и прямых маршрутов профиля.
+	KillSwitchStrict bool `yaml:"kill_switch_strict"`
 
-	AppDir      string `yaml:"-"`
+	// VerifyRoutes после добавления маршрута проверяет его наличие в таблице маршрутизации.
+	VerifyRoutes bool `yaml:"verify_routes"`
+	// RouteConcurrency — число маршрутов профиля, добавляемых параллельно (0 и 1 — последовательно).
+	RouteConcurrency int `yaml:"route_concurrency"`
+
+	// RememberLogin сохраняет логин (не пароль) последнего успешного входа; по умолчанию включено.
+	RememberLogin *bool `yaml:"remember_login"`
+
+	// ConfirmDisconnect запрашивает подтверждение перед разрывом активного подключения; по умолчанию включено.
+	ConfirmDisconnect *bool `yaml:"confirm_disconnect"`
+
+	// AllowProfileHooks разрешает выполнять pre_connect_cmd/post_disconnect_cmd из профилей;
+	// по умолчанию выключено: команды приходят с сервера.
+	AllowProfileHooks bool `yaml:"allow_profile_hooks"`
+
+	// Headless задаёт учётные данные и профиль для запуска с флагом --headless.
+	Headless HeadlessConfig `yaml:"headless"`
+
+	AppDir string `yaml:"-"`
+	// DataDir — каталог логов и файлов состояния: AppDir, если в него можно писать, иначе каталог пользователя.
+	DataDir     string `yaml:"-"`
 	CoreLogFile string `yaml:"-"`
+	// AuditLogFile — журнал значимых действий (вход, подключение, Kill Switch, починка).
+	AuditLogFile string `yaml:"-"`
+	// Path — файл, из которого загружена конфигурация.
+	Path string `yaml:"-"`
+}
+
+// HeadlessConfig описывает автоматический вход и подключение без окон.
+// Если login или пароль не заданы, используются данные из хранилища учётных данных ОС.
+type HeadlessConfig struct {
+	Login string `yaml:"login"`
+	// Password берётся только из переменной CUSTOMVPN_HEADLESS_PASSWORD: в config.yaml пароль не хранится.
+	Password string `yaml:"-"`
+	// PasswordInFile сообщает, что в config.yaml остался ключ headless.password; его значение игнорируется.
+	PasswordInFile bool `yaml:"-"`
+	// Profile — ID или имя профиля; пусто — первый профиль из списка.
+	Profile string `yaml:"profile"`
+}
+
+// ControlRetry задаёт повторы health/sync/profile; /auth не повторяется.
+// Нулевое значение означает значение по умолчанию клиента, attempts: 1 отключает повторы.
+type ControlRetry struct {
+	Attempts int           `yaml:"attempts"`
+	Backoff  time.Duration `yaml:"backoff"`
+}
+
+// LivenessProbe — периодическое TCP-подключение к адресу за туннелем. После Failures неудач подряд
+// подключение считается потерянным, как при падении Core. Пустой Target выключает проверку.
+type LivenessProbe struct {
+	Target   string        `yaml:"target"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Failures int           `yaml:"failures"`
+}
+
+// Enabled сообщает, задан ли адрес проверки.
+func (p LivenessProbe) Enabled() bool {
+	return p.Target != ""
+}
+
+// ControlTimeouts задаёт таймауты запросов к эндпоинтам Control-сервера.
+// Нулевое значение означает таймаут по умолчанию клиента.
+type ControlTimeouts struct {
+	Health  time.Duration `yaml:"health"`
+	Auth    time.Duration `yaml:"auth"`
+	Sync    time.Duration `yaml:"sync"`
+	Profile time.Duration `yaml:"profile"`
 }
 
 // Error содержит дополнительный контекст при неудачной загрузке конфигурации.
@@ -57,6 +193,82 @@ func DetectAppDir() (string, error) {
 	return filepath.Dir(exePath), nil
 }
 
+// dataDirName — подкаталог в каталоге данных пользователя.
+const dataDirName = "CustomVPN"
+
+// DataDir возвращает каталог для логов и файлов состояния. Обычно это appDir, но установка
+// в Program Files недоступна на запись обычному пользователю: тогда используется
+// %LOCALAPPDATA%\CustomVPN (на других ОС — каталог настроек пользователя).
+func DataDir(appDir string) (string, error) {
+	if dirWritable(appDir) {
+		return appDir, nil
+	}
+	var (
+		base string
+		err  error
+	)
+	if runtime.GOOS == "windows" {
+		// на Windows UserCacheDir — это %LOCALAPPDATA%
+		base, err = os.UserCacheDir()
+	} else {
+		base, err = os.UserConfigDir()
+	}
+	if err != nil {
+		return "", fmt.Errorf("app directory %s is not writable and user data directory is unknown: %w", appDir, err)
+	}
+	return filepath.Join(base, dataDirName), nil
+}
+
+// InstanceDir возвращает каталог блокировки единственного экземпляра: appDir, а если туда нельзя
+// писать — %ProgramData%\CustomVPN на Windows и закрытый каталог пользователя (0700) на других ОС:
+// $XDG_RUNTIME_DIR/customvpn или customvpn в каталоге кэша пользователя.
+func InstanceDir(appDir string) (string, error) {
+	if appDir != "" && dirWritable(appDir) {
+		return appDir, nil
+	}
+	if runtime.GOOS == "windows" {
+		base := os.Getenv("ProgramData")
+		if base == "" {
+			base = `C:\ProgramData`
+		}
+		dir := filepath.Join(base, dataDirName)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("create instance directory: %w", err)
+		}
+		return dir, nil
+	}
+	base := os.Getenv("XDG_RUNTIME_DIR")
+	if base == "" {
+		var err error
+		if base, err = os.UserCacheDir(); err != nil {
+			return "", fmt.Errorf("app directory %s is not writable and user cache directory is unknown: %w", appDir, err)
+		}
+	}
+	dir := filepath.Join(base, strings.ToLower(dataDirName))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("create instance directory: %w", err)
+	}
+	// каталог мог остаться от старой версии с общими правами: адрес канала активации видит только владелец
+	if info, err := os.Stat(dir); err == nil && info.Mode().Perm() != 0o700 {
+		if err := os.Chmod(dir, 0o700); err != nil {
+			return "", fmt.Errorf("restrict instance directory: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// dirWritable проверяет запись пробным файлом: права каталога на Windows не отражают ACL.
+func dirWritable(dir string) bool {
+	file, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return false
+	}
+	name := file.Name()
+	_ = file.Close()
+	_ = os.Remove(name)
+	return true
+}
+
 // DefaultPath возвращает путь к config.yaml относительно каталога приложения.
 func DefaultPath(appDir string) string {
 	return filepath.Join(appDir, "config.yaml")
@@ -75,31 +287,147 @@ func Load(path string, appDir string) (*Config, error) {
 		return nil, &Error{Path: path, Err: err}
 	}
 
+	dataDir, err := DataDir(appDir)
+	if err != nil {
+		return nil, &Error{Path: path, Err: err}
+	}
+	cfg, err := parse(data, appDir, dataDir, os.LookupEnv)
+	if err != nil {
+		return nil, &Error{Path: path, Err: err}
+	}
+	cfg.Path = path
+	if err := checkCorePath(cfg.CorePath); err != nil {
+		return nil, &Error{Path: path, Err: err}
+	}
+	if err := cfg.ensureLogDirectories(); err != nil {
+		return nil, &Error{Path: path, Err: err}
+	}
+	return cfg, nil
+}
+
+// parse разбирает YAML, применяет переменные окружения (lookupEnv; nil — без них),
+// значения по умолчанию, appDir и dataDir (пусто — appDir) и валидирует результат.
+func parse(data []byte, appDir, dataDir string, lookupEnv func(string) (string, bool)) (*Config, error) {
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, &Error{Path: path, Err: err}
+		return nil, err
+	}
+	cfg.applyEnvOverrides(lookupEnv)
+	if err := cfg.applyHeadlessPassword(data, lookupEnv); err != nil {
+		return nil, err
 	}
 	cfg.AppDir = appDir
+	cfg.DataDir = dataDir
+	cfg.ControlServerURL = strings.TrimRight(strings.TrimSpace(cfg.ControlServerURL), "/")
 	cfg.LogLevel = normalizeLogLevel(cfg.LogLevel)
+	cfg.CoreLogLevel = strings.TrimSpace(strings.ToLower(cfg.CoreLogLevel))
+	cfg.Theme = normalizeTheme(cfg.Theme)
+	cfg.Language = normalizeLanguage(cfg.Language)
+	cfg.applyDefaults()
 	cfg.applyAppDir()
 	if err := cfg.validate(); err != nil {
-		return nil, &Error{Path: path, Err: err}
-	}
-	if err := cfg.ensureLogDirectories(); err != nil {
-		return nil, &Error{Path: path, Err: err}
+		return nil, err
 	}
 	return &cfg, nil
 }
 
+func (c *Config) applyDefaults() {
+	if c.PreflightAttempts == 0 {
+		c.PreflightAttempts = defaultPreflightAttempts
+	}
+	if c.PreflightBaseDelay == 0 {
+		c.PreflightBaseDelay = defaultPreflightBaseDelay
+	}
+	if c.PreflightMaxDelay == 0 {
+		c.PreflightMaxDelay = defaultPreflightMaxDelay
+	}
+	if c.AutoReconnectDelay == 0 {
+		c.AutoReconnectDelay = defaultAutoReconnectDelay
+	}
+	if c.CoreCheckTimeout == 0 {
+		c.CoreCheckTimeout = defaultCoreCheckTimeout
+	}
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = defaultConnectTimeout
+	}
+	c.LivenessProbe.Target = strings.TrimSpace(c.LivenessProbe.Target)
+	if c.LivenessProbe.Interval == 0 {
+		c.LivenessProbe.Interval = defaultProbeInterval
+	}
+	if c.LivenessProbe.Timeout == 0 {
+		c.LivenessProbe.Timeout = defaultProbeTimeout
+	}
+	if c.LivenessProbe.Failures == 0 {
+		c.LivenessProbe.Failures = defaultProbeFailures
+	}
+	c.TunnelGateway = strings.TrimSpace(c.TunnelGateway)
+	if c.TunnelGateway == "" {
+		c.TunnelGateway = defaultTunnelGateway
+	}
+	for i := range c.TunnelDNS {
+		c.TunnelDNS[i] = strings.TrimSpace(c.TunnelDNS[i])
+	}
+	if len(c.TunnelDNS) == 0 {
+		c.TunnelDNS = []string{defaultTunnelDNS}
+	}
+}
+
+// RememberLoginEnabled сообщает, нужно ли запоминать логин между сессиями.
+func (c *Config) RememberLoginEnabled() bool {
+	return c.RememberLogin == nil || *c.RememberLogin
+}
+
+// UseEnvProxyEnabled сообщает, нужно ли брать прокси из переменных окружения.
+func (c *Config) UseEnvProxyEnabled() bool {
+	return c.UseEnvProxy == nil || *c.UseEnvProxy
+}
+
+// ConfirmDisconnectEnabled сообщает, нужно ли подтверждать разрыв подключения.
+func (c *Config) ConfirmDisconnectEnabled() bool {
+	return c.ConfirmDisconnect == nil || *c.ConfirmDisconnect
+}
+
+// LastLoginPath возвращает путь к файлу с последним успешным логином.
+func (c *Config) LastLoginPath() string {
+	return filepath.Join(c.DataDir, "last_login.json")
+}
+
+// FavoritesPath возвращает путь к файлу с избранными профилями.
+func (c *Config) FavoritesPath() string {
+	return filepath.Join(c.DataDir, "favorites.json")
+}
+
+// HistoryPath возвращает путь к файлу истории подключений.
+func (c *Config) HistoryPath() string {
+	return filepath.Join(c.DataDir, "history.json")
+}
+
+// RoutesPath возвращает путь к файлу с маршрутами, добавленными клиентом.
+func (c *Config) RoutesPath() string {
+	return filepath.Join(c.DataDir, "routes.json")
+}
+
+// CleanupStatePath возвращает путь к файлу с состоянием для починки после сбоя.
+func (c *Config) CleanupStatePath() string {
+	return filepath.Join(c.DataDir, "temp", "cleanup_state.json")
+}
+
 func (c *Config) applyAppDir() {
 	if c.AppDir == "" {
 		return
 	}
 	c.AppDir = filepath.Clean(c.AppDir)
+	if c.DataDir == "" {
+		c.DataDir = c.AppDir
+	}
+	c.DataDir = filepath.Clean(c.DataDir)
+	// Core и сертификат лежат рядом с установкой, логи и состояние — в каталоге данных
 	c.CorePath = makeAbsolute(c.CorePath, c.AppDir)
-	c.LogFile = makeAbsolute(c.LogFile, c.AppDir)
-	logsDir := filepath.Join(c.AppDir, "logs")
+	c.ControlServerCAFile = makeAbsolute(c.ControlServerCAFile, c.AppDir)
+	c.LogFile = makeAbsolute(c.LogFile, c.DataDir)
+	logsDir := filepath.Join(c.DataDir, "logs")
 	c.CoreLogFile = filepath.Join(logsDir, "core.log")
+	c.AuditLogFile = filepath.Join(logsDir, "audit.log")
 }
 
 func (c *Config) validate() error {
@@ -113,12 +441,109 @@ func (c *Config) validate() error {
 	case c.AppDir == "":
 		return errors.New("app directory is unknown")
 	}
+	if err := validateControlServerURL(c.ControlServerURL); err != nil {
+		return err
+	}
 	if c.LogLevel == "" {
 		c.LogLevel = "info"
 	}
 	if _, ok := allowedLevels[c.LogLevel]; !ok {
 		return fmt.Errorf("unsupported log_level %q", c.LogLevel)
 	}
+	if _, ok := allowedCoreLevels[c.CoreLogLevel]; c.CoreLogLevel != "" && !ok {
+		return fmt.Errorf("unsupported core_log_level %q", c.CoreLogLevel)
+	}
+	if _, ok := allowedThemes[c.Theme]; !ok {
+		return fmt.Errorf("unsupported theme %q", c.Theme)
+	}
+	if !i18n.Supported(c.Language) {
+		return fmt.Errorf("unsupported language %q", c.Language)
+	}
+	switch {
+	case c.PreflightAttempts < 1:
+		return fmt.Errorf("preflight_attempts must be positive, got %d", c.PreflightAttempts)
+	case c.PreflightBaseDelay < 0:
+		return fmt.Errorf("preflight_base_delay must not be negative, got %s", c.PreflightBaseDelay)
+	case c.PreflightMaxDelay < c.PreflightBaseDelay:
+		return fmt.Errorf("preflight_max_delay %s is less than preflight_base_delay %s", c.PreflightMaxDelay, c.PreflightBaseDelay)
+	case c.ControlTimeouts.Health < 0, c.ControlTimeouts.Auth < 0, c.ControlTimeouts.Sync < 0, c.ControlTimeouts.Profile < 0:
+		return errors.New("control_timeouts must not be negative")
+	case c.ControlRetry.Attempts < 0, c.ControlRetry.Backoff < 0:
+		return errors.New("control_retry must not be negative")
+	case c.LogMaxSizeMB < 0:
+		return fmt.Errorf("log_max_size_mb must not be negative, got %d", c.LogMaxSizeMB)
+	case c.LogMaxBackups < 0:
+		return fmt.Errorf("log_max_backups must not be negative, got %d", c.LogMaxBackups)
+	case c.AutoReconnectAttempts < 0:
+		return fmt.Errorf("auto_reconnect_attempts must not be negative, got %d", c.AutoReconnectAttempts)
+	case c.AutoReconnectDelay < 0:
+		return fmt.Errorf("auto_reconnect_delay must not be negative, got %s", c.AutoReconnectDelay)
+	case c.CoreCheckTimeout < 0:
+		return fmt.Errorf("core_check_timeout must not be negative, got %s", c.CoreCheckTimeout)
+	case c.ConnectTimeout < 0:
+		return fmt.Errorf("connect_timeout must not be negative, got %s", c.ConnectTimeout)
+	case c.LivenessProbe.Interval < 0, c.LivenessProbe.Timeout < 0, c.LivenessProbe.Failures < 0:
+		return errors.New("liveness_probe must not be negative")
+	}
+	if c.LivenessProbe.Enabled() {
+		if _, _, err := net.SplitHostPort(c.LivenessProbe.Target); err != nil {
+			return fmt.Errorf("liveness_probe.target must be host:port: %w", err)
+		}
+	}
+	if !isIPv4(c.TunnelGateway) {
+		return fmt.Errorf("tunnel_gateway %q is not an IPv4 address", c.TunnelGateway)
+	}
+	for _, server := range c.TunnelDNS {
+		if !isIPv4(server) {
+			return fmt.Errorf("tunnel_dns %q is not an IPv4 address", server)
+		}
+	}
+	return nil
+}
+
+// validateControlServerURL требует абсолютный http(s) URL с хостом: без схемы url.Parse
+// принимает "example.com" как путь, и ошибка проявлялась бы только при preflight.
+func validateControlServerURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("control_server_url %q is not a valid URL: %w", raw, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("control_server_url %q must start with http:// or https://", raw)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("control_server_url %q has no host", raw)
+	}
+	return nil
+}
+
+func isIPv4(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && ip.To4() != nil
+}
+
+// checkCorePath проверяет, что core_path указывает на исполняемый файл,
+// чтобы ошибка обнаружилась при запуске, а не при первом подключении.
+func checkCorePath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("core_path %s: file not found", path)
+		}
+		return fmt.Errorf("core_path %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("core_path %s: not a regular file", path)
+	}
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(path), ".exe") {
+			return fmt.Errorf("core_path %s: expected an .exe file", path)
+		}
+		return nil
+	}
+	if info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("core_path %s: file is not executable", path)
+	}
 	return nil
 }
 
@@ -156,6 +581,46 @@ func normalizeLogLevel(value string) string {
 	return value
 }
 
+// allowedCoreLevels — уровни журнала sing-box, которые можно задать через core_log_level.
+var allowedCoreLevels = map[string]struct{}{
+	"trace": {},
+	"debug": {},
+	"info":  {},
+	"warn":  {},
+	"error": {},
+}
+
+// Оформление окон, допустимые значения theme.
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system"
+)
+
+var allowedThemes = map[string]struct{}{
+	ThemeLight:  {},
+	ThemeDark:   {},
+	ThemeSystem: {},
+}
+
+// normalizeTheme приводит theme к нижнему регистру; пусто — светлая тема.
+func normalizeTheme(value string) string {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return ThemeLight
+	}
+	return value
+}
+
+// normalizeLanguage приводит language к нижнему регистру; пусто — язык по умолчанию.
+func normalizeLanguage(value string) string {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return i18n.DefaultLanguage
+	}
+	return value
+}
+
 var allowedLevels = map[string]struct{}{
 	"debug": {},
 	"info":  {},
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateControlServerURL(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "https", raw: "https://control.example.com"},
+		{name: "http with port and path", raw: "http://10.0.0.1:8080/api"},
+		{name: "schemeless", raw: "control.example.com", wantErr: "must start with http:// or https://"},
+		{name: "schemeless with port", raw: "control.example.com:8443", wantErr: "must start with http:// or https://"},
+		{name: "ftp scheme", raw: "ftp://control.example.com", wantErr: "must start with http:// or https://"},
+		{name: "empty host", raw: "https://", wantErr: "has no host"},
+		{name: "empty host with path", raw: "https:///api", wantErr: "has no host"},
+		{name: "invalid", raw: "https://control example.com", wantErr: "is not a valid URL"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateControlServerURL(tc.raw)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateControlServerURL(%q): %v", tc.raw, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("validateControlServerURL(%q) = %v, want error containing %q", tc.raw, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseNormalizesControlServerURL(t *testing.T) {
+	data := strings.Replace(testConfigYAML, `"https://control.example.com"`, `" https://control.example.com// "`, 1)
+	cfg, err := parse([]byte(data), t.TempDir(), "", nil)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := "https://control.example.com"; cfg.ControlServerURL != want {
+		t.Fatalf("control_server_url = %q, want %q", cfg.ControlServerURL, want)
+	}
+}
+
+func TestParseRejectsSchemelessControlServerURL(t *testing.T) {
+	data := strings.Replace(testConfigYAML, `"https://control.example.com"`, `"control.example.com"`, 1)
+	if _, err := parse([]byte(data), t.TempDir(), "", nil); err == nil || !strings.Contains(err.Error(), "control_server_url") {
+		t.Fatalf("parse error = %v, want control_server_url error", err)
+	}
+}
+
+func TestParseHeadlessPassword(t *testing.T) {
+	cases := []struct {
+		name       string
+		yaml       string
+		env        map[string]string
+		wantPass   string
+		wantInFile bool
+	}{
+		{name: "none", yaml: "headless:\n  login: user\n"},
+		{name: "from environment", yaml: "headless:\n  login: user\n", env: map[string]string{EnvHeadlessPassword: " secret "}, wantPass: " secret "},
+		// пароль из файла не используется, только отмечается для предупреждения
+		{name: "plain text in file", yaml: "headless:\n  login: user\n  password: secret\n", wantInFile: true},
+		{name: "file and environment", yaml: "headless:\n  password: old\n", env: map[string]string{EnvHeadlessPassword: "new"}, wantPass: "new", wantInFile: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lookup := func(name string) (string, bool) {
+				value, ok := tc.env[name]
+				return value, ok
+			}
+			cfg, err := parse([]byte(testConfigYAML+tc.yaml), t.TempDir(), "", lookup)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if cfg.Headless.Password != tc.wantPass || cfg.Headless.PasswordInFile != tc.wantInFile {
+				t.Fatalf("password = %q, in file %v; want %q, %v", cfg.Headless.Password, cfg.Headless.PasswordInFile, tc.wantPass, tc.wantInFile)
+			}
+		})
+	}
+}
+
+func TestInstanceDirIsPrivatePerUser(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("on Windows the lock lives in %ProgramData%")
+	}
+	base := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", base)
+	// каталог от старой версии с общими правами сужается до владельца
+	want := filepath.Join(base, "customvpn")
+	if err := os.Mkdir(want, 0o777); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Chmod(want, 0o777|os.ModeSticky); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+
+	dir, err := InstanceDir("")
+	if err != nil {
+		t.Fatalf("InstanceDir: %v", err)
+	}
+	if dir != want {
+		t.Fatalf("instance dir = %s, want %s", dir, want)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Fatalf("instance dir mode = %o, want 700", perm)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Переменные окружения, которые перекрывают значения config.yaml (например, в CI и контейнерах).
+// Пустая переменная не учитывается.
+const (
+	EnvControlServerURL = "CUSTOMVPN_CONTROL_SERVER_URL"
+	EnvLogLevel         = "CUSTOMVPN_LOG_LEVEL"
+	EnvCorePath         = "CUSTOMVPN_CORE_PATH"
+	// EnvHeadlessPassword задаёт пароль для --headless вместо хранилища учётных данных ОС.
+	EnvHeadlessPassword = "CUSTOMVPN_HEADLESS_PASSWORD"
+)
+
+// envOverrides связывает ключи config.yaml с переменными окружения и полями Config.
+var envOverrides = []struct {
+	key   string
+	env   string
+	field func(*Config) *string
+}{
+	{key: "control_server_url", env: EnvControlServerURL, field: func(c *Config) *string { return &c.ControlServerURL }},
+	{key: "log_level", env: EnvLogLevel, field: func(c *Config) *string { return &c.LogLevel }},
+	{key: "core_path", env: EnvCorePath, field: func(c *Config) *string { return &c.CorePath }},
+}
+
+// applyEnvOverrides подставляет непустые переменные окружения вместо значений из YAML.
+func (c *Config) applyEnvOverrides(lookup func(string) (string, bool)) {
+	if lookup == nil {
+		return
+	}
+	for _, override := range envOverrides {
+		if value, ok := envValue(lookup, override.env); ok {
+			*override.field(c) = value
+		}
+	}
+}
+
+// applyHeadlessPassword берёт пароль --headless из окружения и отмечает устаревший
+// ключ headless.password в config.yaml, чтобы при старте предупредить о нём.
+func (c *Config) applyHeadlessPassword(data []byte, lookup func(string) (string, bool)) error {
+	var legacy struct {
+		Headless struct {
+			Password string `yaml:"password"`
+		} `yaml:"headless"`
+	}
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	c.Headless.PasswordInFile = legacy.Headless.Password != ""
+	if lookup == nil {
+		return nil
+	}
+	// пароль не обрезается: пробелы могут быть его частью
+	if value, ok := lookup(EnvHeadlessPassword); ok && value != "" {
+		c.Headless.Password = value
+	}
+	return nil
+}
+
+// envOverrideFor возвращает значение переменной окружения, перекрывающей ключ config.yaml.
+func envOverrideFor(lookup func(string) (string, bool), key string) (string, bool) {
+	if lookup == nil {
+		return "", false
+	}
+	for _, override := range envOverrides {
+		if override.key == key {
+			return envValue(lookup, override.env)
+		}
+	}
+	return "", false
+}
+
+func envValue(lookup func(string) (string, bool), name string) (string, bool) {
+	value, ok := lookup(name)
+	value = strings.TrimSpace(value)
+	return value, ok && value != ""
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings содержит параметры, которые пользователь может изменить в окне настроек.
+type Settings struct {
+	ControlServerURL string
+	LogLevel         string
+	CorePath         string
+	Theme            string
+}
+
+// CurrentSettings возвращает редактируемые параметры загруженной конфигурации.
+func (c *Config) CurrentSettings() Settings {
+	if c == nil {
+		return Settings{}
+	}
+	return Settings{
+		ControlServerURL: c.ControlServerURL,
+		LogLevel:         c.LogLevel,
+		CorePath:         c.CorePath,
+		Theme:            c.Theme,
+	}
+}
+
+// Save записывает settings в config.yaml по пути path, сохраняя остальные ключи и комментарии.
+// Итоговый файл проверяется так же, как при Load; при ошибке файл не изменяется.
+func Save(path string, appDir string, settings Settings) error {
+	if path == "" {
+		return errors.New("config path is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return errors.New("config root is not a mapping")
+	}
+	root := doc.Content[0]
+	for _, item := range []struct{ key, value string }{
+		{"control_server_url", settings.ControlServerURL},
+		{"log_level", normalizeLogLevel(settings.LogLevel)},
+		{"core_path", settings.CorePath},
+	} {
+		if keepFileValue(item.key, item.value, appDir) || keepRawPath(root, item.key, item.value, appDir) {
+			continue
+		}
+		setScalar(root, item.key, item.value)
+	}
+	setScalar(root, "theme", normalizeTheme(settings.Theme))
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if _, err := parse(buf.Bytes(), appDir, "", os.LookupEnv); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// keepFileValue сообщает, что значение пришло из переменной окружения и не менялось в окне настроек:
+// такой ключ в config.yaml не перезаписывается, чтобы URL и пути из окружения не попадали в файл.
+func keepFileValue(key, value, appDir string) bool {
+	override, ok := envOverrideFor(os.LookupEnv, key)
+	if !ok {
+		return false
+	}
+	switch key {
+	case "control_server_url":
+		override = strings.TrimRight(override, "/")
+	case "log_level":
+		override = normalizeLogLevel(override)
+	case "core_path":
+		override = makeAbsolute(override, appDir)
+	}
+	return value == override
+}
+
+// keepRawPath сообщает, что core_path в файле после приведения к абсолютному пути совпадает с value:
+// относительный путь остаётся в config.yaml как есть и не заменяется абсолютным путём установки.
+func keepRawPath(mapping *yaml.Node, key, value, appDir string) bool {
+	if key != "core_path" {
+		return false
+	}
+	raw, ok := scalarValue(mapping, key)
+	return ok && makeAbsolute(raw, appDir) == value
+}
+
+func scalarValue(mapping *yaml.Node, key string) (string, bool) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key && mapping.Content[i+1].Kind == yaml.ScalarNode {
+			return mapping.Content[i+1].Value, true
+		}
+	}
+	return "", false
+}
+
+func setScalar(mapping *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			node := mapping.Content[i+1]
+			node.Kind = yaml.ScalarNode
+			node.Tag = "!!str"
+			node.Value = value
+			node.Content = nil
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle},
+	)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp config: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("write temp config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("close temp config: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("replace config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfigYAML = `# настройки клиента
+control_server_url: "https://control.example.com"
+core_path: "core/sing-box.exe"
+log_level: "info"
+log_file: "logs/client.log"
+`
+
+func writeTestConfig(t *testing.T) (path, appDir string) {
+	t.Helper()
+	appDir = t.TempDir()
+	path = filepath.Join(appDir, "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfigYAML), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path, appDir
+}
+
+func TestSaveKeepsRelativeCorePath(t *testing.T) {
+	path, appDir := writeTestConfig(t)
+	cfg, err := parse([]byte(testConfigYAML), appDir, "", nil)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	settings := cfg.CurrentSettings()
+	if !filepath.IsAbs(settings.CorePath) {
+		t.Fatalf("loaded core_path %q is not absolute", settings.CorePath)
+	}
+	settings.LogLevel = "debug"
+
+	if err := Save(path, appDir, settings); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	saved := string(data)
+	for _, want := range []string{`core_path: "core/sing-box.exe"`, `log_level: "debug"`, "# настройки клиента"} {
+		if !strings.Contains(saved, want) {
+			t.Fatalf("saved config lacks %s:\n%s", want, saved)
+		}
+	}
+	if strings.Contains(saved, appDir) {
+		t.Fatalf("saved config contains the install path:\n%s", saved)
+	}
+}
+
+func TestSaveWritesChangedCorePath(t *testing.T) {
+	path, appDir := writeTestConfig(t)
+	cfg, err := parse([]byte(testConfigYAML), appDir, "", nil)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	settings := cfg.CurrentSettings()
+	settings.CorePath = filepath.Join(appDir, "bin", "core.exe")
+
+	if err := Save(path, appDir, settings); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	saved, err := parse(data, appDir, "", nil)
+	if err != nil {
+		t.Fatalf("parse saved config: %v", err)
+	}
+	if saved.CorePath != settings.CorePath {
+		t.Fatalf("core_path = %q, want %q", saved.CorePath, settings.CorePath)
+	}
+}
@@ -2,7 +2,11 @@ package controlclient
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,31 +15,141 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"customvpn/client/internal/logging"
 	"customvpn/client/internal/state"
 )
 
+// errReauthFailed помечает ошибку повторной авторизации внутри запроса.
+var errReauthFailed = errors.New("re-authenticate")
+
 // Client инкапсулирует HTTP-взаимодействия с Control-сервером.
 type Client struct {
-	baseURL    *url.URL
-	httpClient *http.Client
-	logger     *logging.Logger
+	baseURL          *url.URL
+	doer             Doer
+	logger           *logging.Logger
+	reauth           ReauthFunc
+	onTokenRefreshed func(token string)
+	timeouts         Timeouts
+	retry            RetryPolicy
+
+	// reauthMu защищает общую повторную авторизацию: параллельные запросы, получившие 401
+	// с одним токеном, ждут один вызов reauth вместо собственного /auth.
+	reauthMu       sync.Mutex
+	reauthInFlight *reauthCall
+	// replacedToken — токен, отклонённый последним, и выданный ему на замену freshToken.
+	replacedToken string
+	freshToken    string
+}
+
+// reauthCall — выполняющаяся повторная авторизация; done закрывается после её завершения.
+type reauthCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// Timeouts задаёт предельное время выполнения для каждого эндпоинта.
+// Нулевые значения заменяются значениями по умолчанию.
+type Timeouts struct {
+	Health  time.Duration
+	Auth    time.Duration
+	Sync    time.Duration
+	Profile time.Duration
+}
+
+// Doer выполняет HTTP-запрос; *http.Client ему удовлетворяет.
+// Через NewWithDoer тесты подставляют фейк без живого сервера.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
 }
 
+// ReauthFunc получает новый authToken, когда сервер отклонил текущий.
+type ReauthFunc func(ctx context.Context) (string, error)
+
 // Options позволяет переопределить зависимости клиента.
 type Options struct {
 	HTTPClient *http.Client
 	Logger     *logging.Logger
+	// ReauthFunc вызывается не более одного раза на запрос, если авторизованный эндпоинт вернул 401/403.
+	// Параллельные запросы, отклонённые с одним токеном, разделяют один вызов.
+	ReauthFunc ReauthFunc
+	// OnTokenRefreshed получает токен, выданный ReauthFunc, чтобы последующие вызовы использовали его.
+	OnTokenRefreshed func(token string)
+	Timeouts         Timeouts
+	// Retry задаёт повторы CheckHealth, SyncProfileList и SyncProfile; Auth не повторяется.
+	Retry RetryPolicy
+	// TLSConfig и PinnedCertSHA256 используются, только если HTTPClient не задан.
+	TLSConfig *tls.Config
+	// PinnedCertSHA256 содержит допустимые SHA-256 отпечатки сертификата сервера (hex, двоеточия допускаются).
+	PinnedCertSHA256 []string
+	// ProxyURL — прокси для всех запросов клиента (preflight, auth, sync, profile, logout):
+	// http://host:port или socks5://host:port, учётные данные допускаются в userinfo.
+	// Используется, только если HTTPClient не задан.
+	ProxyURL string
+	// Если ProxyURL пуст, прокси берётся из HTTP_PROXY/HTTPS_PROXY/NO_PROXY, как у http.DefaultTransport.
+	// NoEnvProxy отключает переменные окружения: без ProxyURL клиент подключается напрямую.
+	NoEnvProxy bool
 }
 
 const (
-	defaultTimeout = 15 * time.Second
+	defaultTimeout       = 15 * time.Second
+	defaultHealthTimeout = 10 * time.Second
 )
 
 // New создаёт новый клиент Control-сервера.
 func New(baseURL string, opts Options) (*Client, error) {
+	parsed, err := parseBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.PinnedCertSHA256) > 0 && !strings.EqualFold(parsed.Scheme, "https") {
+		return nil, fmt.Errorf("certificate pinning requires https baseURL, got %q", parsed.Scheme)
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		proxy, err := proxyFunc(opts.ProxyURL, !opts.NoEnvProxy)
+		if err != nil {
+			return nil, fmt.Errorf("configure proxy: %w", err)
+		}
+		transport, err := newTransport(opts.TLSConfig, opts.PinnedCertSHA256, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("configure tls: %w", err)
+		}
+		// общий таймаут не задаём: каждый метод ограничивает запрос своим контекстом
+		client = &http.Client{Transport: transport}
+	}
+	return newClient(parsed, withDebugLogging(client, opts.Logger), opts), nil
+}
+
+// NewWithDoer создаёт клиент, который выполняет запросы через doer.
+// HTTPClient, TLSConfig, PinnedCertSHA256 и настройки прокси из opts не используются.
+func NewWithDoer(baseURL string, doer Doer, opts Options) (*Client, error) {
+	if doer == nil {
+		return nil, fmt.Errorf("doer is nil")
+	}
+	parsed, err := parseBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(parsed, doer, opts), nil
+}
+
+func newClient(baseURL *url.URL, doer Doer, opts Options) *Client {
+	return &Client{
+		baseURL:          baseURL,
+		doer:             doer,
+		logger:           opts.Logger,
+		reauth:           opts.ReauthFunc,
+		onTokenRefreshed: opts.OnTokenRefreshed,
+		timeouts:         opts.Timeouts.withDefaults(),
+		retry:            opts.Retry.withDefaults(),
+	}
+}
+
+func parseBaseURL(baseURL string) (*url.URL, error) {
 	if baseURL == "" {
 		return nil, fmt.Errorf("baseURL is empty")
 	}
@@ -43,11 +157,7 @@ func New(baseURL string, opts Options) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parse baseURL: %w", err)
 	}
-	client := opts.HTTPClient
-	if client == nil {
-		client = &http.Client{Timeout: defaultTimeout}
-	}
-	return &Client{baseURL: parsed, httpClient: client, logger: opts.Logger}, nil
+	return parsed, nil
 }
 
 // Error описывает проблему при запросах к Control-серверу.
@@ -56,6 +166,8 @@ type Error struct {
 	Kind   state.ErrorKind
 	Status int
 	Err    error
+	// RetryAfter — через сколько сервер разрешит повтор (заголовок Retry-After); 0, если не указано.
+	RetryAfter time.Duration
 }
 
 func (e *Error) Error() string {
@@ -67,34 +179,84 @@ func (e *Error) Error() string {
 
 func (e *Error) Unwrap() error { return e.Err }
 
-// CheckHealth выполняет GET /health и ожидает строку "OK".
-func (c *Client) CheckHealth(ctx context.Context) error {
+func (t Timeouts) withDefaults() Timeouts {
+	if t.Health <= 0 {
+		t.Health = defaultHealthTimeout
+	}
+	if t.Auth <= 0 {
+		t.Auth = defaultTimeout
+	}
+	if t.Sync <= 0 {
+		t.Sync = defaultTimeout
+	}
+	if t.Profile <= 0 {
+		t.Profile = defaultTimeout
+	}
+	return t
+}
+
+// CheckHealth выполняет GET /health и возвращает сведения о сервере.
+// Ответ {"status":"OK","api_version":"1.2"}; старый ответ "OK" считается версией API 0.
+func (c *Client) CheckHealth(ctx context.Context) (state.ServerInfo, error) {
+	var info state.ServerInfo
+	err := withRetry(ctx, c.retry.Attempts, c.retry.Backoff, func() error {
+		var err error
+		info, err = c.checkHealth(ctx)
+		return err
+	})
+	return info, err
+}
+
+func (c *Client) checkHealth(ctx context.Context) (state.ServerInfo, error) {
 	const op = "CheckHealth"
+	ctx, cancel := withTimeout(ctx, c.timeouts.Health)
+	defer cancel()
 	resp, err := c.do(ctx, http.MethodGet, "/health", "", nil)
 	if err != nil {
-		return wrapError(op, state.ErrorKindNetworkUnavailable, err)
+		return state.ServerInfo{}, wrapError(op, state.ErrorKindNetworkUnavailable, err)
 	}
 	defer resp.Body.Close()
+	skew := clockSkew(resp, time.Now())
 	if resp.StatusCode != http.StatusOK {
-		return &Error{Op: op, Kind: state.ErrorKindNetworkUnavailable, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+		return state.ServerInfo{}, &Error{Op: op, Kind: state.ErrorKindNetworkUnavailable, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode), RetryAfter: retryAfter(resp)}
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return wrapError(op, state.ErrorKindNetworkUnavailable, err)
+		return state.ServerInfo{}, wrapError(op, state.ErrorKindNetworkUnavailable, err)
 	}
 	text := strings.TrimSpace(string(body))
+	legacy := state.ServerInfo{Status: "OK", APIVersion: "0", ClockSkew: skew}
 	if text == "OK" {
-		return nil
+		return legacy, nil
 	}
 	if unquoted, err := strconv.Unquote(text); err == nil && strings.TrimSpace(unquoted) == "OK" {
-		return nil
+		return legacy, nil
+	}
+	var dto HealthDTO
+	if err := json.Unmarshal(body, &dto); err == nil {
+		if info, err := dto.Validate(); err == nil {
+			info.ClockSkew = skew
+			return info, nil
+		}
 	}
-	return &Error{Op: op, Kind: state.ErrorKindNetworkUnavailable, Status: http.StatusOK, Err: fmt.Errorf("unexpected body %q", string(body))}
+	return state.ServerInfo{}, &Error{Op: op, Kind: state.ErrorKindNetworkUnavailable, Status: http.StatusOK, Err: fmt.Errorf("unexpected body %q", string(body))}
+}
+
+// clockSkew возвращает разницу между временем сервера из заголовка Date и локальным временем
+// получения ответа. Без заголовка или при неверном формате — 0.
+func clockSkew(resp *http.Response, received time.Time) time.Duration {
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+	return serverTime.Sub(received)
 }
 
 // Auth вызывает /auth и возвращает authToken.
 func (c *Client) Auth(ctx context.Context, login, password string) (string, error) {
 	const op = "Auth"
+	ctx, cancel := withTimeout(ctx, c.timeouts.Auth)
+	defer cancel()
 	payload := AuthRequest{Login: login, Password: password}
 	resp, err := c.doJSON(ctx, http.MethodPost, "/auth", "", payload)
 	if err != nil {
@@ -102,10 +264,13 @@ func (c *Client) Auth(ctx context.Context, login, password string) (string, erro
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return "", &Error{Op: op, Kind: state.ErrorKindAuthFailed, Status: resp.StatusCode, Err: errors.New("auth failed")}
+		return "", authFailure(op, resp)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", &Error{Op: op, Kind: state.ErrorKindTooManyAttempts, Status: resp.StatusCode, Err: errors.New("auth failed: too many attempts"), RetryAfter: retryAfter(resp)}
 	}
 	if resp.StatusCode != http.StatusOK {
-		return "", &Error{Op: op, Kind: state.ErrorKindUnknown, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+		return "", &Error{Op: op, Kind: state.ErrorKindUnknown, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode), RetryAfter: retryAfter(resp)}
 	}
 	var body AuthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
@@ -117,16 +282,90 @@ func (c *Client) Auth(ctx context.Context, login, password string) (string, erro
 	return body.AuthToken, nil
 }
 
+// authFailure различает неверные учётные данные и заблокированную учётную запись по телу ответа.
+// Тело не в JSON (например, устаревшее "Auth Failed") трактуется как неверный логин или пароль.
+func authFailure(op string, resp *http.Response) *Error {
+	var body AuthErrorDTO
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	_ = json.Unmarshal(data, &body)
+	switch code := strings.TrimSpace(body.Error); code {
+	case AuthErrorLocked, AuthErrorDisabled:
+		return &Error{Op: op, Kind: state.ErrorKindAccountLocked, Status: resp.StatusCode, Err: fmt.Errorf("auth failed: account %s", code)}
+	case AuthErrorBadCredentials:
+		return &Error{Op: op, Kind: state.ErrorKindAuthFailed, Status: resp.StatusCode, Err: errors.New("auth failed: bad credentials")}
+	default:
+		return &Error{Op: op, Kind: state.ErrorKindAuthFailed, Status: resp.StatusCode, Err: errors.New("auth failed")}
+	}
+}
+
+// retryAfter читает Retry-After: число секунд или HTTP-дату. Дата в прошлом и ошибка разбора дают 0.
+func retryAfter(resp *http.Response) time.Duration {
+	return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+}
+
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+	if delay := at.Sub(now); delay > 0 {
+		return delay.Round(time.Second)
+	}
+	return 0
+}
+
+// Logout вызывает POST /logout, чтобы сервер аннулировал authToken.
+// Повторная авторизация здесь не выполняется: отклонённый токен и так недействителен.
+func (c *Client) Logout(ctx context.Context, authToken string) error {
+	const op = "Logout"
+	if strings.TrimSpace(authToken) == "" {
+		return wrapError(op, state.ErrorKindAuthFailed, errors.New("auth token is empty"))
+	}
+	ctx, cancel := withTimeout(ctx, c.timeouts.Auth)
+	defer cancel()
+	resp, err := c.send(ctx, http.MethodPost, "/logout", authToken, nil)
+	if err != nil {
+		return wrapError(op, state.ErrorKindNetworkUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return &Error{Op: op, Kind: state.ErrorKindUnknown, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+	}
+	return nil
+}
+
 // SyncProfileList вызывает /sync/profiles.
 func (c *Client) SyncProfileList(ctx context.Context, authToken string) ([]state.Profile, error) {
+	var profiles []state.Profile
+	err := withRetry(ctx, c.retry.Attempts, c.retry.Backoff, func() error {
+		var err error
+		profiles, err = c.syncProfileList(ctx, authToken)
+		return err
+	})
+	return profiles, err
+}
+
+func (c *Client) syncProfileList(ctx context.Context, authToken string) ([]state.Profile, error) {
 	const op = "SyncProfileList"
+	ctx, cancel := withTimeout(ctx, c.timeouts.Sync)
+	defer cancel()
 	resp, err := c.do(ctx, http.MethodGet, "/sync/profiles", authToken, nil)
 	if err != nil {
-		return nil, wrapError(op, state.ErrorKindNetworkUnavailable, err)
+		return nil, requestError(op, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, &Error{Op: op, Kind: state.ErrorKindSyncFailed, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+		return nil, &Error{Op: op, Kind: state.ErrorKindSyncFailed, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode), RetryAfter: retryAfter(resp)}
 	}
 	var payload []ProfileSummaryDTO
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
@@ -145,18 +384,30 @@ func (c *Client) SyncProfileList(ctx context.Context, authToken string) ([]state
 
 // SyncProfile вызывает /profiles/{id}.
 func (c *Client) SyncProfile(ctx context.Context, authToken string, id string) (state.Profile, error) {
+	var profile state.Profile
+	err := withRetry(ctx, c.retry.Attempts, c.retry.Backoff, func() error {
+		var err error
+		profile, err = c.syncProfile(ctx, authToken, id)
+		return err
+	})
+	return profile, err
+}
+
+func (c *Client) syncProfile(ctx context.Context, authToken string, id string) (state.Profile, error) {
 	const op = "SyncProfile"
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return state.Profile{}, wrapError(op, state.ErrorKindSyncFailed, errors.New("profile id is empty"))
 	}
+	ctx, cancel := withTimeout(ctx, c.timeouts.Profile)
+	defer cancel()
 	resp, err := c.do(ctx, http.MethodGet, "/profiles/"+url.PathEscape(id), authToken, nil)
 	if err != nil {
-		return state.Profile{}, wrapError(op, state.ErrorKindNetworkUnavailable, err)
+		return state.Profile{}, requestError(op, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return state.Profile{}, &Error{Op: op, Kind: state.ErrorKindSyncFailed, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+		return state.Profile{}, &Error{Op: op, Kind: state.ErrorKindSyncFailed, Status: resp.StatusCode, Err: fmt.Errorf("unexpected status %d", resp.StatusCode), RetryAfter: retryAfter(resp)}
 	}
 	var payload ProfileDTO
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
@@ -169,30 +420,241 @@ func (c *Client) SyncProfile(ctx context.Context, authToken string, id string) (
 	return profile, nil
 }
 
+// SyncProfilesDetailed загружает полные профили по списку ID, выполняя не более concurrency
+// запросов одновременно. Результат возвращается в порядке ids; при первой ошибке остальные
+// запросы отменяются и возвращается эта ошибка.
+func (c *Client) SyncProfilesDetailed(ctx context.Context, authToken string, ids []string, concurrency int) ([]state.Profile, error) {
+	return c.SyncProfilesDetailedProgress(ctx, authToken, ids, concurrency, nil)
+}
+
+// SyncProfilesDetailedProgress — SyncProfilesDetailed, сообщающий onProgress(done, total) после
+// каждого загруженного профиля. onProgress вызывается из рабочих горутин, но не одновременно.
+func (c *Client) SyncProfilesDetailedProgress(ctx context.Context, authToken string, ids []string, concurrency int, onProgress func(done, total int)) ([]state.Profile, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(ids) {
+		concurrency = len(ids)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	profiles := make([]state.Profile, len(ids))
+	jobs := make(chan int)
+	var (
+		wg         sync.WaitGroup
+		errOnce    sync.Once
+		firstErr   error
+		progressMu sync.Mutex
+		done       int
+	)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				profile, err := c.SyncProfile(ctx, authToken, ids[idx])
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				profiles[idx] = profile
+				if onProgress != nil {
+					progressMu.Lock()
+					done++
+					onProgress(done, len(ids))
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for idx := range ids {
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, wrapError("SyncProfilesDetailed", state.ErrorKindNetworkUnavailable, err)
+	}
+	return profiles, nil
+}
+
 func (c *Client) do(ctx context.Context, method, path, authToken string, body io.Reader) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+	}
+	resp, err := c.send(ctx, method, path, authToken, payload)
+	if err != nil {
+		return nil, err
+	}
+	if authToken == "" || c.reauth == nil || !isAuthRejected(resp.StatusCode) {
+		return resp, nil
+	}
+	status := resp.StatusCode
+	resp.Body.Close()
+	if c.logger != nil {
+		c.logger.Infof("control server rejected token for %s %s (status %d), re-authenticating", method, path, status)
+	}
+	token, err := c.refreshToken(ctx, authToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w after status %d: %w", errReauthFailed, status, err)
+	}
+	return c.send(ctx, method, path, token, payload)
+}
+
+// refreshToken возвращает токен на замену отклонённого rejected. Если его уже заменили, повторный
+// вход не выполняется; одновременные вызовы ждут одну повторную авторизацию и получают её результат.
+func (c *Client) refreshToken(ctx context.Context, rejected string) (string, error) {
+	c.reauthMu.Lock()
+	if c.freshToken != "" && c.replacedToken == rejected {
+		token := c.freshToken
+		c.reauthMu.Unlock()
+		return token, nil
+	}
+	if call := c.reauthInFlight; call != nil {
+		c.reauthMu.Unlock()
+		select {
+		case <-call.done:
+			return call.token, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &reauthCall{done: make(chan struct{})}
+	c.reauthInFlight = call
+	c.reauthMu.Unlock()
+
+	call.token, call.err = c.reauth(ctx)
+	if call.err == nil && strings.TrimSpace(call.token) == "" {
+		call.err = errors.New("empty auth token")
+	}
+	if call.err == nil && c.onTokenRefreshed != nil {
+		c.onTokenRefreshed(call.token)
+	}
+	c.reauthMu.Lock()
+	c.reauthInFlight = nil
+	if call.err == nil {
+		c.replacedToken, c.freshToken = rejected, call.token
+	}
+	c.reauthMu.Unlock()
+	close(call.done)
+	return call.token, call.err
+}
+
+func (c *Client) send(ctx context.Context, method, path, authToken string, payload []byte) (*http.Response, error) {
 	rel, err := url.Parse(path)
 	if err != nil {
 		return nil, err
 	}
 	full := c.baseURL.ResolveReference(rel)
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
 	req, err := http.NewRequestWithContext(ctx, method, full.String(), body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
-	if body != nil {
+	// профили с core_config большие: сжатие запрашивается явно и распаковывается в decodeGzipBody
+	req.Header.Set("Accept-Encoding", "gzip")
+	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	if authToken != "" {
 		req.Header.Set("Authorization", "Bearer "+authToken)
 	}
-	resp, err := c.httpClient.Do(req)
+	// X-Request-ID связывает строку лога клиента со строкой лога сервера
+	requestID := newRequestID()
+	if requestID != "" {
+		req.Header.Set(requestIDHeader, requestID)
+	}
+	resp, err := c.doer.Do(req)
 	if err != nil {
+		if c.logger != nil {
+			c.logger.Debugf("control request %s %s failed: request_id=%s error=%v", method, rel.Path, requestID, err)
+		}
+		return nil, err
+	}
+	if c.logger != nil {
+		c.logger.Debugf("control request %s %s: status=%d request_id=%s", method, rel.Path, resp.StatusCode, requestID)
+	}
+	if err := decodeGzipBody(resp); err != nil {
+		resp.Body.Close()
 		return nil, err
 	}
 	return resp, nil
 }
 
+const requestIDHeader = "X-Request-ID"
+
+// newRequestID возвращает 16 случайных hex-символов; пустая строка, если генерация не удалась.
+func newRequestID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// decodeGzipBody подменяет тело ответа с Content-Encoding: gzip распакованным потоком.
+// При явном Accept-Encoding транспорт не распаковывает ответ сам.
+func decodeGzipBody(resp *http.Response) error {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// пустое тело (например, HEAD или 204) распаковывать нечего
+			return nil
+		}
+		return fmt.Errorf("gzip response: %w", err)
+	}
+	resp.Body = &gzipBody{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// gzipBody закрывает и распаковщик, и исходное тело ответа.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	zerr := b.Reader.Close()
+	if err := b.body.Close(); err != nil {
+		return err
+	}
+	return zerr
+}
+
+func isAuthRejected(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
 func (c *Client) doJSON(ctx context.Context, method, path, authToken string, payload any) (*http.Response, error) {
 	var body io.Reader
 	if payload != nil {
@@ -205,6 +667,28 @@ func (c *Client) doJSON(ctx context.Context, method, path, authToken string, pay
 	return c.do(ctx, method, path, authToken, body)
 }
 
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// requestError оборачивает ошибку выполнения запроса. Неудачная повторная авторизация сохраняет
+// вид ошибки /auth (AuthFailed, AccountLocked): пользователя возвращают к вводу логина, а не
+// показывают сетевую ошибку.
+func requestError(op string, err error) error {
+	if !errors.Is(err, errReauthFailed) {
+		return wrapError(op, state.ErrorKindNetworkUnavailable, err)
+	}
+	kind := state.ErrorKindAuthFailed
+	var authErr *Error
+	if errors.As(err, &authErr) && authErr.Kind != "" {
+		kind = authErr.Kind
+	}
+	return &Error{Op: op, Kind: kind, Err: err}
+}
+
 func wrapError(op string, kind state.ErrorKind, err error) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,155 @@
+package controlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// profileServer отдаёт /profiles/{id} и считает одновременные запросы.
+type profileServer struct {
+	delay  time.Duration
+	failID string
+
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+	mu       sync.Mutex
+	served   []string
+}
+
+func (s *profileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/profiles/")
+	current := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		seen := s.maxSeen.Load()
+		if current <= seen || s.maxSeen.CompareAndSwap(seen, current) {
+			break
+		}
+	}
+	s.mu.Lock()
+	s.served = append(s.served, id)
+	s.mu.Unlock()
+	select {
+	case <-time.After(s.delay):
+	case <-r.Context().Done():
+		return
+	}
+	if id == s.failID {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(ProfileDTO{
+		ID:         id,
+		Name:       "Profile " + id,
+		Host:       "203.0.113.10",
+		Port:       443,
+		CoreConfig: json.RawMessage(`{"outbounds":[]}`),
+	})
+}
+
+func newProfileClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := New(srv.URL, Options{HTTPClient: srv.Client(), Retry: RetryPolicy{Attempts: 1}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+func TestSyncProfilesDetailedKeepsOrderAndBound(t *testing.T) {
+	srv := &profileServer{delay: 20 * time.Millisecond}
+	client := newProfileClient(t, srv)
+	ids := make([]string, 12)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p%02d", i)
+	}
+
+	profiles, err := client.SyncProfilesDetailed(context.Background(), "token", ids, 3)
+	if err != nil {
+		t.Fatalf("SyncProfilesDetailed: %v", err)
+	}
+	if len(profiles) != len(ids) {
+		t.Fatalf("got %d profiles, want %d", len(profiles), len(ids))
+	}
+	for i, profile := range profiles {
+		if profile.ID != ids[i] || len(profile.CoreConfigRaw) == 0 {
+			t.Fatalf("profile %d = %s (core_config %d bytes), want %s with core_config", i, profile.ID, len(profile.CoreConfigRaw), ids[i])
+		}
+	}
+	if peak := srv.maxSeen.Load(); peak > 3 || peak < 2 {
+		t.Fatalf("peak concurrency = %d, want 2..3", peak)
+	}
+}
+
+func TestSyncProfilesDetailedStopsOnFirstError(t *testing.T) {
+	srv := &profileServer{delay: 10 * time.Millisecond, failID: "p01"}
+	client := newProfileClient(t, srv)
+	ids := make([]string, 40)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p%02d", i)
+	}
+
+	profiles, err := client.SyncProfilesDetailed(context.Background(), "token", ids, 2)
+	if err == nil {
+		t.Fatalf("expected error, got %d profiles", len(profiles))
+	}
+	var cErr *Error
+	if !errors.As(err, &cErr) || cErr.Status != http.StatusInternalServerError {
+		t.Fatalf("error = %v, want *Error with status 500", err)
+	}
+	srv.mu.Lock()
+	served := len(srv.served)
+	srv.mu.Unlock()
+	if served >= len(ids) {
+		t.Fatalf("all %d profiles requested after the first error", served)
+	}
+}
+
+func TestSyncProfilesDetailedEmpty(t *testing.T) {
+	client := newProfileClient(t, http.NotFoundHandler())
+	profiles, err := client.SyncProfilesDetailed(context.Background(), "token", nil, 4)
+	if err != nil || profiles != nil {
+		t.Fatalf("SyncProfilesDetailed(nil) = %v, %v", profiles, err)
+	}
+}
+
+func TestSyncProfilesDetailedProgressReportsEachProfile(t *testing.T) {
+	srv := &profileServer{delay: 5 * time.Millisecond}
+	client := newProfileClient(t, srv)
+	ids := []string{"p00", "p01", "p02", "p03", "p04"}
+
+	var mu sync.Mutex
+	var reported []int
+	_, err := client.SyncProfilesDetailedProgress(context.Background(), "token", ids, 2, func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if total != len(ids) {
+			t.Errorf("total = %d, want %d", total, len(ids))
+		}
+		reported = append(reported, done)
+	})
+	if err != nil {
+		t.Fatalf("SyncProfilesDetailedProgress: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != len(ids) {
+		t.Fatalf("progress reported %d times, want %d", len(reported), len(ids))
+	}
+	for i, done := range reported {
+		if done != i+1 {
+			t.Fatalf("progress = %v, want 1..%d in order", reported, len(ids))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package controlclient
+
+import (
+	"net/http"
+	"time"
+
+	"customvpn/client/internal/logging"
+)
+
+// debugTransport пишет в лог каждый запрос к Control-серверу: метод, путь, статус и длительность.
+// Заголовок Authorization, тело запроса и query не логируются.
+type debugTransport struct {
+	next   http.RoundTripper
+	logger *logging.Logger
+}
+
+// withDebugLogging оборачивает транспорт клиента, не меняя переданный *http.Client.
+// Уровень логгера меняется на лету (настройки), поэтому он проверяется при каждом запросе.
+func withDebugLogging(client *http.Client, logger *logging.Logger) *http.Client {
+	if logger == nil {
+		return client
+	}
+	wrapped := *client
+	next := wrapped.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped.Transport = &debugTransport{next: next, logger: logger}
+	return &wrapped
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.logger.Level() != logging.LevelDebug {
+		return t.next.RoundTrip(req)
+	}
+	auth := ""
+	if req.Header.Get("Authorization") != "" {
+		auth = " auth=<redacted>"
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.logger.Debugf("control request %s %s%s failed after %s: %v", req.Method, req.URL.Path, auth, duration, err)
+		return resp, err
+	}
+	t.logger.Debugf("control request %s %s%s -> %d in %s", req.Method, req.URL.Path, auth, resp.StatusCode, duration)
+	return resp, nil
+}
@@ -3,6 +3,7 @@ package controlclient
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 
 	"customvpn/client/internal/state"
@@ -19,6 +20,13 @@ type ProfileDTO struct {
 	DirectRoutes []string        `json:"direct_routes"`
 	TunnelRoutes []string        `json:"tunnel_routes"`
 	KillSwitch  bool            `json:"kill_switch"`
+	EnableIPv6   bool            `json:"enable_ipv6"`
+	TunnelDNS    []string        `json:"tunnel_dns"`
+	// PreConnectCmd и PostDisconnectCmd — необязательные команды профиля (см. allow_profile_hooks).
+	PreConnectCmd     string `json:"pre_connect_cmd"`
+	PostDisconnectCmd string `json:"post_disconnect_cmd"`
+	Status            string `json:"status"`
+	RouteMetric       int    `json:"route_metric"`
 }
 
 // ProfileSummaryDTO matches /sync/profiles response.
@@ -26,6 +34,14 @@ type ProfileSummaryDTO struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
 	Country string `json:"country"`
+	// Status — active или disabled; старые серверы поле не передают.
+	Status string `json:"status"`
+}
+
+// HealthDTO matches /health JSON response.
+type HealthDTO struct {
+	Status     string `json:"status"`
+	APIVersion string `json:"api_version"`
 }
 
 // AuthRequest encodes /auth request body.
@@ -39,6 +55,20 @@ type AuthResponse struct {
 	AuthToken string `json:"authToken"`
 }
 
+// AuthErrorDTO — тело отказа /auth: {"error":"bad_credentials"} (401), {"error":"locked"} (403)
+// или {"error":"too_many_attempts"} (429).
+type AuthErrorDTO struct {
+	Error string `json:"error"`
+}
+
+// Коды отказа /auth.
+const (
+	AuthErrorBadCredentials = "bad_credentials"
+	AuthErrorLocked         = "locked"
+	AuthErrorDisabled       = "disabled"
+	AuthErrorTooMany        = "too_many_attempts"
+)
+
 // Validate converts DTO to state.Profile with basic validation.
 func (dto ProfileDTO) Validate() (state.Profile, error) {
 	if dto.ID == "" {
@@ -53,6 +83,13 @@ func (dto ProfileDTO) Validate() (state.Profile, error) {
 	if dto.Port <= 0 || dto.Port > 65535 {
 		return state.Profile{}, fmt.Errorf("profile %s: invalid port %d", dto.ID, dto.Port)
 	}
+	if dto.RouteMetric < 0 || dto.RouteMetric > state.MaxRouteMetric {
+		return state.Profile{}, fmt.Errorf("profile %s: route_metric %d out of range 0..%d", dto.ID, dto.RouteMetric, state.MaxRouteMetric)
+	}
+	tunnelDNS, err := normalizeIPs(dto.TunnelDNS)
+	if err != nil {
+		return state.Profile{}, fmt.Errorf("profile %s: tunnel_dns: %w", dto.ID, err)
+	}
 	return state.Profile{
 		ID:            dto.ID,
 		Name:          dto.Name,
@@ -63,9 +100,27 @@ func (dto ProfileDTO) Validate() (state.Profile, error) {
 		DirectRoutes:  normalizeCIDRs(dto.DirectRoutes),
 		TunnelRoutes:  normalizeCIDRs(dto.TunnelRoutes),
 		KillSwitchEnabled: dto.KillSwitch,
+		IPv6Enabled:       dto.EnableIPv6,
+		TunnelDNS:         tunnelDNS,
+		PreConnectCmd:     strings.TrimSpace(dto.PreConnectCmd),
+		PostDisconnectCmd: strings.TrimSpace(dto.PostDisconnectCmd),
+		Status:            normalizeProfileStatus(dto.Status),
+		RouteMetric:       dto.RouteMetric,
 	}, nil
 }
 
+// Validate converts health DTO to state.ServerInfo.
+func (dto HealthDTO) Validate() (state.ServerInfo, error) {
+	if strings.TrimSpace(dto.Status) != "OK" {
+		return state.ServerInfo{}, fmt.Errorf("server status %q", dto.Status)
+	}
+	version := strings.TrimSpace(dto.APIVersion)
+	if version == "" {
+		version = "0"
+	}
+	return state.ServerInfo{Status: dto.Status, APIVersion: version}, nil
+}
+
 // Validate converts list item DTO to state.Profile summary.
 func (dto ProfileSummaryDTO) Validate() (state.Profile, error) {
 	if dto.ID == "" {
@@ -78,9 +133,21 @@ func (dto ProfileSummaryDTO) Validate() (state.Profile, error) {
 		ID:      dto.ID,
 		Name:    dto.Name,
 		Country: dto.Country,
+		Status:  normalizeProfileStatus(dto.Status),
 	}, nil
 }
 
+// normalizeProfileStatus приводит status к active или disabled: пусто — active,
+// неизвестное значение считается disabled, чтобы не подключаться к профилю, который сервер не разрешил.
+func normalizeProfileStatus(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", state.ProfileStatusActive:
+		return state.ProfileStatusActive
+	default:
+		return state.ProfileStatusDisabled
+	}
+}
+
 func normalizeCIDRs(values []string) []string {
 	result := make([]string, 0, len(values))
 	for _, value := range values {
@@ -92,3 +159,19 @@ func normalizeCIDRs(values []string) []string {
 	}
 	return result
 }
+
+func normalizeIPs(values []string) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", value)
+		}
+		result = append(result, ip.String())
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package controlclient
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// chunkedGzipProfile отдаёт профиль сжатым потоком частями, без Content-Length.
+func chunkedGzipProfile(t *testing.T, coreConfig json.RawMessage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		body, err := json.Marshal(ProfileDTO{
+			ID:         "p1",
+			Name:       "Profile p1",
+			Host:       "203.0.113.10",
+			Port:       443,
+			CoreConfig: coreConfig,
+		})
+		if err != nil {
+			t.Errorf("marshal: %v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		flusher := w.(http.Flusher)
+		for chunk := range slices.Chunk(body, 4096) {
+			_, _ = zw.Write(chunk)
+			_ = zw.Flush()
+			flusher.Flush()
+		}
+		_ = zw.Close()
+	}
+}
+
+// transferRecorder запоминает, как сервер передал тело ответа.
+type transferRecorder struct {
+	client        *http.Client
+	contentLength int64
+	encoding      []string
+}
+
+func (d *transferRecorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.client.Do(req)
+	if err == nil {
+		d.contentLength = resp.ContentLength
+		d.encoding = resp.TransferEncoding
+	}
+	return resp, err
+}
+
+func TestSyncProfileDecodesChunkedGzip(t *testing.T) {
+	// конфигурация заметно больше порога сжатия сервера (1 КБ)
+	coreConfig := json.RawMessage(`{"outbounds":[],"padding":"` + strings.Repeat("x", 64<<10) + `"}`)
+	srv := httptest.NewServer(chunkedGzipProfile(t, coreConfig))
+	t.Cleanup(srv.Close)
+	doer := &transferRecorder{client: srv.Client()}
+	client, err := NewWithDoer(srv.URL, doer, Options{Retry: RetryPolicy{Attempts: 1}})
+	if err != nil {
+		t.Fatalf("NewWithDoer: %v", err)
+	}
+
+	profile, err := client.SyncProfile(context.Background(), "token", "p1")
+	if err != nil {
+		t.Fatalf("SyncProfile: %v", err)
+	}
+	if doer.contentLength != -1 || !slices.Equal(doer.encoding, []string{"chunked"}) {
+		t.Fatalf("response length %d, transfer encoding %q, want chunked", doer.contentLength, doer.encoding)
+	}
+	if profile.ID != "p1" || string(profile.CoreConfigRaw) != string(coreConfig) {
+		t.Fatalf("profile %q decoded with %d bytes of core config, want %d", profile.ID, len(profile.CoreConfigRaw), len(coreConfig))
+	}
+}
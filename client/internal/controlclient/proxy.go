@@ -0,0 +1,34 @@
+package controlclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyFunc возвращает функцию выбора прокси для транспорта.
+// Явно заданный proxyURL имеет приоритет; иначе при useEnv используются HTTP_PROXY/HTTPS_PROXY/NO_PROXY.
+// nil означает прямое подключение.
+func proxyFunc(proxyURL string, useEnv bool) (func(*http.Request) (*url.URL, error), error) {
+	proxyURL = strings.TrimSpace(proxyURL)
+	if proxyURL == "" {
+		if useEnv {
+			return http.ProxyFromEnvironment, nil
+		}
+		return nil, nil
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy url: %w", err)
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (expected http or socks5)", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("proxy url %q has no host", parsed.Redacted())
+	}
+	return http.ProxyURL(parsed), nil
+}
@@ -0,0 +1,147 @@
+package controlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+// tokenServer принимает только текущий токен и отдаёт профили и их список.
+type tokenServer struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (s *tokenServer) setToken(token string) {
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	want := "Bearer " + s.token
+	s.mu.Unlock()
+	if r.Header.Get("Authorization") != want {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.URL.Path == "/sync/profiles" {
+		_ = json.NewEncoder(w).Encode([]ProfileSummaryDTO{{ID: "p1", Name: "Profile p1"}})
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/profiles/")
+	_ = json.NewEncoder(w).Encode(ProfileDTO{ID: id, Name: "Profile " + id, Host: "203.0.113.10", Port: 443})
+}
+
+func newReauthClient(t *testing.T, handler http.Handler, reauth ReauthFunc, onRefreshed func(string)) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := New(srv.URL, Options{
+		HTTPClient:       srv.Client(),
+		Retry:            RetryPolicy{Attempts: 1},
+		ReauthFunc:       reauth,
+		OnTokenRefreshed: onRefreshed,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+func TestReauthFailureKeepsAuthErrorKind(t *testing.T) {
+	cases := []struct {
+		name      string
+		reauthErr error
+		want      state.ErrorKind
+	}{
+		{name: "password changed", reauthErr: &Error{Op: "Auth", Kind: state.ErrorKindAuthFailed, Status: http.StatusUnauthorized, Err: errors.New("auth failed")}, want: state.ErrorKindAuthFailed},
+		{name: "account locked", reauthErr: &Error{Op: "Auth", Kind: state.ErrorKindAccountLocked, Status: http.StatusForbidden, Err: errors.New("account locked")}, want: state.ErrorKindAccountLocked},
+		{name: "no credentials", reauthErr: errors.New("no stored credentials for re-authentication"), want: state.ErrorKindAuthFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &tokenServer{token: "server-only"}
+			client := newReauthClient(t, server, func(context.Context) (string, error) { return "", tc.reauthErr }, nil)
+
+			_, listErr := client.SyncProfileList(context.Background(), "stale")
+			_, profileErr := client.SyncProfile(context.Background(), "stale", "p1")
+			for _, err := range []error{listErr, profileErr} {
+				var clientErr *Error
+				if !errors.As(err, &clientErr) || clientErr.Kind != tc.want {
+					t.Fatalf("error = %v, want kind %s", err, tc.want)
+				}
+				if !errors.Is(err, tc.reauthErr) {
+					t.Fatalf("error %v does not wrap the re-auth failure", err)
+				}
+			}
+		})
+	}
+}
+
+func TestReauthIsSingleFlight(t *testing.T) {
+	server := &tokenServer{token: "fresh"}
+	var reauths, refreshed atomic.Int32
+	client := newReauthClient(t, server, func(context.Context) (string, error) {
+		reauths.Add(1)
+		// пока /auth выполняется, остальные рабочие тоже получают 401
+		time.Sleep(50 * time.Millisecond)
+		return "fresh", nil
+	}, func(string) { refreshed.Add(1) })
+
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+	profiles, err := client.SyncProfilesDetailed(context.Background(), "stale", ids, len(ids))
+	if err != nil {
+		t.Fatalf("SyncProfilesDetailed: %v", err)
+	}
+	if len(profiles) != len(ids) {
+		t.Fatalf("profiles = %d, want %d", len(profiles), len(ids))
+	}
+	if got := reauths.Load(); got != 1 {
+		t.Fatalf("re-auth calls = %d, want 1", got)
+	}
+	if got := refreshed.Load(); got != 1 {
+		t.Fatalf("token refreshed callbacks = %d, want 1", got)
+	}
+
+	// запрос, начатый со старым токеном позже, берёт уже выданную замену
+	if _, err := client.SyncProfile(context.Background(), "stale", "g"); err != nil {
+		t.Fatalf("SyncProfile with replaced token: %v", err)
+	}
+	if got := reauths.Load(); got != 1 {
+		t.Fatalf("re-auth calls after replaced token = %d, want 1", got)
+	}
+}
+
+func TestReauthAgainWhenFreshTokenRejected(t *testing.T) {
+	server := &tokenServer{token: "first"}
+	var reauths atomic.Int32
+	client := newReauthClient(t, server, func(context.Context) (string, error) {
+		if reauths.Add(1) == 1 {
+			return "first", nil
+		}
+		return "second", nil
+	}, nil)
+
+	if _, err := client.SyncProfileList(context.Background(), "stale"); err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	// сервер отозвал и выданный токен: повторный вход выполняется снова
+	server.setToken("second")
+	if _, err := client.SyncProfileList(context.Background(), "first"); err != nil {
+		t.Fatalf("second refresh: %v", err)
+	}
+	if got := reauths.Load(); got != 2 {
+		t.Fatalf("re-auth calls = %d, want 2", got)
+	}
+}
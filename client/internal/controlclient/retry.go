@@ -0,0 +1,88 @@
+package controlclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 500 * time.Millisecond
+	// MaxRetryAfter ограничивает паузу, которую может запросить сервер через Retry-After.
+	MaxRetryAfter = 2 * time.Minute
+)
+
+// RetryPolicy задаёт повторы идемпотентных GET-запросов (health, sync, profile).
+// Нулевые значения заменяются значениями по умолчанию; Attempts = 1 отключает повторы.
+type RetryPolicy struct {
+	Attempts int
+	// Backoff — пауза перед второй попыткой; далее удваивается.
+	Backoff time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Attempts <= 0 {
+		p.Attempts = defaultRetryAttempts
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = defaultRetryBackoff
+	}
+	return p
+}
+
+// withRetry вызывает fn до attempts раз, пока ошибка временная: сетевая, 429 или 5xx.
+// Ответы 4xx, ошибки разбора и отмена ctx возвращаются сразу. Пауза не короче Retry-After ответа.
+func withRetry(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn()
+		if err == nil || attempt == attempts || !isRetryable(err) {
+			return err
+		}
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		timer := time.NewTimer(max(backoff, RetryAfter(err)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// isRetryable сообщает, имеет ли смысл повторить запрос.
+// Повторная авторизация внутри запроса не повторяется, чтобы не множить вызовы /auth.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, errReauthFailed) {
+		return false
+	}
+	var clientErr *Error
+	if !errors.As(err, &clientErr) {
+		return false
+	}
+	if clientErr.Status != 0 {
+		return clientErr.Status >= http.StatusInternalServerError || clientErr.Status == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(clientErr.Err, &urlErr)
+}
+
+// RetryAfter возвращает паузу из заголовка Retry-After ответа, ограниченную MaxRetryAfter;
+// 0, если сервер её не указал.
+func RetryAfter(err error) time.Duration {
+	var clientErr *Error
+	if !errors.As(err, &clientErr) || clientErr.RetryAfter <= 0 {
+		return 0
+	}
+	return min(clientErr.RetryAfter, MaxRetryAfter)
+}
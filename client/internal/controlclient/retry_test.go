@@ -0,0 +1,120 @@
+package controlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "delta seconds", value: "30", want: 30 * time.Second},
+		{name: "delta seconds with spaces", value: " 5 ", want: 5 * time.Second},
+		{name: "zero seconds", value: "0", want: 0},
+		{name: "negative seconds", value: "-10", want: 0},
+		{name: "http date", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{name: "http date rfc850", value: now.Add(time.Minute).Format(time.RFC850), want: time.Minute},
+		{name: "http date in past", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "garbage", value: "soon", want: 0},
+		{name: "empty", value: "", want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseRetryAfter(tc.value, now); got != tc.want {
+				t.Fatalf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterClampsToMax(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{name: "within limit", err: &Error{Op: "CheckHealth", RetryAfter: 10 * time.Second}, want: 10 * time.Second},
+		{name: "clamped", err: &Error{Op: "CheckHealth", RetryAfter: time.Hour}, want: MaxRetryAfter},
+		{name: "wrapped", err: fmt.Errorf("preflight: %w", &Error{Op: "CheckHealth", RetryAfter: time.Second}), want: time.Second},
+		{name: "not set", err: &Error{Op: "CheckHealth"}, want: 0},
+		{name: "other error", err: errors.New("boom"), want: 0},
+		{name: "nil", err: nil, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RetryAfter(tc.err); got != tc.want {
+				t.Fatalf("RetryAfter(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+// statusDoer отвечает кодом status с заголовком Retry-After, не обращаясь к сети,
+// и запоминает время запросов.
+type statusDoer struct {
+	status     int
+	retryAfter string
+	calls      []time.Time
+}
+
+func (d *statusDoer) Do(*http.Request) (*http.Response, error) {
+	d.calls = append(d.calls, time.Now())
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Retry-After", d.retryAfter)
+	rec.WriteHeader(d.status)
+	return rec.Result(), nil
+}
+
+func TestCheckHealthSurfacesRetryAfter(t *testing.T) {
+	cases := []struct {
+		name       string
+		retryAfter string
+		want       time.Duration
+	}{
+		{name: "delta seconds", retryAfter: "7", want: 7 * time.Second},
+		{name: "http date", retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), want: MaxRetryAfter},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doer := &statusDoer{status: http.StatusServiceUnavailable, retryAfter: tc.retryAfter}
+			client, err := NewWithDoer("https://control.test", doer, Options{Retry: RetryPolicy{Attempts: 1}})
+			if err != nil {
+				t.Fatalf("NewWithDoer: %v", err)
+			}
+			_, err = client.CheckHealth(context.Background())
+			if err == nil {
+				t.Fatalf("CheckHealth succeeded on 503")
+			}
+			if got := RetryAfter(err); got != tc.want {
+				t.Fatalf("RetryAfter = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryWaitsForRetryAfter(t *testing.T) {
+	doer := &statusDoer{status: http.StatusTooManyRequests, retryAfter: "1"}
+	client, err := NewWithDoer("https://control.test", doer, Options{Retry: RetryPolicy{Attempts: 2, Backoff: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("NewWithDoer: %v", err)
+	}
+	if _, err := client.CheckHealth(context.Background()); err == nil {
+		t.Fatalf("CheckHealth succeeded on 429")
+	}
+	if len(doer.calls) != 2 {
+		t.Fatalf("requests = %d, want 2", len(doer.calls))
+	}
+	// пауза между попытками не короче Retry-After, хотя backoff всего 1ms
+	if gap := doer.calls[1].Sub(doer.calls[0]); gap < time.Second {
+		t.Fatalf("retry after %v, want at least 1s", gap)
+	}
+}
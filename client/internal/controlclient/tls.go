@@ -0,0 +1,80 @@
+package controlclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrCertificatePinMismatch возвращается, если сертификат сервера не совпал ни с одним из закреплённых отпечатков.
+var ErrCertificatePinMismatch = errors.New("server certificate does not match pinned fingerprints")
+
+// newTransport создаёт HTTP-транспорт с учётом прокси, TLS-настроек и закрепления сертификатов.
+func newTransport(tlsConfig *tls.Config, pins []string, proxy func(*http.Request) (*url.URL, error)) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	if tlsConfig == nil && len(pins) == 0 {
+		return transport, nil
+	}
+	var cfg *tls.Config
+	if tlsConfig != nil {
+		cfg = tlsConfig.Clone()
+	} else {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if len(pins) > 0 {
+		fingerprints, err := parseFingerprints(pins)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			// цепочку не проверяем: доверие определяется только закреплённым отпечатком
+			cfg.InsecureSkipVerify = true
+		}
+		cfg.VerifyPeerCertificate = verifyPinnedLeaf(fingerprints)
+	}
+	transport.TLSClientConfig = cfg
+	return transport, nil
+}
+
+func verifyPinnedLeaf(fingerprints [][]byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("%w: no certificate presented", ErrCertificatePinMismatch)
+		}
+		sum := sha256.Sum256(rawCerts[0])
+		for _, fp := range fingerprints {
+			if bytes.Equal(fp, sum[:]) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: got %s", ErrCertificatePinMismatch, hex.EncodeToString(sum[:]))
+	}
+}
+
+func parseFingerprints(values []string) ([][]byte, error) {
+	result := make([][]byte, 0, len(values))
+	for _, value := range values {
+		normalized := strings.ToLower(strings.TrimSpace(value))
+		normalized = strings.ReplaceAll(normalized, ":", "")
+		if normalized == "" {
+			continue
+		}
+		raw, err := hex.DecodeString(normalized)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid SHA-256 fingerprint %q", value)
+		}
+		result = append(result, raw)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("pinned fingerprint list is empty")
+	}
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package controlclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/health" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write([]byte("OK"))
+}
+
+// newHealthTLSServer запускает TLS-сервер /health со стандартным сертификатом httptest.
+func newHealthTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(healthHandler))
+	// отклонённые клиентом рукопожатия ожидаемы: не засоряем вывод тестов
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newMITMServer запускает TLS-сервер /health со своим самоподписанным сертификатом для 127.0.0.1,
+// как прокси, перехватывающий соединение.
+func newMITMServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "mitm.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(healthHandler))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func certFingerprint(srv *httptest.Server) string {
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func newTLSTestClient(t *testing.T, url string, opts Options) *Client {
+	t.Helper()
+	opts.NoEnvProxy = true
+	opts.Retry = RetryPolicy{Attempts: 1}
+	client, err := New(url, opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+func TestPinnedCertificateMatches(t *testing.T) {
+	srv := newHealthTLSServer(t)
+	client := newTLSTestClient(t, srv.URL, Options{PinnedCertSHA256: []string{strings.Repeat("0", 64), certFingerprint(srv)}})
+
+	if _, err := client.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth with matching pin: %v", err)
+	}
+}
+
+func TestPinnedCertificateRejectsMITM(t *testing.T) {
+	// клиент закрепил сертификат настоящего сервера, а отвечает перехватчик со своим сертификатом
+	server := newHealthTLSServer(t)
+	mitm := newMITMServer(t)
+	client := newTLSTestClient(t, mitm.URL, Options{PinnedCertSHA256: []string{certFingerprint(server)}})
+
+	_, err := client.CheckHealth(context.Background())
+	if !errors.Is(err, ErrCertificatePinMismatch) {
+		t.Fatalf("CheckHealth error = %v, want ErrCertificatePinMismatch", err)
+	}
+	var clientErr *Error
+	if !errors.As(err, &clientErr) || clientErr.Kind != state.ErrorKindNetworkUnavailable {
+		t.Fatalf("CheckHealth error = %#v, want kind %s", err, state.ErrorKindNetworkUnavailable)
+	}
+}
+
+func TestCustomCAFromTLSConfig(t *testing.T) {
+	srv := newHealthTLSServer(t)
+
+	// без корня частного CA сертификат сервера не проверяется
+	plain := newTLSTestClient(t, srv.URL, Options{})
+	if _, err := plain.CheckHealth(context.Background()); err == nil {
+		t.Fatalf("CheckHealth succeeded without the private CA")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	client := newTLSTestClient(t, srv.URL, Options{TLSConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}})
+	if _, err := client.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth with private CA: %v", err)
+	}
+}
+
+func TestPinnedCertificateWithCustomCAStillChecksPin(t *testing.T) {
+	srv := newHealthTLSServer(t)
+	mitm := newMITMServer(t)
+	// цепочка перехватчика доверенная, но отпечаток не совпадает с закреплённым
+	roots := x509.NewCertPool()
+	roots.AddCert(mitm.Certificate())
+	client := newTLSTestClient(t, mitm.URL, Options{
+		TLSConfig:        &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		PinnedCertSHA256: []string{certFingerprint(srv)},
+	})
+
+	if _, err := client.CheckHealth(context.Background()); !errors.Is(err, ErrCertificatePinMismatch) {
+		t.Fatalf("CheckHealth error = %v, want ErrCertificatePinMismatch", err)
+	}
+}
@@ -0,0 +1,49 @@
+package controlclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"customvpn/client/internal/state"
+)
+
+// Диапазон старших версий API Control-сервера, с которыми совместим клиент.
+const (
+	MinAPIMajor = 0
+	MaxAPIMajor = 1
+)
+
+// APIVersionError сообщает о несовместимой версии API сервера.
+type APIVersionError struct {
+	Version string
+}
+
+func (e *APIVersionError) Error() string {
+	return fmt.Sprintf("unsupported control API version %q (supported major %d..%d)", e.Version, MinAPIMajor, MaxAPIMajor)
+}
+
+// CheckAPIVersion проверяет, что старшая версия API сервера поддерживается клиентом.
+func CheckAPIVersion(info state.ServerInfo) error {
+	major, err := apiMajor(info.APIVersion)
+	if err != nil {
+		return err
+	}
+	if major < MinAPIMajor || major > MaxAPIMajor {
+		return &APIVersionError{Version: info.APIVersion}
+	}
+	return nil
+}
+
+func apiMajor(version string) (int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" {
+		return 0, nil
+	}
+	head, _, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(head)
+	if err != nil || major < 0 {
+		return 0, &APIVersionError{Version: version}
+	}
+	return major, nil
+}
@@ -0,0 +1,14 @@
+// Package credstore хранит логин и пароль пользователя в хранилище учётных данных ОС.
+package credstore
+
+import "errors"
+
+// targetName — имя записи в хранилище учётных данных.
+const targetName = "CustomVPN/credentials"
+
+var (
+	// ErrNotFound возвращается Load, если сохранённых учётных данных нет.
+	ErrNotFound = errors.New("credentials not found")
+	// ErrNotSupported возвращается на платформах без хранилища учётных данных.
+	ErrNotSupported = errors.New("credential store is not supported on this platform")
+)
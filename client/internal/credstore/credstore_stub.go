@@ -0,0 +1,18 @@
+//go:build !windows
+
+package credstore
+
+// Save сохраняет логин и пароль.
+func Save(_, _ string) error {
+	return ErrNotSupported
+}
+
+// Load возвращает сохранённые логин и пароль.
+func Load() (string, string, error) {
+	return "", "", ErrNotSupported
+}
+
+// Delete удаляет сохранённые учётные данные.
+func Delete() error {
+	return ErrNotSupported
+}
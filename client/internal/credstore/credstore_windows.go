@@ -0,0 +1,124 @@
+//go:build windows
+
+package credstore
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	modAdvapi32     = windows.NewLazySystemDLL("advapi32.dll")
+	procCredWriteW  = modAdvapi32.NewProc("CredWriteW")
+	procCredReadW   = modAdvapi32.NewProc("CredReadW")
+	procCredDeleteW = modAdvapi32.NewProc("CredDeleteW")
+	procCredFree    = modAdvapi32.NewProc("CredFree")
+)
+
+// credential повторяет структуру CREDENTIALW из wincred.h.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// Save сохраняет логин и пароль в диспетчере учётных данных Windows.
+// Пароль хранится как UTF-16, как это делают стандартные инструменты Windows.
+func Save(login, password string) error {
+	target, err := windows.UTF16PtrFromString(targetName)
+	if err != nil {
+		return err
+	}
+	user, err := windows.UTF16PtrFromString(login)
+	if err != nil {
+		return err
+	}
+	blob := encodeUTF16(password)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		UserName:           user,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	ret, _, callErr := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return fmt.Errorf("CredWrite: %w", callErr)
+	}
+	return nil
+}
+
+// Load возвращает сохранённые логин и пароль или ErrNotFound.
+func Load() (string, string, error) {
+	target, err := windows.UTF16PtrFromString(targetName)
+	if err != nil {
+		return "", "", err
+	}
+	var pcred *credential
+	ret, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&pcred)))
+	if ret == 0 {
+		if errors.Is(callErr, windows.ERROR_NOT_FOUND) {
+			return "", "", ErrNotFound
+		}
+		return "", "", fmt.Errorf("CredRead: %w", callErr)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(pcred)))
+	login := windows.UTF16PtrToString(pcred.UserName)
+	var password string
+	if pcred.CredentialBlobSize > 0 && pcred.CredentialBlob != nil {
+		password = decodeUTF16(unsafe.Slice(pcred.CredentialBlob, pcred.CredentialBlobSize))
+	}
+	return login, password, nil
+}
+
+// Delete удаляет сохранённые учётные данные; отсутствие записи не считается ошибкой.
+func Delete() error {
+	target, err := windows.UTF16PtrFromString(targetName)
+	if err != nil {
+		return err
+	}
+	ret, _, callErr := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if ret == 0 && !errors.Is(callErr, windows.ERROR_NOT_FOUND) {
+		return fmt.Errorf("CredDelete: %w", callErr)
+	}
+	return nil
+}
+
+func encodeUTF16(value string) []byte {
+	units := utf16.Encode([]rune(value))
+	buf := make([]byte, len(units)*2)
+	for i, u := range units {
+		buf[i*2] = byte(u)
+		buf[i*2+1] = byte(u >> 8)
+	}
+	return buf
+}
+
+func decodeUTF16(buf []byte) string {
+	units := make([]uint16, len(buf)/2)
+	for i := range units {
+		units[i] = uint16(buf[i*2]) | uint16(buf[i*2+1])<<8
+	}
+	return string(utf16.Decode(units))
+}
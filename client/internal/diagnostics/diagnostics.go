@@ -0,0 +1,212 @@
+// Package diagnostics собирает архив с логами и состоянием клиента для поддержки.
+package diagnostics
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/state"
+)
+
+const redacted = "[redacted]"
+
+// urlRedacted заменяет секреты внутри URL: скобки из redacted были бы экранированы.
+const urlRedacted = "REDACTED"
+
+// Collect записывает во временный каталог zip-архив с client.log, core.log, снимком состояния
+// и конфигурацией и возвращает путь к нему. Токен, пароль и учётные данные в URL вырезаются.
+func Collect(ctx *state.AppContext, cfg *config.Config) (string, error) {
+	if cfg == nil {
+		return "", errors.New("config is nil")
+	}
+	name := fmt.Sprintf("customvpn-diagnostics-%s.zip", time.Now().Format("20060102-150405"))
+	path := filepath.Join(os.TempDir(), name)
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("create diagnostics archive: %w", err)
+	}
+	zw := zip.NewWriter(file)
+	err = writeBundle(zw, ctx, cfg)
+	if closeErr := zw.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("finalize diagnostics archive: %w", closeErr)
+	}
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close diagnostics archive: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+func writeBundle(zw *zip.Writer, ctx *state.AppContext, cfg *config.Config) error {
+	var notes []string
+	for _, entry := range []struct{ name, path string }{
+		{"client.log", cfg.LogFile},
+		{"core.log", cfg.CoreLogFile},
+	} {
+		if err := addFile(zw, entry.name, entry.path); err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", entry.name, err))
+		}
+	}
+	if err := addJSON(zw, "state.json", snapshotState(ctx)); err != nil {
+		return err
+	}
+	configData, err := yaml.Marshal(redactConfig(cfg))
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := addBytes(zw, "config.yaml", configData); err != nil {
+		return err
+	}
+	if len(notes) > 0 {
+		return addJSON(zw, "notes.json", notes)
+	}
+	return nil
+}
+
+// stateSnapshot — безопасный для передачи срез AppContext.
+type stateSnapshot struct {
+	CollectedAt       time.Time             `json:"collected_at"`
+	State             state.State           `json:"state"`
+	SelectedProfileID string                `json:"selected_profile_id"`
+	AuthToken         string                `json:"auth_token"`
+	Server            *state.ServerInfo     `json:"server,omitempty"`
+	LastError         *state.ErrorInfo      `json:"last_error,omitempty"`
+	DefaultGateway    *state.GatewayInfo    `json:"default_gateway,omitempty"`
+	DefaultGatewayV6  *state.GatewayInfo    `json:"default_gateway_v6,omitempty"`
+	KillSwitchRules   []string              `json:"kill_switch_rules,omitempty"`
+	Routes            []state.RouteRecord   `json:"routes"`
+	DNS               []state.DNSRecord     `json:"dns"`
+	Processes         []state.ProcessRecord `json:"processes"`
+	Profiles          []profileSummary      `json:"profiles"`
+	ReconnectAttempt  int                   `json:"reconnect_attempt"`
+	ConnectedSince    *time.Time            `json:"connected_since,omitempty"`
+}
+
+// profileSummary не содержит core_config: в нём бывают ключи и идентификаторы пользователя.
+type profileSummary struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Country      string   `json:"country"`
+	Host         string   `json:"host"`
+	Port         int      `json:"port"`
+	DirectRoutes []string `json:"direct_routes"`
+	TunnelRoutes []string `json:"tunnel_routes"`
+	KillSwitch   bool     `json:"kill_switch"`
+	IPv6Enabled  bool     `json:"enable_ipv6"`
+	TunnelDNS    []string `json:"tunnel_dns,omitempty"`
+}
+
+func snapshotState(ctx *state.AppContext) stateSnapshot {
+	snap := stateSnapshot{CollectedAt: time.Now()}
+	if ctx == nil {
+		return snap
+	}
+	// поля цикла событий читаются из снимка: Collect вызывается не из цикла
+	session := ctx.Session()
+	snap.State = session.State
+	snap.SelectedProfileID = ctx.SelectedProfileID()
+	if ctx.AuthToken() != "" {
+		snap.AuthToken = redacted
+	}
+	snap.Server = session.ServerInfo
+	snap.LastError = session.LastError
+	snap.DefaultGateway, snap.DefaultGatewayV6 = ctx.DefaultGateways()
+	snap.KillSwitchRules, _ = ctx.KillSwitch()
+	snap.Routes = ctx.RoutesRegistry.ListByKinds()
+	sort.Slice(snap.Routes, func(i, j int) bool { return snap.Routes[i].CreatedAt.Before(snap.Routes[j].CreatedAt) })
+	snap.DNS = ctx.DNSRegistry.List()
+	snap.Processes = ctx.ProcessRegistry.List()
+	snap.ReconnectAttempt = session.ReconnectAttempt
+	snap.ConnectedSince = session.ConnectedSince
+	for _, p := range ctx.Profiles() {
+		snap.Profiles = append(snap.Profiles, profileSummary{
+			ID:           p.ID,
+			Name:         p.Name,
+			Country:      p.Country,
+			Host:         p.Host,
+			Port:         p.Port,
+			DirectRoutes: p.DirectRoutes,
+			TunnelRoutes: p.TunnelRoutes,
+			KillSwitch:   p.KillSwitchEnabled,
+			IPv6Enabled:  p.IPv6Enabled,
+			TunnelDNS:    p.TunnelDNS,
+		})
+	}
+	return snap
+}
+
+// redactConfig возвращает копию конфигурации без учётных данных в URL Control-сервера и прокси.
+func redactConfig(cfg *config.Config) config.Config {
+	copied := *cfg
+	copied.ControlServerURL = redactURL(cfg.ControlServerURL)
+	if cfg.ControlProxyURL != "" {
+		copied.ControlProxyURL = redactURL(cfg.ControlProxyURL)
+	}
+	return copied
+}
+
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if parsed.User != nil {
+		parsed.User = url.User(urlRedacted)
+	}
+	query := parsed.Query()
+	for key := range query {
+		query.Set(key, urlRedacted)
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+func addFile(zw *zip.Writer, name, path string) error {
+	if path == "" {
+		return errors.New("path is not configured")
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func addJSON(zw *zip.Writer, name string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return addBytes(zw, name, data)
+}
+
+func addBytes(zw *zip.Writer, name string, data []byte) error {
+	dst, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	if _, err := dst.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package diagnostics
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Reveal показывает файл в файловом менеджере (Finder на macOS, каталог через xdg-open на Linux).
+func Reveal(path string) error {
+	if runtime.GOOS == "darwin" {
+		return exec.Command("open", "-R", path).Start()
+	}
+	return exec.Command("xdg-open", filepath.Dir(path)).Start()
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package diagnostics
+
+import "os/exec"
+
+// Reveal открывает Проводник с выделенным файлом.
+func Reveal(path string) error {
+	// explorer.exe возвращает ненулевой код даже при успехе, поэтому ждём только запуска
+	return exec.Command("explorer.exe", "/select,", path).Start()
+}
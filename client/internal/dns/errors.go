@@ -0,0 +1,6 @@
+package dns
+
+import "errors"
+
+// ErrTimeout — системная команда настройки DNS не завершилась до истечения контекста.
+var ErrTimeout = errors.New("dns command timed out")
@@ -4,9 +4,28 @@ package dns
 
 import (
 	"os/exec"
+	"strconv"
 	"syscall"
+	"time"
 )
 
+// commandWaitDelay — сколько ждать закрытия вывода после завершения процесса по отмене контекста.
+const commandWaitDelay = 2 * time.Second
+
+// applyCommandAttributes скрывает окно консоли и запускает команду в отдельной группе процессов:
+// при отмене контекста завершается всё дерево через taskkill /T, а не только powershell.exe.
 func applyCommandAttributes(cmd *exec.Cmd) {
-	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		kill.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+		if err := kill.Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = commandWaitDelay
 }
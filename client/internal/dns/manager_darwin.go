@@ -0,0 +1,149 @@
+//go:build darwin
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"customvpn/client/internal/logging"
+)
+
+// scutilServicePrefix — ключ динамического хранилища для интерфейсов без сетевой службы (utun).
+const scutilServicePrefix = "State:/Network/Service/customvpn-"
+
+type Manager struct {
+	logger *logging.Logger
+}
+
+func NewManager(logger *logging.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// SetInterfaceDNS назначает DNS-серверы интерфейсу. Для интерфейсов, у которых есть
+// сетевая служба, используется networksetup, для остальных (utun) — запись в scutil.
+func (m *Manager) SetInterfaceDNS(ctx context.Context, iface string, servers []string) error {
+	if strings.TrimSpace(iface) == "" {
+		return fmt.Errorf("interface alias is empty")
+	}
+	serverList := make([]string, 0, len(servers))
+	for _, server := range servers {
+		server = strings.TrimSpace(server)
+		if server != "" {
+			serverList = append(serverList, server)
+		}
+	}
+	if len(serverList) == 0 {
+		return fmt.Errorf("dns servers are empty")
+	}
+	service, err := networkServiceForDevice(ctx, iface)
+	if err != nil {
+		return err
+	}
+	if service == "" {
+		if m.logger != nil {
+			m.logger.Debugf("dns: interface %s has no network service, using scutil", iface)
+		}
+		script := fmt.Sprintf(
+			"d.init\nd.add ServerAddresses * %s\nd.add InterfaceName %s\nset %s%s/DNS\nquit\n",
+			strings.Join(serverList, " "), iface, scutilServicePrefix, iface,
+		)
+		return runScutil(ctx, script)
+	}
+	args := append([]string{"-setdnsservers", service}, serverList...)
+	return runNetworkSetup(ctx, args...)
+}
+
+// ResetInterfaceDNS возвращает интерфейсу DNS-серверы, полученные автоматически (DHCP).
+func (m *Manager) ResetInterfaceDNS(ctx context.Context, iface string) error {
+	if strings.TrimSpace(iface) == "" {
+		return fmt.Errorf("interface alias is empty")
+	}
+	service, err := networkServiceForDevice(ctx, iface)
+	if err != nil {
+		return err
+	}
+	if service == "" {
+		return runScutil(ctx, fmt.Sprintf("remove %s%s/DNS\nquit\n", scutilServicePrefix, iface))
+	}
+	return runNetworkSetup(ctx, "-setdnsservers", service, "Empty")
+}
+
+// networkServiceForDevice переводит имя устройства (en0) в имя сетевой службы (Wi-Fi).
+// Пустая строка без ошибки означает, что у устройства нет службы.
+func networkServiceForDevice(ctx context.Context, device string) (string, error) {
+	output, err := commandOutput(ctx, "networksetup", "-listnetworkserviceorder")
+	if err != nil {
+		return "", err
+	}
+	return parseServiceOrder(output, device), nil
+}
+
+// parseServiceOrder разбирает вывод networksetup -listnetworkserviceorder:
+//
+//	(1) Wi-Fi
+//	(Hardware Port: Wi-Fi, Device: en0)
+func parseServiceOrder(output, device string) string {
+	var service string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "(Hardware Port:"):
+			if strings.HasSuffix(line, "Device: "+device+")") && service != "" {
+				return service
+			}
+		case strings.HasPrefix(line, "("):
+			if idx := strings.Index(line, ") "); idx != -1 {
+				// отключённые службы помечены звёздочкой: "(*) Wi-Fi"
+				service = strings.TrimSpace(line[idx+2:])
+			}
+		}
+	}
+	return ""
+}
+
+func runNetworkSetup(ctx context.Context, args ...string) error {
+	_, err := commandOutput(ctx, "networksetup", args...)
+	return err
+}
+
+func runScutil(ctx context.Context, script string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, "scutil")
+	cmd.Stdin = strings.NewReader(script)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("scutil: %w", ErrTimeout)
+		}
+		trimmed := strings.TrimSpace(string(output))
+		if trimmed != "" {
+			return fmt.Errorf("scutil failed: %s", trimmed)
+		}
+		return fmt.Errorf("scutil failed: %w", err)
+	}
+	return nil
+}
+
+func commandOutput(ctx context.Context, name string, args ...string) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%s: %w", name, ErrTimeout)
+		}
+		trimmed := strings.TrimSpace(string(output))
+		if trimmed != "" {
+			return "", fmt.Errorf("%s %s failed: %s", name, strings.Join(args, " "), trimmed)
+		}
+		return "", fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+	return string(output), nil
+}
@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !darwin
 
 package dns
 
@@ -16,5 +16,9 @@ func NewManager(_ *logging.Logger) *Manager {
 }
 
 func (m *Manager) SetInterfaceDNS(_ context.Context, _ string, _ []string) error {
-	return fmt.Errorf("dns manager is only implemented on Windows")
+	return fmt.Errorf("dns manager is not implemented on this platform")
+}
+
+func (m *Manager) ResetInterfaceDNS(_ context.Context, _ string) error {
+	return fmt.Errorf("dns manager is not implemented on this platform")
 }
@@ -4,6 +4,7 @@ package dns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -45,6 +46,18 @@ func (m *Manager) SetInterfaceDNS(ctx context.Context, iface string, servers []s
 	return runPowerShell(ctx, script)
 }
 
+// ResetInterfaceDNS возвращает интерфейсу DNS-серверы, полученные автоматически (DHCP).
+func (m *Manager) ResetInterfaceDNS(ctx context.Context, iface string) error {
+	if strings.TrimSpace(iface) == "" {
+		return fmt.Errorf("interface alias is empty")
+	}
+	script := fmt.Sprintf(
+		"Set-DnsClientServerAddress -InterfaceAlias '%s' -ResetServerAddresses -ErrorAction Stop | Out-Null",
+		escapeSingleQuotes(iface),
+	)
+	return runPowerShell(ctx, script)
+}
+
 func runPowerShell(ctx context.Context, script string) error {
 	if ctx == nil {
 		ctx = context.Background()
@@ -53,6 +66,9 @@ func runPowerShell(ctx context.Context, script string) error {
 	applyCommandAttributes(cmd)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("powershell: %w", ErrTimeout)
+		}
 		trimmed := strings.TrimSpace(string(output))
 		if trimmed != "" {
 			return fmt.Errorf("powershell failed: %s", trimmed)
@@ -5,3 +5,9 @@ import "errors"
 var ErrLocalPolicyMergeDisabled = errors.New("local firewall rules are disabled by policy")
 var ErrFirewallDisabled = errors.New("windows firewall is disabled")
 var ErrLocalPolicyMergeUnsupported = errors.New("allowlocalpolicymerge is not supported")
+
+// ErrPartialApply — часть правил была создана до ошибки; они уже сняты или возвращены вызывающему.
+var ErrPartialApply = errors.New("firewall rules were applied partially")
+
+// ErrStrictKillSwitchUnsupported — строгий Kill Switch (блокировка всего трафика) на этой платформе не реализован.
+var ErrStrictKillSwitchUnsupported = errors.New("strict kill switch is not supported on this platform")
@@ -0,0 +1,404 @@
+//go:build linux
+
+package firewall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"customvpn/client/internal/logging"
+)
+
+// Правила Kill Switch живут в отдельной таблице nftables — аналоге группы "CustomVPN KillSwitch"
+// брандмауэра Windows. Каждое правило помечено комментарием с именем, по которому оно снимается.
+const (
+	nftCommand    = "nft"
+	nftTable      = "customvpn_killswitch"
+	nftChain      = "output"
+	nftMaxComment = 128
+)
+
+type Manager struct {
+	logger *logging.Logger
+}
+
+func NewManager(logger *logging.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// nftRule — одно правило цепочки output: выражение nft и имя-комментарий.
+type nftRule struct {
+	name string
+	expr string
+}
+
+// BlockDNSOnInterface запрещает исходящий DNS (порт 53) через интерфейс.
+// Правила добавляются одной транзакцией nft: при ошибке не остаётся ни одного.
+func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []string, _ string) ([]string, error) {
+	if m.logger != nil {
+		m.logger.Debugf("firewall block dns start: interface=%s", iface)
+	}
+	if err := checkInterfaceArg(ctx, iface); err != nil {
+		return nil, err
+	}
+	rules := []nftRule{
+		{name: fmt.Sprintf("CustomVPN DNS Block (%s) UDP", iface), expr: fmt.Sprintf("oifname %s udp dport 53 counter drop", nftQuote(iface))},
+		{name: fmt.Sprintf("CustomVPN DNS Block (%s) TCP", iface), expr: fmt.Sprintf("oifname %s tcp dport 53 counter drop", nftQuote(iface))},
+	}
+	created, err := m.addRules(ctx, rules)
+	if err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall block dns failed: interface=%s error=%v", iface, err)
+		}
+		return nil, err
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall block dns done: interface=%s rules=%d", iface, len(created))
+	}
+	return created, nil
+}
+
+// BlockAllOnInterface — строгий вариант Kill Switch: запрещает весь исходящий трафик через интерфейс,
+// кроме адресов и подсетей allowed (адрес VPN-сервера, прямые маршруты профиля), DHCP и IPv6 link-local.
+// Трафик туннеля идёт через свой интерфейс и правилами не затрагивается. Правила добавляются после
+// правил BlockDNSOnInterface, поэтому DNS через интерфейс остаётся закрыт и для адресов из allowed.
+func (m *Manager) BlockAllOnInterface(ctx context.Context, iface string, allowed []string) ([]string, error) {
+	if m.logger != nil {
+		m.logger.Debugf("firewall block all start: interface=%s allowed=%v", iface, allowed)
+	}
+	if err := checkInterfaceArg(ctx, iface); err != nil {
+		return nil, err
+	}
+	rules, err := blockAllRules(iface, allowed)
+	if err != nil {
+		return nil, err
+	}
+	created, err := m.addRules(ctx, rules)
+	if err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall block all failed: interface=%s error=%v", iface, err)
+		}
+		return nil, err
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall block all done: interface=%s rules=%d", iface, len(created))
+	}
+	return created, nil
+}
+
+// blockAllRules строит правила строгого Kill Switch: разрешения идут перед запрещающим правилом.
+// Элемент allowed — IP-адрес или подсеть CIDR.
+func blockAllRules(iface string, allowed []string) ([]nftRule, error) {
+	quoted := nftQuote(iface)
+	rules := make([]nftRule, 0, len(allowed)+3)
+	for _, addr := range allowed {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		var target string
+		ip := net.ParseIP(addr)
+		if ip != nil {
+			target = ip.String()
+		} else {
+			cidrIP, network, err := net.ParseCIDR(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed address %q", addr)
+			}
+			ip, target = cidrIP, network.String()
+		}
+		family := "ip6"
+		if ip.To4() != nil {
+			family = "ip"
+		}
+		rules = append(rules, nftRule{
+			name: fmt.Sprintf("CustomVPN Allow (%s) %s", iface, target),
+			expr: fmt.Sprintf("oifname %s %s daddr %s accept", quoted, family, target),
+		})
+	}
+	rules = append(rules,
+		// без DHCP аренда адреса на интерфейсе истечёт во время сессии
+		nftRule{name: fmt.Sprintf("CustomVPN Allow (%s) DHCP", iface), expr: fmt.Sprintf("oifname %s udp sport 68 udp dport 67 accept", quoted)},
+		// link-local и multicast нужны IPv6 для обнаружения соседей
+		nftRule{name: fmt.Sprintf("CustomVPN Allow (%s) IPv6 link-local", iface), expr: fmt.Sprintf("oifname %s ip6 daddr { fe80::/10, ff02::/16 } accept", quoted)},
+		nftRule{name: fmt.Sprintf("CustomVPN Block All (%s)", iface), expr: fmt.Sprintf("oifname %s counter drop", quoted)},
+	)
+	return rules, nil
+}
+
+// BlockIPv6OnInterface запрещает исходящий IPv6 через интерфейс, кроме link-local.
+// Если у интерфейса нет глобальных IPv6-адресов, правила не создаются.
+func (m *Manager) BlockIPv6OnInterface(ctx context.Context, iface string) ([]string, error) {
+	if m.logger != nil {
+		m.logger.Debugf("firewall block ipv6 start: interface=%s", iface)
+	}
+	if err := checkInterfaceArg(ctx, iface); err != nil {
+		return nil, err
+	}
+	global, err := hasGlobalIPv6(iface)
+	if err != nil {
+		return nil, err
+	}
+	if !global {
+		if m.logger != nil {
+			m.logger.Debugf("firewall block ipv6 skipped: interface=%s has no global IPv6 addresses", iface)
+		}
+		return nil, nil
+	}
+	rules := []nftRule{{
+		name: fmt.Sprintf("CustomVPN IPv6 Block (%s)", iface),
+		expr: fmt.Sprintf("oifname %s ip6 daddr != fe80::/10 counter drop", nftQuote(iface)),
+	}}
+	created, err := m.addRules(ctx, rules)
+	if err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall block ipv6 failed: interface=%s error=%v", iface, err)
+		}
+		return nil, err
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall block ipv6 done: interface=%s", iface)
+	}
+	return created, nil
+}
+
+// CheckAvailable проверяет, что интерфейс существует, а nft установлен и доступен (нужны права root).
+func (m *Manager) CheckAvailable(ctx context.Context, iface string) error {
+	if m.logger != nil {
+		m.logger.Debugf("firewall check start: interface=%s", iface)
+	}
+	if err := checkInterfaceArg(ctx, iface); err != nil {
+		return err
+	}
+	if _, err := net.InterfaceByName(iface); err != nil {
+		return fmt.Errorf("interface not found: %w", err)
+	}
+	if _, err := runNft(ctx, nil, "list", "tables"); err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall check failed: interface=%s error=%v", iface, err)
+		}
+		return err
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall check ok: interface=%s", iface)
+	}
+	return nil
+}
+
+// EnableLocalPolicyMerge нужен только брандмауэру Windows: CheckAvailable на Linux не возвращает
+// ErrLocalPolicyMergeDisabled.
+func (m *Manager) EnableLocalPolicyMerge(_ context.Context) error {
+	return ErrLocalPolicyMergeUnsupported
+}
+
+// RemoveRules снимает правила с указанными именами; отсутствующие правила пропускаются.
+func (m *Manager) RemoveRules(ctx context.Context, rules []string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall remove rules start: count=%d", len(rules))
+	}
+	handles, err := m.ruleHandles(ctx)
+	if errors.Is(err, errNftTableMissing) {
+		return nil
+	}
+	if err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall remove rules failed: %v", err)
+		}
+		return err
+	}
+	var script strings.Builder
+	for _, name := range rules {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := handles[name]
+		if len(found) == 0 && m.logger != nil {
+			m.logger.Debugf("firewall rule remove skipped: %s (not found)", name)
+		}
+		for _, handle := range found {
+			fmt.Fprintf(&script, "delete rule inet %s %s handle %d\n", nftTable, nftChain, handle)
+		}
+	}
+	if script.Len() > 0 {
+		if _, err := runNft(ctx, []byte(script.String()), "-f", "-"); err != nil {
+			if m.logger != nil {
+				m.logger.Debugf("firewall remove rules failed: %v", err)
+			}
+			return err
+		}
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall remove rules done")
+	}
+	return nil
+}
+
+// RemoveKillSwitchGroup удаляет таблицу Kill Switch целиком и возвращает число удалённых правил.
+func (m *Manager) RemoveKillSwitchGroup(ctx context.Context) (int, error) {
+	if m.logger != nil {
+		m.logger.Debugf("firewall remove group start: %s", nftTable)
+	}
+	handles, err := m.ruleHandles(ctx)
+	// без nft таблицу некому было создать: снимать нечего
+	if errors.Is(err, errNftTableMissing) || errors.Is(err, exec.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, list := range handles {
+		removed += len(list)
+	}
+	if _, err := runNft(ctx, nil, "delete", "table", "inet", nftTable); err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall remove group failed: %v", err)
+		}
+		return 0, err
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall remove group done: removed=%d", removed)
+	}
+	return removed, nil
+}
+
+// addRules создаёт таблицу и цепочку, если их нет, и добавляет правила одной транзакцией.
+// Правило с тем же именем заменяется: сначала снимаются старые копии.
+func (m *Manager) addRules(ctx context.Context, rules []nftRule) ([]string, error) {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule.name) > nftMaxComment {
+			return nil, fmt.Errorf("rule name %q is longer than %d bytes", rule.name, nftMaxComment)
+		}
+		names = append(names, rule.name)
+	}
+	script := fmt.Sprintf("add table inet %s\nadd chain inet %s %s { type filter hook output priority 0 ; policy accept ; }\n", nftTable, nftTable, nftChain)
+	if _, err := runNft(ctx, []byte(script), "-f", "-"); err != nil {
+		return nil, err
+	}
+	if err := m.RemoveRules(ctx, names); err != nil {
+		return nil, err
+	}
+	var add strings.Builder
+	for _, rule := range rules {
+		fmt.Fprintf(&add, "add rule inet %s %s %s comment %s\n", nftTable, nftChain, rule.expr, nftQuote(rule.name))
+	}
+	if _, err := runNft(ctx, []byte(add.String()), "-f", "-"); err != nil {
+		return nil, err
+	}
+	if m.logger != nil {
+		for _, name := range names {
+			m.logger.Debugf("firewall rule added: %s", name)
+		}
+	}
+	return names, nil
+}
+
+var errNftTableMissing = errors.New("kill switch table does not exist")
+
+// ruleHandles возвращает handle правил цепочки Kill Switch по именам-комментариям.
+func (m *Manager) ruleHandles(ctx context.Context) (map[string][]int, error) {
+	output, err := runNft(ctx, nil, "-a", "list", "chain", "inet", nftTable, nftChain)
+	if err != nil {
+		if strings.Contains(output, "No such file or directory") {
+			return map[string][]int{}, errNftTableMissing
+		}
+		return nil, err
+	}
+	return parseRuleHandles(output), nil
+}
+
+// parseRuleHandles разбирает строки вида `... comment "NAME" # handle 7` из `nft -a list chain`.
+func parseRuleHandles(output string) map[string][]int {
+	handles := make(map[string][]int)
+	for _, line := range strings.Split(output, "\n") {
+		commentAt := strings.Index(line, `comment "`)
+		handleAt := strings.LastIndex(line, "# handle ")
+		if commentAt < 0 || handleAt < 0 {
+			continue
+		}
+		rest := line[commentAt+len(`comment "`):]
+		end := strings.Index(rest, `"`)
+		if end < 0 {
+			continue
+		}
+		handle, err := strconv.Atoi(strings.TrimSpace(line[handleAt+len("# handle "):]))
+		if err != nil {
+			continue
+		}
+		name := rest[:end]
+		handles[name] = append(handles[name], handle)
+	}
+	return handles
+}
+
+// runNft запускает nft; stdin передаётся скрипту при `-f -`. Вывод возвращается и при ошибке.
+func runNft(ctx context.Context, stdin []byte, args ...string) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, nftCommand, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	output, err := cmd.CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return text, fmt.Errorf("nftables is not installed: %w", err)
+		}
+		return text, fmt.Errorf("nft %s: %w: %s", strings.Join(args, " "), err, text)
+	}
+	return text, nil
+}
+
+// nftQuote заключает строку в кавычки nft; кавычки внутри имени недопустимы и заменяются.
+func nftQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
+
+func checkInterfaceArg(ctx context.Context, iface string) error {
+	if strings.TrimSpace(iface) == "" {
+		return fmt.Errorf("interface alias is empty")
+	}
+	if ctx != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	return nil
+}
+
+// hasGlobalIPv6 сообщает, есть ли у интерфейса IPv6-адрес вне link-local.
+func hasGlobalIPv6(name string) (bool, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return false, fmt.Errorf("interface not found: %w", err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return false, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil {
+			continue
+		}
+		if ipNet.IP.IsGlobalUnicast() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package firewall
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBlockAllRules(t *testing.T) {
+	rules, err := blockAllRules("eth0", []string{"203.0.113.10", " 192.168.0.0/16 ", "2001:db8::1", "10.1.2.3/8"})
+	if err != nil {
+		t.Fatalf("blockAllRules: %v", err)
+	}
+	want := []nftRule{
+		{name: "CustomVPN Allow (eth0) 203.0.113.10", expr: `oifname "eth0" ip daddr 203.0.113.10 accept`},
+		{name: "CustomVPN Allow (eth0) 192.168.0.0/16", expr: `oifname "eth0" ip daddr 192.168.0.0/16 accept`},
+		{name: "CustomVPN Allow (eth0) 2001:db8::1", expr: `oifname "eth0" ip6 daddr 2001:db8::1 accept`},
+		{name: "CustomVPN Allow (eth0) 10.0.0.0/8", expr: `oifname "eth0" ip daddr 10.0.0.0/8 accept`},
+		{name: "CustomVPN Allow (eth0) DHCP", expr: `oifname "eth0" udp sport 68 udp dport 67 accept`},
+		{name: "CustomVPN Allow (eth0) IPv6 link-local", expr: `oifname "eth0" ip6 daddr { fe80::/10, ff02::/16 } accept`},
+		{name: "CustomVPN Block All (eth0)", expr: `oifname "eth0" counter drop`},
+	}
+	if !slices.Equal(rules, want) {
+		t.Fatalf("rules:\n%v\nwant:\n%v", rules, want)
+	}
+}
+
+func TestBlockAllRulesDropIsLast(t *testing.T) {
+	rules, err := blockAllRules(`Wi"Fi`, nil)
+	if err != nil {
+		t.Fatalf("blockAllRules: %v", err)
+	}
+	last := rules[len(rules)-1]
+	// правило drop последнее: разрешения выше него срабатывают первыми
+	if last.expr != `oifname "Wi'Fi" counter drop` {
+		t.Fatalf("last rule = %q, want drop for the quoted interface", last.expr)
+	}
+	for _, rule := range rules[:len(rules)-1] {
+		if !strings.HasSuffix(rule.expr, " accept") {
+			t.Fatalf("rule before drop is not accept: %q", rule.expr)
+		}
+	}
+}
+
+func TestBlockAllRulesRejectsBadAddress(t *testing.T) {
+	if _, err := blockAllRules("eth0", []string{"vpn.example.com"}); err == nil {
+		t.Fatalf("hostname accepted as allowed address")
+	}
+}
+
+func TestParseRuleHandles(t *testing.T) {
+	output := `table inet customvpn_killswitch {
+	chain output { # handle 1
+		type filter hook output priority filter; policy accept;
+		oifname "eth0" ip daddr 203.0.113.10 accept comment "CustomVPN Allow (eth0) 203.0.113.10" # handle 4
+		oifname "eth0" counter packets 0 bytes 0 drop comment "CustomVPN Block All (eth0)" # handle 5
+		oifname "eth0" counter packets 3 bytes 180 drop comment "CustomVPN Block All (eth0)" # handle 9
+	}
+}`
+	handles := parseRuleHandles(output)
+	if got := handles["CustomVPN Allow (eth0) 203.0.113.10"]; !slices.Equal(got, []int{4}) {
+		t.Fatalf("allow handles = %v, want [4]", got)
+	}
+	if got := handles["CustomVPN Block All (eth0)"]; !slices.Equal(got, []int{5, 9}) {
+		t.Fatalf("block handles = %v, want [5 9]", got)
+	}
+}
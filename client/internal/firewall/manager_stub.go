@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package firewall
 
@@ -16,21 +16,29 @@ func NewManager(_ *logging.Logger) *Manager {
 }
 
 func (m *Manager) BlockDNSOnInterface(_ context.Context, _ string, _ []string, _ string) ([]string, error) {
-	return nil, fmt.Errorf("firewall manager is only implemented on Windows")
+	return nil, fmt.Errorf("firewall manager is not implemented on this platform")
+}
+
+func (m *Manager) BlockAllOnInterface(_ context.Context, _ string, _ []string) ([]string, error) {
+	return nil, ErrStrictKillSwitchUnsupported
+}
+
+func (m *Manager) BlockIPv6OnInterface(_ context.Context, _ string) ([]string, error) {
+	return nil, fmt.Errorf("firewall manager is not implemented on this platform")
 }
 
 func (m *Manager) CheckAvailable(_ context.Context, _ string) error {
-	return fmt.Errorf("firewall manager is only implemented on Windows")
+	return fmt.Errorf("firewall manager is not implemented on this platform")
 }
 
 func (m *Manager) EnableLocalPolicyMerge(_ context.Context) error {
-	return fmt.Errorf("firewall manager is only implemented on Windows")
+	return fmt.Errorf("firewall manager is not implemented on this platform")
 }
 
 func (m *Manager) RemoveRules(_ context.Context, _ []string) error {
 	return nil
 }
 
-func (m *Manager) RemoveKillSwitchGroup(_ context.Context) error {
-	return nil
+func (m *Manager) RemoveKillSwitchGroup(_ context.Context) (int, error) {
+	return 0, nil
 }
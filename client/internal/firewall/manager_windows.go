@@ -28,6 +28,7 @@ const (
 
 	netFwProtocolTCP = 6
 	netFwProtocolUDP = 17
+	netFwProtocolAny = 256
 )
 
 type Manager struct {
@@ -38,6 +39,9 @@ func NewManager(logger *logging.Logger) *Manager {
 	return &Manager{logger: logger}
 }
 
+// BlockDNSOnInterface запрещает исходящий DNS (порт 53) с адресов интерфейса.
+// Правила применяются целиком или никак: при ошибке созданные правила снимаются,
+// а в результате остаются только те, что откатить не удалось.
 func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []string, _ string) ([]string, error) {
 	if m.logger != nil {
 		m.logger.Debugf("firewall block dns start: interface=%s", iface)
@@ -52,7 +56,7 @@ func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []str
 		default:
 		}
 	}
-	localAddrs, err := interfaceAddresses(iface)
+	addrsV4, addrsV6, err := interfaceAddresses(iface)
 	if err != nil {
 		if m.logger != nil {
 			m.logger.Debugf("firewall block dns failed: interface=%s error=%v", iface, err)
@@ -60,14 +64,27 @@ func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []str
 		return nil, err
 	}
 	if m.logger != nil {
-		m.logger.Debugf("firewall block dns: interface=%s addr_count=%d", iface, len(localAddrs))
+		m.logger.Debugf("firewall block dns: interface=%s ipv4_count=%d ipv6_count=%d", iface, len(addrsV4), len(addrsV6))
 	}
-	rules := []struct {
+	// API брандмауэра может отклонить правило со смешанными IPv4/IPv6 LocalAddresses,
+	// поэтому правила создаются отдельно для каждого семейства адресов интерфейса.
+	type dnsRule struct {
 		name     string
 		protocol int
-	}{
-		{name: fmt.Sprintf("CustomVPN DNS Block (%s) UDP", iface), protocol: netFwProtocolUDP},
-		{name: fmt.Sprintf("CustomVPN DNS Block (%s) TCP", iface), protocol: netFwProtocolTCP},
+		addrs    []string
+	}
+	var rules []dnsRule
+	if len(addrsV4) > 0 {
+		rules = append(rules,
+			dnsRule{name: fmt.Sprintf("CustomVPN DNS Block (%s) UDP", iface), protocol: netFwProtocolUDP, addrs: addrsV4},
+			dnsRule{name: fmt.Sprintf("CustomVPN DNS Block (%s) TCP", iface), protocol: netFwProtocolTCP, addrs: addrsV4},
+		)
+	}
+	if len(addrsV6) > 0 {
+		rules = append(rules,
+			dnsRule{name: fmt.Sprintf("CustomVPN DNS Block (%s) IPv6 UDP", iface), protocol: netFwProtocolUDP, addrs: addrsV6},
+			dnsRule{name: fmt.Sprintf("CustomVPN DNS Block (%s) IPv6 TCP", iface), protocol: netFwProtocolTCP, addrs: addrsV6},
+		)
 	}
 	created := make([]string, 0, len(rules))
 	err = withFirewallPolicy(func(policy *ole.IDispatch) error {
@@ -85,7 +102,7 @@ func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []str
 					m.logger.Debugf("firewall rule remove skipped: %s (%v)", rule.name, err)
 				}
 			}
-			if err := addBlockRule(rulesDisp, rule.name, iface, localAddrs, rule.protocol); err != nil {
+			if err := addBlockRule(rulesDisp, rule.name, iface, rule.addrs, rule.protocol, "53"); err != nil {
 				return err
 			}
 			created = append(created, rule.name)
@@ -96,13 +113,14 @@ func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []str
 		return nil
 	})
 	if err != nil {
-		if len(created) > 0 {
-			_ = m.RemoveRules(ctx, created)
-		}
+		var remaining []string
+		remaining, err = rollbackPartial(created, err, func(names []string) error {
+			return m.RemoveRules(ctx, names)
+		})
 		if m.logger != nil {
-			m.logger.Debugf("firewall block dns failed: interface=%s error=%v", iface, err)
+			m.logger.Debugf("firewall block dns failed: interface=%s remaining=%v error=%v", iface, remaining, err)
 		}
-		return created, err
+		return remaining, err
 	}
 	if m.logger != nil {
 		m.logger.Debugf("firewall block dns done: interface=%s rules=%d", iface, len(created))
@@ -110,6 +128,68 @@ func (m *Manager) BlockDNSOnInterface(ctx context.Context, iface string, _ []str
 	return created, nil
 }
 
+// BlockAllOnInterface (строгий Kill Switch) реализован только для nftables на Linux.
+func (m *Manager) BlockAllOnInterface(_ context.Context, _ string, _ []string) ([]string, error) {
+	return nil, ErrStrictKillSwitchUnsupported
+}
+
+// BlockIPv6OnInterface запрещает исходящий IPv6-трафик с глобальных адресов интерфейса.
+// Если у интерфейса нет глобальных IPv6-адресов, правила не создаются.
+func (m *Manager) BlockIPv6OnInterface(ctx context.Context, iface string) ([]string, error) {
+	if m.logger != nil {
+		m.logger.Debugf("firewall block ipv6 start: interface=%s", iface)
+	}
+	if strings.TrimSpace(iface) == "" {
+		return nil, fmt.Errorf("interface alias is empty")
+	}
+	if ctx != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+	}
+	localAddrs, err := interfaceIPv6Addresses(iface)
+	if err != nil {
+		return nil, err
+	}
+	if len(localAddrs) == 0 {
+		if m.logger != nil {
+			m.logger.Debugf("firewall block ipv6 skipped: interface=%s has no global IPv6 addresses", iface)
+		}
+		return nil, nil
+	}
+	name := fmt.Sprintf("CustomVPN IPv6 Block (%s)", iface)
+	var created []string
+	err = withFirewallPolicy(func(policy *ole.IDispatch) error {
+		rulesDisp, cleanup, err := firewallRules(policy)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if err := removeRuleByName(rulesDisp, name); err != nil {
+			if m.logger != nil {
+				m.logger.Debugf("firewall rule remove skipped: %s (%v)", name, err)
+			}
+		}
+		if err := addBlockRule(rulesDisp, name, iface, localAddrs, netFwProtocolAny, ""); err != nil {
+			return err
+		}
+		created = append(created, name)
+		return nil
+	})
+	if err != nil {
+		if m.logger != nil {
+			m.logger.Debugf("firewall block ipv6 failed: interface=%s error=%v", iface, err)
+		}
+		return nil, err
+	}
+	if m.logger != nil {
+		m.logger.Debugf("firewall block ipv6 done: interface=%s addrs=%v", iface, localAddrs)
+	}
+	return created, nil
+}
+
 func (m *Manager) CheckAvailable(ctx context.Context, iface string) error {
 	if m.logger != nil {
 		m.logger.Debugf("firewall check start: interface=%s", iface)
@@ -249,17 +329,19 @@ func (m *Manager) RemoveRules(ctx context.Context, rules []string) error {
 	return err
 }
 
-func (m *Manager) RemoveKillSwitchGroup(ctx context.Context) error {
+// RemoveKillSwitchGroup удаляет все правила группы Kill Switch и возвращает число удалённых.
+func (m *Manager) RemoveKillSwitchGroup(ctx context.Context) (int, error) {
 	if m.logger != nil {
 		m.logger.Debugf("firewall remove group start: %s", killSwitchGroup)
 	}
 	if ctx != nil {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return 0, ctx.Err()
 		default:
 		}
 	}
+	removed := 0
 	err := withFirewallPolicy(func(policy *ole.IDispatch) error {
 		rulesDisp, cleanup, err := firewallRules(policy)
 		if err != nil {
@@ -277,6 +359,7 @@ func (m *Manager) RemoveKillSwitchGroup(ctx context.Context) error {
 				}
 				continue
 			}
+			removed++
 			if m.logger != nil {
 				m.logger.Debugf("firewall group rule removed: %s", name)
 			}
@@ -287,10 +370,10 @@ func (m *Manager) RemoveKillSwitchGroup(ctx context.Context) error {
 		if err != nil {
 			m.logger.Debugf("firewall remove group failed: %v", err)
 		} else {
-			m.logger.Debugf("firewall remove group done")
+			m.logger.Debugf("firewall remove group done: removed=%d", removed)
 		}
 	}
-	return err
+	return removed, err
 }
 
 func withFirewallPolicy(fn func(*ole.IDispatch) error) error {
@@ -328,7 +411,7 @@ func firewallRules(policy *ole.IDispatch) (*ole.IDispatch, func(), error) {
 	return rules, cleanup, nil
 }
 
-func addBlockRule(rules *ole.IDispatch, name, iface string, localAddrs []string, protocol int) error {
+func addBlockRule(rules *ole.IDispatch, name, iface string, localAddrs []string, protocol int, remotePorts string) error {
 	ruleObj, err := oleutil.CreateObject("HNetCfg.FwRule")
 	if err != nil {
 		return fmt.Errorf("create firewall rule: %w", err)
@@ -347,7 +430,9 @@ func addBlockRule(rules *ole.IDispatch, name, iface string, localAddrs []string,
 	_, _ = oleutil.PutProperty(rule, "Action", netFwActionBlock)
 	_, _ = oleutil.PutProperty(rule, "Enabled", true)
 	_, _ = oleutil.PutProperty(rule, "Protocol", protocol)
-	_, _ = oleutil.PutProperty(rule, "RemotePorts", "53")
+	if remotePorts != "" {
+		_, _ = oleutil.PutProperty(rule, "RemotePorts", remotePorts)
+	}
 	_, _ = oleutil.PutProperty(rule, "Profiles", netFwProfile2All)
 	if len(localAddrs) > 0 {
 		_, _ = oleutil.PutProperty(rule, "LocalAddresses", strings.Join(localAddrs, ","))
@@ -484,16 +569,17 @@ func interfaceByName(name string) (*net.Interface, error) {
 	return nil, err
 }
 
-func interfaceAddresses(name string) ([]string, error) {
+// interfaceAddresses возвращает IPv4- и IPv6-адреса интерфейса раздельно.
+// Ошибка — только если у интерфейса нет адресов ни одного семейства.
+func interfaceAddresses(name string) (v4, v6 []string, err error) {
 	iface, err := interfaceByName(name)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	addrs, err := iface.Addrs()
 	if err != nil {
-		return nil, fmt.Errorf("read interface addresses: %w", err)
+		return nil, nil, fmt.Errorf("read interface addresses: %w", err)
 	}
-	var result []string
 	for _, addr := range addrs {
 		var ip net.IP
 		switch v := addr.(type) {
@@ -505,10 +591,36 @@ func interfaceAddresses(name string) ([]string, error) {
 		if ip == nil {
 			continue
 		}
-		result = append(result, ip.String())
+		if ip.To4() != nil {
+			v4 = append(v4, ip.String())
+		} else {
+			v6 = append(v6, ip.String())
+		}
+	}
+	if len(v4)+len(v6) == 0 {
+		return nil, nil, fmt.Errorf("interface %s has no addresses", name)
+	}
+	return v4, v6, nil
+}
+
+// interfaceIPv6Addresses возвращает глобальные IPv6-адреса интерфейса;
+// link-local не блокируем, чтобы не ломать обнаружение соседей.
+func interfaceIPv6Addresses(name string) ([]string, error) {
+	iface, err := interfaceByName(name)
+	if err != nil {
+		return nil, err
 	}
-	if len(result) == 0 {
-		return nil, fmt.Errorf("interface %s has no addresses", name)
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("read interface addresses: %w", err)
+	}
+	var result []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		result = append(result, ipNet.IP.String())
 	}
 	return result, nil
 }
@@ -0,0 +1,18 @@
+package firewall
+
+import "fmt"
+
+// rollbackPartial снимает правила, созданные до ошибки err, чтобы набор применялся целиком или никак.
+// Возвращает правила, которые остались в системе (их должен снять вызывающий), и ошибку,
+// оборачивающую и ErrPartialApply, и исходную err.
+// Если ничего не было создано, err возвращается как есть.
+func rollbackPartial(created []string, err error, remove func([]string) error) ([]string, error) {
+	if len(created) == 0 {
+		return nil, err
+	}
+	partial := fmt.Errorf("%w: %d rules created before failure: %w", ErrPartialApply, len(created), err)
+	if removeErr := remove(created); removeErr != nil {
+		return created, fmt.Errorf("%w; rollback failed: %v", partial, removeErr)
+	}
+	return nil, partial
+}
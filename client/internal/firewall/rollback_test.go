@@ -0,0 +1,75 @@
+package firewall
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+var errTCPRule = errors.New("add rule CustomVPN KillSwitch DNS TCP: access denied")
+
+// addRules создаёт правила по очереди, как BlockDNSOnInterface, и откатывает их при ошибке.
+func addRules(names []string, failAt int, remove func([]string) error) ([]string, error) {
+	var created []string
+	for i, name := range names {
+		if i == failAt {
+			return rollbackPartial(created, errTCPRule, remove)
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+func TestRollbackPartialSecondRuleFails(t *testing.T) {
+	rules := []string{"CustomVPN KillSwitch DNS UDP", "CustomVPN KillSwitch DNS TCP"}
+	var removed [][]string
+	remaining, err := addRules(rules, 1, func(names []string) error {
+		removed = append(removed, slices.Clone(names))
+		return nil
+	})
+
+	if !errors.Is(err, ErrPartialApply) {
+		t.Fatalf("error %v does not wrap ErrPartialApply", err)
+	}
+	if !errors.Is(err, errTCPRule) {
+		t.Fatalf("error %v does not wrap the original failure", err)
+	}
+	if len(remaining) != 0 {
+		t.Fatalf("remaining = %q, want none after rollback", remaining)
+	}
+	// UDP-правило снято ровно один раз: вызывающему снимать нечего
+	if len(removed) != 1 || !slices.Equal(removed[0], rules[:1]) {
+		t.Fatalf("removed = %q, want one removal of %q", removed, rules[:1])
+	}
+}
+
+func TestRollbackPartialRemoveFails(t *testing.T) {
+	errRemove := errors.New("remove rule: access denied")
+	remaining, err := addRules([]string{"udp", "tcp"}, 1, func([]string) error { return errRemove })
+
+	if !errors.Is(err, ErrPartialApply) || !errors.Is(err, errTCPRule) {
+		t.Fatalf("error %v does not wrap ErrPartialApply and the original failure", err)
+	}
+	// снять правило не удалось: оно возвращается вызывающему для повторной очистки
+	if !slices.Equal(remaining, []string{"udp"}) {
+		t.Fatalf("remaining = %q, want [udp]", remaining)
+	}
+}
+
+func TestRollbackPartialFirstRuleFails(t *testing.T) {
+	called := false
+	remaining, err := addRules([]string{"udp", "tcp"}, 0, func([]string) error {
+		called = true
+		return nil
+	})
+
+	if err != errTCPRule {
+		t.Fatalf("error = %v, want original error unchanged", err)
+	}
+	if errors.Is(err, ErrPartialApply) {
+		t.Fatalf("nothing was created, error must not be partial: %v", err)
+	}
+	if called || len(remaining) != 0 {
+		t.Fatalf("rollback ran with nothing created: called=%v remaining=%q", called, remaining)
+	}
+}
@@ -0,0 +1,66 @@
+// Package i18n — каталог пользовательских строк клиента и функции T/Tf.
+// Пакет не зависит от Fyne: строки берут и state machine, и сценарии app, и окна ui.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Языки интерфейса (ключ language в config.yaml).
+const (
+	LangRU = "ru"
+	LangEN = "en"
+	// DefaultLanguage — язык по умолчанию; из него же берутся строки, которых нет в каталоге языка.
+	DefaultLanguage = LangRU
+)
+
+// catalogs — строки по языкам; ключ — «раздел.имя», например status.connected.
+var catalogs = map[string]map[string]string{
+	LangRU: messagesRU,
+	LangEN: messagesEN,
+}
+
+var current atomic.Value
+
+// Supported сообщает, есть ли каталог для языка.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// SetLanguage выбирает язык строк; неизвестный язык заменяется DefaultLanguage.
+// Окна строятся один раз, поэтому язык задаётся до создания UI.
+func SetLanguage(lang string) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if !Supported(lang) {
+		lang = DefaultLanguage
+	}
+	current.Store(lang)
+}
+
+// Language возвращает текущий язык.
+func Language() string {
+	if lang, ok := current.Load().(string); ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// T возвращает строку по ключу на текущем языке. Если ключа нет ни в текущем каталоге,
+// ни в каталоге по умолчанию, возвращается сам ключ, чтобы пропуск был заметен в окне.
+func T(key string) string {
+	if text, ok := catalogs[Language()][key]; ok {
+		return text
+	}
+	if text, ok := catalogs[DefaultLanguage][key]; ok {
+		return text
+	}
+	return key
+}
+
+// Tf форматирует строку каталога через fmt.Sprintf.
+func Tf(key string, args ...any) string {
+	return fmt.Sprintf(T(key), args...)
+}
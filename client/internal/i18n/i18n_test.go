@@ -0,0 +1,40 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// mojibakeMarkers — пары символов, которые появляются, когда UTF-8 прочитан как Windows-1251
+// («Не» превращается в «РќРµ»); в нормальном русском тексте они не встречаются.
+var mojibakeMarkers = []string{"Рќ", "РЅ", "Рµ", "СЃ", "Рѕ", "вЂ"}
+
+func TestCatalogsAreValidUTF8(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, text := range catalog {
+			if !utf8.ValidString(text) {
+				t.Errorf("%s %s: invalid UTF-8 %q", lang, key, text)
+			}
+			for _, marker := range mojibakeMarkers {
+				if strings.Contains(text, marker) {
+					t.Errorf("%s %s: mojibake %q in %q", lang, key, marker, text)
+				}
+			}
+		}
+	}
+}
+
+func TestRussianGatewayMessages(t *testing.T) {
+	SetLanguage(LangRU)
+	cases := map[string]string{
+		"error.gateway_detect":    "Не удалось определить шлюз по умолчанию",
+		"error.multiple_gateways": "Обнаружено несколько шлюзов по умолчанию",
+		"error.ipv6_gateway":      "Не удалось определить маршрут IPv6 по умолчанию",
+	}
+	for key, want := range cases {
+		if got := T(key); got != want {
+			t.Errorf("T(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
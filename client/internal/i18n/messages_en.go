@@ -0,0 +1,220 @@
+package i18n
+
+var messagesEN = map[string]string{
+	// статусы, которые выставляет state machine
+	"status.preflight":           "Checking server availability...",
+	"status.enter_credentials":   "Enter your login and password",
+	"status.authenticating":      "Signing in",
+	"status.syncing":             "Updating server lists",
+	"status.preparing_env":       "Preparing environment",
+	"status.loading_profiles":    "Loading profiles %d/%d",
+	"status.disconnected":        "Disconnected",
+	"status.disconnected_lost":   "Disconnected (connection lost)",
+	"status.session_expired":     "Session expired. Please sign in again",
+	"status.connecting":          "Connecting...",
+	"status.connecting_prefix":   "Connecting",
+	"status.refreshing_profiles": "Refreshing profile list",
+	"status.cancelling":          "Cancelling connection...",
+	"status.connect_cancelled":   "Connection cancelled",
+	"status.disconnecting":       "Disconnecting...",
+	"status.connected":           "Connected",
+	"status.network_changed":     "Network changed. Reconnecting...",
+	"status.connection_lost":     "Connection lost. %s...",
+	"status.reconnecting":        "Reconnecting",
+	"status.reconnecting_n":      "Reconnecting (%d/%d)",
+	"status.retrying_manual":     "Retrying the check...",
+	"status.retrying_auto":       "Retrying the connection check...",
+
+	// короткие уведомления
+	"notice.cleanup_started":      "Cleanup started",
+	"notice.cleanup_done":         "Cleanup finished",
+	"notice.cleanup_done_errors":  "Cleanup finished with errors",
+	"notice.credentials_required": "Enter login and password",
+	"notice.select_profile":       "Select a profile",
+	"notice.server_available":     "Server is reachable",
+	"notice.clock_skew":           "System clock differs from the server time by %d min. Synchronize your clock, otherwise sign-in may fail",
+
+	// сообщения об ошибках
+	"error.auth_failed":                  "Authorization failed",
+	"error.auth_timeout":                 "The authorization server did not respond in time",
+	"error.bad_credentials":              "Invalid login or password",
+	"error.account_locked":               "The account is locked or disabled. Contact your administrator",
+	"error.too_many_attempts":            "Too many failed sign-in attempts. Try again later",
+	"error.too_many_attempts_minutes":    "Too many failed sign-in attempts. Try again in %d min",
+	"error.auth_unreachable":             "Could not connect to the authorization server",
+	"error.auth_status":                  "Authorization failed (code %d)",
+	"error.control_unreachable":          "No connection to the control server",
+	"error.preflight_unavailable":        "No connection to the control server. Retrying in 5 seconds",
+	"error.api_version":                  "Control server API version %s is not supported. Update the application",
+	"error.cert_pin":                     "The control server certificate does not match the pinned one. The connection may be intercepted",
+	"error.control_timeout":              "The control server did not respond in time",
+	"error.control_status":               "Control server is unavailable (code %d)",
+	"error.retry_in":                     ". Retrying in %d s",
+	"error.server_timeout":               "The server did not respond in time",
+	"error.with_status":                  "%s (code %d)",
+	"error.load_data":                    "Failed to load data",
+	"error.load_profiles":                "Failed to load the profile list",
+	"error.refresh_profiles":             "Failed to refresh the profile list",
+	"error.prepare_routes":               "Failed to prepare routes",
+	"error.prepare_routes_timeout":       "Timed out while preparing routes",
+	"error.elevation_required":           "Insufficient privileges. Run the application as administrator",
+	"error.gateway_detect":               "Failed to detect the default gateway",
+	"error.multiple_gateways":            "Several default gateways detected",
+	"error.connect_failed":               "Failed to connect",
+	"error.connect_timeout":              "Connection timed out",
+	"error.process_exited_connecting":    "A process exited while connecting",
+	"error.process_failed":               "A process exited with an error",
+	"error.process_exited":               "A process exited unexpectedly",
+	"error.tunnel_dead":                  "The tunnel stopped passing traffic",
+	"error.operation_timeout":            "Operation %s timed out",
+	"error.profile_not_found":            "The selected profile was not found",
+	"error.profile_disabled":             "Profile \"%s\" is disabled by the administrator, choose another one",
+	"error.profile_no_host":              "The profile has no address",
+	"error.profile_bad_port":             "The profile has no valid port",
+	"error.load_profile":                 "Failed to load the profile",
+	"error.ipv6_gateway":                 "Failed to detect the default IPv6 route",
+	"error.config_not_loaded":            "Application configuration is not loaded",
+	"error.router_not_ready":             "Route manager is not initialized",
+	"error.route_gateway_missing":        "Route gateway is not set",
+	"error.route_add":                    "Failed to add route %s",
+	"error.routes_interrupted":           "Adding routes was interrupted",
+	"error.core_bind_address":            "Failed to detect the network interface address for Core",
+	"error.core_config_write":            "Failed to write the Core configuration",
+	"error.core_check_timeout":           "Core configuration check did not finish in time",
+	"error.core_check_failed":            "Core configuration check failed",
+	"error.core_start":                   "Failed to start Core",
+	"error.tunnel_interface":             "Failed to detect the tunnel interface",
+	"error.dns_not_ready":                "DNS manager is not initialized",
+	"error.tunnel_dns_timeout":           "Tunnel DNS setup did not finish within %s",
+	"error.tunnel_dns":                   "Failed to configure tunnel DNS",
+	"error.killswitch_not_ready":         "Kill Switch is not initialized",
+	"error.killswitch_no_interface":      "Kill Switch cannot detect the primary interface",
+	"error.killswitch_unavailable":       "Kill Switch is unavailable",
+	"error.killswitch_partial":           "Failed to apply Kill Switch (partially applied)",
+	"error.killswitch_apply":             "Failed to apply Kill Switch",
+	"error.killswitch_policy_denied":     "Kill Switch not applied: local firewall rules are disabled and permission to enable them was not given",
+	"error.killswitch_merge_unsupported": "Kill Switch is unavailable: AllowLocalPolicyMerge is not supported by the system",
+	"error.local_policy_enable":          "Failed to enable local firewall rules",
+	"error.killswitch_after_merge":       "Kill Switch is unavailable after enabling local rules",
+	"error.firewall_not_ready":           "Firewall is not initialized",
+	"error.ipv6_block_unavailable":       "Failed to block IPv6: the firewall is unavailable",
+	"error.ipv6_block":                   "Failed to block IPv6",
+	"error.pre_connect_hook":             "The pre-connect command failed",
+
+	// предпросмотр подключения
+	"preview.profile":    "Profile: %s (%s)\n",
+	"preview.server":     "Server: %s\n",
+	"preview.tunnel_dns": "Tunnel DNS: %s\n",
+	"preview.routes":     "\nRoutes (%d):\n",
+	"preview.skipped":    "\nSkipped (IPv6 disabled): %s\n",
+	"preview.on":         "on",
+	"preview.off":        "off",
+
+	// состояния для подсказки значка в трее
+	"state.app_starting":        "Starting",
+	"state.preflight":           "Checking server",
+	"state.waiting_login":       "Waiting for sign-in",
+	"state.authenticating":      "Signing in",
+	"state.syncing":             "Loading profiles",
+	"state.preparing_env":       "Preparing environment",
+	"state.ready":               "Disconnected",
+	"state.refreshing_profiles": "Refreshing profiles",
+	"state.connecting":          "Connecting",
+	"state.connected":           "Connected",
+	"state.disconnecting":       "Disconnecting",
+	"state.reconnecting":        "Reconnecting",
+	"state.error":               "Error",
+	"state.exiting":             "Shutting down",
+
+	// окна
+	"ui.error_title":               "Error",
+	"ui.error_default":             "An error occurred",
+	"ui.copy":                      "Copy",
+	"ui.details":                   "Details",
+	"ui.no_data":                   "no data",
+	"ui.error_kind":                "Kind: %s",
+	"ui.error_details":             "Details: %s",
+	"ui.error_time":                "Time: %s",
+	"ui.local_policy_confirm":      "Local firewall rules are disabled on this system. Allow them now? Administrator rights are required.",
+	"ui.gateway_title":             "Choose network",
+	"ui.gateway_prompt":            "Several default gateways detected. Choose the network to connect through:",
+	"ui.gateway_option":            "%s — %s (metric %d)",
+	"ui.connect":                   "Connect",
+	"ui.disconnect":                "Disconnect",
+	"ui.cancel":                    "Cancel",
+	"ui.close":                     "Close",
+	"ui.save":                      "Save",
+	"ui.cleanup_begun":             "Cleanup started",
+	"ui.cleanup_summary":           "\nRoutes removed: %d, firewall rules removed: %d",
+	"ui.repair":                    "Repair",
+	"ui.login_title":               "%s — Sign in",
+	"ui.login_subtitle":            "Authorization",
+	"ui.login":                     "Login",
+	"ui.password":                  "Password",
+	"ui.remember_me":               "Remember me",
+	"ui.sign_in":                   "Sign in",
+	"ui.checking_server":           "Checking connection to the server...",
+	"ui.retry_check":               "Retry check",
+	"ui.test_server":               "Test server",
+	"ui.profile_filter":            "Search by name or country",
+	"ui.profiles":                  "Profiles",
+	"ui.status":                    "Status:",
+	"ui.settings":                  "Settings",
+	"ui.refresh":                   "Refresh",
+	"ui.show_logs":                 "Show logs",
+	"ui.history":                   "History",
+	"ui.exit":                      "Exit",
+	"ui.confirm_disconnect":        "Drop the active connection?",
+	"ui.settings_control_server":   "Control server",
+	"ui.settings_log_level":        "Log level",
+	"ui.settings_core_path":        "Core path",
+	"ui.settings_theme":            "Theme",
+	"ui.settings_save_unavailable": "Saving settings is unavailable",
+	"ui.settings_save_failed":      "Failed to save: %v",
+	"ui.settings_saved":            "Settings saved. Restart the application to apply the changes.",
+	"ui.theme_light":               "Light",
+	"ui.theme_dark":                "Dark",
+	"ui.theme_system":              "System",
+	"ui.show":                      "Show",
+	"ui.hide":                      "Hide",
+	"ui.collect_diagnostics":       "Collect diagnostics",
+	"ui.diagnostics_title":         "Diagnostics",
+	"ui.diagnostics_failed":        "Failed to collect diagnostics: %v",
+	"ui.diagnostics_saved":         "Archive saved:\n%s",
+	"ui.preview_connect":           "Connection preview",
+	"ui.preview_failed":            "Failed to build the connection plan: %v",
+	"ui.copy_core_config":          "Copy Core configuration",
+	"ui.core_config_failed":        "Failed to get the Core configuration: %v",
+	"ui.core_config_copied":        "The Core configuration of the selected profile was copied to the clipboard. Passwords, secrets and tokens are hidden.",
+	"ui.export_plan":               "Export connection plan",
+	"ui.export_plan_failed":        "Failed to export the connection plan: %v",
+	"ui.export_plan_saved":         "Connection plan saved:\n%s",
+	"ui.connected_for":             "Connected for: %02d:%02d:%02d",
+	"ui.server_via":                "%s via %s",
+	"ui.rate_mb":                   "%.1f MB/s",
+	"ui.rate_kb":                   "%.1f KB/s",
+	"ui.rate_b":                    "%.0f B/s",
+	"ui.tray_unavailable":          "The tray icon is unavailable, so the window cannot be minimized to the tray.\nQuit %s?",
+	"ui.profile_other":             "Other",
+	"ui.favorites":                 "Favorites",
+	"ui.favorites_count":           "Favorites (%d)",
+	"ui.favorite_add":              "Add to favorites",
+	"ui.favorite_remove":           "Remove from favorites",
+	"ui.favorites_save_failed":     "Failed to save favorites: %v",
+	"ui.profile_disabled":          " (disabled)",
+	"ui.history_window":            "Connection history",
+	"ui.history_unavailable":       "Connection history is unavailable",
+	"ui.history_empty":             "No connections yet",
+	"ui.history_profile":           "Profile",
+	"ui.history_connected":         "Connected",
+	"ui.history_disconnected":      "Disconnected",
+	"ui.history_duration":          "Duration",
+	"ui.history_reason":            "Reason",
+	"ui.history_open":              "in progress",
+	"ui.end_user":                  "user",
+	"ui.end_crash":                 "Core crash",
+	"ui.end_error":                 "error",
+	"ui.logs_title":                "Logs",
+	"ui.core_logs_window":          "Core logs",
+	"ui.core_log_missing":          "Core log path is not set",
+}
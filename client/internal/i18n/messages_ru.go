@@ -0,0 +1,220 @@
+package i18n
+
+var messagesRU = map[string]string{
+	// статусы, которые выставляет state machine
+	"status.preflight":           "Проверяем доступность сервера...",
+	"status.enter_credentials":   "Введите логин и пароль",
+	"status.authenticating":      "Выполняется авторизация",
+	"status.syncing":             "Обновление списков серверов",
+	"status.preparing_env":       "Подготовка окружения",
+	"status.loading_profiles":    "Загрузка профилей %d/%d",
+	"status.disconnected":        "Отключено",
+	"status.disconnected_lost":   "Отключено (соединение потеряно)",
+	"status.session_expired":     "Сессия истекла. Войдите снова",
+	"status.connecting":          "Подключение...",
+	"status.connecting_prefix":   "Подключение",
+	"status.refreshing_profiles": "Обновление списка профилей",
+	"status.cancelling":          "Отмена подключения...",
+	"status.connect_cancelled":   "Подключение отменено",
+	"status.disconnecting":       "Отключение...",
+	"status.connected":           "Подключено",
+	"status.network_changed":     "Сеть изменилась. Переподключение...",
+	"status.connection_lost":     "Соединение потеряно. %s...",
+	"status.reconnecting":        "Переподключение",
+	"status.reconnecting_n":      "Переподключение (%d/%d)",
+	"status.retrying_manual":     "Повторяем проверку...",
+	"status.retrying_auto":       "Повторяем проверку соединения...",
+
+	// короткие уведомления
+	"notice.cleanup_started":      "Очистка запущена",
+	"notice.cleanup_done":         "Очистка завершена",
+	"notice.cleanup_done_errors":  "Очистка завершена с ошибками",
+	"notice.credentials_required": "Укажите логин и пароль",
+	"notice.select_profile":       "Выберите профиль",
+	"notice.server_available":     "Сервер доступен",
+	"notice.clock_skew":           "Системное время отличается от времени сервера на %d мин. Синхронизируйте часы, иначе вход может не работать",
+
+	// сообщения об ошибках
+	"error.auth_failed":                  "Ошибка авторизации",
+	"error.auth_timeout":                 "Истекло время ожидания ответа сервера авторизации",
+	"error.bad_credentials":              "Неверный логин или пароль",
+	"error.account_locked":               "Учётная запись заблокирована или отключена. Обратитесь к администратору",
+	"error.too_many_attempts":            "Слишком много неудачных попыток входа. Повторите позже",
+	"error.too_many_attempts_minutes":    "Слишком много неудачных попыток входа. Повторите через %d мин",
+	"error.auth_unreachable":             "Не удалось подключиться к серверу авторизации",
+	"error.auth_status":                  "Ошибка авторизации (код %d)",
+	"error.control_unreachable":          "Нет связи с управляющим сервером",
+	"error.preflight_unavailable":        "Нет связи с управляющим сервером. Повторим через 5 секунд",
+	"error.api_version":                  "Версия API управляющего сервера (%s) не поддерживается. Обновите приложение",
+	"error.cert_pin":                     "Сертификат управляющего сервера не совпадает с закреплённым. Возможен перехват соединения",
+	"error.control_timeout":              "Истекло время ожидания ответа управляющего сервера",
+	"error.control_status":               "Управляющий сервер недоступен (код %d)",
+	"error.retry_in":                     ". Повторим через %d с",
+	"error.server_timeout":               "Истекло время ожидания ответа сервера",
+	"error.with_status":                  "%s (код %d)",
+	"error.load_data":                    "Не удалось загрузить данные",
+	"error.load_profiles":                "Не удалось загрузить список профилей",
+	"error.refresh_profiles":             "Не удалось обновить список профилей",
+	"error.prepare_routes":               "Не удалось подготовить маршруты",
+	"error.prepare_routes_timeout":       "Истекло время ожидания при подготовке маршрутов",
+	"error.elevation_required":           "Недостаточно прав. Запустите приложение от имени администратора",
+	"error.gateway_detect":               "Не удалось определить шлюз по умолчанию",
+	"error.multiple_gateways":            "Обнаружено несколько шлюзов по умолчанию",
+	"error.connect_failed":               "Не удалось подключиться",
+	"error.connect_timeout":              "Превышено время подключения",
+	"error.process_exited_connecting":    "Процесс завершился во время подключения",
+	"error.process_failed":               "Процесс завершился с ошибкой",
+	"error.process_exited":               "Процесс завершился неожиданно",
+	"error.tunnel_dead":                  "Туннель перестал пропускать трафик",
+	"error.operation_timeout":            "Таймаут операции %s",
+	"error.profile_not_found":            "Не удалось найти выбранный профиль",
+	"error.profile_disabled":             "Профиль «%s» отключён администратором, выберите другой",
+	"error.profile_no_host":              "Профиль не содержит адрес",
+	"error.profile_bad_port":             "Профиль не содержит корректный порт",
+	"error.load_profile":                 "Не удалось загрузить профиль",
+	"error.ipv6_gateway":                 "Не удалось определить маршрут IPv6 по умолчанию",
+	"error.config_not_loaded":            "Конфигурация приложения не загружена",
+	"error.router_not_ready":             "Маршрутизатор не инициализирован",
+	"error.route_gateway_missing":        "Маршрутный шлюз не задан",
+	"error.route_add":                    "Не удалось добавить маршрут %s",
+	"error.routes_interrupted":           "Добавление маршрутов прервано",
+	"error.core_bind_address":            "Не удалось определить адрес сетевого интерфейса для Core",
+	"error.core_config_write":            "Не удалось записать конфигурацию Core",
+	"error.core_check_timeout":           "Проверка конфигурации Core не завершилась вовремя",
+	"error.core_check_failed":            "Проверка конфигурации Core не прошла",
+	"error.core_start":                   "Не удалось запустить Core",
+	"error.tunnel_interface":             "Не удалось определить интерфейс туннеля",
+	"error.dns_not_ready":                "DNS менеджер не инициализирован",
+	"error.tunnel_dns_timeout":           "Настройка DNS туннеля не завершилась за %s",
+	"error.tunnel_dns":                   "Не удалось настроить DNS туннеля",
+	"error.killswitch_not_ready":         "Kill Switch не инициализирован",
+	"error.killswitch_no_interface":      "Kill Switch не может определить основной интерфейс",
+	"error.killswitch_unavailable":       "Kill Switch недоступен",
+	"error.killswitch_partial":           "Не удалось применить Kill Switch (частично)",
+	"error.killswitch_apply":             "Не удалось применить Kill Switch",
+	"error.killswitch_policy_denied":     "Kill Switch не применён: локальные правила брандмауэра запрещены, а разрешение на их включение не получено",
+	"error.killswitch_merge_unsupported": "Kill Switch недоступен: AllowLocalPolicyMerge не поддерживается в системе",
+	"error.local_policy_enable":          "Не удалось включить локальные правила брандмауэра",
+	"error.killswitch_after_merge":       "Kill Switch недоступен после включения локальных правил",
+	"error.firewall_not_ready":           "Брандмауэр не инициализирован",
+	"error.ipv6_block_unavailable":       "Не удалось заблокировать IPv6: брандмауэр недоступен",
+	"error.ipv6_block":                   "Не удалось заблокировать IPv6",
+	"error.pre_connect_hook":             "Команда перед подключением завершилась с ошибкой",
+
+	// предпросмотр подключения
+	"preview.profile":    "Профиль: %s (%s)\n",
+	"preview.server":     "Сервер: %s\n",
+	"preview.tunnel_dns": "DNS туннеля: %s\n",
+	"preview.routes":     "\nМаршруты (%d):\n",
+	"preview.skipped":    "\nПропущены (IPv6 выключен): %s\n",
+	"preview.on":         "вкл",
+	"preview.off":        "выкл",
+
+	// состояния для подсказки значка в трее
+	"state.app_starting":        "Запуск",
+	"state.preflight":           "Проверка сервера",
+	"state.waiting_login":       "Ожидание входа",
+	"state.authenticating":      "Авторизация",
+	"state.syncing":             "Загрузка профилей",
+	"state.preparing_env":       "Подготовка окружения",
+	"state.ready":               "Отключено",
+	"state.refreshing_profiles": "Обновление профилей",
+	"state.connecting":          "Подключение",
+	"state.connected":           "Подключено",
+	"state.disconnecting":       "Отключение",
+	"state.reconnecting":        "Переподключение",
+	"state.error":               "Ошибка",
+	"state.exiting":             "Завершение работы",
+
+	// окна
+	"ui.error_title":               "Ошибка",
+	"ui.error_default":             "Произошла ошибка",
+	"ui.copy":                      "Скопировать",
+	"ui.details":                   "Подробности",
+	"ui.no_data":                   "нет данных",
+	"ui.error_kind":                "Тип: %s",
+	"ui.error_details":             "Детали: %s",
+	"ui.error_time":                "Время: %s",
+	"ui.local_policy_confirm":      "В системе запрещены локальные правила брандмауэра. Разрешить их сейчас? Для этого нужны права администратора.",
+	"ui.gateway_title":             "Выбор сети",
+	"ui.gateway_prompt":            "Обнаружено несколько шлюзов по умолчанию. Выберите сеть, через которую подключаться:",
+	"ui.gateway_option":            "%s — %s (метрика %d)",
+	"ui.connect":                   "Подключиться",
+	"ui.disconnect":                "Отключиться",
+	"ui.cancel":                    "Отмена",
+	"ui.close":                     "Закрыть",
+	"ui.save":                      "Сохранить",
+	"ui.cleanup_begun":             "Очистка начата",
+	"ui.cleanup_summary":           "\nУдалено маршрутов: %d, правил брандмауэра: %d",
+	"ui.repair":                    "Починка",
+	"ui.login_title":               "%s — Вход",
+	"ui.login_subtitle":            "Авторизация",
+	"ui.login":                     "Логин",
+	"ui.password":                  "Пароль",
+	"ui.remember_me":               "Запомнить меня",
+	"ui.sign_in":                   "Войти",
+	"ui.checking_server":           "Проверяем связь с сервером...",
+	"ui.retry_check":               "Повторить проверку",
+	"ui.test_server":               "Проверить сервер",
+	"ui.profile_filter":            "Поиск по названию или стране",
+	"ui.profiles":                  "Профили",
+	"ui.status":                    "Статус:",
+	"ui.settings":                  "Настройки",
+	"ui.refresh":                   "Обновить",
+	"ui.show_logs":                 "Показать логи",
+	"ui.history":                   "История",
+	"ui.exit":                      "Выход",
+	"ui.confirm_disconnect":        "Разорвать активное подключение?",
+	"ui.settings_control_server":   "Control-сервер",
+	"ui.settings_log_level":        "Уровень логов",
+	"ui.settings_core_path":        "Путь к Core",
+	"ui.settings_theme":            "Оформление",
+	"ui.settings_save_unavailable": "Сохранение настроек недоступно",
+	"ui.settings_save_failed":      "Не удалось сохранить: %v",
+	"ui.settings_saved":            "Настройки сохранены. Перезапустите приложение, чтобы применить изменения.",
+	"ui.theme_light":               "Светлая",
+	"ui.theme_dark":                "Тёмная",
+	"ui.theme_system":              "Как в системе",
+	"ui.show":                      "Показать",
+	"ui.hide":                      "Скрыть",
+	"ui.collect_diagnostics":       "Собрать диагностику",
+	"ui.diagnostics_title":         "Диагностика",
+	"ui.diagnostics_failed":        "Не удалось собрать диагностику: %v",
+	"ui.diagnostics_saved":         "Архив сохранён:\n%s",
+	"ui.preview_connect":           "Предпросмотр подключения",
+	"ui.preview_failed":            "Не удалось построить план подключения: %v",
+	"ui.copy_core_config":          "Скопировать конфигурацию Core",
+	"ui.core_config_failed":        "Не удалось получить конфигурацию Core: %v",
+	"ui.core_config_copied":        "Конфигурация Core выбранного профиля скопирована в буфер обмена. Пароли, секреты и токены скрыты.",
+	"ui.export_plan":               "Экспорт плана подключения",
+	"ui.export_plan_failed":        "Не удалось экспортировать план подключения: %v",
+	"ui.export_plan_saved":         "План подключения сохранён:\n%s",
+	"ui.connected_for":             "Время подключения: %02d:%02d:%02d",
+	"ui.server_via":                "%s через %s",
+	"ui.rate_mb":                   "%.1f МБ/с",
+	"ui.rate_kb":                   "%.1f КБ/с",
+	"ui.rate_b":                    "%.0f Б/с",
+	"ui.tray_unavailable":          "Значок в трее недоступен, поэтому окно нельзя свернуть в трей.\nЗавершить работу %s?",
+	"ui.profile_other":             "Прочее",
+	"ui.favorites":                 "Избранное",
+	"ui.favorites_count":           "Избранное (%d)",
+	"ui.favorite_add":              "Добавить в избранное",
+	"ui.favorite_remove":           "Убрать из избранного",
+	"ui.favorites_save_failed":     "Не удалось сохранить избранное: %v",
+	"ui.profile_disabled":          " (отключён)",
+	"ui.history_window":            "История подключений",
+	"ui.history_unavailable":       "История подключений недоступна",
+	"ui.history_empty":             "Подключений пока не было",
+	"ui.history_profile":           "Профиль",
+	"ui.history_connected":         "Подключение",
+	"ui.history_disconnected":      "Отключение",
+	"ui.history_duration":          "Длительность",
+	"ui.history_reason":            "Причина",
+	"ui.history_open":              "не завершена",
+	"ui.end_user":                  "пользователь",
+	"ui.end_crash":                 "падение Core",
+	"ui.end_error":                 "ошибка",
+	"ui.logs_title":                "Логи",
+	"ui.core_logs_window":          "Логи Core",
+	"ui.core_log_missing":          "Путь к логу Core не задан",
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -37,33 +38,81 @@ func ParseLevel(value string) Level {
 
 // Logger представляет потокобезопасный файловый логгер с уровнями.
 type Logger struct {
-	minLevel Level
+	// minLevel хранит Level; атомарен, чтобы SetLevel работал без блокировки записи.
+	minLevel atomic.Int32
 	writer   io.Writer
 	closer   io.Closer
 	mu       sync.Mutex
+
+	path       string
+	size       int64
+	maxSize    int64
+	maxBackups int
+}
+
+// Options задаёт параметры ротации лог-файла.
+type Options struct {
+	// MaxSizeBytes — размер файла, после которого он переименовывается в .1 (0 — без ротации).
+	MaxSizeBytes int64
+	// MaxBackups — сколько архивных файлов .1…N хранить; более старые удаляются.
+	MaxBackups int
 }
 
 // New создаёт новый логгер, пишущий в указанный файл.
-func New(path string, level Level) (*Logger, error) {
+func New(path string, level Level, opts Options) (*Logger, error) {
 	if path == "" {
 		return nil, fmt.Errorf("log path is empty")
 	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, fmt.Errorf("create log directory for %s: %w", path, err)
 	}
+	file, size, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	maxBackups := opts.MaxBackups
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+	logger := &Logger{
+		writer:     file,
+		closer:     file,
+		path:       path,
+		size:       size,
+		maxSize:    opts.MaxSizeBytes,
+		maxBackups: maxBackups,
+	}
+	logger.minLevel.Store(int32(level))
+	return logger, nil
+}
+
+func openLogFile(path string) (*os.File, int64, error) {
 	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
 	if err != nil {
-		return nil, fmt.Errorf("open log file %s: %w", path, err)
+		return nil, 0, fmt.Errorf("open log file %s: %w", path, err)
 	}
-	return &Logger{minLevel: level, writer: file, closer: file}, nil
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, fmt.Errorf("stat log file %s: %w", path, err)
+	}
+	return file, info.Size(), nil
 }
 
 // Close освобождает ресурсы файлового логгера.
 func (l *Logger) Close() error {
-	if l == nil || l.closer == nil {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closer == nil {
 		return nil
 	}
-	return l.closer.Close()
+	err := l.closer.Close()
+	l.closer = nil
+	l.writer = io.Discard
+	return err
 }
 
 // Debugf пишет отладочное сообщение.
@@ -82,13 +131,55 @@ func (l *Logger) Errorf(format string, args ...any) {
 }
 
 func (l *Logger) write(level Level, format string, args ...any) {
-	if l == nil || level < l.minLevel {
+	if l == nil || level < Level(l.minLevel.Load()) {
 		return
 	}
 	entry := fmt.Sprintf(format, args...)
+	line := fmt.Sprintf("%s [%s] %s\n", time.Now().UTC().Format(time.RFC3339), level.String(), entry)
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	fmt.Fprintf(l.writer, "%s [%s] %s\n", time.Now().UTC().Format(time.RFC3339), level.String(), entry)
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		l.rotate()
+	}
+	n, _ := io.WriteString(l.writer, line)
+	l.size += int64(n)
+}
+
+// rotate сдвигает архивы (.1 → .2 …), переименовывает текущий файл в .1 и открывает новый.
+// Вызывается под l.mu.
+func (l *Logger) rotate() {
+	if l.path == "" || l.closer == nil {
+		return
+	}
+	if err := l.closer.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "logging: close %s before rotation: %v\n", l.path, err)
+	}
+	if l.maxBackups == 0 {
+		_ = os.Remove(l.path)
+	} else {
+		_ = os.Remove(backupPath(l.path, l.maxBackups))
+		for i := l.maxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(backupPath(l.path, i), backupPath(l.path, i+1))
+		}
+		if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+			fmt.Fprintf(os.Stderr, "logging: rotate %s: %v\n", l.path, err)
+		}
+	}
+	file, size, err := openLogFile(l.path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logging: reopen after rotation: %v\n", err)
+		l.writer = io.Discard
+		l.closer = nil
+		l.size = 0
+		return
+	}
+	l.writer = file
+	l.closer = file
+	l.size = size
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
 }
 
 // Level возвращает минимальный уровень логгера.
@@ -96,7 +187,15 @@ func (l *Logger) Level() Level {
 	if l == nil {
 		return LevelInfo
 	}
-	return l.minLevel
+	return Level(l.minLevel.Load())
+}
+
+// SetLevel меняет минимальный уровень логгера на лету.
+func (l *Logger) SetLevel(level Level) {
+	if l == nil {
+		return
+	}
+	l.minLevel.Store(int32(level))
 }
 
 // String возвращает текстовое представление уровня.
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRotationKeepsMaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.log")
+	const maxSize = 1024
+	logger, err := New(path, LevelInfo, Options{MaxSizeBytes: maxSize, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer logger.Close()
+
+	// около 5 КБ: не меньше четырёх ротаций при трёх файлах в итоге
+	message := strings.Repeat("x", 100)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				logger.Infof("%s", message)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if info.Size() > maxSize {
+			t.Fatalf("%s is %d bytes, over the %d limit", name, info.Size(), maxSize)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("backup .3 exists beyond MaxBackups: %v", err)
+	}
+}
+
+func TestRotationWithoutBackupsTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.log")
+	logger, err := New(path, LevelInfo, Options{MaxSizeBytes: 256})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer logger.Close()
+
+	for i := 0; i < 20; i++ {
+		logger.Infof("%s", strings.Repeat("y", 60))
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("backup created with MaxBackups 0: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > 256 {
+		t.Fatalf("log file after rotation: %v, %v", info, err)
+	}
+}
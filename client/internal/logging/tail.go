@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Tailer читает дописанные в файл строки, начиная с последних maxBytes байт.
+// Незавершённая последняя строка придерживается до появления перевода строки.
+type Tailer struct {
+	path     string
+	maxBytes int64
+	offset   int64
+	started  bool
+	pending  []byte
+}
+
+// NewTailer создаёт Tailer для файла path; maxBytes ограничивает первое чтение хвостом файла.
+func NewTailer(path string, maxBytes int64) *Tailer {
+	return &Tailer{path: path, maxBytes: maxBytes}
+}
+
+// Read возвращает новые полные строки с момента предыдущего вызова.
+// Отсутствующий файл не считается ошибкой: возвращается пустая строка.
+// Если файл усечён или заменён ротацией, чтение начинается заново.
+func (t *Tailer) Read() (string, error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("open %s: %w", t.path, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("stat %s: %w", t.path, err)
+	}
+	size := info.Size()
+	skipPartial := false
+	if !t.started || size < t.offset {
+		t.started = true
+		t.pending = nil
+		t.offset = 0
+		if t.maxBytes > 0 && size > t.maxBytes {
+			t.offset = size - t.maxBytes
+			skipPartial = true
+		}
+	}
+	if size == t.offset {
+		return "", nil
+	}
+	if _, err := file.Seek(t.offset, io.SeekStart); err != nil {
+		return "", fmt.Errorf("seek %s: %w", t.path, err)
+	}
+	chunk, err := io.ReadAll(io.LimitReader(file, size-t.offset))
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", t.path, err)
+	}
+	t.offset += int64(len(chunk))
+	if skipPartial {
+		// начали с середины файла: первая строка, скорее всего, обрезана
+		idx := bytes.IndexByte(chunk, '\n')
+		if idx == -1 {
+			t.pending = append(t.pending, chunk...)
+			return "", nil
+		}
+		chunk = chunk[idx+1:]
+	}
+	data := append(t.pending, chunk...)
+	last := bytes.LastIndexByte(data, '\n')
+	if last == -1 {
+		t.pending = data
+		return "", nil
+	}
+	t.pending = append([]byte(nil), data[last+1:]...)
+	return strings.ToValidUTF8(string(data[:last+1]), "�"), nil
+}
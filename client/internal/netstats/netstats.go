@@ -0,0 +1,19 @@
+// Package netstats читает счётчики трафика сетевых интерфейсов.
+package netstats
+
+import (
+	"errors"
+
+	"customvpn/client/internal/state"
+)
+
+// ErrInterfaceNotFound возвращается, если интерфейс с указанным индексом исчез.
+var ErrInterfaceNotFound = errors.New("network interface not found")
+
+// Read возвращает текущие счётчики байт интерфейса с индексом index.
+func Read(index int) (state.InterfaceCounters, error) {
+	if index <= 0 {
+		return state.InterfaceCounters{}, ErrInterfaceNotFound
+	}
+	return readCounters(index)
+}
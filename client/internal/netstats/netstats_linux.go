@@ -0,0 +1,52 @@
+//go:build linux
+
+package netstats
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+func readCounters(index int) (state.InterfaceCounters, error) {
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return state.InterfaceCounters{}, fmt.Errorf("%w: index=%d", ErrInterfaceNotFound, index)
+	}
+	dir := filepath.Join("/sys/class/net", iface.Name, "statistics")
+	in, err := readCounter(filepath.Join(dir, "rx_bytes"))
+	if err != nil {
+		return state.InterfaceCounters{}, err
+	}
+	out, err := readCounter(filepath.Join(dir, "tx_bytes"))
+	if err != nil {
+		return state.InterfaceCounters{}, err
+	}
+	return state.InterfaceCounters{
+		InterfaceIndex: index,
+		BytesIn:        in,
+		BytesOut:       out,
+		SampledAt:      time.Now(),
+	}, nil
+}
+
+func readCounter(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("%w: %s", ErrInterfaceNotFound, path)
+		}
+		return 0, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return value, nil
+}
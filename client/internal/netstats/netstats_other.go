@@ -0,0 +1,13 @@
+//go:build !windows && !linux
+
+package netstats
+
+import (
+	"fmt"
+
+	"customvpn/client/internal/state"
+)
+
+func readCounters(_ int) (state.InterfaceCounters, error) {
+	return state.InterfaceCounters{}, fmt.Errorf("interface counters are not implemented on this platform")
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package netstats
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"customvpn/client/internal/state"
+)
+
+func readCounters(index int) (state.InterfaceCounters, error) {
+	row := windows.MibIfRow2{InterfaceIndex: uint32(index)}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormal, &row); err != nil {
+		if errors.Is(err, windows.ERROR_FILE_NOT_FOUND) || errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return state.InterfaceCounters{}, fmt.Errorf("%w: index=%d", ErrInterfaceNotFound, index)
+		}
+		return state.InterfaceCounters{}, fmt.Errorf("GetIfEntry2: %w", err)
+	}
+	return state.InterfaceCounters{
+		InterfaceIndex: index,
+		BytesIn:        row.InOctets,
+		BytesOut:       row.OutOctets,
+		SampledAt:      time.Now(),
+	}, nil
+}
@@ -22,6 +22,9 @@ type handle struct {
 // ExitCallback вызывается при завершении процесса.
 type ExitCallback func(name state.ProcessName, exitCode int, reason string)
 
+// LineCallback получает каждую строку stdout/stderr процесса.
+type LineCallback func(name state.ProcessName, line string)
+
 // Launcher отвечает за запуск и остановку процессов Core.
 type Launcher struct {
 	logger *logging.Logger
@@ -43,7 +46,8 @@ func (l *Launcher) SetExitCallback(cb ExitCallback) {
 }
 
 // Start запускает процесс с заданными аргументами и перенаправлением вывода в файл.
-func (l *Launcher) Start(name state.ProcessName, binary string, args []string, logFile string) (*state.ProcessRecord, error) {
+// Если onLine задан, каждая строка вывода дополнительно передаётся в него.
+func (l *Launcher) Start(name state.ProcessName, binary string, args []string, logFile string, onLine LineCallback) (*state.ProcessRecord, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	if binary == "" {
@@ -62,8 +66,12 @@ func (l *Launcher) Start(name state.ProcessName, binary string, args []string, l
 	if err != nil {
 		return nil, err
 	}
-	cmd.Stdout = logWriter
-	cmd.Stderr = logWriter
+	var output io.Writer = logWriter
+	if onLine != nil {
+		output = io.MultiWriter(logWriter, newLineWriter(func(line string) { onLine(name, line) }))
+	}
+	cmd.Stdout = output
+	cmd.Stderr = output
 	if err := cmd.Start(); err != nil {
 		logWriter.Close()
 		return nil, fmt.Errorf("start %s: %w", name, err)
@@ -0,0 +1,48 @@
+package process
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// maxPendingLine ограничивает накопление строки без перевода строки.
+const maxPendingLine = 4096
+
+// lineWriter разбивает поток на строки и передаёт их в callback.
+// Stdout и stderr пишут в один lineWriter параллельно, поэтому запись защищена мьютексом.
+type lineWriter struct {
+	mu      sync.Mutex
+	pending []byte
+	emit    func(line string)
+}
+
+func newLineWriter(emit func(line string)) *lineWriter {
+	return &lineWriter{emit: emit}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx == -1 {
+			break
+		}
+		w.emitLine(w.pending[:idx])
+		w.pending = w.pending[idx+1:]
+	}
+	if len(w.pending) > maxPendingLine {
+		w.emitLine(w.pending)
+		w.pending = nil
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) emitLine(raw []byte) {
+	line := strings.TrimSpace(strings.ToValidUTF8(string(raw), "�"))
+	if line != "" {
+		w.emit(line)
+	}
+}
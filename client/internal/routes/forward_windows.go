@@ -0,0 +1,125 @@
+//go:build windows
+
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"customvpn/client/internal/state"
+)
+
+var (
+	modiphlpapi            = windows.NewLazySystemDLL("iphlpapi.dll")
+	procGetIPForwardTable2 = modiphlpapi.NewProc("GetIpForwardTable2")
+	procFreeMibTable       = modiphlpapi.NewProc("FreeMibTable")
+)
+
+// sockaddrInet повторяет SOCKADDR_INET: семейство и адрес sockaddr_in/sockaddr_in6.
+type sockaddrInet struct {
+	Family uint16
+	Data   [26]byte
+}
+
+func (s *sockaddrInet) ip() net.IP {
+	switch s.Family {
+	case windows.AF_INET:
+		// sin_port (2 байта), затем sin_addr
+		return net.IP(append([]byte(nil), s.Data[2:6]...))
+	case windows.AF_INET6:
+		// sin6_port (2), sin6_flowinfo (4), затем sin6_addr
+		return net.IP(append([]byte(nil), s.Data[6:22]...))
+	}
+	return nil
+}
+
+// mibIPForwardRow2 повторяет MIB_IPFORWARD_ROW2 (104 байта).
+type mibIPForwardRow2 struct {
+	InterfaceLuid        uint64
+	InterfaceIndex       uint32
+	DestinationPrefix    sockaddrInet
+	PrefixLength         uint8
+	_                    [3]byte
+	NextHop              sockaddrInet
+	SitePrefixLength     uint8
+	ValidLifetime        uint32
+	PreferredLifetime    uint32
+	Metric               uint32
+	Protocol             uint32
+	Loopback             uint8
+	AutoconfigureAddress uint8
+	Publish              uint8
+	Immortal             uint8
+	Age                  uint32
+	Origin               uint32
+}
+
+// forwardTable возвращает копию таблицы маршрутизации GetIpForwardTable2 для семейства адресов.
+func forwardTable(family uint32) ([]mibIPForwardRow2, error) {
+	var table unsafe.Pointer
+	ret, _, _ := procGetIPForwardTable2.Call(uintptr(family), uintptr(unsafe.Pointer(&table)))
+	if ret != 0 {
+		return nil, fmt.Errorf("GetIpForwardTable2: %w", windows.Errno(ret))
+	}
+	defer procFreeMibTable.Call(uintptr(table))
+	// MIB_IPFORWARD_TABLE2: ULONG NumEntries, затем массив строк с выравниванием 8
+	count := *(*uint32)(table)
+	rows := unsafe.Slice((*mibIPForwardRow2)(unsafe.Add(table, 8)), count)
+	return append([]mibIPForwardRow2(nil), rows...), nil
+}
+
+func addressFamily(ip net.IP) uint32 {
+	if ip.To4() != nil {
+		return windows.AF_INET
+	}
+	return windows.AF_INET6
+}
+
+// routeExists ищет маршрут в таблице GetIpForwardTable2.
+func routeExists(_ context.Context, destination *net.IPNet, gateway net.IP, ifaceIndex int) (bool, error) {
+	rows, err := forwardTable(addressFamily(destination.IP))
+	if err != nil {
+		return false, err
+	}
+	prefixLen, _ := destination.Mask.Size()
+	for i := range rows {
+		row := &rows[i]
+		if int(row.PrefixLength) != prefixLen || !destination.IP.Equal(row.DestinationPrefix.ip()) {
+			continue
+		}
+		if ifaceIndex > 0 && int(row.InterfaceIndex) != ifaceIndex {
+			continue
+		}
+		if sameGateway(gateway, row.NextHop.ip()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listRoutesVia возвращает маршруты, у которых следующий узел равен gateway.
+func listRoutesVia(_ context.Context, gateway net.IP) ([]state.RouteRecord, error) {
+	rows, err := forwardTable(addressFamily(gateway))
+	if err != nil {
+		return nil, err
+	}
+	var records []state.RouteRecord
+	for i := range rows {
+		row := &rows[i]
+		if !gateway.Equal(row.NextHop.ip()) {
+			continue
+		}
+		prefix := &net.IPNet{IP: row.DestinationPrefix.ip(), Mask: net.CIDRMask(int(row.PrefixLength), len(row.DestinationPrefix.ip())*8)}
+		records = append(records, state.RouteRecord{
+			Destination:    prefix.String(),
+			Gateway:        gateway.String(),
+			InterfaceIndex: int(row.InterfaceIndex),
+			Metric:         int(row.Metric),
+		})
+	}
+	return records, nil
+}
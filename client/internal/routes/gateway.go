@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"sort"
+
+	"customvpn/client/internal/state"
+)
+
+// appendGateway добавляет шлюз, пропуская повтор той же пары адрес/интерфейс.
+func appendGateway(gateways []*state.GatewayInfo, info *state.GatewayInfo) []*state.GatewayInfo {
+	for _, existing := range gateways {
+		if existing.IP == info.IP && existing.InterfaceIndex == info.InterfaceIndex {
+			return gateways
+		}
+	}
+	return append(gateways, info)
+}
+
+// sortGateways упорядочивает шлюзы по метрике, при равенстве — по индексу интерфейса.
+func sortGateways(gateways []*state.GatewayInfo) {
+	sort.SliceStable(gateways, func(i, j int) bool {
+		if gateways[i].Metric != gateways[j].Metric {
+			return gateways[i].Metric < gateways[j].Metric
+		}
+		return gateways[i].InterfaceIndex < gateways[j].InterfaceIndex
+	})
+}
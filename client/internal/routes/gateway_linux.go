@@ -0,0 +1,154 @@
+//go:build linux
+
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"customvpn/client/internal/state"
+)
+
+const ipCommandTimeout = 5 * time.Second
+
+// DetectDefaultGateway ищет единственный маршрут по умолчанию (IPv4) через `ip route`.
+func DetectDefaultGateway() (*state.GatewayInfo, error) {
+	gateways, err := DetectDefaultGateways()
+	if err != nil {
+		return nil, err
+	}
+	if len(gateways) > 1 {
+		return nil, fmt.Errorf("multiple default gateways detected")
+	}
+	return gateways[0], nil
+}
+
+// DetectDefaultGateways возвращает все маршруты по умолчанию (IPv4), упорядоченные по метрике.
+func DetectDefaultGateways() ([]*state.GatewayInfo, error) {
+	gateways, err := detectDefaultRoutes("-4")
+	if err != nil {
+		return nil, err
+	}
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("default gateway not found")
+	}
+	sortGateways(gateways)
+	return gateways, nil
+}
+
+// DetectDefaultGatewayV6 ищет маршрут по умолчанию IPv6.
+// Если IPv6 шлюза нет, возвращает nil без ошибки.
+func DetectDefaultGatewayV6() (*state.GatewayInfo, error) {
+	return detectDefaultRoute("-6")
+}
+
+// DetectGatewayForIP находит интерфейс, через который доступен указанный IPv4 адрес.
+func DetectGatewayForIP(ip net.IP) (*state.GatewayInfo, error) {
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("target ip must be IPv4")
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces: %w", err)
+	}
+	var match *state.GatewayInfo
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil || !ipNet.Contains(ip.To4()) {
+				continue
+			}
+			info := &state.GatewayInfo{
+				IP:             ip.String(),
+				InterfaceIndex: iface.Index,
+				InterfaceName:  iface.Name,
+				Metric:         1,
+			}
+			if match == nil {
+				match = info
+				continue
+			}
+			if match.InterfaceIndex != info.InterfaceIndex {
+				return nil, fmt.Errorf("multiple interfaces match target ip")
+			}
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no interface found for %s", ip.String())
+	}
+	return match, nil
+}
+
+func detectDefaultRoute(family string) (*state.GatewayInfo, error) {
+	gateways, err := detectDefaultRoutes(family)
+	if err != nil {
+		return nil, err
+	}
+	switch len(gateways) {
+	case 0:
+		return nil, nil
+	case 1:
+		return gateways[0], nil
+	}
+	return nil, fmt.Errorf("multiple default gateways detected")
+}
+
+func detectDefaultRoutes(family string) ([]*state.GatewayInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ipCommandTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "ip", family, "route", "show", "default").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ip %s route show default: %w: %s", family, err, strings.TrimSpace(string(output)))
+	}
+	var gateways []*state.GatewayInfo
+	for _, line := range strings.Split(string(output), "\n") {
+		info, ok := parseDefaultRoute(line)
+		if !ok {
+			continue
+		}
+		gateways = appendGateway(gateways, info)
+	}
+	return gateways, nil
+}
+
+// parseDefaultRoute разбирает строку вида
+// "default via 192.168.1.1 dev eth0 proto dhcp metric 100".
+// Маршруты без шлюза (например, через TUN) пропускаются.
+func parseDefaultRoute(line string) (*state.GatewayInfo, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "default" {
+		return nil, false
+	}
+	info := &state.GatewayInfo{Metric: 1}
+	for i := 1; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "via":
+			info.IP = fields[i+1]
+		case "dev":
+			info.InterfaceName = fields[i+1]
+		case "metric":
+			if metric, err := strconv.Atoi(fields[i+1]); err == nil && metric > 0 {
+				info.Metric = metric
+			}
+		}
+	}
+	if info.IP == "" || info.InterfaceName == "" {
+		return nil, false
+	}
+	if iface, err := net.InterfaceByName(info.InterfaceName); err == nil {
+		info.InterfaceIndex = iface.Index
+	}
+	return info, true
+}
@@ -1,4 +1,4 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package routes
 
@@ -9,11 +9,19 @@ import (
 	"customvpn/client/internal/state"
 )
 
-// DetectDefaultGateway возвращает ошибку на не-Windows платформах.
+// DetectDefaultGateway возвращает ошибку на платформах без реализации.
 func DetectDefaultGateway() (*state.GatewayInfo, error) {
-	return nil, fmt.Errorf("DetectDefaultGateway is only implemented on Windows")
+	return nil, fmt.Errorf("DetectDefaultGateway is not implemented on this platform")
+}
+
+func DetectDefaultGateways() ([]*state.GatewayInfo, error) {
+	return nil, fmt.Errorf("DetectDefaultGateways is not implemented on this platform")
+}
+
+func DetectDefaultGatewayV6() (*state.GatewayInfo, error) {
+	return nil, fmt.Errorf("DetectDefaultGatewayV6 is not implemented on this platform")
 }
 
 func DetectGatewayForIP(_ net.IP) (*state.GatewayInfo, error) {
-	return nil, fmt.Errorf("DetectGatewayForIP is only implemented on Windows")
+	return nil, fmt.Errorf("DetectGatewayForIP is not implemented on this platform")
 }
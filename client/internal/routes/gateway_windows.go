@@ -16,6 +16,19 @@ const gaaFlagIncludeGateways = 0x0080
 
 // DetectDefaultGateway ищет единственный маршрут по умолчанию (IPv4) на Windows.
 func DetectDefaultGateway() (*state.GatewayInfo, error) {
+	gateways, err := DetectDefaultGateways()
+	if err != nil {
+		return nil, err
+	}
+	if len(gateways) > 1 {
+		return nil, fmt.Errorf("multiple default gateways detected")
+	}
+	return gateways[0], nil
+}
+
+// DetectDefaultGateways возвращает все маршруты по умолчанию (IPv4) на активных адаптерах,
+// упорядоченные по возрастанию метрики.
+func DetectDefaultGateways() ([]*state.GatewayInfo, error) {
 	flags := uint32(gaaFlagIncludeGateways)
 	var size uint32
 	if err := windows.GetAdaptersAddresses(windows.AF_INET, flags, 0, nil, &size); err != windows.ERROR_BUFFER_OVERFLOW {
@@ -26,7 +39,7 @@ func DetectDefaultGateway() (*state.GatewayInfo, error) {
 	if err := windows.GetAdaptersAddresses(windows.AF_INET, flags, 0, addresses, &size); err != nil {
 		return nil, fmt.Errorf("GetAdaptersAddresses: %w", err)
 	}
-	var gateway *state.GatewayInfo
+	var gateways []*state.GatewayInfo
 	for adapter := addresses; adapter != nil; adapter = adapter.Next {
 		if adapter.OperStatus != windows.IfOperStatusUp {
 			continue
@@ -53,18 +66,62 @@ func DetectDefaultGateway() (*state.GatewayInfo, error) {
 			if info.Metric <= 0 {
 				info.Metric = 1
 			}
+			gateways = appendGateway(gateways, info)
+		}
+	}
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("default gateway not found")
+	}
+	sortGateways(gateways)
+	return gateways, nil
+}
+
+// DetectDefaultGatewayV6 ищет маршрут по умолчанию IPv6 на Windows.
+// Если IPv6 шлюза нет, возвращает nil без ошибки.
+func DetectDefaultGatewayV6() (*state.GatewayInfo, error) {
+	flags := uint32(gaaFlagIncludeGateways)
+	var size uint32
+	if err := windows.GetAdaptersAddresses(windows.AF_INET6, flags, 0, nil, &size); err != windows.ERROR_BUFFER_OVERFLOW {
+		return nil, fmt.Errorf("GetAdaptersAddresses sizing: %w", err)
+	}
+	buffer := make([]byte, size)
+	addresses := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buffer[0]))
+	if err := windows.GetAdaptersAddresses(windows.AF_INET6, flags, 0, addresses, &size); err != nil {
+		return nil, fmt.Errorf("GetAdaptersAddresses: %w", err)
+	}
+	var gateway *state.GatewayInfo
+	for adapter := addresses; adapter != nil; adapter = adapter.Next {
+		if adapter.OperStatus != windows.IfOperStatusUp {
+			continue
+		}
+		for gw := adapter.FirstGatewayAddress; gw != nil; gw = gw.Next {
+			raw := (*windows.RawSockaddrAny)(unsafe.Pointer(gw.Address.Sockaddr))
+			if raw == nil || raw.Addr.Family != windows.AF_INET6 {
+				continue
+			}
+			sa6 := (*windows.RawSockaddrInet6)(unsafe.Pointer(gw.Address.Sockaddr))
+			ip := net.IP(sa6.Addr[:])
+			if ip.IsUnspecified() {
+				continue
+			}
+			info := &state.GatewayInfo{
+				IP:             ip.String(),
+				InterfaceIndex: int(adapter.Ipv6IfIndex),
+				InterfaceName:  windows.UTF16PtrToString(adapter.FriendlyName),
+				Metric:         int(adapter.Ipv6Metric),
+			}
+			if info.Metric <= 0 {
+				info.Metric = 1
+			}
 			if gateway == nil {
 				gateway = info
 				continue
 			}
 			if gateway.IP != info.IP || gateway.InterfaceIndex != info.InterfaceIndex {
-				return nil, fmt.Errorf("multiple default gateways detected")
+				return nil, fmt.Errorf("multiple IPv6 default gateways detected")
 			}
 		}
 	}
-	if gateway == nil {
-		return nil, fmt.Errorf("default gateway not found")
-	}
 	return gateway, nil
 }
 
@@ -2,49 +2,68 @@ package routes
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os/exec"
-	"strconv"
 	"strings"
 	"time"
 
 	"customvpn/client/internal/logging"
 	"customvpn/client/internal/state"
-
-	"golang.org/x/text/encoding/charmap"
 )
 
-// Manager управляет добавлением и удалением маршрутов через системную утилиту route.exe.
+// ErrRouteNotFound возвращается VerifyRoute, если маршрута нет в таблице маршрутизации,
+// и RemoveRoute, если удаляемый маршрут уже отсутствует.
+var ErrRouteNotFound = errors.New("route is missing from the routing table")
+
+// routeNotFoundMarkers — признаки отсутствующего маршрута в выводе route.exe (английская
+// и русская Windows), ip (ESRCH) и route на BSD/macOS. Сравнение без учёта регистра.
+var routeNotFoundMarkers = []string{
+	"element not found",
+	"элемент не найден",
+	"no such process",
+	"not in table",
+}
+
+// Manager управляет добавлением и удалением маршрутов через системную утилиту
+// (route.exe на Windows, ip на Linux). Аргументы команд строят платформенные файлы.
 type Manager struct {
 	logger   *logging.Logger
 	routeExe string
+	verify   bool
+}
+
+// Options задаёт необязательное поведение менеджера маршрутов.
+type Options struct {
+	// VerifyRoutes включает проверку таблицы маршрутизации после AddCIDRRoute:
+	// на некоторых системах route.exe завершается успешно, не добавив маршрут.
+	VerifyRoutes bool
 }
 
 // NewManager создаёт новый экземпляр менеджера маршрутов.
-func NewManager(logger *logging.Logger) *Manager {
+func NewManager(logger *logging.Logger, opts Options) *Manager {
 	return &Manager{
 		logger:   logger,
-		routeExe: "route.exe",
+		routeExe: routeCommand,
+		verify:   opts.VerifyRoutes,
 	}
 }
 
 // AddHostRoute добавляет host-маршрут до конкретного IPv4-адреса.
-func (m *Manager) AddHostRoute(ctx context.Context, dest net.IP, gateway *state.GatewayInfo, kind state.RouteKind) (state.RouteRecord, error) {
+// metric больше 0 заменяет метрику шлюза.
+func (m *Manager) AddHostRoute(ctx context.Context, dest net.IP, gateway *state.GatewayInfo, kind state.RouteKind, metric int) (state.RouteRecord, error) {
 	if dest == nil || dest.To4() == nil {
 		return state.RouteRecord{}, fmt.Errorf("destination must be IPv4")
 	}
 	if gateway == nil || gateway.IP == "" {
 		return state.RouteRecord{}, fmt.Errorf("gateway is not defined")
 	}
-	mask := "255.255.255.255"
-	metric := gateway.Metric
-	if metric <= 0 {
-		metric = 1
-	}
-	args := []string{"ADD", dest.String(), "MASK", mask, gateway.IP, "METRIC", strconv.Itoa(metric)}
-	if gateway.InterfaceIndex > 0 {
-		args = append(args, "IF", strconv.Itoa(gateway.InterfaceIndex))
+	metric = routeMetric(gateway, metric)
+	network := &net.IPNet{IP: dest.To4(), Mask: net.CIDRMask(32, 32)}
+	args, err := addRouteArgs(network, gateway, metric)
+	if err != nil {
+		return state.RouteRecord{}, err
 	}
 	if err := m.runRouteCommand(ctx, args...); err != nil {
 		return state.RouteRecord{}, err
@@ -63,7 +82,8 @@ func (m *Manager) AddHostRoute(ctx context.Context, dest net.IP, gateway *state.
 }
 
 // AddCIDRRoute добавляет маршрут до подсети в формате CIDR.
-func (m *Manager) AddCIDRRoute(ctx context.Context, cidr string, gateway *state.GatewayInfo, kind state.RouteKind) (state.RouteRecord, error) {
+// metric больше 0 заменяет метрику шлюза.
+func (m *Manager) AddCIDRRoute(ctx context.Context, cidr string, gateway *state.GatewayInfo, kind state.RouteKind, metric int) (state.RouteRecord, error) {
 	if cidr == "" {
 		return state.RouteRecord{}, fmt.Errorf("cidr is empty")
 	}
@@ -74,19 +94,11 @@ func (m *Manager) AddCIDRRoute(ctx context.Context, cidr string, gateway *state.
 	if err != nil {
 		return state.RouteRecord{}, fmt.Errorf("parse cidr %s: %w", cidr, err)
 	}
-	ip := network.IP.To4()
-	if ip == nil {
-		return state.RouteRecord{}, fmt.Errorf("cidr %s is not IPv4", cidr)
-	}
-	mask, err := maskToIPv4String(network.Mask)
+	metric = routeMetric(gateway, metric)
+	args, err := addRouteArgs(network, gateway, metric)
 	if err != nil {
 		return state.RouteRecord{}, err
 	}
-	metric := gatewayMetric(gateway)
-	args := []string{"ADD", ip.String(), "MASK", mask, gateway.IP, "METRIC", strconv.Itoa(metric)}
-	if gateway != nil && gateway.InterfaceIndex > 0 {
-		args = append(args, "IF", strconv.Itoa(gateway.InterfaceIndex))
-	}
 	if err := m.runRouteCommand(ctx, args...); err != nil {
 		return state.RouteRecord{}, err
 	}
@@ -100,39 +112,68 @@ func (m *Manager) AddCIDRRoute(ctx context.Context, cidr string, gateway *state.
 		CreatedAt:      time.Now(),
 		Active:         true,
 	}
+	if m.verify {
+		if err := m.VerifyRoute(ctx, record); err != nil {
+			return state.RouteRecord{}, err
+		}
+	}
 	return record, nil
 }
 
+// VerifyRoute проверяет, что маршрут с назначением и шлюзом записи есть в таблице маршрутизации.
+// Если в записи указан индекс интерфейса, он тоже должен совпасть.
+func (m *Manager) VerifyRoute(ctx context.Context, record state.RouteRecord) error {
+	destination, err := parseDestination(record.Destination)
+	if err != nil {
+		return err
+	}
+	var gateway net.IP
+	if record.Gateway != "" {
+		gateway = net.ParseIP(record.Gateway)
+		if gateway == nil {
+			return fmt.Errorf("invalid route gateway %q", record.Gateway)
+		}
+	}
+	found, err := routeExists(ctx, destination, gateway, record.InterfaceIndex)
+	if err != nil {
+		return fmt.Errorf("verify route %s: %w", record.Destination, err)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s via %s", ErrRouteNotFound, record.Destination, record.Gateway)
+	}
+	if m.logger != nil {
+		m.logger.Debugf("route %s via %s verified", record.Destination, record.Gateway)
+	}
+	return nil
+}
+
 // RemoveRoute удаляет ранее добавленный маршрут.
 func (m *Manager) RemoveRoute(ctx context.Context, record state.RouteRecord) error {
-	destination := record.Destination
-	if destination == "" {
+	if record.Destination == "" {
 		return fmt.Errorf("route destination is empty")
 	}
-	if (destination == "0.0.0.0" || destination == "0.0.0.0/0") && record.Gateway == "" {
+	if isDefaultDestination(record.Destination) && record.Gateway == "" {
 		return fmt.Errorf("refusing to delete default route without gateway")
 	}
-	if idx := strings.Index(destination, "/"); idx != -1 {
-		destination = destination[:idx]
-	}
-	args := []string{"DELETE", destination}
-	if record.Destination != "" {
-		if _, network, err := net.ParseCIDR(record.Destination); err == nil {
-			if mask, err := maskToIPv4String(network.Mask); err == nil {
-				args = append(args, "MASK", mask)
-			}
-		}
-	}
-	if record.Gateway != "" {
-		args = append(args, record.Gateway)
+	args, err := deleteRouteArgs(record)
+	if err != nil {
+		return err
 	}
-	if record.InterfaceIndex > 0 {
-		args = append(args, "IF", strconv.Itoa(record.InterfaceIndex))
+	output, err := m.routeCommandOutput(ctx, args...)
+	if err != nil && isRouteNotFoundOutput(output) {
+		// маршрут уже удалён (например, вместе с интерфейсом туннеля): для очистки это не ошибка
+		return fmt.Errorf("%w: %s via %s", ErrRouteNotFound, record.Destination, record.Gateway)
 	}
-	return m.runRouteCommand(ctx, args...)
+	return err
 }
 
 func (m *Manager) runRouteCommand(ctx context.Context, args ...string) error {
+	_, err := m.routeCommandOutput(ctx, args...)
+	return err
+}
+
+// routeCommandOutput выполняет команду маршрутизации и возвращает её вывод, перекодированный из OEM.
+func (m *Manager) routeCommandOutput(ctx context.Context, args ...string) (string, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -143,32 +184,81 @@ func (m *Manager) runRouteCommand(ctx context.Context, args ...string) error {
 	decoded := decodeOEMText(trimmed)
 	if err != nil {
 		if decoded != "" {
-			return fmt.Errorf("route %s failed: %s", strings.Join(args, " "), decoded)
+			return decoded, fmt.Errorf("route %s failed: %s", strings.Join(args, " "), decoded)
 		}
-		return fmt.Errorf("route %s failed: %w", strings.Join(args, " "), err)
+		return decoded, fmt.Errorf("route %s failed: %w", strings.Join(args, " "), err)
 	}
 	if m.logger != nil && decoded != "" {
 		m.logger.Debugf("route %s -> %s", strings.Join(args, " "), decoded)
 	}
-	return nil
+	return decoded, nil
 }
 
-func decodeOEMText(text string) string {
-	if text == "" {
-		return ""
+// isRouteNotFoundOutput сообщает, что команда удаления не нашла маршрут.
+func isRouteNotFoundOutput(output string) bool {
+	output = strings.ToLower(output)
+	for _, marker := range routeNotFoundMarkers {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutesViaGateway возвращает маршруты системной таблицы, ведущие через gateway.
+// Используется при очистке после аварийного завершения, когда реестр маршрутов пуст.
+// Маршруты по умолчанию в результат не включаются.
+func (m *Manager) RoutesViaGateway(ctx context.Context, gateway string, kind state.RouteKind) ([]state.RouteRecord, error) {
+	ip := net.ParseIP(strings.TrimSpace(gateway))
+	if ip == nil || ip.IsUnspecified() {
+		return nil, fmt.Errorf("invalid gateway %q", gateway)
 	}
-	decoded, err := charmap.CodePage866.NewDecoder().String(text)
+	records, err := listRoutesVia(ctx, ip)
 	if err != nil {
-		return text
+		return nil, err
+	}
+	result := make([]state.RouteRecord, 0, len(records))
+	for _, record := range records {
+		if isDefaultDestination(record.Destination) {
+			continue
+		}
+		record.ID = fmt.Sprintf("%s-%s-scan", kind, record.Destination)
+		record.Kind = kind
+		record.Active = true
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// parseDestination разбирает назначение маршрута: CIDR или одиночный адрес (host-маршрут).
+func parseDestination(destination string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(destination); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(destination)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid route destination %q", destination)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
 	}
-	return decoded
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
 }
 
-func maskToIPv4String(mask net.IPMask) (string, error) {
-	if len(mask) != net.IPv4len {
-		return "", fmt.Errorf("only IPv4 masks are supported")
+// sameGateway сравнивает шлюзы; пустой или нулевой адрес означает маршрут on-link.
+func sameGateway(expected, actual net.IP) bool {
+	if expected == nil || expected.IsUnspecified() {
+		return actual == nil || actual.IsUnspecified()
 	}
-	return net.IP(mask).String(), nil
+	return expected.Equal(actual)
+}
+
+func isDefaultDestination(destination string) bool {
+	switch destination {
+	case "0.0.0.0", "0.0.0.0/0", "::", "::/0":
+		return true
+	}
+	return false
 }
 
 func gatewayMetric(info *state.GatewayInfo) int {
@@ -178,6 +268,14 @@ func gatewayMetric(info *state.GatewayInfo) int {
 	return info.Metric
 }
 
+// routeMetric возвращает override, если он задан (Profile.RouteMetric), иначе метрику шлюза.
+func routeMetric(info *state.GatewayInfo, override int) int {
+	if override > 0 {
+		return override
+	}
+	return gatewayMetric(info)
+}
+
 func gatewayIP(info *state.GatewayInfo) string {
 	if info == nil {
 		return ""
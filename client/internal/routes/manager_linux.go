@@ -0,0 +1,166 @@
+//go:build linux
+
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"customvpn/client/internal/state"
+)
+
+const routeCommand = "ip"
+
+func applyRouteCommandAttributes(_ *exec.Cmd) {}
+
+func addRouteArgs(network *net.IPNet, gateway *state.GatewayInfo, metric int) ([]string, error) {
+	args := []string{familyFlag(network.IP), "route", "add", network.String()}
+	args = append(args, nexthopArgs(gateway.IP, gateway.InterfaceName, gateway.InterfaceIndex)...)
+	args = append(args, "metric", strconv.Itoa(metric))
+	return args, nil
+}
+
+func deleteRouteArgs(record state.RouteRecord) ([]string, error) {
+	_, network, err := net.ParseCIDR(record.Destination)
+	if err != nil {
+		ip := net.ParseIP(record.Destination)
+		if ip == nil {
+			return nil, fmt.Errorf("parse route destination %s: %w", record.Destination, err)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	args := []string{familyFlag(network.IP), "route", "del", network.String()}
+	args = append(args, nexthopArgs(record.Gateway, "", record.InterfaceIndex)...)
+	if record.Metric > 0 {
+		args = append(args, "metric", strconv.Itoa(record.Metric))
+	}
+	return args, nil
+}
+
+// nexthopArgs строит "via <gw> dev <iface>"; шлюз "::" или "0.0.0.0" означает on-link маршрут.
+func nexthopArgs(gatewayIP, ifaceName string, ifaceIndex int) []string {
+	var args []string
+	if ip := net.ParseIP(gatewayIP); ip != nil && !ip.IsUnspecified() {
+		args = append(args, "via", ip.String())
+	}
+	if ifaceName == "" && ifaceIndex > 0 {
+		if iface, err := net.InterfaceByIndex(ifaceIndex); err == nil {
+			ifaceName = iface.Name
+		}
+	}
+	if ifaceName != "" {
+		args = append(args, "dev", ifaceName)
+	}
+	return args
+}
+
+// routeExists ищет маршрут через `ip route show exact <cidr>`.
+func routeExists(ctx context.Context, destination *net.IPNet, gateway net.IP, ifaceIndex int) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	output, err := exec.CommandContext(ctx, routeCommand, familyFlag(destination.IP), "route", "show", "exact", destination.String()).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("ip route show exact %s: %w: %s", destination.String(), err, strings.TrimSpace(string(output)))
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if matchRouteLine(line, gateway, ifaceIndex) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchRouteLine проверяет шлюз и интерфейс в строке вида "10.0.0.0/8 via 192.168.1.1 dev eth0 metric 5".
+func matchRouteLine(line string, gateway net.IP, ifaceIndex int) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	var via net.IP
+	var dev string
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "via":
+			via = net.ParseIP(fields[i+1])
+		case "dev":
+			dev = fields[i+1]
+		}
+	}
+	if !sameGateway(gateway, via) {
+		return false
+	}
+	if ifaceIndex > 0 && dev != "" {
+		if iface, err := net.InterfaceByName(dev); err == nil && iface.Index != ifaceIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// listRoutesVia разбирает `ip route show` и возвращает маршруты через gateway.
+func listRoutesVia(ctx context.Context, gateway net.IP) ([]state.RouteRecord, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	output, err := exec.CommandContext(ctx, routeCommand, familyFlag(gateway), "route", "show").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ip route show: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	var records []state.RouteRecord
+	for _, line := range strings.Split(string(output), "\n") {
+		record, ok := parseRouteVia(line, gateway)
+		if ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// parseRouteVia разбирает строку "10.0.0.0/8 via 100.64.127.1 dev tun0 metric 5".
+func parseRouteVia(line string, gateway net.IP) (state.RouteRecord, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] == "default" {
+		return state.RouteRecord{}, false
+	}
+	record := state.RouteRecord{Destination: fields[0]}
+	matched := false
+	for i := 1; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "via":
+			matched = gateway.Equal(net.ParseIP(fields[i+1]))
+		case "dev":
+			if iface, err := net.InterfaceByName(fields[i+1]); err == nil {
+				record.InterfaceIndex = iface.Index
+			}
+		case "metric":
+			if metric, err := strconv.Atoi(fields[i+1]); err == nil {
+				record.Metric = metric
+			}
+		}
+	}
+	if !matched {
+		return state.RouteRecord{}, false
+	}
+	record.Gateway = gateway.String()
+	return record, true
+}
+
+func familyFlag(ip net.IP) string {
+	if ip.To4() != nil {
+		return "-4"
+	}
+	return "-6"
+}
+
+func decodeOEMText(text string) string {
+	return text
+}
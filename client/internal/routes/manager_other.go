@@ -1,7 +1,36 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package routes
 
-import "os/exec"
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+
+	"customvpn/client/internal/state"
+)
+
+const routeCommand = "route"
 
 func applyRouteCommandAttributes(_ *exec.Cmd) {}
+
+func addRouteArgs(_ *net.IPNet, _ *state.GatewayInfo, _ int) ([]string, error) {
+	return nil, fmt.Errorf("route manager is not implemented on this platform")
+}
+
+func deleteRouteArgs(_ state.RouteRecord) ([]string, error) {
+	return nil, fmt.Errorf("route manager is not implemented on this platform")
+}
+
+func routeExists(_ context.Context, _ *net.IPNet, _ net.IP, _ int) (bool, error) {
+	return false, fmt.Errorf("route verification is not implemented on this platform")
+}
+
+func listRoutesVia(_ context.Context, _ net.IP) ([]state.RouteRecord, error) {
+	return nil, fmt.Errorf("route listing is not implemented on this platform")
+}
+
+func decodeOEMText(text string) string {
+	return text
+}
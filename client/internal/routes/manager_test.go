@@ -0,0 +1,29 @@
+package routes
+
+import "testing"
+
+func TestIsRouteNotFoundOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{name: "route.exe english", output: "The route deletion failed: Element not found.", want: true},
+		{name: "route.exe russian", output: "Ошибка удаления маршрута: Элемент не найден.", want: true},
+		{name: "route.exe russian upper case", output: "ОШИБКА УДАЛЕНИЯ МАРШРУТА: ЭЛЕМЕНТ НЕ НАЙДЕН.", want: true},
+		{name: "ip linux", output: "RTNETLINK answers: No such process", want: true},
+		{name: "route bsd", output: "route: writing to routing socket: not in table", want: true},
+		{name: "route.exe elevation english", output: "The requested operation requires elevation.", want: false},
+		{name: "route.exe elevation russian", output: "Запрошенная операция требует повышения.", want: false},
+		{name: "route.exe bad argument", output: "Bad argument 10.0.0.0/8", want: false},
+		{name: "ip permission", output: "RTNETLINK answers: Operation not permitted", want: false},
+		{name: "empty", output: "", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRouteNotFoundOutput(tc.output); got != tc.want {
+				t.Fatalf("isRouteNotFoundOutput(%q) = %v, want %v", tc.output, got, tc.want)
+			}
+		})
+	}
+}
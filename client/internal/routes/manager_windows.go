@@ -3,10 +3,82 @@
 package routes
 
 import (
+	"fmt"
+	"net"
 	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
+
+	"customvpn/client/internal/state"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
+const routeCommand = "route.exe"
+
 func applyRouteCommandAttributes(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
 }
+
+func addRouteArgs(network *net.IPNet, gateway *state.GatewayInfo, metric int) ([]string, error) {
+	var args []string
+	if ip := network.IP.To4(); ip != nil {
+		mask, err := maskToIPv4String(network.Mask)
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"ADD", ip.String(), "MASK", mask, gateway.IP, "METRIC", strconv.Itoa(metric)}
+	} else {
+		// для IPv6 route.exe принимает префикс целиком, без MASK
+		args = []string{"ADD", network.String(), gateway.IP, "METRIC", strconv.Itoa(metric)}
+	}
+	if gateway.InterfaceIndex > 0 {
+		args = append(args, "IF", strconv.Itoa(gateway.InterfaceIndex))
+	}
+	return args, nil
+}
+
+func deleteRouteArgs(record state.RouteRecord) ([]string, error) {
+	destination := record.Destination
+	var args []string
+	if _, network, err := net.ParseCIDR(destination); err == nil && network.IP.To4() == nil {
+		args = []string{"DELETE", network.String()}
+	} else {
+		if idx := strings.Index(destination, "/"); idx != -1 {
+			destination = destination[:idx]
+		}
+		args = []string{"DELETE", destination}
+		if network != nil {
+			if mask, err := maskToIPv4String(network.Mask); err == nil {
+				args = append(args, "MASK", mask)
+			}
+		}
+	}
+	if record.Gateway != "" {
+		args = append(args, record.Gateway)
+	}
+	if record.InterfaceIndex > 0 {
+		args = append(args, "IF", strconv.Itoa(record.InterfaceIndex))
+	}
+	return args, nil
+}
+
+// decodeOEMText переводит вывод route.exe из OEM-кодировки консоли (CP866).
+func decodeOEMText(text string) string {
+	if text == "" {
+		return ""
+	}
+	decoded, err := charmap.CodePage866.NewDecoder().String(text)
+	if err != nil {
+		return text
+	}
+	return decoded
+}
+
+func maskToIPv4String(mask net.IPMask) (string, error) {
+	if len(mask) != net.IPv4len {
+		return "", fmt.Errorf("only IPv4 masks are supported")
+	}
+	return net.IP(mask).String(), nil
+}
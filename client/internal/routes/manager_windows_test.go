@@ -0,0 +1,73 @@
+//go:build windows
+
+package routes
+
+import (
+	"net"
+	"slices"
+	"testing"
+
+	"customvpn/client/internal/state"
+)
+
+func TestRouteArgs(t *testing.T) {
+	tunnel := &state.GatewayInfo{IP: "172.19.0.1", InterfaceIndex: 31}
+	// IPv6-маршруты в туннель добавляются on-link: шлюз "::" и индекс интерфейса TUN
+	tunnelV6 := &state.GatewayInfo{IP: "::", InterfaceIndex: 31}
+	cases := []struct {
+		name       string
+		cidr       string
+		gateway    *state.GatewayInfo
+		metric     int
+		wantAdd    []string
+		wantDelete []string
+	}{
+		{
+			name:       "ipv4",
+			cidr:       "10.0.0.0/8",
+			gateway:    tunnel,
+			metric:     5,
+			wantAdd:    []string{"ADD", "10.0.0.0", "MASK", "255.0.0.0", "172.19.0.1", "METRIC", "5", "IF", "31"},
+			wantDelete: []string{"DELETE", "10.0.0.0", "MASK", "255.0.0.0", "172.19.0.1", "IF", "31"},
+		},
+		{
+			name:       "ipv6 on-link tunnel",
+			cidr:       "2000::/3",
+			gateway:    tunnelV6,
+			metric:     5,
+			wantAdd:    []string{"ADD", "2000::/3", "::", "METRIC", "5", "IF", "31"},
+			wantDelete: []string{"DELETE", "2000::/3", "::", "IF", "31"},
+		},
+		{
+			name:       "ipv6 without interface",
+			cidr:       "2001:db8::/32",
+			gateway:    &state.GatewayInfo{IP: "fe80::1"},
+			metric:     25,
+			wantAdd:    []string{"ADD", "2001:db8::/32", "fe80::1", "METRIC", "25"},
+			wantDelete: []string{"DELETE", "2001:db8::/32", "fe80::1"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, network, err := net.ParseCIDR(tc.cidr)
+			if err != nil {
+				t.Fatalf("parse %s: %v", tc.cidr, err)
+			}
+			add, err := addRouteArgs(network, tc.gateway, tc.metric)
+			if err != nil {
+				t.Fatalf("addRouteArgs: %v", err)
+			}
+			if !slices.Equal(add, tc.wantAdd) {
+				t.Fatalf("add args = %q, want %q", add, tc.wantAdd)
+			}
+			record := state.RouteRecord{Destination: tc.cidr, Gateway: tc.gateway.IP, InterfaceIndex: tc.gateway.InterfaceIndex}
+			del, err := deleteRouteArgs(record)
+			if err != nil {
+				t.Fatalf("deleteRouteArgs: %v", err)
+			}
+			if !slices.Equal(del, tc.wantDelete) {
+				t.Fatalf("delete args = %q, want %q", del, tc.wantDelete)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package state
+
+import "time"
+
+// TunnelProbePayload сообщает, что проверка туннеля не прошла Failures раз подряд.
+type TunnelProbePayload struct {
+	Failures int
+	Reason   string
+}
+
+// startLivenessProbe на время Connected периодически вызывает ProbeTunnel. После заданного числа
+// неудач подряд отправляется EventSysTunnelProbeFailed, и проверка завершается.
+func (m *Machine) startLivenessProbe() {
+	if m.callbacks.ProbeTunnel == nil || m.ctx.Config == nil || !m.ctx.Config.LivenessProbe.Enabled() {
+		return
+	}
+	m.stopLivenessProbe()
+	settings := m.ctx.Config.LivenessProbe
+	stop := make(chan struct{})
+	m.livenessProbeStop = stop
+	probe := m.callbacks.ProbeTunnel
+	m.runAsync(func() {
+		ticker := time.NewTicker(settings.Interval)
+		defer ticker.Stop()
+		failures := 0
+		for {
+			select {
+			case <-stop:
+				return
+			case <-m.done:
+				return
+			case <-ticker.C:
+			}
+			err := probe()
+			if err == nil {
+				if failures > 0 {
+					m.logger.Infof("liveness probe: %s reachable again after %d failures", settings.Target, failures)
+				}
+				failures = 0
+				continue
+			}
+			failures++
+			m.logger.Infof("liveness probe: %s failed (%d/%d): %v", settings.Target, failures, settings.Failures, err)
+			if failures < settings.Failures {
+				continue
+			}
+			// проверка могла завершиться уже после выхода из Connected
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			_ = m.Dispatch(Event{Type: EventSysTunnelProbeFailed, Payload: TunnelProbePayload{Failures: failures, Reason: err.Error()}, TS: time.Now()})
+			return
+		}
+	})
+}
+
+func (m *Machine) stopLivenessProbe() {
+	if m.livenessProbeStop != nil {
+		close(m.livenessProbeStop)
+		m.livenessProbeStop = nil
+	}
+}
@@ -2,13 +2,13 @@
 
 import (
 	"errors"
-	"fmt"
 	"runtime/debug"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"customvpn/client/internal/i18n"
 	"customvpn/client/internal/logging"
 )
 
@@ -23,11 +23,14 @@ const (
 	StateSyncInProgress    State = "SyncInProgress"
 	StatePreparingEnv      State = "PreparingEnvironment"
 	StateReadyDisconnected State = "ReadyDisconnected"
-	StateConnecting        State = "Connecting"
-	StateConnected         State = "Connected"
-	StateDisconnecting     State = "Disconnecting"
-	StateError             State = "Error"
-	StateExiting           State = "Exiting"
+	// StateRefreshingProfiles — повторная синхронизация списка профилей из ReadyDisconnected.
+	StateRefreshingProfiles State = "RefreshingProfiles"
+	StateConnecting         State = "Connecting"
+	StateConnected          State = "Connected"
+	StateDisconnecting      State = "Disconnecting"
+	StateReconnecting       State = "Reconnecting"
+	StateError              State = "Error"
+	StateExiting            State = "Exiting"
 )
 
 // EventType представляет собой тип события из очереди state machine.
@@ -38,10 +41,12 @@ const (
 	EventUICredentialsChanged  EventType = "UI_CREDENTIALS_CHANGED"
 	EventUIClickLogin          EventType = "UI_CLICK_LOGIN"
 	EventUIClickRetryPreflight EventType = "UI_CLICK_RETRY_PREFLIGHT"
+	EventUITestConnection      EventType = "UI_TEST_CONNECTION"
 	EventUISelectProfile       EventType = "UI_SELECT_PROFILE"
 	EventUIClickConnect        EventType = "UI_CLICK_CONNECT"
 	EventUIClickDisconnect     EventType = "UI_CLICK_DISCONNECT"
 	EventUIClickCleanup        EventType = "UI_CLICK_CLEANUP"
+	EventUIClickRefresh        EventType = "UI_CLICK_REFRESH"
 	EventUIOpenSettings        EventType = "UI_OPEN_SETTINGS"
 	EventUICloseWindow         EventType = "UI_CLOSE_WINDOW"
 	EventUIShowWindow          EventType = "UI_SHOW_WINDOW"
@@ -53,25 +58,47 @@ const (
 	EventTrayDisconnect EventType = "TRAY_DISCONNECT"
 	EventTrayExit       EventType = "TRAY_EXIT"
 
-	EventSysPreflightSuccess  EventType = "SYS_PREFLIGHT_SUCCESS"
-	EventSysPreflightFailure  EventType = "SYS_PREFLIGHT_FAILURE"
-	EventSysPreflightRetry    EventType = "SYS_PREFLIGHT_RETRY"
-	EventSysAuthSuccess       EventType = "SYS_AUTH_SUCCESS"
-	EventSysAuthFailure       EventType = "SYS_AUTH_FAILURE"
-	EventSysSyncSuccess       EventType = "SYS_SYNC_SUCCESS"
-	EventSysSyncFailure       EventType = "SYS_SYNC_FAILURE"
-	EventSysPrepareEnvSuccess EventType = "SYS_PREPARE_ENV_SUCCESS"
-	EventSysPrepareEnvFailure EventType = "SYS_PREPARE_ENV_FAILURE"
-	EventSysConnectingSuccess EventType = "SYS_CONNECTING_SUCCESS"
-	EventSysConnectingFailure EventType = "SYS_CONNECTING_FAILURE"
-	EventSysDisconnectingDone EventType = "SYS_DISCONNECTING_DONE"
-	EventSysProcessExited     EventType = "SYS_PROCESS_EXITED"
-	EventSysCleanupDone       EventType = "SYS_CLEANUP_DONE"
-	EventSysTimeout           EventType = "SYS_TIMEOUT"
+	EventSysPreflightSuccess   EventType = "SYS_PREFLIGHT_SUCCESS"
+	EventSysPreflightFailure   EventType = "SYS_PREFLIGHT_FAILURE"
+	EventSysPreflightRetry     EventType = "SYS_PREFLIGHT_RETRY"
+	EventSysAuthSuccess        EventType = "SYS_AUTH_SUCCESS"
+	EventSysAuthFailure        EventType = "SYS_AUTH_FAILURE"
+	EventSysTokenRefreshed     EventType = "SYS_TOKEN_REFRESHED"
+	EventSysSyncSuccess        EventType = "SYS_SYNC_SUCCESS"
+	EventSysSyncFailure        EventType = "SYS_SYNC_FAILURE"
+	EventSysSyncProgress       EventType = "SYS_SYNC_PROGRESS"
+	EventSysPrepareEnvSuccess  EventType = "SYS_PREPARE_ENV_SUCCESS"
+	EventSysPrepareEnvFailure  EventType = "SYS_PREPARE_ENV_FAILURE"
+	EventSysConnectingSuccess  EventType = "SYS_CONNECTING_SUCCESS"
+	EventSysConnectingFailure  EventType = "SYS_CONNECTING_FAILURE"
+	EventSysConnectingCanceled EventType = "SYS_CONNECTING_CANCELED"
+	EventSysDisconnectingDone  EventType = "SYS_DISCONNECTING_DONE"
+	EventSysProcessExited      EventType = "SYS_PROCESS_EXITED"
+	EventSysCleanupDone        EventType = "SYS_CLEANUP_DONE"
+	EventSysTimeout            EventType = "SYS_TIMEOUT"
+	EventSysReconnectRetry     EventType = "SYS_RECONNECT_RETRY"
+	EventSysNetworkChanged     EventType = "SYS_NETWORK_CHANGED"
+	EventSysCoreLog            EventType = "SYS_CORE_LOG"
+	EventSysTrafficSample      EventType = "SYS_TRAFFIC_SAMPLE"
+	EventSysTestConnectionDone EventType = "SYS_TEST_CONNECTION_DONE"
+	EventSysTunnelProbeFailed  EventType = "SYS_TUNNEL_PROBE_FAILED"
 )
 
 const preflightRetryDelay = 5 * time.Second
 
+// networkWatchInterval — период проверки маршрута по умолчанию в состоянии Connected.
+const networkWatchInterval = 5 * time.Second
+
+// syncProgressMinTotal — меньше стольких профилей синхронизация быстрая, прогресс не показывается.
+const syncProgressMinTotal = 10
+
+// clockSkewWarnThreshold — расхождение часов с сервером, при котором срок действия токенов
+// проверяется неверно и пользователю предлагается синхронизировать время.
+const clockSkewWarnThreshold = 2 * time.Minute
+
+// trafficPollInterval — период чтения счётчиков интерфейса туннеля в состоянии Connected.
+const trafficPollInterval = time.Second
+
 // Event инкапсулирует событие очереди и произвольную полезную нагрузку.
 type Event struct {
 	Type    EventType
@@ -83,6 +110,7 @@ type Event struct {
 type CredentialsPayload struct {
 	Login    string
 	Password string
+	Remember bool
 }
 
 // SelectionPayload используется для изменения выбранных ID.
@@ -90,6 +118,11 @@ type SelectionPayload struct {
 	ID string
 }
 
+// PreflightSuccessPayload содержит сведения о Control-сервере.
+type PreflightSuccessPayload struct {
+	Server ServerInfo
+}
+
 // AuthSuccessPayload содержит authToken.
 type AuthSuccessPayload struct {
 	Token string
@@ -100,6 +133,12 @@ type SyncSuccessPayload struct {
 	Profiles []Profile
 }
 
+// SyncProgressPayload сообщает, сколько профилей из Total уже загружено во время синхронизации.
+type SyncProgressPayload struct {
+	Done  int
+	Total int
+}
+
 // PrepareEnvSuccessPayload содержит найденный default gateway.
 type PrepareEnvSuccessPayload struct {
 	Gateway GatewayInfo
@@ -110,6 +149,8 @@ type ScenarioResultPayload struct {
 	Kind             ErrorKind
 	Message          string
 	TechnicalMessage string
+	// RetryAfter — пауза перед автоматическим повтором, которую запросил сервер; 0 — по умолчанию.
+	RetryAfter time.Duration
 }
 
 // ProcessExitPayload сообщает о завершении дочернего процесса.
@@ -119,9 +160,36 @@ type ProcessExitPayload struct {
 	Reason   string
 }
 
+// CoreLogPayload передаёт строку вывода Core для строки статуса.
+type CoreLogPayload struct {
+	Line string
+}
+
+// ConnectingSuccessPayload содержит интерфейс туннеля и адрес сервера установленного подключения.
+type ConnectingSuccessPayload struct {
+	Tunnel GatewayInfo
+	Server string
+	// Gateway и GatewayV6 — маршруты по умолчанию, выбранные сценарием подключения.
+	Gateway   *GatewayInfo
+	GatewayV6 *GatewayInfo
+}
+
+// TrafficSamplePayload содержит очередной замер счётчиков интерфейса туннеля.
+type TrafficSamplePayload struct {
+	Counters InterfaceCounters
+}
+
+// TestConnectionPayload содержит результат разовой проверки Control-сервера.
+type TestConnectionPayload struct {
+	OK      bool
+	Message string
+}
+
 // CleanupResultPayload reports cleanup completion details.
 type CleanupResultPayload struct {
-	Errors []string
+	Errors               []string
+	RoutesRemoved        int
+	FirewallRulesRemoved int
 }
 
 // TimeoutPayload описывает операцию, превысившую таймаут.
@@ -131,12 +199,14 @@ type TimeoutPayload struct {
 
 // Callbacks содержит функции, вызываемые state machine для побочных эффектов.
 type Callbacks struct {
-	StartPreflight      func(ctx *AppContext)
-	StartAuth           func(ctx *AppContext, login, password string)
-	StartSync           func(ctx *AppContext)
-	StartPrepareEnv     func(ctx *AppContext)
-	StartConnecting     func(ctx *AppContext)
-	StartDisconnecting  func(ctx *AppContext)
+	StartPreflight     func(ctx *AppContext)
+	StartAuth          func(ctx *AppContext, login, password string)
+	StartSync          func(ctx *AppContext)
+	StartPrepareEnv    func(ctx *AppContext)
+	StartConnecting    func(ctx *AppContext)
+	StartDisconnecting func(ctx *AppContext)
+	// CancelConnecting прерывает выполняющийся сценарий подключения; вызывается синхронно.
+	CancelConnecting    func(ctx *AppContext)
 	ForceCleanup        func(ctx *AppContext)
 	CleanupAndExit      func(ctx *AppContext)
 	ShowLoginWindow     func(ctx *AppContext)
@@ -146,23 +216,56 @@ type Callbacks struct {
 	ShowModalError      func(info *ErrorInfo)
 	ShowTransientNotice func(message string)
 	ShowCleanupStarted  func()
-	ShowCleanupDone     func(hasErrors bool)
+	ShowCleanupDone     func(result CleanupResultPayload)
+	ShowSettings        func(ctx *AppContext)
+	// RememberLogin сохраняет логин после успешной авторизации.
+	RememberLogin func(login string)
+	// StoreCredentials сохраняет (remember=true) или удаляет учётные данные в хранилище ОС после входа.
+	StoreCredentials func(login, password string, remember bool)
+	// DetectGateways возвращает текущие маршруты по умолчанию для наблюдения за сменой сети.
+	DetectGateways func() ([]*GatewayInfo, error)
+	// TestConnection выполняет разовую проверку /health и отправляет EventSysTestConnectionDone.
+	TestConnection func(ctx *AppContext)
+	// ReadInterfaceCounters возвращает счётчики байт интерфейса с указанным индексом.
+	ReadInterfaceCounters func(index int) (InterfaceCounters, error)
+	// OnStateChanged вызывается из goroutine event-loop после каждой смены состояния.
+	OnStateChanged func(prev, next State)
+	// ProbeTunnel проверяет, что туннель пропускает трафик (liveness_probe в config.yaml).
+	ProbeTunnel func() error
+	// OnSessionStarted и OnSessionEnded вызываются при входе в Connected и выходе из него.
+	OnSessionStarted func(ctx *AppContext)
+	OnSessionEnded   func(ctx *AppContext, reason SessionEndReason)
 }
 
 // Machine инкапсулирует event-loop и текущее состояние приложения.
 type Machine struct {
-	ctx                 *AppContext
-	callbacks           Callbacks
-	logger              *logging.Logger
-	events              chan Event
-	priority            chan Event
-	done                chan struct{}
-	stopped             atomic.Bool
-	loopOnce            sync.Once
-	stopOnce            sync.Once
-	wg                  sync.WaitGroup
-	pendingPF           bool
+	ctx       *AppContext
+	callbacks Callbacks
+	logger    *logging.Logger
+	events    chan Event
+	priority  chan Event
+	done      chan struct{}
+	stopped   atomic.Bool
+	loopOnce  sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	pendingPF bool
+	// timersMu защищает таймеры повторов: Stop вызывается не из event-loop.
+	timersMu            sync.Mutex
 	preflightRetryTimer *time.Timer
+	reconnectTimer      *time.Timer
+	reconnectCleaning   bool
+	// connectCancelRequested — пользователь нажал «Отключиться» во время Connecting.
+	connectCancelRequested bool
+	networkWatchStop       chan struct{}
+	trafficPollStop        chan struct{}
+	livenessProbeStop      chan struct{}
+	// pendingPFMessage — текст ошибки, которая показывается после очистки по pendingPF.
+	pendingPFMessage string
+	// sessionEnd — причина выхода из Connected для истории; пусто — ошибка.
+	sessionEnd SessionEndReason
+	// testingConnection — выполняется разовая проверка сервера по кнопке в окне входа.
+	testingConnection bool
 }
 
 // ErrMachineStopped возвращается при попытке отправить событие после остановки петли.
@@ -190,11 +293,10 @@ func (m *Machine) Start() {
 // Stop завершает event-loop.
 func (m *Machine) Stop() {
 	m.stopOnce.Do(func() {
-		m.cancelPreflightRetry()
 		m.stopped.Store(true)
+		m.cancelPreflightRetry()
+		m.cancelReconnect()
 		close(m.done)
-		close(m.priority)
-		close(m.events)
 	})
 }
 
@@ -232,20 +334,13 @@ func (m *Machine) Dispatch(evt Event) error {
 	if m.isExitEvent(evt.Type) {
 		ch = m.priority
 	}
+	// каналы не закрываются: отправка из горутин таймеров не гоняется со Stop,
+	// а при заполненной очереди ждёт места или остановки
 	select {
 	case <-m.done:
 		return ErrMachineStopped
 	case ch <- evt:
 		return nil
-	default:
-		// если канал заполнен, блокируемся, пока возможно отправить
-		if m.stopped.Load() {
-			return ErrMachineStopped
-		}
-		if m.safeSend(ch, evt) {
-			return nil
-		}
-		return ErrMachineStopped
 	}
 }
 
@@ -256,25 +351,18 @@ func (m *Machine) loop() {
 		}
 
 		select {
-		case evt, ok := <-m.priority:
-			if !ok {
-				return
-			}
+		case evt := <-m.priority:
 			m.handleEvent(evt)
 			continue
 		default:
 		}
 
 		select {
-		case evt, ok := <-m.priority:
-			if !ok {
-				return
-			}
+		case <-m.done:
+			return
+		case evt := <-m.priority:
 			m.handleEvent(evt)
-		case evt, ok := <-m.events:
-			if !ok {
-				return
-			}
+		case evt := <-m.events:
 			m.handleEvent(evt)
 		}
 	}
@@ -286,6 +374,8 @@ func (m *Machine) loopSafely() {
 }
 
 func (m *Machine) handleEvent(evt Event) {
+	// снимок публикуется и при досрочном выходе из обработчика
+	defer m.ctx.PublishSession()
 	if evt.TS.IsZero() {
 		evt.TS = time.Now()
 	}
@@ -296,12 +386,26 @@ func (m *Machine) handleEvent(evt Event) {
 		if m.callbacks.ShowCleanupStarted != nil {
 			m.callbacks.ShowCleanupStarted()
 		} else {
-			m.showTransient("Очистка запущена")
+			m.showTransient(i18n.T("notice.cleanup_started"))
 		}
 		m.invokeForceCleanup()
 		return
 	}
+	if evt.Type == EventSysTokenRefreshed {
+		m.applyRefreshedToken(evt)
+		return
+	}
+	if evt.Type == EventSysTestConnectionDone {
+		// результат проверки только показывается: состояние не меняется
+		m.testingConnection = false
+		payload, _ := evt.Payload.(TestConnectionPayload)
+		m.showTransient(payload.Message)
+		return
+	}
 	if m.isExitEvent(evt.Type) {
+		if m.ctx.State == StateConnected {
+			m.sessionEnd = SessionEndUser
+		}
 		m.transition(StateExiting)
 		m.invokeCleanup()
 		return
@@ -322,12 +426,16 @@ func (m *Machine) handleEvent(evt Event) {
 		m.handlePreparingEnv(evt)
 	case StateReadyDisconnected:
 		m.handleReady(evt)
+	case StateRefreshingProfiles:
+		m.handleRefreshingProfiles(evt)
 	case StateConnecting:
 		m.handleConnecting(evt)
 	case StateConnected:
 		m.handleConnected(evt)
 	case StateDisconnecting:
 		m.handleDisconnecting(evt)
+	case StateReconnecting:
+		m.handleReconnecting(evt)
 	case StateError:
 		m.handleErrorState(evt)
 	case StateExiting:
@@ -338,13 +446,13 @@ func (m *Machine) handleEvent(evt Event) {
 	if evt.Type == EventSysCleanupDone {
 		payload, _ := evt.Payload.(CleanupResultPayload)
 		if m.callbacks.ShowCleanupDone != nil {
-			m.callbacks.ShowCleanupDone(len(payload.Errors) > 0)
+			m.callbacks.ShowCleanupDone(payload)
 			return
 		}
 		if len(payload.Errors) == 0 {
-			m.showTransient("Очистка завершена")
+			m.showTransient(i18n.T("notice.cleanup_done"))
 		} else {
-			m.showTransient("Очистка завершена с ошибками")
+			m.showTransient(i18n.T("notice.cleanup_done_errors"))
 		}
 	}
 }
@@ -352,7 +460,7 @@ func (m *Machine) handleEvent(evt Event) {
 func (m *Machine) handleAppStarting(evt Event) {
 	switch evt.Type {
 	case EventUILaunch:
-		m.ctx.UI.StatusText = "Проверяем доступность сервера..."
+		m.ctx.UI.StatusText = i18n.T("status.preflight")
 		m.transition(StatePreflightCheck)
 		m.invokePreflight()
 	case EventUICredentialsChanged:
@@ -366,14 +474,21 @@ func (m *Machine) handlePreflight(evt Event) {
 	switch evt.Type {
 	case EventSysPreflightSuccess:
 		m.cancelPreflightRetry()
-		m.ctx.UI.StatusText = "Введите логин и пароль"
+		if payload, ok := evt.Payload.(PreflightSuccessPayload); ok {
+			server := payload.Server
+			m.ctx.ServerInfo = &server
+		}
+		m.ctx.UI.StatusText = i18n.T("status.enter_credentials")
 		m.transition(StateWaitingLogin)
 		m.invokeShowLogin()
+		m.warnClockSkew()
 	case EventSysPreflightFailure:
 		payload, _ := evt.Payload.(ScenarioResultPayload)
 		m.onPreflightFailure(payload)
 	case EventUIClickRetryPreflight:
 		m.handlePreflightRetry(true)
+	case EventUITestConnection:
+		m.invokeTestConnection()
 	case EventSysPreflightRetry:
 		m.handlePreflightRetry(false)
 	case EventUICredentialsChanged:
@@ -390,12 +505,14 @@ func (m *Machine) handleWaitingLogin(evt Event) {
 	case EventUIClickLogin:
 		m.applyCredentials(evt)
 		if strings.TrimSpace(m.ctx.UI.LoginInput) == "" || strings.TrimSpace(m.ctx.UI.PasswordInput) == "" {
-			m.showTransient("Укажите логин и пароль")
+			m.showTransient(i18n.T("notice.credentials_required"))
 			return
 		}
-		m.ctx.UI.StatusText = "Выполняется авторизация"
+		m.ctx.UI.StatusText = i18n.T("status.authenticating")
 		m.transition(StateAuthInProgress)
 		m.invokeAuth()
+	case EventUITestConnection:
+		m.invokeTestConnection()
 	case EventUICloseWindow:
 		m.invokeHideMain()
 	case EventUIShowWindow, EventTrayShowWindow:
@@ -409,9 +526,15 @@ func (m *Machine) handleAuthInProgress(evt Event) {
 	switch evt.Type {
 	case EventSysAuthSuccess:
 		payload, _ := evt.Payload.(AuthSuccessPayload)
-		m.ctx.AuthToken = payload.Token
+		m.ctx.SetAuthToken(payload.Token)
 		m.ctx.LastError = nil
-		m.ctx.UI.StatusText = "Обновление списков серверов"
+		if m.callbacks.RememberLogin != nil && strings.TrimSpace(m.ctx.UI.LoginInput) != "" {
+			m.callbacks.RememberLogin(m.ctx.UI.LoginInput)
+		}
+		if m.callbacks.StoreCredentials != nil {
+			m.callbacks.StoreCredentials(m.ctx.UI.LoginInput, m.ctx.UI.PasswordInput, m.ctx.UI.RememberCredentials)
+		}
+		m.ctx.UI.StatusText = i18n.T("status.syncing")
 		m.transition(StateSyncInProgress)
 		m.invokeSync()
 	case EventSysAuthFailure:
@@ -422,7 +545,7 @@ func (m *Machine) handleAuthInProgress(evt Event) {
 		}
 		message := payload.Message
 		if message == "" {
-			message = "Ошибка авторизации"
+			message = i18n.T("error.auth_failed")
 		}
 		technical := payload.TechnicalMessage
 		if technical == "" {
@@ -438,8 +561,8 @@ func (m *Machine) handleSyncInProgress(evt Event) {
 	switch evt.Type {
 	case EventSysSyncSuccess:
 		payload, _ := evt.Payload.(SyncSuccessPayload)
-		m.ctx.Profiles = payload.Profiles
-		m.ctx.UI.StatusText = "Подготовка окружения"
+		m.ctx.SetProfiles(payload.Profiles)
+		m.ctx.UI.StatusText = i18n.T("status.preparing_env")
 		m.transition(StatePreparingEnv)
 		m.invokePrepareEnv()
 	case EventSysSyncFailure:
@@ -450,29 +573,88 @@ func (m *Machine) handleSyncInProgress(evt Event) {
 		}
 		message := payload.Message
 		if message == "" {
-			message = "Не удалось загрузить данные"
+			message = i18n.T("error.load_data")
 		}
 		technical := payload.TechnicalMessage
 		if technical == "" {
 			technical = "sync failed"
 		}
 		m.enterError(kind, message, technical)
+	case EventSysSyncProgress:
+		m.applySyncProgress(evt)
 	default:
 		m.logger.Debugf("sync: ignored %s", evt.Type)
 	}
 }
 
+func (m *Machine) applySyncProgress(evt Event) {
+	payload, _ := evt.Payload.(SyncProgressPayload)
+	if payload.Total < syncProgressMinTotal {
+		return
+	}
+	m.ctx.UI.StatusText = i18n.Tf("status.loading_profiles", payload.Done, payload.Total)
+	m.refreshUI()
+}
+
+// handleRefreshingProfiles обновляет список профилей по кнопке «Обновить» с текущим authToken.
+// Истёкший токен обновляется клиентом Control-сервера; если повторный вход не удался — окно логина.
+func (m *Machine) handleRefreshingProfiles(evt Event) {
+	switch evt.Type {
+	case EventSysSyncSuccess:
+		payload, _ := evt.Payload.(SyncSuccessPayload)
+		m.applyRefreshedProfiles(payload.Profiles)
+		m.ctx.UI.StatusText = i18n.T("status.disconnected")
+		m.transition(StateReadyDisconnected)
+	case EventSysSyncFailure:
+		payload, _ := evt.Payload.(ScenarioResultPayload)
+		if payload.Kind == ErrorKindAuthFailed || payload.Kind == ErrorKindAccountLocked {
+			m.ctx.SetAuthToken("")
+			m.ctx.UI.StatusText = i18n.T("status.session_expired")
+			m.transition(StateWaitingLogin)
+			m.invokeShowLogin()
+			return
+		}
+		m.ctx.UI.StatusText = i18n.T("status.disconnected")
+		m.transition(StateReadyDisconnected)
+		message := payload.Message
+		if message == "" {
+			message = i18n.T("error.refresh_profiles")
+		}
+		m.showTransient(message)
+	case EventSysSyncProgress:
+		m.applySyncProgress(evt)
+	case EventUISelectProfile:
+		m.applyProfileSelection(evt)
+	case EventUICloseWindow, EventTrayHideWindow:
+		m.invokeHideMain()
+	case EventUIShowWindow, EventTrayShowWindow:
+		m.invokeShowMain()
+	default:
+		m.logger.Debugf("refreshingProfiles: ignored %s", evt.Type)
+	}
+}
+
+// applyRefreshedProfiles заменяет список профилей и сохраняет выбор, если профиль остался.
+func (m *Machine) applyRefreshedProfiles(profiles []Profile) {
+	selected := m.ctx.SelectedProfileID()
+	if !m.ctx.SetProfiles(profiles) {
+		m.logger.Infof("selected profile %s is gone after refresh", selected)
+		m.ctx.UI.SelectedProfileID = ""
+	}
+	m.logger.Infof("profiles refreshed: %d profiles", len(profiles))
+}
+
 func (m *Machine) handlePreparingEnv(evt Event) {
 	switch evt.Type {
 	case EventSysPrepareEnvSuccess:
 		payload, _ := evt.Payload.(PrepareEnvSuccessPayload)
 		gw := payload.Gateway
 		if strings.TrimSpace(gw.IP) != "" {
-			m.ctx.DefaultGateway = &gw
+			m.ctx.SetDefaultGateway(&gw)
 		} else {
-			m.ctx.DefaultGateway = nil
+			m.ctx.SetDefaultGateway(nil)
 		}
-		m.ctx.UI.StatusText = "Отключено"
+		m.ctx.UI.StatusText = i18n.T("status.disconnected")
 		m.transition(StateReadyDisconnected)
 		m.invokeShowMain()
 	case EventSysPrepareEnvFailure:
@@ -483,7 +665,7 @@ func (m *Machine) handlePreparingEnv(evt Event) {
 		}
 		message := payload.Message
 		if message == "" {
-			message = "Не удалось подготовить маршруты"
+			message = i18n.T("error.prepare_routes")
 		}
 		technical := payload.TechnicalMessage
 		if technical == "" {
@@ -500,20 +682,28 @@ func (m *Machine) handleReady(evt Event) {
 	case EventUISelectProfile:
 		m.applyProfileSelection(evt)
 	case EventUIClickConnect, EventTrayConnect:
-		if m.ctx.SelectedProfileID == "" {
-			m.showTransient("Выберите профиль")
+		if m.ctx.SelectedProfileID() == "" {
+			m.showTransient(i18n.T("notice.select_profile"))
 			return
 		}
 		m.pendingPF = false
-		m.ctx.UI.StatusText = "Подключение..."
+		m.ctx.ReconnectAttempt = 0
+		m.ctx.UI.StatusText = i18n.T("status.connecting")
 		m.transition(StateConnecting)
 		m.invokeConnect()
+	case EventUIClickRefresh:
+		m.ctx.UI.StatusText = i18n.T("status.refreshing_profiles")
+		m.transition(StateRefreshingProfiles)
+		m.invokeSync()
 	case EventUICloseWindow, EventTrayHideWindow:
 		m.invokeHideMain()
 	case EventUIShowWindow, EventTrayShowWindow:
 		m.invokeShowMain()
 	case EventUIOpenSettings:
 		m.logger.Debugf("settings dialog requested")
+		if m.callbacks.ShowSettings != nil {
+			m.callbacks.ShowSettings(m.ctx)
+		}
 	default:
 		m.logger.Debugf("ready: ignored %s", evt.Type)
 	}
@@ -521,23 +711,77 @@ func (m *Machine) handleReady(evt Event) {
 
 func (m *Machine) handleConnecting(evt Event) {
 	switch evt.Type {
+	case EventUIClickDisconnect, EventTrayDisconnect:
+		if m.connectCancelRequested {
+			return
+		}
+		m.connectCancelRequested = true
+		m.ctx.ReconnectAttempt = 0
+		m.ctx.UI.StatusText = i18n.T("status.cancelling")
+		m.refreshUI()
+		if m.callbacks.CancelConnecting != nil {
+			m.callbacks.CancelConnecting(m.ctx)
+		}
+	case EventSysConnectingCanceled:
+		m.connectCancelRequested = false
+		m.ctx.UI.StatusText = i18n.T("status.connect_cancelled")
+		m.transition(StateReadyDisconnected)
 	case EventSysConnectingSuccess:
-		m.ctx.UI.StatusText = "Подключено"
+		if m.connectCancelRequested {
+			// подключение завершилось раньше, чем сработала отмена: отключаемся штатно
+			m.connectCancelRequested = false
+			m.ctx.UI.StatusText = i18n.T("status.disconnecting")
+			m.transition(StateDisconnecting)
+			m.invokeDisconnect()
+			return
+		}
+		if m.ctx.ReconnectAttempt > 0 {
+			m.logger.Infof("reconnect succeeded on attempt %d", m.ctx.ReconnectAttempt)
+		}
+		m.ctx.ReconnectAttempt = 0
+		if payload, ok := evt.Payload.(ConnectingSuccessPayload); ok {
+			tunnel := payload.Tunnel
+			m.ctx.TunnelInterface = &tunnel
+			m.ctx.ConnectedServer = payload.Server
+			m.ctx.SetDefaultGateways(payload.Gateway, payload.GatewayV6)
+		}
+		m.ctx.UI.StatusText = i18n.T("status.connected")
 		m.transition(StateConnected)
 	case EventSysConnectingFailure:
 		payload, _ := evt.Payload.(ScenarioResultPayload)
+		if m.connectCancelRequested {
+			// артефакты уже откатаны сценарием; ошибка — следствие отмены
+			m.connectCancelRequested = false
+			m.ctx.UI.StatusText = i18n.T("status.connect_cancelled")
+			m.transition(StateReadyDisconnected)
+			return
+		}
+		if m.ctx.ReconnectAttempt > 0 && m.beginReconnect(false) {
+			return
+		}
 		kind := payload.Kind
 		if kind == "" {
 			kind = ErrorKindProcessFailed
 		}
 		message := payload.Message
 		if message == "" {
-			message = "Не удалось подключиться"
+			message = i18n.T("error.connect_failed")
 		}
 		m.enterError(kind, message, "connecting failed")
+	case EventSysCoreLog:
+		payload, _ := evt.Payload.(CoreLogPayload)
+		if m.connectCancelRequested || strings.TrimSpace(payload.Line) == "" {
+			return
+		}
+		prefix := i18n.T("status.connecting_prefix")
+		if m.ctx.UI.IsReconnecting {
+			prefix = m.reconnectStatus()
+		}
+		m.ctx.UI.StatusText = prefix + ": " + coreStatusLine(payload.Line)
+		m.refreshUI()
 	case EventSysProcessExited:
 		payload, _ := evt.Payload.(ProcessExitPayload)
-		m.enterError(ErrorKindProcessFailed, "Процесс завершился во время подключения", payload.Reason)
+		m.enterError(ErrorKindProcessFailed, i18n.T("error.process_exited_connecting"), payload.Reason)
 	default:
 		m.logger.Debugf("connecting: ignored %s", evt.Type)
 	}
@@ -549,24 +793,60 @@ func (m *Machine) handleConnected(evt Event) {
 		m.applyProfileSelection(evt)
 	case EventUIClickDisconnect, EventTrayDisconnect:
 		m.pendingPF = false
-		m.ctx.UI.StatusText = "Отключение..."
+		m.sessionEnd = SessionEndUser
+		m.ctx.UI.DisconnectReason = DisconnectReasonUser
+		m.ctx.UI.StatusText = i18n.T("status.disconnecting")
 		m.transition(StateDisconnecting)
 		m.invokeDisconnect()
+	case EventSysNetworkChanged:
+		payload, _ := evt.Payload.(PrepareEnvSuccessPayload)
+		m.logger.Infof("default gateway changed to %s (%s), reconnecting", payload.Gateway.IP, payload.Gateway.InterfaceName)
+		// смена сети — не сбой: счётчик попыток не расходуется
+		m.ctx.ReconnectAttempt = 0
+		m.ctx.UI.StatusText = i18n.T("status.network_changed")
+		m.reconnectCleaning = true
+		m.transition(StateReconnecting)
+		m.invokeDisconnect()
 	case EventSysProcessExited:
 		payload, _ := evt.Payload.(ProcessExitPayload)
+		m.sessionEnd = SessionEndCrash
+		if m.beginReconnect(true) {
+			m.logger.Infof("core exited unexpectedly (%s), reconnecting", payload.Reason)
+			return
+		}
 		m.pendingPF = true
-		m.ctx.UI.StatusText = "Отключение..."
+		m.pendingPFMessage = i18n.T("error.process_failed")
+		m.ctx.UI.DisconnectReason = DisconnectReasonLost
+		m.ctx.UI.StatusText = i18n.T("status.disconnecting")
 		m.transition(StateDisconnecting)
 		m.invokeDisconnect()
 		m.ctx.LastError = &ErrorInfo{
 			Kind:             ErrorKindProcessFailed,
-			UserMessage:      "Процесс завершился неожиданно",
+			UserMessage:      i18n.T("error.process_exited"),
 			TechnicalMessage: payload.Reason,
 			OccurredAt:       time.Now(),
 		}
+	case EventSysTunnelProbeFailed:
+		// Core работает, но туннель не пропускает трафик — поступаем как при падении Core
+		payload, _ := evt.Payload.(TunnelProbePayload)
+		if m.beginReconnect(true) {
+			m.logger.Infof("tunnel liveness probe failed %d times (%s), reconnecting", payload.Failures, payload.Reason)
+			return
+		}
+		m.logger.Errorf("tunnel liveness probe failed %d times (%s), disconnecting", payload.Failures, payload.Reason)
+		m.pendingPF = true
+		m.pendingPFMessage = i18n.T("error.tunnel_dead")
+		m.ctx.UI.DisconnectReason = DisconnectReasonLost
+		m.ctx.UI.StatusText = i18n.T("status.disconnecting")
+		m.transition(StateDisconnecting)
+		m.invokeDisconnect()
+	case EventSysTrafficSample:
+		payload, _ := evt.Payload.(TrafficSamplePayload)
+		m.applyTrafficSample(payload.Counters)
+		m.refreshUI()
 	case EventSysTimeout:
 		payload, _ := evt.Payload.(TimeoutPayload)
-		m.enterError(ErrorKindUnknown, fmt.Sprintf("Таймаут операции %s", payload.Operation), "timeout in connected")
+		m.enterError(ErrorKindUnknown, i18n.Tf("error.operation_timeout", payload.Operation), "timeout in connected")
 	default:
 		m.logger.Debugf("connected: ignored %s", evt.Type)
 	}
@@ -577,17 +857,56 @@ func (m *Machine) handleDisconnecting(evt Event) {
 	case EventUISelectProfile:
 		m.applyProfileSelection(evt)
 	case EventSysDisconnectingDone:
-		m.ctx.UI.StatusText = "Отключено"
+		m.ctx.UI.StatusText = i18n.T("status.disconnected")
 		m.transition(StateReadyDisconnected)
 		if m.pendingPF {
 			m.pendingPF = false
-			m.enterError(ErrorKindProcessFailed, "Процесс завершился с ошибкой", "process crashed")
+			m.enterError(ErrorKindProcessFailed, m.pendingPFMessage, "process crashed")
+			// подробности — в окне ошибки, строка статуса говорит, что разрыв не по вине пользователя
+			m.ctx.UI.StatusText = i18n.T("status.disconnected_lost")
+			m.refreshUI()
 		}
 	default:
 		m.logger.Debugf("disconnecting: ignored %s", evt.Type)
 	}
 }
 
+func (m *Machine) handleReconnecting(evt Event) {
+	switch evt.Type {
+	case EventUISelectProfile:
+		m.applyProfileSelection(evt)
+	case EventSysDisconnectingDone:
+		m.reconnectCleaning = false
+		m.scheduleReconnect()
+	case EventSysReconnectRetry:
+		if m.reconnectCleaning {
+			return
+		}
+		m.cancelReconnect()
+		m.ctx.UI.StatusText = m.reconnectStatus() + "..."
+		m.transition(StateConnecting)
+		m.invokeConnect()
+	case EventUIClickDisconnect, EventTrayDisconnect:
+		m.cancelReconnect()
+		m.ctx.ReconnectAttempt = 0
+		if m.reconnectCleaning {
+			// очистка после падения Core ещё идёт: дождёмся её в Disconnecting
+			m.reconnectCleaning = false
+			m.ctx.UI.StatusText = i18n.T("status.disconnecting")
+			m.transition(StateDisconnecting)
+			return
+		}
+		m.ctx.UI.StatusText = i18n.T("status.disconnected")
+		m.transition(StateReadyDisconnected)
+	case EventUICloseWindow, EventTrayHideWindow:
+		m.invokeHideMain()
+	case EventUIShowWindow, EventTrayShowWindow:
+		m.invokeShowMain()
+	default:
+		m.logger.Debugf("reconnecting: ignored %s", evt.Type)
+	}
+}
+
 func (m *Machine) handleErrorState(evt Event) {
 	if evt.Type == EventUICredentialsChanged {
 		m.applyCredentials(evt)
@@ -599,17 +918,17 @@ func (m *Machine) handleErrorState(evt Event) {
 	}
 	if evt.Type == EventUIClickLogin && m.ctx.LastError != nil {
 		m.applyCredentials(evt)
-		m.ctx.UI.StatusText = "Выполняется авторизация"
+		m.ctx.UI.StatusText = i18n.T("status.authenticating")
 		m.transition(StateAuthInProgress)
 		m.invokeAuth()
 		return
 	}
 	if (evt.Type == EventUIClickConnect || evt.Type == EventTrayConnect) && m.ctx.LastError != nil && (m.ctx.LastError.Kind == ErrorKindProcessFailed || m.ctx.LastError.Kind == ErrorKindRoutingFailed) {
-		if m.ctx.SelectedProfileID == "" {
-			m.showTransient("Выберите профиль")
+		if m.ctx.SelectedProfileID() == "" {
+			m.showTransient(i18n.T("notice.select_profile"))
 			return
 		}
-		m.ctx.UI.StatusText = "Подключение..."
+		m.ctx.UI.StatusText = i18n.T("status.connecting")
 		m.transition(StateConnecting)
 		m.invokeConnect()
 		return
@@ -623,12 +942,22 @@ func (m *Machine) applyCredentials(evt Event) {
 	if payload, ok := evt.Payload.(CredentialsPayload); ok {
 		m.ctx.UI.LoginInput = payload.Login
 		m.ctx.UI.PasswordInput = payload.Password
+		m.ctx.UI.RememberCredentials = payload.Remember
 	}
 }
 
+func (m *Machine) applyRefreshedToken(evt Event) {
+	payload, ok := evt.Payload.(AuthSuccessPayload)
+	if !ok || strings.TrimSpace(payload.Token) == "" {
+		return
+	}
+	m.ctx.SetAuthToken(payload.Token)
+	m.logger.Debugf("auth token refreshed")
+}
+
 func (m *Machine) applyProfileSelection(evt Event) {
 	if payload, ok := evt.Payload.(SelectionPayload); ok {
-		m.ctx.SelectedProfileID = payload.ID
+		m.ctx.SetSelectedProfileID(payload.ID)
 		m.ctx.UI.SelectedProfileID = payload.ID
 		m.refreshUI()
 	}
@@ -641,12 +970,59 @@ func (m *Machine) transition(next State) {
 	prev := m.ctx.State
 	m.ctx.State = next
 	m.logger.Debugf("state transition %s → %s", prev, next)
+	if prev == StateConnected {
+		m.endSession()
+		m.stopNetworkWatch()
+		m.stopTrafficPoll()
+		m.stopLivenessProbe()
+		m.ctx.TunnelInterface = nil
+		m.ctx.ConnectedServer = ""
+		m.ctx.Traffic = nil
+	}
+	if next == StateConnected {
+		m.sessionEnd = ""
+		if m.callbacks.OnSessionStarted != nil {
+			m.callbacks.OnSessionStarted(m.ctx)
+		}
+		m.startNetworkWatch()
+		m.startTrafficPoll()
+		m.startLivenessProbe()
+	}
 	m.updateUIForState(next)
+	if m.callbacks.OnStateChanged != nil {
+		m.callbacks.OnStateChanged(prev, next)
+	}
+}
+
+// endSession сообщает о выходе из Connected; вызывается до сброса данных подключения в ctx.
+func (m *Machine) endSession() {
+	reason := m.sessionEnd
+	if reason == "" {
+		reason = SessionEndError
+	}
+	m.sessionEnd = ""
+	if m.callbacks.OnSessionEnded != nil {
+		m.callbacks.OnSessionEnded(m.ctx, reason)
+	}
 }
 
 func (m *Machine) updateUIForState(state State) {
 	m.ctx.UI.CanLogin = false
 	m.ctx.UI.AllowPreflightRetry = false
+	// Connecting сохраняет признак: из Reconnecting он остаётся выставленным, из остальных состояний уже сброшен
+	if state != StateConnecting {
+		m.ctx.UI.IsReconnecting = state == StateReconnecting
+	}
+	m.ctx.UI.IsRefreshing = state == StateRefreshingProfiles
+	if state == StateConnecting || state == StateConnected {
+		m.ctx.UI.DisconnectReason = DisconnectReasonNone
+	}
+	if state == StateConnected {
+		now := time.Now()
+		m.ctx.ConnectedSince = &now
+	} else {
+		m.ctx.ConnectedSince = nil
+	}
 	switch state {
 	case StateWaitingLogin:
 		m.ctx.UI.IsLoginVisible = true
@@ -664,6 +1040,9 @@ func (m *Machine) updateUIForState(state State) {
 		m.ctx.UI.IsConnected = true
 	case StateDisconnecting:
 		m.ctx.UI.IsConnecting = false
+	case StateReconnecting:
+		m.ctx.UI.IsConnecting = true
+		m.ctx.UI.IsConnected = false
 	case StateError:
 		m.ctx.UI.IsConnecting = false
 		if m.ctx.LastError != nil {
@@ -694,6 +1073,16 @@ func (m *Machine) invokePreflight() {
 	}
 }
 
+// invokeTestConnection запускает разовую проверку сервера, если предыдущая уже завершилась.
+func (m *Machine) invokeTestConnection() {
+	if m.callbacks.TestConnection == nil || m.testingConnection {
+		return
+	}
+	m.testingConnection = true
+	m.logger.Debugf("test connection requested")
+	m.runAsync(func() { m.callbacks.TestConnection(m.ctx) })
+}
+
 func (m *Machine) invokeAuth() {
 	if m.callbacks.StartAuth != nil {
 		login := m.ctx.UI.LoginInput
@@ -789,10 +1178,24 @@ func (m *Machine) showTransient(message string) {
 	}
 }
 
+// warnClockSkew предупреждает о расхождении локальных часов с сервером: из-за него
+// действующий токен может отклоняться, а просроченный — приниматься.
+func (m *Machine) warnClockSkew() {
+	if m.ctx.ServerInfo == nil {
+		return
+	}
+	skew := m.ctx.ServerInfo.ClockSkew
+	if skew.Abs() < clockSkewWarnThreshold {
+		return
+	}
+	m.logger.Infof("warning: local clock differs from control server by %s", skew.Round(time.Second))
+	m.showTransient(i18n.Tf("notice.clock_skew", int(skew.Abs().Minutes())))
+}
+
 func (m *Machine) onPreflightFailure(payload ScenarioResultPayload) {
 	message := strings.TrimSpace(payload.Message)
 	if message == "" {
-		message = "Нет связи с управляющим сервером. Повторим через 5 секунд"
+		message = i18n.T("error.preflight_unavailable")
 	}
 	m.ctx.UI.StatusText = message
 	m.ctx.UI.AllowPreflightRetry = true
@@ -801,7 +1204,11 @@ func (m *Machine) onPreflightFailure(payload ScenarioResultPayload) {
 	m.ctx.UI.IsMainVisible = false
 	m.refreshUI()
 	m.invokeShowLogin()
-	m.schedulePreflightRetry(preflightRetryDelay)
+	if payload.Kind == ErrorKindIncompatibleServer {
+		// повтор не поможет, пока не обновят клиент или сервер; остаётся ручной повтор
+		return
+	}
+	m.schedulePreflightRetry(max(preflightRetryDelay, payload.RetryAfter))
 }
 
 func (m *Machine) handlePreflightRetry(manual bool) {
@@ -809,9 +1216,9 @@ func (m *Machine) handlePreflightRetry(manual bool) {
 	m.ctx.UI.AllowPreflightRetry = false
 	m.ctx.UI.CanLogin = false
 	if manual {
-		m.ctx.UI.StatusText = "Повторяем проверку..."
+		m.ctx.UI.StatusText = i18n.T("status.retrying_manual")
 	} else {
-		m.ctx.UI.StatusText = "Повторяем проверку соединения..."
+		m.ctx.UI.StatusText = i18n.T("status.retrying_auto")
 	}
 	m.refreshUI()
 	m.invokePreflight()
@@ -821,19 +1228,223 @@ func (m *Machine) schedulePreflightRetry(delay time.Duration) {
 	if delay <= 0 {
 		delay = preflightRetryDelay
 	}
-	m.cancelPreflightRetry()
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	m.stopPreflightRetryLocked()
+	// после Stop таймер не заводится: иначе он сработает уже без event-loop
+	if m.stopped.Load() {
+		return
+	}
 	m.preflightRetryTimer = time.AfterFunc(delay, func() {
 		_ = m.Dispatch(Event{Type: EventSysPreflightRetry})
 	})
 }
 
 func (m *Machine) cancelPreflightRetry() {
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	m.stopPreflightRetryLocked()
+}
+
+func (m *Machine) stopPreflightRetryLocked() {
 	if m.preflightRetryTimer != nil {
 		m.preflightRetryTimer.Stop()
 		m.preflightRetryTimer = nil
 	}
 }
 
+func (m *Machine) reconnectLimit() int {
+	if m.ctx.Config == nil {
+		return 0
+	}
+	return m.ctx.Config.AutoReconnectAttempts
+}
+
+func (m *Machine) reconnectDelay() time.Duration {
+	if m.ctx.Config == nil || m.ctx.Config.AutoReconnectDelay <= 0 {
+		return preflightRetryDelay
+	}
+	return m.ctx.Config.AutoReconnectDelay
+}
+
+// beginReconnect переводит автомат в Reconnecting, если попытки ещё остались.
+// needsCleanup означает, что перед повтором нужно снять маршруты упавшей сессии.
+func (m *Machine) beginReconnect(needsCleanup bool) bool {
+	limit := m.reconnectLimit()
+	if m.ctx.ReconnectAttempt >= limit {
+		m.ctx.ReconnectAttempt = 0
+		return false
+	}
+	m.ctx.ReconnectAttempt++
+	m.ctx.UI.StatusText = i18n.Tf("status.connection_lost", m.reconnectStatus())
+	m.transition(StateReconnecting)
+	if needsCleanup {
+		m.reconnectCleaning = true
+		m.invokeDisconnect()
+		return true
+	}
+	m.scheduleReconnect()
+	return true
+}
+
+// reconnectStatus — строка статуса переподключения: «Переподключение (2/5)»;
+// без номера попытки, если переподключение вызвано сменой сети.
+func (m *Machine) reconnectStatus() string {
+	if m.ctx.ReconnectAttempt > 0 {
+		return i18n.Tf("status.reconnecting_n", m.ctx.ReconnectAttempt, m.reconnectLimit())
+	}
+	return i18n.T("status.reconnecting")
+}
+
+func (m *Machine) scheduleReconnect() {
+	delay := m.reconnectDelay()
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	m.stopReconnectLocked()
+	if m.stopped.Load() {
+		return
+	}
+	m.reconnectTimer = time.AfterFunc(delay, func() {
+		_ = m.Dispatch(Event{Type: EventSysReconnectRetry})
+	})
+}
+
+func (m *Machine) cancelReconnect() {
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	m.stopReconnectLocked()
+}
+
+func (m *Machine) stopReconnectLocked() {
+	if m.reconnectTimer != nil {
+		m.reconnectTimer.Stop()
+		m.reconnectTimer = nil
+	}
+}
+
+// startNetworkWatch запускает фоновую проверку маршрута по умолчанию на время Connected.
+// При смене шлюза отправляется EventSysNetworkChanged, и наблюдение завершается.
+func (m *Machine) startNetworkWatch() {
+	current, _ := m.ctx.DefaultGateways()
+	if m.callbacks.DetectGateways == nil || current == nil {
+		return
+	}
+	m.stopNetworkWatch()
+	baseline := *current
+	stop := make(chan struct{})
+	m.networkWatchStop = stop
+	detect := m.callbacks.DetectGateways
+	m.runAsync(func() {
+		ticker := time.NewTicker(networkWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-m.done:
+				return
+			case <-ticker.C:
+			}
+			gateways, err := detect()
+			if err != nil || len(gateways) == 0 {
+				// во время переключения сети шлюза может не быть; ждём появления нового
+				continue
+			}
+			// при нескольких шлюзах сеть считается прежней, пока выбранный шлюз на месте
+			if containsGateway(gateways, baseline) {
+				continue
+			}
+			_ = m.Dispatch(Event{Type: EventSysNetworkChanged, Payload: PrepareEnvSuccessPayload{Gateway: *gateways[0]}})
+			return
+		}
+	})
+}
+
+// startTrafficPoll запускает ежесекундное чтение счётчиков интерфейса туннеля на время Connected.
+// Ошибки чтения (например, интерфейс исчез при падении Core) не прерывают опрос.
+func (m *Machine) startTrafficPoll() {
+	if m.callbacks.ReadInterfaceCounters == nil || m.ctx.TunnelInterface == nil {
+		return
+	}
+	m.stopTrafficPoll()
+	index := m.ctx.TunnelInterface.InterfaceIndex
+	stop := make(chan struct{})
+	m.trafficPollStop = stop
+	read := m.callbacks.ReadInterfaceCounters
+	m.runAsync(func() {
+		ticker := time.NewTicker(trafficPollInterval)
+		defer ticker.Stop()
+		failing := false
+		for {
+			select {
+			case <-stop:
+				return
+			case <-m.done:
+				return
+			case <-ticker.C:
+			}
+			counters, err := read(index)
+			if err != nil {
+				if !failing {
+					m.logger.Debugf("traffic poll: read counters of interface %d failed: %v", index, err)
+					failing = true
+				}
+				continue
+			}
+			failing = false
+			_ = m.Dispatch(Event{Type: EventSysTrafficSample, Payload: TrafficSamplePayload{Counters: counters}})
+		}
+	})
+}
+
+func (m *Machine) stopTrafficPoll() {
+	if m.trafficPollStop != nil {
+		close(m.trafficPollStop)
+		m.trafficPollStop = nil
+	}
+}
+
+// applyTrafficSample обновляет счётчики и скорость по разнице с предыдущим замером.
+func (m *Machine) applyTrafficSample(counters InterfaceCounters) {
+	stats := &TrafficStats{Counters: counters}
+	if prev := m.ctx.Traffic; prev != nil && prev.Counters.InterfaceIndex == counters.InterfaceIndex {
+		elapsed := counters.SampledAt.Sub(prev.Counters.SampledAt).Seconds()
+		// при сбросе счётчиков (интерфейс пересоздан) скорость считаем нулевой
+		if elapsed > 0 && counters.BytesIn >= prev.Counters.BytesIn && counters.BytesOut >= prev.Counters.BytesOut {
+			stats.RateIn = float64(counters.BytesIn-prev.Counters.BytesIn) / elapsed
+			stats.RateOut = float64(counters.BytesOut-prev.Counters.BytesOut) / elapsed
+		}
+	}
+	m.ctx.Traffic = stats
+}
+
+// coreStatusLine укорачивает строку Core до размера строки статуса.
+func coreStatusLine(line string) string {
+	const maxRunes = 120
+	line = strings.TrimSpace(line)
+	runes := []rune(line)
+	if len(runes) <= maxRunes {
+		return line
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
+
+func containsGateway(gateways []*GatewayInfo, target GatewayInfo) bool {
+	for _, gw := range gateways {
+		if gw != nil && gw.IP == target.IP && gw.InterfaceIndex == target.InterfaceIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Machine) stopNetworkWatch() {
+	if m.networkWatchStop != nil {
+		close(m.networkWatchStop)
+		m.networkWatchStop = nil
+	}
+}
+
 func (m *Machine) refreshUI() {
 	if m.callbacks.UpdateUI != nil {
 		m.callbacks.UpdateUI(m.ctx)
@@ -843,13 +1454,3 @@ func (m *Machine) refreshUI() {
 func (m *Machine) isExitEvent(t EventType) bool {
 	return t == EventTrayExit || t == EventUIExit
 }
-
-func (m *Machine) safeSend(ch chan Event, evt Event) (ok bool) {
-	defer func() {
-		if recover() != nil {
-			ok = false
-		}
-	}()
-	ch <- evt
-	return true
-}
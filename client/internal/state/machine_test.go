@@ -0,0 +1,257 @@
+package state
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/logging"
+)
+
+const testWaitTimeout = 5 * time.Second
+
+func newTestLogger(t *testing.T) *logging.Logger {
+	t.Helper()
+	logger, err := logging.New(filepath.Join(t.TempDir(), "client.log"), logging.LevelDebug, logging.Options{})
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	t.Cleanup(func() { _ = logger.Close() })
+	return logger
+}
+
+// testScenarios — сценарии, которые сразу завершаются успехом, как это делает Application
+// при доступном сервере: результат возвращается в цикл событием из горутины runAsync.
+type testScenarios struct {
+	machine *Machine
+	// onConnecting вызывается в горутине сценария подключения до отправки результата.
+	onConnecting func(ctx *AppContext)
+	// onSync заменяет успешную синхронизацию, если задан.
+	onSync func()
+
+	mu          sync.Mutex
+	transitions [][2]State
+	statuses    []string
+	connected   chan struct{}
+}
+
+func (s *testScenarios) statusTexts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.statuses...)
+}
+
+func newTestMachine(t *testing.T, s *testScenarios) *Machine {
+	t.Helper()
+	s.connected = make(chan struct{})
+	ctx := NewAppContext(&config.Config{})
+	m := NewMachine(ctx, newTestLogger(t), Callbacks{
+		StartPreflight: func(*AppContext) {
+			_ = s.machine.Dispatch(Event{Type: EventSysPreflightSuccess, Payload: PreflightSuccessPayload{Server: ServerInfo{Status: "ok", APIVersion: "1"}}})
+		},
+		StartAuth: func(_ *AppContext, _, _ string) {
+			_ = s.machine.Dispatch(Event{Type: EventSysAuthSuccess, Payload: AuthSuccessPayload{Token: "token-1"}})
+		},
+		StartSync: func(*AppContext) {
+			if s.onSync != nil {
+				s.onSync()
+				return
+			}
+			profiles := []Profile{{ID: "p1", Name: "First", Host: "203.0.113.10", Port: 443}, {ID: "p2", Name: "Second", Host: "203.0.113.20", Port: 443}}
+			_ = s.machine.Dispatch(Event{Type: EventSysSyncSuccess, Payload: SyncSuccessPayload{Profiles: profiles}})
+		},
+		StartPrepareEnv: func(*AppContext) {
+			_ = s.machine.Dispatch(Event{Type: EventSysPrepareEnvSuccess, Payload: PrepareEnvSuccessPayload{}})
+		},
+		StartConnecting: func(ctx *AppContext) {
+			if s.onConnecting != nil {
+				s.onConnecting(ctx)
+			}
+			gateway := &GatewayInfo{IP: "192.0.2.1", InterfaceName: "eth0", InterfaceIndex: 2}
+			payload := ConnectingSuccessPayload{
+				Tunnel:  GatewayInfo{IP: "172.19.0.1", InterfaceName: "tun0", InterfaceIndex: 9},
+				Server:  "203.0.113.10:443",
+				Gateway: gateway,
+			}
+			_ = s.machine.Dispatch(Event{Type: EventSysConnectingSuccess, Payload: payload})
+		},
+		UpdateUI: func(ctx *AppContext) {
+			s.mu.Lock()
+			s.statuses = append(s.statuses, ctx.UI.StatusText)
+			s.mu.Unlock()
+		},
+		OnStateChanged: func(prev, next State) {
+			s.mu.Lock()
+			s.transitions = append(s.transitions, [2]State{prev, next})
+			s.mu.Unlock()
+			if next == StateConnected {
+				close(s.connected)
+			}
+		},
+	})
+	s.machine = m
+	m.Start()
+	t.Cleanup(func() {
+		m.Stop()
+		m.WaitAsync(testWaitTimeout)
+	})
+	return m
+}
+
+// runConnectFlow проходит путь запуск → вход → синхронизация → подключение, как это делает UI.
+func runConnectFlow(t *testing.T, m *Machine, s *testScenarios) {
+	t.Helper()
+	dispatch := func(evt Event) {
+		if err := m.Dispatch(evt); err != nil {
+			t.Fatalf("dispatch %s: %v", evt.Type, err)
+		}
+	}
+	dispatch(Event{Type: EventUILaunch})
+	waitForSession(t, m.ctx, StateWaitingLogin)
+	dispatch(Event{Type: EventUIClickLogin, Payload: CredentialsPayload{Login: "user", Password: "secret"}})
+	waitForSession(t, m.ctx, StateReadyDisconnected)
+	dispatch(Event{Type: EventUISelectProfile, Payload: SelectionPayload{ID: "p1"}})
+	dispatch(Event{Type: EventUIClickConnect})
+	select {
+	case <-s.connected:
+	case <-time.After(testWaitTimeout):
+		t.Fatalf("machine did not reach %s, session state %s", StateConnected, m.ctx.Session().State)
+	}
+}
+
+func waitForSession(t *testing.T, ctx *AppContext, want State) {
+	t.Helper()
+	deadline := time.Now().Add(testWaitTimeout)
+	for ctx.Session().State != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("state %s not reached, got %s", want, ctx.Session().State)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMachineConnectTransitionSequence(t *testing.T) {
+	s := &testScenarios{}
+	m := newTestMachine(t, s)
+	runConnectFlow(t, m, s)
+
+	want := [][2]State{
+		{StateAppStarting, StatePreflightCheck},
+		{StatePreflightCheck, StateWaitingLogin},
+		{StateWaitingLogin, StateAuthInProgress},
+		{StateAuthInProgress, StateSyncInProgress},
+		{StateSyncInProgress, StatePreparingEnv},
+		{StatePreparingEnv, StateReadyDisconnected},
+		{StateReadyDisconnected, StateConnecting},
+		{StateConnecting, StateConnected},
+	}
+	s.mu.Lock()
+	got := append([][2]State(nil), s.transitions...)
+	s.mu.Unlock()
+	if len(got) != len(want) {
+		t.Fatalf("transitions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("transition %d = %s → %s, want %s → %s", i, got[i][0], got[i][1], want[i][0], want[i][1])
+		}
+	}
+
+	if gateway, _ := m.ctx.DefaultGateways(); gateway == nil || gateway.InterfaceName != "eth0" {
+		t.Fatalf("default gateway = %+v, want eth0 from the connecting result", gateway)
+	}
+}
+
+// TestAppContextConcurrentAccess проверяется с -race: сценарии пишут поля под mutex,
+// а UI и диагностика читают их и снимок Session, пока цикл событий подключается.
+func TestAppContextConcurrentAccess(t *testing.T) {
+	s := &testScenarios{
+		onConnecting: func(ctx *AppContext) {
+			ctx.SetGatewayChoice("eth0")
+			ctx.SetKillSwitch([]string{"CustomVPN-KillSwitch-DNS"})
+			ctx.SetCoreConfigFilePath("p1", "/tmp/core-p1.json")
+		},
+	}
+	m := newTestMachine(t, s)
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				session := m.ctx.Session()
+				if session.LastError != nil {
+					_ = session.LastError.UserMessage
+				}
+				_ = m.ctx.Profiles()
+				_, _ = m.ctx.SelectedProfile()
+				_, _ = m.ctx.DefaultGateways()
+				_, _ = m.ctx.KillSwitch()
+				_ = m.ctx.AuthToken()
+				_ = m.ctx.GatewayChoice()
+			}
+		}()
+	}
+	runConnectFlow(t, m, s)
+	close(stop)
+	readers.Wait()
+
+	if rules, active := m.ctx.KillSwitch(); !active || len(rules) != 1 {
+		t.Fatalf("kill switch = %v active=%v", rules, active)
+	}
+	waitForSession(t, m.ctx, StateConnected)
+	session := m.ctx.Session()
+	if session.ConnectedSince == nil || session.ServerInfo == nil || session.ServerInfo.Status != "ok" {
+		t.Fatalf("session snapshot = %+v, want connected with server info", session)
+	}
+}
+
+func TestSyncProgressUpdatesStatusWithoutLeavingSync(t *testing.T) {
+	progressSent := make(chan struct{})
+	s := &testScenarios{}
+	s.onSync = func() {
+		// короткий каталог: прогресс не показывается
+		_ = s.machine.Dispatch(Event{Type: EventSysSyncProgress, Payload: SyncProgressPayload{Done: 1, Total: syncProgressMinTotal - 1}})
+		_ = s.machine.Dispatch(Event{Type: EventSysSyncProgress, Payload: SyncProgressPayload{Done: 12, Total: 80}})
+		close(progressSent)
+	}
+	m := newTestMachine(t, s)
+	if err := m.Dispatch(Event{Type: EventUILaunch}); err != nil {
+		t.Fatalf("dispatch launch: %v", err)
+	}
+	waitForSession(t, m.ctx, StateWaitingLogin)
+	if err := m.Dispatch(Event{Type: EventUIClickLogin, Payload: CredentialsPayload{Login: "user", Password: "secret"}}); err != nil {
+		t.Fatalf("dispatch login: %v", err)
+	}
+	<-progressSent
+	want := i18n.Tf("status.loading_profiles", 12, 80)
+	deadline := time.Now().Add(testWaitTimeout)
+	for {
+		statuses := s.statusTexts()
+		if len(statuses) > 0 && statuses[len(statuses)-1] == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status texts = %q, want last %q", statuses, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for _, status := range s.statusTexts() {
+		if status == i18n.Tf("status.loading_profiles", 1, syncProgressMinTotal-1) {
+			t.Fatalf("progress of a small catalog must not be shown")
+		}
+	}
+	if current := m.ctx.Session().State; current != StateSyncInProgress {
+		t.Fatalf("state = %s, want %s", current, StateSyncInProgress)
+	}
+}
@@ -14,25 +14,64 @@ type ErrorKind string
 const (
 	ErrorKindNetworkUnavailable ErrorKind = "NetworkUnavailable"
 	ErrorKindAuthFailed         ErrorKind = "AuthFailed"
+	// ErrorKindAccountLocked — учётная запись заблокирована или отключена на сервере.
+	ErrorKindAccountLocked ErrorKind = "AccountLocked"
+	// ErrorKindTooManyAttempts — сервер временно запретил вход после серии неудачных попыток.
+	ErrorKindTooManyAttempts    ErrorKind = "TooManyAttempts"
 	ErrorKindSyncFailed         ErrorKind = "SyncFailed"
 	ErrorKindRoutingFailed      ErrorKind = "RoutingFailed"
 	ErrorKindProcessFailed      ErrorKind = "ProcessFailed"
 	ErrorKindConfigFailed       ErrorKind = "ConfigFailed"
+	ErrorKindIncompatibleServer ErrorKind = "IncompatibleServer"
 	ErrorKindUnknown            ErrorKind = "Unknown"
 )
 
 // Profile описывает прокси-сервер, полученный от Control-сервера и используемый в приложении.
 type Profile struct {
-	ID                 string          `json:"id"`
-	Name               string          `json:"name"`
-	Country            string          `json:"country"`
-	Host               string          `json:"host"`
-	Port               int             `json:"port"`
-	CoreConfigRaw      json.RawMessage `json:"core_config"`
-	DirectRoutes       []string        `json:"direct_routes"`
-	TunnelRoutes       []string        `json:"tunnel_routes"`
-	KillSwitchEnabled  bool            `json:"kill_switch"`
-	CoreConfigFilePath string          `json:"-"`
+	ID                string          `json:"id"`
+	Name              string          `json:"name"`
+	Country           string          `json:"country"`
+	Host              string          `json:"host"`
+	Port              int             `json:"port"`
+	CoreConfigRaw     json.RawMessage `json:"core_config"`
+	DirectRoutes      []string        `json:"direct_routes"`
+	TunnelRoutes      []string        `json:"tunnel_routes"`
+	KillSwitchEnabled bool            `json:"kill_switch"`
+	IPv6Enabled       bool            `json:"enable_ipv6"`
+	// TunnelDNS — DNS-серверы, назначаемые интерфейсу туннеля; пусто — сервер по умолчанию.
+	TunnelDNS []string `json:"tunnel_dns"`
+	// PreConnectCmd и PostDisconnectCmd выполняются до подключения и после отключения,
+	// только если в config.yaml включён allow_profile_hooks.
+	PreConnectCmd     string `json:"pre_connect_cmd"`
+	PostDisconnectCmd string `json:"post_disconnect_cmd"`
+	// Status — ProfileStatusActive или ProfileStatusDisabled: отключённый профиль виден в списке,
+	// но подключиться к нему нельзя.
+	Status string `json:"status"`
+	// RouteMetric больше 0 заменяет метрику шлюза у всех маршрутов профиля (1..MaxRouteMetric).
+	RouteMetric        int    `json:"route_metric"`
+	CoreConfigFilePath string `json:"-"`
+}
+
+// Состояния профиля (Profile.Status).
+const (
+	ProfileStatusActive   = "active"
+	ProfileStatusDisabled = "disabled"
+)
+
+// MaxRouteMetric — наибольшая допустимая Profile.RouteMetric (предел route.exe на Windows).
+const MaxRouteMetric = 9999
+
+// Enabled сообщает, можно ли подключаться к профилю.
+func (p Profile) Enabled() bool {
+	return p.Status != ProfileStatusDisabled
+}
+
+// ServerInfo описывает Control-сервер по ответу /health.
+type ServerInfo struct {
+	Status     string
+	APIVersion string
+	// ClockSkew — насколько часы сервера (заголовок Date) опережают локальные; 0, если Date нет.
+	ClockSkew time.Duration
 }
 
 // GatewayInfo описывает маршрут по умолчанию Windows.
@@ -64,10 +103,14 @@ type RouteRecord struct {
 	Active         bool
 }
 
-// RoutesRegistry хранит добавленные маршруты.
+// RoutesRegistry хранит добавленные маршруты. После EnablePersistence каждое изменение
+// сохраняется в файл, чтобы следующий запуск мог удалить маршруты, оставшиеся после сбоя.
 type RoutesRegistry struct {
 	mu     sync.RWMutex
 	Routes map[string]RouteRecord
+
+	path           string
+	onPersistError func(error)
 }
 
 // NewRoutesRegistry создаёт пустой реестр маршрутов.
@@ -83,13 +126,18 @@ func (r *RoutesRegistry) Upsert(record RouteRecord) {
 		record.CreatedAt = time.Now()
 	}
 	r.Routes[record.ID] = record
+	r.persist()
 }
 
 // Remove удаляет запись маршрута по ID.
 func (r *RoutesRegistry) Remove(id string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, ok := r.Routes[id]; !ok {
+		return
+	}
 	delete(r.Routes, id)
+	r.persist()
 }
 
 // ListByKinds возвращает копию записей по указанным типам маршрутов.
@@ -117,6 +165,52 @@ func (r *RoutesRegistry) ListByKinds(kinds ...RouteKind) []RouteRecord {
 	return filtered
 }
 
+// DNSRecord описывает интерфейс, DNS которого изменён приложением.
+type DNSRecord struct {
+	Interface string
+	Servers   []string
+	AppliedAt time.Time
+}
+
+// DNSRegistry хранит интерфейсы, DNS которых нужно восстановить при отключении.
+type DNSRegistry struct {
+	mu      sync.RWMutex
+	Entries map[string]DNSRecord
+}
+
+// NewDNSRegistry создаёт пустой реестр DNS.
+func NewDNSRegistry() DNSRegistry {
+	return DNSRegistry{Entries: make(map[string]DNSRecord)}
+}
+
+// Upsert обновляет или добавляет запись по имени интерфейса.
+func (r *DNSRegistry) Upsert(record DNSRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record.AppliedAt.IsZero() {
+		record.AppliedAt = time.Now()
+	}
+	r.Entries[record.Interface] = record
+}
+
+// Remove удаляет запись интерфейса.
+func (r *DNSRegistry) Remove(iface string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.Entries, iface)
+}
+
+// List возвращает копию всех записей.
+func (r *DNSRegistry) List() []DNSRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]DNSRecord, 0, len(r.Entries))
+	for _, record := range r.Entries {
+		all = append(all, record)
+	}
+	return all
+}
+
 // ProcessName идентифицирует процесс Core.
 type ProcessName string
 
@@ -134,6 +228,37 @@ const (
 	ProcessFailed   ProcessStatus = "Failed"
 )
 
+// DisconnectReason — причина последнего завершения подключения.
+type DisconnectReason string
+
+const (
+	DisconnectReasonNone DisconnectReason = ""
+	DisconnectReasonUser DisconnectReason = "User"
+	// DisconnectReasonLost — подключение разорвано падением Core, а не пользователем.
+	DisconnectReasonLost DisconnectReason = "Lost"
+)
+
+// SessionEndReason — причина завершения сессии в истории подключений.
+type SessionEndReason string
+
+const (
+	SessionEndUser  SessionEndReason = "user"
+	SessionEndCrash SessionEndReason = "crash"
+	// SessionEndError — прочие разрывы: сбой проверки туннеля, смена сети, таймаут.
+	SessionEndError SessionEndReason = "error"
+)
+
+// SessionRecord — запись истории подключений.
+type SessionRecord struct {
+	ProfileID   string    `json:"profile_id"`
+	ProfileName string    `json:"profile_name"`
+	ConnectedAt time.Time `json:"connected_at"`
+	// DisconnectedAt — nil, пока сессия идёт или если клиент завершился, не записав её конец.
+	DisconnectedAt *time.Time       `json:"disconnected_at,omitempty"`
+	Duration       time.Duration    `json:"duration"`
+	Reason         SessionEndReason `json:"reason,omitempty"`
+}
+
 // ProcessRecord хранит сведения о дочернем процессе.
 type ProcessRecord struct {
 	Name       ProcessName
@@ -173,6 +298,17 @@ func (r *ProcessRegistry) Get(name ProcessName) (ProcessRecord, bool) {
 	return record, ok
 }
 
+// List возвращает копию всех записей процессов.
+func (r *ProcessRegistry) List() []ProcessRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]ProcessRecord, 0, len(r.Processes))
+	for _, record := range r.Processes {
+		all = append(all, record)
+	}
+	return all
+}
+
 // ErrorInfo описывает ошибку для UI и логов.
 type ErrorInfo struct {
 	Kind             ErrorKind
@@ -183,31 +319,90 @@ type ErrorInfo struct {
 
 // UIState хранит минимально необходимую информацию для управления UI.
 type UIState struct {
-	IsLoginVisible      bool
-	IsMainVisible       bool
-	IsConnecting        bool
-	IsConnected         bool
-	SelectedProfileID   string
-	StatusText          string
-	LoginInput          string
-	PasswordInput       string
+	IsLoginVisible bool
+	IsMainVisible  bool
+	IsConnecting   bool
+	IsConnected    bool
+	// IsReconnecting — идёт автоматическое переподключение: Reconnecting и следующий за ним Connecting.
+	IsReconnecting bool
+	// IsRefreshing — идёт обновление списка профилей по кнопке «Обновить».
+	IsRefreshing      bool
+	SelectedProfileID string
+	StatusText        string
+	LoginInput        string
+	PasswordInput     string
+	// RememberCredentials — отмечен «Запомнить меня»: после входа логин и пароль сохраняются в хранилище ОС.
+	RememberCredentials bool
 	CanLogin            bool
 	AllowPreflightRetry bool
+	// DisconnectReason — почему завершилось последнее подключение; сбрасывается при новом подключении.
+	DisconnectReason DisconnectReason
+}
+
+// InterfaceCounters — счётчики байт сетевого интерфейса на момент SampledAt.
+type InterfaceCounters struct {
+	InterfaceIndex int
+	BytesIn        uint64
+	BytesOut       uint64
+	SampledAt      time.Time
+}
+
+// TrafficStats — последние счётчики туннеля и скорость (байт/с) между двумя замерами.
+type TrafficStats struct {
+	Counters InterfaceCounters
+	RateIn   float64
+	RateOut  float64
+}
+
+// SessionSnapshot — копия полей цикла событий для чтения из других горутин
+// (диагностика, предпросмотр подключения).
+type SessionSnapshot struct {
+	State            State
+	ServerInfo       *ServerInfo
+	LastError        *ErrorInfo
+	ReconnectAttempt int
+	ConnectedSince   *time.Time
 }
 
 // AppContext содержит всё состояние приложения.
+// Поля под mu (токен, профили, выбранный профиль, шлюзы, Kill Switch) пишут и сценарии
+// в горутинах runAsync, и цикл событий, поэтому доступ к ним — только через методы.
+// Остальные поля меняет и читает лишь цикл событий; другие горутины читают их через Session.
 type AppContext struct {
-	Config            *config.Config
-	AuthToken         string
-	Profiles          []Profile
-	SelectedProfileID string
-	DefaultGateway    *GatewayInfo
-	KillSwitchRules   []string
-	RoutesRegistry    RoutesRegistry
-	ProcessRegistry   ProcessRegistry
-	LastError         *ErrorInfo
-	UI                UIState
-	State             State
+	Config *config.Config
+	// ServerInfo — сведения о Control-сервере, полученные при preflight.
+	ServerInfo      *ServerInfo
+	RoutesRegistry  RoutesRegistry
+	DNSRegistry     DNSRegistry
+	ProcessRegistry ProcessRegistry
+	LastError       *ErrorInfo
+	UI              UIState
+	State           State
+	// ConnectedSince — момент последнего входа в Connected; nil, если соединения нет.
+	ConnectedSince *time.Time
+	// TunnelInterface — интерфейс туннеля текущего подключения; nil вне Connected.
+	TunnelInterface *GatewayInfo
+	// ConnectedServer — адрес сервера текущего подключения (host:port); пусто вне Connected.
+	ConnectedServer string
+	// Traffic — последние счётчики трафика туннеля; nil, пока нет двух замеров.
+	Traffic *TrafficStats
+	// ReconnectAttempt — номер текущей попытки автоматического переподключения (0 — не переподключаемся).
+	ReconnectAttempt int
+
+	mu                sync.RWMutex
+	authToken         string
+	profiles          []Profile
+	selectedProfileID string
+	defaultGateway    *GatewayInfo
+	// defaultGatewayV6 — маршрут по умолчанию IPv6; nil, если IPv6 недоступен.
+	defaultGatewayV6 *GatewayInfo
+	killSwitchRules  []string
+	// killSwitchActive — DNS вне туннеля заблокирован Kill Switch текущего подключения.
+	killSwitchActive bool
+	// gatewayChoice — интерфейс шлюза, выбранный пользователем при нескольких маршрутах по умолчанию.
+	gatewayChoice string
+	// session — снимок полей цикла событий, публикуемый после каждого события.
+	session SessionSnapshot
 }
 
 // NewAppContext создаёт AppContext с инициализированными реестрами.
@@ -215,17 +410,209 @@ func NewAppContext(cfg *config.Config) *AppContext {
 	return &AppContext{
 		Config:          cfg,
 		RoutesRegistry:  NewRoutesRegistry(),
+		DNSRegistry:     NewDNSRegistry(),
 		ProcessRegistry: NewProcessRegistry(),
 		State:           StateAppStarting,
+		session:         SessionSnapshot{State: StateAppStarting},
+	}
+}
+
+// Session возвращает снимок полей цикла событий на момент последнего обработанного события.
+func (ctx *AppContext) Session() SessionSnapshot {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.session
+}
+
+// PublishSession копирует поля цикла событий в снимок Session. Вызывается только из цикла событий.
+func (ctx *AppContext) PublishSession() {
+	snap := SessionSnapshot{State: ctx.State, ReconnectAttempt: ctx.ReconnectAttempt}
+	if ctx.ServerInfo != nil {
+		server := *ctx.ServerInfo
+		snap.ServerInfo = &server
+	}
+	if ctx.LastError != nil {
+		lastErr := *ctx.LastError
+		snap.LastError = &lastErr
+	}
+	if ctx.ConnectedSince != nil {
+		since := *ctx.ConnectedSince
+		snap.ConnectedSince = &since
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.session = snap
+}
+
+// AuthToken возвращает токен текущей сессии.
+func (ctx *AppContext) AuthToken() string {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.authToken
+}
+
+// SetAuthToken сохраняет токен сессии; пустая строка сбрасывает его.
+func (ctx *AppContext) SetAuthToken(token string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.authToken = token
+}
+
+// Profiles возвращает копию списка профилей.
+func (ctx *AppContext) Profiles() []Profile {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return append([]Profile(nil), ctx.profiles...)
+}
+
+// SetProfiles заменяет список профилей. Если выбранного профиля в нём больше нет,
+// выбор сбрасывается и возвращается false.
+func (ctx *AppContext) SetProfiles(profiles []Profile) bool {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.profiles = profiles
+	if ctx.selectedProfileID != "" && ctx.indexOfProfileLocked(ctx.selectedProfileID) < 0 {
+		ctx.selectedProfileID = ""
+		return false
+	}
+	return true
+}
+
+// SelectedProfileID возвращает ID выбранного профиля.
+func (ctx *AppContext) SelectedProfileID() string {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.selectedProfileID
+}
+
+// SetSelectedProfileID выбирает профиль.
+func (ctx *AppContext) SetSelectedProfileID(id string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.selectedProfileID = id
+}
+
+// FindProfile возвращает копию профиля с указанным ID.
+func (ctx *AppContext) FindProfile(id string) (Profile, bool) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	if i := ctx.indexOfProfileLocked(id); i >= 0 {
+		return ctx.profiles[i], true
+	}
+	return Profile{}, false
+}
+
+// SelectedProfile возвращает копию выбранного профиля.
+func (ctx *AppContext) SelectedProfile() (Profile, bool) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	if i := ctx.indexOfProfileLocked(ctx.selectedProfileID); i >= 0 {
+		return ctx.profiles[i], true
 	}
+	return Profile{}, false
 }
 
-func (ctx *AppContext) FindProfile(id string) *Profile {
-	for i := range ctx.Profiles {
-		if ctx.Profiles[i].ID == id {
-			return &ctx.Profiles[i]
+// UpdateProfile заменяет профиль с тем же ID; false, если такого профиля нет.
+func (ctx *AppContext) UpdateProfile(profile Profile) bool {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	i := ctx.indexOfProfileLocked(profile.ID)
+	if i < 0 {
+		return false
+	}
+	ctx.profiles[i] = profile
+	return true
+}
+
+// SetCoreConfigFilePath запоминает путь к конфигурации Core профиля; пустой путь сбрасывает его.
+func (ctx *AppContext) SetCoreConfigFilePath(profileID, path string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if i := ctx.indexOfProfileLocked(profileID); i >= 0 {
+		ctx.profiles[i].CoreConfigFilePath = path
+	}
+}
+
+func (ctx *AppContext) indexOfProfileLocked(id string) int {
+	if id == "" {
+		return -1
+	}
+	for i := range ctx.profiles {
+		if ctx.profiles[i].ID == id {
+			return i
 		}
 	}
-	return nil
+	return -1
+}
+
+// DefaultGateways возвращает маршруты по умолчанию IPv4 и IPv6, сохранённые при подключении.
+func (ctx *AppContext) DefaultGateways() (*GatewayInfo, *GatewayInfo) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.defaultGateway, ctx.defaultGatewayV6
+}
+
+// SetDefaultGateways сохраняет маршруты по умолчанию IPv4 и IPv6.
+func (ctx *AppContext) SetDefaultGateways(v4, v6 *GatewayInfo) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.defaultGateway = v4
+	ctx.defaultGatewayV6 = v6
+}
+
+// SetDefaultGateway обновляет только маршрут по умолчанию IPv4.
+func (ctx *AppContext) SetDefaultGateway(v4 *GatewayInfo) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.defaultGateway = v4
 }
 
+// GatewayChoice возвращает интерфейс шлюза, выбранный пользователем; пусто, если выбора не было.
+func (ctx *AppContext) GatewayChoice() string {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.gatewayChoice
+}
+
+// SetGatewayChoice запоминает выбранный интерфейс до выхода из клиента; пустая строка сбрасывает выбор.
+func (ctx *AppContext) SetGatewayChoice(iface string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.gatewayChoice = iface
+}
+
+// KillSwitch возвращает копию правил Kill Switch и признак его активности.
+func (ctx *AppContext) KillSwitch() ([]string, bool) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return append([]string(nil), ctx.killSwitchRules...), ctx.killSwitchActive
+}
+
+// KillSwitchActive сообщает, заблокирован ли DNS вне туннеля.
+func (ctx *AppContext) KillSwitchActive() bool {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	return ctx.killSwitchActive
+}
+
+// SetKillSwitch заменяет правила Kill Switch; он активен, если правила есть.
+func (ctx *AppContext) SetKillSwitch(rules []string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.killSwitchRules = append([]string(nil), rules...)
+	ctx.killSwitchActive = len(rules) > 0
+}
+
+// AddKillSwitchRules добавляет правила, которые снимаются вместе с Kill Switch.
+func (ctx *AppContext) AddKillSwitchRules(rules []string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.killSwitchRules = append(ctx.killSwitchRules, rules...)
+}
+
+// SetKillSwitchActive меняет признак активности, не трогая список правил.
+func (ctx *AppContext) SetKillSwitchActive(active bool) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.killSwitchActive = active
+}
@@ -0,0 +1,72 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnablePersistence включает запись реестра в path при каждом Upsert/Remove.
+// Файл переписывается целиком под мьютексом реестра, поэтому всегда совпадает с памятью.
+// onError получает ошибки записи; запись в памяти при этом сохраняется.
+func (r *RoutesRegistry) EnablePersistence(path string, onError func(error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.path = path
+	r.onPersistError = onError
+	return r.persistLocked()
+}
+
+// LoadPersistedRoutes читает маршруты, сохранённые предыдущим запуском.
+// Отсутствующий файл означает пустой список.
+func LoadPersistedRoutes(path string) ([]RouteRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []RouteRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return records, nil
+}
+
+func (r *RoutesRegistry) persist() {
+	if r.path == "" {
+		return
+	}
+	if err := r.persistLocked(); err != nil && r.onPersistError != nil {
+		r.onPersistError(err)
+	}
+}
+
+// persistLocked атомарно записывает реестр: временный файл и rename. Вызывается под r.mu.
+func (r *RoutesRegistry) persistLocked() error {
+	if r.path == "" {
+		return nil
+	}
+	records := make([]RouteRecord, 0, len(r.Routes))
+	for _, record := range r.Routes {
+		records = append(records, record)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
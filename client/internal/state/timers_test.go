@@ -0,0 +1,57 @@
+package state
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"customvpn/client/internal/config"
+)
+
+// TestRetryTimersScheduleCancelStop проверяется с -race: таймеры повторов заводятся и снимаются
+// из разных горутин, срабатывают (Dispatch из горутины таймера) и останавливаются вместе с Stop.
+func TestRetryTimersScheduleCancelStop(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		ctx := NewAppContext(&config.Config{AutoReconnectDelay: time.Microsecond})
+		m := NewMachine(ctx, newTestLogger(t), Callbacks{})
+		m.Start()
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					m.schedulePreflightRetry(time.Microsecond)
+					m.scheduleReconnect()
+					if i%3 == 0 {
+						m.cancelPreflightRetry()
+						m.cancelReconnect()
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func(delay time.Duration) {
+			defer wg.Done()
+			time.Sleep(delay)
+			m.Stop()
+		}(time.Duration(round) * 50 * time.Microsecond)
+		wg.Wait()
+		m.Stop()
+		if !m.WaitAsync(testWaitTimeout) {
+			t.Fatalf("round %d: background tasks did not finish", round)
+		}
+
+		// после Stop таймеры не остаются заведёнными
+		m.timersMu.Lock()
+		preflight, reconnect := m.preflightRetryTimer, m.reconnectTimer
+		m.timersMu.Unlock()
+		if preflight != nil || reconnect != nil {
+			t.Fatalf("round %d: timers left after Stop: preflight=%v reconnect=%v", round, preflight != nil, reconnect != nil)
+		}
+		if err := m.Dispatch(Event{Type: EventSysPreflightRetry}); err != ErrMachineStopped {
+			t.Fatalf("round %d: dispatch after Stop = %v, want ErrMachineStopped", round, err)
+		}
+	}
+}
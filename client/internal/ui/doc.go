@@ -1,3 +1,3 @@
 package ui
 
-// Package ui will be responsible for the Fyne-powered interface and tray integration.
+// Package ui implements app.UI with Fyne windows and tray integration.
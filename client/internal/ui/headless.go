@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+
+	"fyne.io/fyne/v2"
+)
+
+// headlessTrayMenu собирает меню трея для режима без окон: окон нет, поэтому вместо
+// «Показать»/«Скрыть» — управление подключением.
+func (m *Manager) headlessTrayMenu(diagnosticsItem, quitItem *fyne.MenuItem) *fyne.Menu {
+	connectItem := fyne.NewMenuItem(i18n.T("ui.connect"), func() { m.sendSimpleEvent(state.EventTrayConnect) })
+	disconnectItem := fyne.NewMenuItem(i18n.T("ui.disconnect"), func() { m.sendSessionEvent(state.EventTrayDisconnect) })
+	return fyne.NewMenu(m.appName, connectItem, disconnectItem, fyne.NewMenuItemSeparator(), diagnosticsItem, fyne.NewMenuItemSeparator(), quitItem)
+}
+
+// logStatus пишет в лог смену строки статуса. Вызывается только из UI goroutine.
+func (m *Manager) logStatus(status string) {
+	if status == m.lastStatus {
+		return
+	}
+	m.lastStatus = status
+	if m.logger != nil && status != "" {
+		m.logger.Infof("status: %s", status)
+	}
+}
+
+func (m *Manager) logError(info *state.ErrorInfo) {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Errorf("error %s: %s (%s)", info.Kind, info.UserMessage, info.TechnicalMessage)
+}
+
+func (m *Manager) logNotice(message string) {
+	if m.logger != nil {
+		m.logger.Infof("notice: %s", message)
+	}
+}
+
+func (m *Manager) logCleanupDone(result state.CleanupResultPayload) {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Infof("cleanup finished: routes removed %d, firewall rules removed %d, errors %d", result.RoutesRemoved, result.FirewallRulesRemoved, len(result.Errors))
+	for _, err := range result.Errors {
+		m.logger.Errorf("cleanup error: %s", err)
+	}
+}
+
+func (m *Manager) logDiagnostics(path string, err error) {
+	if m.logger == nil {
+		return
+	}
+	if err != nil {
+		m.logger.Errorf("collect diagnostics failed: %v", err)
+		return
+	}
+	m.logger.Infof("diagnostics archive saved: %s", path)
+}
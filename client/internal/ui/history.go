@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// historyColumns — ключи каталога для заголовков столбцов окна истории подключений.
+var historyColumns = []string{"ui.history_profile", "ui.history_connected", "ui.history_disconnected", "ui.history_duration", "ui.history_reason"}
+
+var historyColumnWidths = []float32{200, 150, 150, 110, 120}
+
+var sessionEndTitles = map[state.SessionEndReason]string{
+	state.SessionEndUser:  "ui.end_user",
+	state.SessionEndCrash: "ui.end_crash",
+	state.SessionEndError: "ui.end_error",
+}
+
+// ShowSessionHistory открывает окно истории подключений; повторный вызов обновляет уже открытое окно.
+func (m *Manager) ShowSessionHistory() {
+	m.callOnUI(func() {
+		if m.sessionHistory == nil {
+			dialog.ShowInformation(i18n.T("ui.history"), i18n.T("ui.history_unavailable"), m.activeWindow())
+			return
+		}
+		if m.historyWin != nil {
+			m.historyWin.SetContent(m.buildHistoryContent())
+			m.historyWin.RequestFocus()
+			return
+		}
+		win := m.app.NewWindow(i18n.T("ui.history_window"))
+		win.Resize(fyne.NewSize(760, 420))
+		win.SetContent(m.buildHistoryContent())
+		win.SetOnClosed(func() { m.historyWin = nil })
+		m.historyWin = win
+		win.Show()
+	})
+}
+
+func (m *Manager) buildHistoryContent() fyne.CanvasObject {
+	records := m.sessionHistory()
+	if len(records) == 0 {
+		return container.NewCenter(widget.NewLabel(i18n.T("ui.history_empty")))
+	}
+	table := widget.NewTableWithHeaders(
+		func() (int, int) { return len(records), len(historyColumns) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(historyCell(records[id.Row], id.Col))
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		if id.Row < 0 && id.Col >= 0 {
+			obj.(*widget.Label).SetText(i18n.T(historyColumns[id.Col]))
+		}
+	}
+	for col, width := range historyColumnWidths {
+		table.SetColumnWidth(col, width)
+	}
+	return table
+}
+
+func historyCell(record state.SessionRecord, col int) string {
+	switch col {
+	case 0:
+		if record.ProfileName != "" {
+			return record.ProfileName
+		}
+		return record.ProfileID
+	case 1:
+		return record.ConnectedAt.Local().Format("02.01.2006 15:04:05")
+	case 2:
+		if record.DisconnectedAt == nil {
+			return "—"
+		}
+		return record.DisconnectedAt.Local().Format("02.01.2006 15:04:05")
+	case 3:
+		if record.DisconnectedAt == nil {
+			return "—"
+		}
+		return formatSessionDuration(record.Duration)
+	case 4:
+		if record.DisconnectedAt == nil {
+			return i18n.T("ui.history_open")
+		}
+		if key, ok := sessionEndTitles[record.Reason]; ok {
+			return i18n.T(key)
+		}
+		return string(record.Reason)
+	}
+	return ""
+}
+
+func formatSessionDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total/60)%60, total%60)
+}
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// shieldSVG — значок щита для индикатора Kill Switch.
+const shieldSVG = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path fill="#000000" d="M12 1 3 5v6c0 5.55 3.84 10.74 9 12 5.16-1.26 9-6.45 9-12V5l-9-4z"/></svg>`
+
+var shieldResource = fyne.NewStaticResource("shield.svg", []byte(shieldSVG))
+
+var (
+	killSwitchOnIcon  = theme.NewSuccessThemedResource(shieldResource)
+	killSwitchOffIcon = theme.NewDisabledResource(shieldResource)
+)
+
+// updateKillSwitchIcon подсвечивает щит, пока Kill Switch подключения блокирует DNS вне туннеля.
+func (m *Manager) updateKillSwitchIcon(snap uiSnapshot) {
+	if m.killSwitchIcon == nil {
+		return
+	}
+	if snap.IsConnected && snap.KillSwitchActive {
+		m.killSwitchIcon.SetResource(killSwitchOnIcon)
+		return
+	}
+	m.killSwitchIcon.SetResource(killSwitchOffIcon)
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/logging"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// logViewerMaxBytes — сколько последних байт лога Core держать в окне.
+	logViewerMaxBytes = 64 * 1024
+	logViewerInterval = time.Second
+)
+
+// ShowCoreLogs открывает окно с хвостом лога Core; повторный вызов поднимает уже открытое окно.
+func (m *Manager) ShowCoreLogs() {
+	m.callOnUI(func() {
+		if m.logWin != nil {
+			m.logWin.RequestFocus()
+			return
+		}
+		if m.coreLogFile == "" {
+			dialog.ShowInformation(i18n.T("ui.logs_title"), i18n.T("ui.core_log_missing"), m.activeWindow())
+			return
+		}
+		m.openLogWindow()
+	})
+}
+
+func (m *Manager) openLogWindow() {
+	win := m.app.NewWindow(i18n.T("ui.core_logs_window"))
+	win.Resize(fyne.NewSize(820, 480))
+	output := widget.NewMultiLineEntry()
+	output.Wrapping = fyne.TextWrapOff
+	output.TextStyle = fyne.TextStyle{Monospace: true}
+	output.Disable()
+	win.SetContent(output)
+
+	tailer := logging.NewTailer(m.coreLogFile, logViewerMaxBytes)
+	stop := make(chan struct{})
+	win.SetOnClosed(func() {
+		close(stop)
+		m.logWin = nil
+	})
+	m.logWin = win
+	win.Show()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("core log viewer")
+		ticker := time.NewTicker(logViewerInterval)
+		defer ticker.Stop()
+		for {
+			text, err := tailer.Read()
+			if err != nil && m.logger != nil {
+				m.logger.Debugf("core log viewer: %v", err)
+			}
+			if text != "" {
+				m.callOnUI(func() {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					output.SetText(trimLogText(output.Text+text, logViewerMaxBytes))
+					output.CursorRow = strings.Count(output.Text, "\n")
+				})
+			}
+			select {
+			case <-m.stopCh:
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// trimLogText оставляет не больше limit последних байт, обрезая по границе строки.
+func trimLogText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	text = text[len(text)-limit:]
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			return text[i+1:]
+		}
+	}
+	return text
+}
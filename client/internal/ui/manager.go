@@ -1,15 +1,18 @@
 ﻿package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
 	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
-	"unicode/utf8"
 
+	"customvpn/client/internal/config"
 	"customvpn/client/internal/logging"
+	"customvpn/client/internal/i18n"
 	"customvpn/client/internal/state"
 
 	"fyne.io/fyne/v2"
@@ -18,11 +21,9 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"fyne.io/systray"
-	"golang.org/x/text/encoding/charmap"
 )
 
 // Options описывает параметры инициализации UI Manager.
@@ -31,31 +32,109 @@ type Options struct {
 	AppName  string
 	Logger   *logging.Logger
 	Dispatch func(state.Event) error
+	// SaveSettings проверяет и сохраняет настройки; ошибка показывается в окне настроек.
+	SaveSettings func(config.Settings) error
+	// Theme — оформление окон из config.yaml: light, dark или system.
+	Theme string
+	// CoreLogFile — лог Core, который показывает окно «Показать логи».
+	CoreLogFile string
+	// ConfirmDisconnect — спрашивать подтверждение перед разрывом подключения или выходом во время сессии.
+	ConfirmDisconnect bool
+	// CollectDiagnostics собирает архив диагностики и возвращает путь к нему.
+	CollectDiagnostics func() (string, error)
+	// Headless — работа без окон: только трей, ошибки и уведомления пишутся в лог.
+	Headless bool
+	// PreviewConnect — отладочный предпросмотр маршрутов и DNS выбранного профиля;
+	// nil скрывает пункт меню.
+	PreviewConnect func() (string, error)
+	// CoreConfig — отладочная выгрузка конфигурации Core выбранного профиля со скрытыми секретами;
+	// nil скрывает пункт меню.
+	CoreConfig func() (string, error)
+	// ExportConnectPlan — план подключения к выбранному профилю в JSON для аудита;
+	// nil скрывает пункт меню.
+	ExportConnectPlan func() ([]byte, error)
+	// Favorites — ID избранных профилей; SaveFavorites сохраняет список после изменения.
+	Favorites     []string
+	SaveFavorites func([]string) error
+	// SessionHistory возвращает историю подключений, новые сессии первыми.
+	SessionHistory func() []state.SessionRecord
 }
 
+// uiShutdownTimeout ограничивает ожидание закрытия окон в Shutdown.
+const uiShutdownTimeout = 3 * time.Second
+
 // Manager управляет окнами Fyne и связывает их со state machine.
 type Manager struct {
 	app                     fyne.App
 	appName                 string
 	logger                  *logging.Logger
 	dispatch                func(state.Event) error
+	saveSettings            func(config.Settings) error
+	// theme — применённое оформление (light, dark, system); меняется только в goroutine UI.
+	theme                   string
+	coreLogFile             string
+	confirmDisconnect       bool
+	collectDiagnostics      func() (string, error)
+	previewConnect          func() (string, error)
+	coreConfig              func() (string, error)
+	exportConnectPlan       func() ([]byte, error)
+	saveFavorites           func([]string) error
+	sessionHistory          func() []state.SessionRecord
+	headless                bool
+	// lastStatus — последний записанный в лог статус в headless-режиме; читается в goroutine UI.
+	lastStatus              string
+	// sessionActive — последнее состояние Connected/Connecting из снимка; читается в goroutine UI.
+	sessionActive           bool
+	logWin                  fyne.Window
+	historyWin              fyne.Window
 	loginWin                fyne.Window
 	mainWin                 fyne.Window
 	loginWinVisible         bool
 	mainWinVisible          bool
 	loginEntry              *widget.Entry
 	passwordEntry           *widget.Entry
+	rememberCheck           *widget.Check
 	loginStatus             *widget.Label
 	loginBtn                *widget.Button
 	retryBtn                *widget.Button
 	mainStatus              *widget.Label
 	statusCircle            *canvas.Circle
+	// statusPulse — пульсация индикатора во время переподключения; nil, когда не запущена.
+	statusPulse             *fyne.Animation
+	// statusFlash — однократная красная вспышка индикатора после потери соединения.
+	statusFlash             *fyne.Animation
+	lastDisconnectReason    state.DisconnectReason
 	spinner                 *widget.ProgressBarInfinite
-	profileList             *widget.List
+	uptimeLabel             *widget.Label
+	uptimeSince             time.Time
+	uptimeStop              chan struct{}
+	trafficLabel            *widget.Label
+	// connectionLabel — сервер и интерфейс туннеля текущего подключения.
+	connectionLabel         *widget.Label
+	// killSwitchIcon — щит в строке статуса: подсвечен, пока Kill Switch активен.
+	killSwitchIcon          *widget.Icon
+	// trayAvailable — значок в трее установлен; без него скрытое окно нельзя вернуть.
+	trayAvailable           bool
+	// trayIcon — текущий значок трея; trayPulse — мигание во время подключения.
+	trayIcon                fyne.Resource
+	trayPulse               *fyne.Animation
+	// trayStateTitle и trayStatusText составляют подсказку значка; trayTooltip — последняя установленная.
+	trayStateTitle          string
+	trayStatusText          string
+	trayTooltip             string
+	profileTree             *widget.Tree
 	profiles                []state.Profile
+	// profileGroups — отфильтрованные профили, сгруппированные по странам для profileTree.
+	profileGroups           []profileGroup
+	// favorites — ID избранных профилей в порядке добавления; profileFavorites — видимые из них.
+	favorites               []string
+	profileFavorites        []state.Profile
+	selectedProfileID       string
+	profileFilter           *widget.Entry
 	connectBtn              *widget.Button
 	disconnectBtn           *widget.Button
 	settingsBtn             *widget.Button
+	refreshBtn              *widget.Button
 	exitBtn                 *widget.Button
 	cleanupDialog           *dialog.CustomDialog
 	cleanupDialogLabel      *widget.Label
@@ -65,6 +144,9 @@ type Manager struct {
 	suppressProfileSelect   bool
 	updateCh                chan uiSnapshot
 	stopCh                  chan struct{}
+	// loopDone закрывается после выхода из цикла Fyne: очередь главного потока больше не обрабатывается.
+	loopDone                chan struct{}
+	loopDoneOnce            sync.Once
 	runOnce                 sync.Once
 	shutdownOnce            sync.Once
 	wg                      sync.WaitGroup
@@ -77,13 +159,23 @@ type uiSnapshot struct {
 	MainVisible         bool
 	IsConnecting        bool
 	IsConnected         bool
+	IsReconnecting      bool
+	IsRefreshing        bool
+	KillSwitchActive    bool
+	DisconnectReason    state.DisconnectReason
 	SelectedProfileID   string
 	StatusText          string
 	CanLogin            bool
 	AllowPreflightRetry bool
 	LoginInput          string
 	PasswordInput       string
+	RememberCredentials bool
 	Profiles            []state.Profile
+	ConnectedSince      *time.Time
+	Traffic             *state.TrafficStats
+	// ConnectedServer и TunnelName описывают текущее подключение; пусты вне Connected.
+	ConnectedServer string
+	TunnelName      string
 }
 
 // NewManager создаёт новый UI Manager.
@@ -97,18 +189,33 @@ func NewManager(opts Options) *Manager {
 		name = "CustomVPN"
 	}
 	fyneApp := fyneapp.NewWithID(appID)
-	fyneApp.Settings().SetTheme(newWindows11Theme())
+	fyneApp.Settings().SetTheme(newWindows11Theme(opts.Theme))
 	m := &Manager{
 		app:      fyneApp,
 		appName:  name,
 		logger:   opts.Logger,
 		dispatch: opts.Dispatch,
+		saveSettings: opts.SaveSettings,
+		theme:        opts.Theme,
+		coreLogFile:  opts.CoreLogFile,
+		confirmDisconnect: opts.ConfirmDisconnect,
+		collectDiagnostics: opts.CollectDiagnostics,
+		previewConnect: opts.PreviewConnect,
+		coreConfig:     opts.CoreConfig,
+		exportConnectPlan: opts.ExportConnectPlan,
+		saveFavorites:  opts.SaveFavorites,
+		sessionHistory: opts.SessionHistory,
+		favorites:      append([]string(nil), opts.Favorites...),
+		headless: opts.Headless,
 		updateCh: make(chan uiSnapshot, 16),
 		stopCh:   make(chan struct{}),
+		loopDone: make(chan struct{}),
 		lastShownLogin: true,
 	}
-	m.buildLoginWindow()
-	m.buildMainWindow()
+	if !m.headless {
+		m.buildLoginWindow()
+		m.buildMainWindow()
+	}
 	m.setupTray()
 	return m
 }
@@ -144,18 +251,36 @@ func (m *Manager) Quit() {
 }
 
 // RunMainLoop блокирует текущую горутину до завершения цикла Fyne.
+// Если Shutdown уже вызван, цикл не запускается: Quit до Run не завершил бы его.
 func (m *Manager) RunMainLoop() {
+	defer m.loopDoneOnce.Do(func() { close(m.loopDone) })
 	if m.app == nil {
 		return
 	}
+	select {
+	case <-m.stopCh:
+		return
+	default:
+	}
 	m.app.Run()
 }
 
-// Shutdown останавливает обновления и закрывает Fyne-приложение.
+// Shutdown останавливает обновления и закрывает Fyne-приложение. Ждёт закрытия окон
+// не дольше uiShutdownTimeout: если цикл Fyne ещё не запущен, Quit выполнится при его старте.
 func (m *Manager) Shutdown() {
 	m.shutdownOnce.Do(func() {
 		close(m.stopCh)
-		m.callOnUI(func() {
+		closed := make(chan struct{})
+		go m.callOnUI(func() {
+			defer close(closed)
+			m.stopUptimeTicker()
+			m.stopStatusPulse()
+			if m.logWin != nil {
+				m.logWin.Close()
+			}
+			if m.historyWin != nil {
+				m.historyWin.Close()
+			}
 			if m.mainWin != nil {
 				m.mainWin.Close()
 			}
@@ -168,6 +293,14 @@ func (m *Manager) Shutdown() {
 				m.app.Quit()
 			}
 		})
+		select {
+		case <-closed:
+		case <-m.loopDone:
+		case <-time.After(uiShutdownTimeout):
+			if m.logger != nil {
+				m.logger.Errorf("ui shutdown: windows were not closed within %s", uiShutdownTimeout)
+			}
+		}
 	})
 }
 
@@ -205,10 +338,8 @@ func (m *Manager) ShowLoginWindow(_ *state.AppContext) {
 			if !wasVisible {
 				m.loginWin.Show()
 			}
-			if !wasVisible && m.loginEntry != nil {
-				if canvas := m.loginWin.Canvas(); canvas != nil {
-					canvas.Focus(m.loginEntry)
-				}
+			if !wasVisible {
+				m.focusLoginForm()
 			}
 			m.loginWinVisible = true
 			m.lastShownLogin = true
@@ -232,9 +363,12 @@ func (m *Manager) ShowMainWindow(_ *state.AppContext) {
 	})
 }
 
-// HideMainWindow скрывает главное окно.
+// HideMainWindow скрывает главное окно. Без трея окна остаются видимыми.
 func (m *Manager) HideMainWindow(_ *state.AppContext) {
 	m.callOnUI(func() {
+		if !m.trayAvailable {
+			return
+		}
 		if m.loginWin != nil {
 			m.loginWin.Hide()
 			m.loginWinVisible = false
@@ -256,24 +390,44 @@ func (m *Manager) UpdateUI(ctx *state.AppContext) {
 		MainVisible:         ctx.UI.IsMainVisible,
 		IsConnecting:        ctx.UI.IsConnecting,
 		IsConnected:         ctx.UI.IsConnected,
+		IsReconnecting:      ctx.UI.IsReconnecting,
+		IsRefreshing:        ctx.UI.IsRefreshing,
+		KillSwitchActive:    ctx.KillSwitchActive(),
+		DisconnectReason:    ctx.UI.DisconnectReason,
 		SelectedProfileID:   ctx.UI.SelectedProfileID,
 		StatusText:          ctx.UI.StatusText,
 		CanLogin:            ctx.UI.CanLogin,
 		AllowPreflightRetry: ctx.UI.AllowPreflightRetry,
 		LoginInput:          ctx.UI.LoginInput,
 		PasswordInput:       ctx.UI.PasswordInput,
-		Profiles:            append([]state.Profile(nil), ctx.Profiles...),
+		RememberCredentials: ctx.UI.RememberCredentials,
+		Profiles:            ctx.Profiles(),
 	}
-	select {
-	case <-m.stopCh:
-		return
-	case m.updateCh <- snap:
-	default:
+	if ctx.ConnectedSince != nil {
+		since := *ctx.ConnectedSince
+		snap.ConnectedSince = &since
+	}
+	if ctx.Traffic != nil {
+		traffic := *ctx.Traffic
+		snap.Traffic = &traffic
+	}
+	snap.ConnectedServer = ctx.ConnectedServer
+	if ctx.TunnelInterface != nil {
+		snap.TunnelName = ctx.TunnelInterface.InterfaceName
+	}
+	for {
 		select {
-		case <-m.updateCh:
+		case <-m.stopCh:
+			return
+		case m.updateCh <- snap:
+			return
 		default:
+			// очередь полна: самый старый снимок всё равно будет перекрыт, освобождаем место
+			select {
+			case <-m.updateCh:
+			default:
+			}
 		}
-		m.updateCh <- snap
 	}
 }
 
@@ -282,44 +436,184 @@ func (m *Manager) ShowModalError(info *state.ErrorInfo) {
 	if info == nil {
 		return
 	}
+	if m.headless {
+		m.logError(info)
+		return
+	}
 	m.callOnUI(func() {
 		win := m.activeWindow()
-		message := info.UserMessage
+		message := strings.TrimSpace(info.UserMessage)
 		if message == "" {
-			message = "Произошла ошибка"
+			message = i18n.T("ui.error_default")
 		}
-		message = normalizeUserText(message)
-		dialog.ShowError(fmt.Errorf(message), win)
-		if (info.Kind == state.ErrorKindAuthFailed || info.Kind == state.ErrorKindNetworkUnavailable) && m.loginStatus != nil {
+		m.showErrorDialog(message, info, win)
+		if (info.Kind == state.ErrorKindAuthFailed || info.Kind == state.ErrorKindAccountLocked || info.Kind == state.ErrorKindTooManyAttempts || info.Kind == state.ErrorKindNetworkUnavailable) && m.loginStatus != nil {
 			m.loginStatus.SetText(message)
 		}
 	})
 }
 
+// showErrorDialog показывает понятное сообщение и сворачиваемый блок с техническими подробностями.
+func (m *Manager) showErrorDialog(message string, info *state.ErrorInfo, parent fyne.Window) {
+	if parent == nil {
+		return
+	}
+	messageLabel := widget.NewLabel(message)
+	messageLabel.Wrapping = fyne.TextWrapWord
+	details := errorDetailsText(info)
+	detailsLabel := widget.NewLabel(details)
+	detailsLabel.Wrapping = fyne.TextWrapWord
+	detailsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	copyBtn := widget.NewButton(i18n.T("ui.copy"), func() {
+		if m.app != nil {
+			m.app.Clipboard().SetContent(details)
+		}
+	})
+	accordion := widget.NewAccordion(
+		widget.NewAccordionItem(i18n.T("ui.details"), container.NewVBox(detailsLabel, container.NewHBox(copyBtn))),
+	)
+	var dlg *dialog.CustomDialog
+	okBtn := widget.NewButton("OK", func() { dlg.Hide() })
+	okBtn.Importance = widget.HighImportance
+	content := container.NewVBox(messageLabel, accordion, container.NewHBox(layout.NewSpacer(), okBtn))
+	dlg = dialog.NewCustomWithoutButtons(i18n.T("ui.error_title"), content, parent)
+	dlg.Resize(fyne.NewSize(480, 0))
+	dlg.Show()
+}
+
+// errorDetailsText собирает текст для блока «Подробности».
+func errorDetailsText(info *state.ErrorInfo) string {
+	kind := info.Kind
+	if kind == "" {
+		kind = state.ErrorKindUnknown
+	}
+	technical := strings.TrimSpace(info.TechnicalMessage)
+	if technical == "" {
+		technical = i18n.T("ui.no_data")
+	}
+	lines := []string{i18n.Tf("ui.error_kind", kind), i18n.Tf("ui.error_details", technical)}
+	if !info.OccurredAt.IsZero() {
+		lines = append(lines, i18n.Tf("ui.error_time", info.OccurredAt.Format("2006-01-02 15:04:05")))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ShowTransientNotice отображает краткое уведомление.
 func (m *Manager) ShowTransientNotice(message string) {
 	if strings.TrimSpace(message) == "" {
 		return
 	}
+	if m.headless {
+		m.logNotice(message)
+		return
+	}
 	m.callOnUI(func() {
 		dialog.ShowInformation("CustomVPN", message, m.activeWindow())
 	})
 }
 
+// ShowSettings открывает окно редактирования основных параметров config.yaml.
+func (m *Manager) ShowSettings(ctx *state.AppContext) {
+	if ctx == nil || ctx.Config == nil || m.headless {
+		return
+	}
+	current := ctx.Config.CurrentSettings()
+	m.callOnUI(func() {
+		m.showSettingsDialog(current)
+	})
+}
+
 // ConfirmEnableLocalPolicyMerge asks the user to allow local firewall rules.
 func (m *Manager) ConfirmEnableLocalPolicyMerge() bool {
+	if m.headless {
+		m.logNotice("local firewall rules are disabled by policy; not enabling them without confirmation")
+		return false
+	}
 	return m.confirmDialog(
 		"Kill Switch",
-		"В системе запрещены локальные правила брандмауэра. Разрешить их сейчас? Для этого нужны права администратора.",
+		i18n.T("ui.local_policy_confirm"),
 	)
 }
 
+// ChooseGateway предлагает выбрать шлюз по умолчанию, если их обнаружено несколько.
+// Блокирует вызывающего до выбора; false — пользователь отказался или ctx отменён.
+func (m *Manager) ChooseGateway(ctx context.Context, gateways []*state.GatewayInfo) (*state.GatewayInfo, bool) {
+	if m == nil || m.app == nil || len(gateways) == 0 {
+		return nil, false
+	}
+	if m.headless {
+		m.logNotice(fmt.Sprintf("several default gateways found, using %s (%s)", gateways[0].IP, gateways[0].InterfaceName))
+		return gateways[0], true
+	}
+	select {
+	case <-m.stopCh:
+		return nil, false
+	default:
+	}
+	options := make([]string, len(gateways))
+	for i, gw := range gateways {
+		options[i] = i18n.Tf("ui.gateway_option", gw.InterfaceName, gw.IP, gw.Metric)
+	}
+	ch := make(chan int, 1)
+	var dlg *dialog.CustomDialog
+	m.callOnUI(func() {
+		label := widget.NewLabel(i18n.T("ui.gateway_prompt"))
+		label.Wrapping = fyne.TextWrapWord
+		radio := widget.NewRadioGroup(options, nil)
+		radio.Required = true
+		radio.SetSelected(options[0])
+		done := func(idx int) {
+			select {
+			case ch <- idx:
+			default:
+			}
+			dlg.Hide()
+		}
+		connectBtn := widget.NewButton(i18n.T("ui.connect"), func() {
+			for i, option := range options {
+				if option == radio.Selected {
+					done(i)
+					return
+				}
+			}
+		})
+		connectBtn.Importance = widget.HighImportance
+		cancelBtn := widget.NewButton(i18n.T("ui.cancel"), func() { done(-1) })
+		content := container.NewVBox(label, radio, container.NewHBox(layout.NewSpacer(), cancelBtn, connectBtn))
+		dlg = dialog.NewCustomWithoutButtons(i18n.T("ui.gateway_title"), content, m.activeWindow())
+		dlg.Resize(fyne.NewSize(480, 0))
+		dlg.Show()
+	})
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case idx := <-ch:
+		if idx < 0 {
+			return nil, false
+		}
+		return gateways[idx], true
+	case <-ctx.Done():
+	case <-m.stopCh:
+	}
+	m.callOnUI(func() {
+		if dlg != nil {
+			dlg.Hide()
+		}
+	})
+	return nil, false
+}
+
 // ShowCleanupStarted shows a single cleanup dialog without an enabled close button.
 func (m *Manager) ShowCleanupStarted() {
+	if m.headless {
+		m.logNotice("cleanup started")
+		return
+	}
 	m.callOnUI(func() {
 		m.ensureCleanupDialog()
 		if m.cleanupDialogLabel != nil {
-			m.cleanupDialogLabel.SetText("Очистка начата")
+			m.cleanupDialogLabel.SetText(i18n.T("ui.cleanup_begun"))
 		}
 		if m.cleanupDialogButton != nil {
 			m.cleanupDialogButton.Disable()
@@ -331,15 +625,20 @@ func (m *Manager) ShowCleanupStarted() {
 }
 
 // ShowCleanupDone updates the cleanup dialog to a finished state.
-func (m *Manager) ShowCleanupDone(hasErrors bool) {
+func (m *Manager) ShowCleanupDone(result state.CleanupResultPayload) {
+	if m.headless {
+		m.logCleanupDone(result)
+		return
+	}
 	m.callOnUI(func() {
 		m.ensureCleanupDialog()
 		if m.cleanupDialogLabel != nil {
-			if hasErrors {
-				m.cleanupDialogLabel.SetText("Очистка завершена с ошибками")
-			} else {
-				m.cleanupDialogLabel.SetText("Очистка завершена")
+			text := i18n.T("notice.cleanup_done")
+			if len(result.Errors) > 0 {
+				text = i18n.T("notice.cleanup_done_errors")
 			}
+			text += i18n.Tf("ui.cleanup_summary", result.RoutesRemoved, result.FirewallRulesRemoved)
+			m.cleanupDialogLabel.SetText(text)
 		}
 		if m.cleanupDialogButton != nil {
 			m.cleanupDialogButton.Enable()
@@ -356,7 +655,20 @@ func (m *Manager) processUpdates() {
 		case <-m.stopCh:
 			return
 		case snap := <-m.updateCh:
-			m.applySnapshot(snap)
+			m.applySnapshot(m.latestSnapshot(snap))
+		}
+	}
+}
+
+// latestSnapshot вычитывает накопившиеся снимки и возвращает последний:
+// при всплеске переходов применяется только итоговое состояние.
+func (m *Manager) latestSnapshot(snap uiSnapshot) uiSnapshot {
+	for {
+		select {
+		case next := <-m.updateCh:
+			snap = next
+		default:
+			return snap
 		}
 	}
 }
@@ -372,19 +684,24 @@ func (m *Manager) logPanic(scope string) {
 
 func (m *Manager) applySnapshot(snap uiSnapshot) {
 	m.callOnUI(func() {
-		snap.StatusText = normalizeUserText(snap.StatusText)
+		m.sessionActive = snap.IsConnected || snap.IsConnecting
+		if m.headless {
+			m.logStatus(snap.StatusText)
+		}
 		m.updateLoginControls(snap)
 		if m.mainStatus != nil {
 			m.mainStatus.SetText(snap.StatusText)
 		}
-		m.updateCredentials(snap.LoginInput, snap.PasswordInput)
+		m.updateCredentials(snap.LoginInput, snap.PasswordInput, snap.RememberCredentials)
 		m.updateProfiles(snap.Profiles, snap.SelectedProfileID)
 		m.updateButtons(snap)
 		m.updateStatusIndicator(snap)
+		m.updateKillSwitchIcon(snap)
+		m.updateTray(snap)
 	})
 }
 
-func (m *Manager) updateCredentials(login, password string) {
+func (m *Manager) updateCredentials(login, password string, remember bool) {
 	if m.loginEntry == nil || m.passwordEntry == nil {
 		return
 	}
@@ -395,31 +712,47 @@ func (m *Manager) updateCredentials(login, password string) {
 	if m.passwordEntry.Text != password {
 		m.passwordEntry.SetText(password)
 	}
+	if m.rememberCheck != nil && m.rememberCheck.Checked != remember {
+		m.rememberCheck.SetChecked(remember)
+	}
 	m.suppressCredEvents = false
 }
 
 func (m *Manager) updateProfiles(list []state.Profile, selectedID string) {
 	m.profiles = list
-	if m.profileList == nil {
-		return
+	m.selectedProfileID = selectedID
+	m.applyProfileFilter()
+}
+
+// applyProfileFilter пересобирает дерево профилей по строке поиска
+// и восстанавливает выделение, если выбранный профиль остался видимым.
+func (m *Manager) applyProfileFilter() {
+	query := ""
+	if m.profileFilter != nil {
+		query = m.profileFilter.Text
 	}
-	m.profileList.Refresh()
-	if selectedID == "" {
-		m.suppressProfileSelect = true
-		m.profileList.UnselectAll()
-		m.suppressProfileSelect = false
+	visible := filterProfiles(m.profiles, query)
+	hadFavorites := len(m.profileFavorites) > 0
+	m.profileGroups = groupProfiles(visible)
+	m.profileFavorites = favoriteProfilesOf(visible, m.favorites)
+	if m.profileTree == nil {
 		return
 	}
-	if idx := findProfileIndex(list, selectedID); idx >= 0 {
-		m.suppressProfileSelect = true
-		m.profileList.Select(idx)
-		m.suppressProfileSelect = false
+	m.profileTree.Refresh()
+	if !hadFavorites && len(m.profileFavorites) > 0 {
+		m.profileTree.OpenBranch(favoritesNodeID)
 	}
+	if strings.TrimSpace(query) != "" {
+		// при поиске совпадения не должны прятаться в свёрнутых странах
+		m.profileTree.OpenAllBranches()
+	}
+	m.selectProfileNode()
 }
 
 func (m *Manager) updateButtons(snap uiSnapshot) {
+	idle := snap.MainVisible && !snap.IsConnecting && !snap.IsConnected && !snap.IsRefreshing
 	if m.connectBtn != nil {
-		if snap.MainVisible && !snap.IsConnecting && !snap.IsConnected && snap.SelectedProfileID != "" {
+		if idle && snap.SelectedProfileID != "" {
 			m.connectBtn.Enable()
 		} else {
 			m.connectBtn.Disable()
@@ -433,7 +766,18 @@ func (m *Manager) updateButtons(snap uiSnapshot) {
 		}
 	}
 	if m.settingsBtn != nil {
-		m.settingsBtn.Disable()
+		if idle {
+			m.settingsBtn.Enable()
+		} else {
+			m.settingsBtn.Disable()
+		}
+	}
+	if m.refreshBtn != nil {
+		if idle {
+			m.refreshBtn.Enable()
+		} else {
+			m.refreshBtn.Disable()
+		}
 	}
 }
 
@@ -445,13 +789,31 @@ func (m *Manager) updateStatusIndicator(snap uiSnapshot) {
 	switch {
 	case snap.IsConnected:
 		fill = theme.SuccessColor()
+	case snap.IsReconnecting:
+		fill = reconnectColor
 	case snap.IsConnecting:
 		fill = theme.WarningColor()
 	default:
 		fill = theme.DisabledColor()
 	}
-	m.statusCircle.FillColor = fill
-	m.statusCircle.Refresh()
+	lost := snap.DisconnectReason == state.DisconnectReasonLost && snap.DisconnectReason != m.lastDisconnectReason
+	m.lastDisconnectReason = snap.DisconnectReason
+	if snap.IsReconnecting && !snap.IsConnected {
+		m.stopStatusFlash()
+		m.startStatusPulse()
+	} else if !snap.IsConnected && !snap.IsConnecting && (lost || m.statusFlash != nil) {
+		// вспышка доигрывает до конца, даже если за ней пришли новые снимки
+		m.stopStatusPulse()
+		if lost {
+			m.stopStatusFlash()
+			m.startStatusFlash(fill)
+		}
+	} else {
+		m.stopStatusPulse()
+		m.stopStatusFlash()
+		m.statusCircle.FillColor = fill
+		m.statusCircle.Refresh()
+	}
 	if snap.IsConnecting {
 		m.spinner.Show()
 		m.spinner.Start()
@@ -459,6 +821,127 @@ func (m *Manager) updateStatusIndicator(snap uiSnapshot) {
 		m.spinner.Stop()
 		m.spinner.Hide()
 	}
+	if snap.IsConnected && snap.ConnectedSince != nil {
+		m.startUptimeTicker(*snap.ConnectedSince)
+	} else {
+		m.stopUptimeTicker()
+	}
+	if m.connectionLabel != nil {
+		if text := connectionDetails(snap); snap.IsConnected && text != "" {
+			m.connectionLabel.SetText(text)
+			m.connectionLabel.Show()
+		} else {
+			m.connectionLabel.Hide()
+		}
+	}
+	if m.trafficLabel != nil {
+		if snap.IsConnected && snap.Traffic != nil {
+			m.trafficLabel.SetText(formatTraffic(*snap.Traffic))
+			m.trafficLabel.Show()
+		} else {
+			m.trafficLabel.Hide()
+		}
+	}
+}
+
+// reconnectColor — оранжевый индикатор переподключения, отличный от жёлтого «Подключение».
+var reconnectColor = color.NRGBA{R: 0xF7, G: 0x8C, B: 0x1F, A: 0xFF}
+
+// startStatusPulse запускает пульсацию индикатора переподключения. Вызывается только из UI goroutine.
+func (m *Manager) startStatusPulse() {
+	if m.statusPulse != nil {
+		return
+	}
+	faded := reconnectColor
+	faded.A = 0x50
+	pulse := canvas.NewColorRGBAAnimation(reconnectColor, faded, 700*time.Millisecond, func(c color.Color) {
+		m.statusCircle.FillColor = c
+		m.statusCircle.Refresh()
+	})
+	pulse.AutoReverse = true
+	pulse.RepeatCount = fyne.AnimationRepeatForever
+	m.statusPulse = pulse
+	pulse.Start()
+}
+
+// stopStatusPulse останавливает пульсацию. Вызывается только из UI goroutine.
+func (m *Manager) stopStatusPulse() {
+	if m.statusPulse == nil {
+		return
+	}
+	m.statusPulse.Stop()
+	m.statusPulse = nil
+}
+
+// startStatusFlash на пару секунд окрашивает индикатор в красный и плавно возвращает цвет to.
+// Вызывается только из UI goroutine.
+func (m *Manager) startStatusFlash(to color.Color) {
+	flash := canvas.NewColorRGBAAnimation(theme.ErrorColor(), to, 2*time.Second, func(c color.Color) {
+		m.statusCircle.FillColor = c
+		m.statusCircle.Refresh()
+	})
+	m.statusFlash = flash
+	flash.Start()
+}
+
+// stopStatusFlash прерывает вспышку. Вызывается только из UI goroutine.
+func (m *Manager) stopStatusFlash() {
+	if m.statusFlash == nil {
+		return
+	}
+	m.statusFlash.Stop()
+	m.statusFlash = nil
+}
+
+// startUptimeTicker запускает ежесекундное обновление времени подключения.
+// Вызывается только из UI goroutine.
+func (m *Manager) startUptimeTicker(since time.Time) {
+	if m.uptimeLabel == nil {
+		return
+	}
+	if m.uptimeStop != nil && m.uptimeSince.Equal(since) {
+		return
+	}
+	m.stopUptimeTicker()
+	m.uptimeSince = since
+	m.uptimeLabel.SetText(formatUptime(time.Since(since)))
+	m.uptimeLabel.Show()
+	stop := make(chan struct{})
+	m.uptimeStop = stop
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("uptime ticker")
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				text := formatUptime(time.Since(since))
+				m.callOnUI(func() {
+					if m.uptimeStop == stop && m.uptimeLabel != nil {
+						m.uptimeLabel.SetText(text)
+					}
+				})
+			}
+		}
+	}()
+}
+
+// stopUptimeTicker останавливает таймер и скрывает метку. Вызывается только из UI goroutine.
+func (m *Manager) stopUptimeTicker() {
+	if m.uptimeStop != nil {
+		close(m.uptimeStop)
+		m.uptimeStop = nil
+	}
+	m.uptimeSince = time.Time{}
+	if m.uptimeLabel != nil {
+		m.uptimeLabel.Hide()
+	}
 }
 
 func (m *Manager) updateLoginControls(snap uiSnapshot) {
@@ -486,49 +969,53 @@ func (m *Manager) buildLoginWindow() {
 	if m.app == nil {
 		return
 	}
-	win := m.app.NewWindow(fmt.Sprintf("%s — Вход", m.appName))
+	win := m.app.NewWindow(i18n.Tf("ui.login_title", m.appName))
 	win.Resize(fyne.NewSize(460, 560))
 	win.CenterOnScreen()
 	win.SetFixedSize(true)
 
 	title := widget.NewLabelWithStyle(m.appName, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-	subtitle := widget.NewLabelWithStyle("Авторизация", fyne.TextAlignLeading, fyne.TextStyle{Bold: false})
+	subtitle := widget.NewLabelWithStyle(i18n.T("ui.login_subtitle"), fyne.TextAlignLeading, fyne.TextStyle{Bold: false})
 
 	m.loginEntry = widget.NewEntry()
-	m.loginEntry.SetPlaceHolder("Логин")
+	m.loginEntry.SetPlaceHolder(i18n.T("ui.login"))
 	m.loginEntry.OnChanged = func(string) { m.handleCredentialsEdited() }
 	m.loginEntry.OnSubmitted = func(string) { m.handleLoginClicked() }
 
 	m.passwordEntry = widget.NewPasswordEntry()
-	m.passwordEntry.SetPlaceHolder("Пароль")
+	m.passwordEntry.SetPlaceHolder(i18n.T("ui.password"))
 	m.passwordEntry.OnChanged = func(string) { m.handleCredentialsEdited() }
 	m.passwordEntry.OnSubmitted = func(string) { m.handleLoginClicked() }
 
-	loginButton := widget.NewButton("Войти", m.handleLoginClicked)
+	m.rememberCheck = widget.NewCheck(i18n.T("ui.remember_me"), func(bool) { m.handleCredentialsEdited() })
+
+	loginButton := widget.NewButton(i18n.T("ui.sign_in"), m.handleLoginClicked)
 	loginButton.Importance = widget.HighImportance
 	loginButton.Disable()
 	m.loginBtn = loginButton
 
-	m.loginStatus = widget.NewLabel("Проверяем связь с сервером...")
+	m.loginStatus = widget.NewLabel(i18n.T("ui.checking_server"))
 	m.loginStatus.Alignment = fyne.TextAlignLeading
 	m.loginStatus.Wrapping = fyne.TextWrapWord
 
-	retryButton := widget.NewButton("Повторить проверку", m.handleRetryPreflight)
+	retryButton := widget.NewButton(i18n.T("ui.retry_check"), m.handleRetryPreflight)
 	retryButton.Hide()
 	m.retryBtn = retryButton
-	cleanupButton := widget.NewButton("Починка", func() { m.sendSimpleEvent(state.EventUIClickCleanup) })
+	cleanupButton := widget.NewButton(i18n.T("ui.repair"), func() { m.sendSimpleEvent(state.EventUIClickCleanup) })
+	testButton := widget.NewButton(i18n.T("ui.test_server"), func() { m.sendSimpleEvent(state.EventUITestConnection) })
 
 	fields := container.NewVBox(
-		widget.NewLabelWithStyle("Логин", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(i18n.T("ui.login"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		m.loginEntry,
-		widget.NewLabelWithStyle("Пароль", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(i18n.T("ui.password"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		m.passwordEntry,
+		m.rememberCheck,
 	)
 	header := container.NewVBox(title, subtitle)
 	form := container.NewVBox(fields, loginButton, layout.NewSpacer())
 	statusSlot := canvas.NewRectangle(color.Transparent)
 	statusSlot.SetMinSize(fyne.NewSize(0, 72))
-	statusBox := container.NewVBox(m.loginStatus, retryButton, cleanupButton)
+	statusBox := container.NewVBox(m.loginStatus, retryButton, testButton, cleanupButton)
 	statusArea := container.NewVBox(widget.NewSeparator(), container.NewMax(statusSlot, statusBox))
 	content := container.NewBorder(header, statusArea, nil, nil, form)
 	win.SetContent(container.NewPadded(content))
@@ -548,49 +1035,55 @@ func (m *Manager) buildMainWindow() {
 	win.SetFixedSize(true)
 	m.statusCircle = canvas.NewCircle(theme.DisabledColor())
 	m.statusCircle.Resize(fyne.NewSize(14, 14))
-	m.mainStatus = widget.NewLabel("Отключено")
+	m.mainStatus = widget.NewLabel(i18n.T("status.disconnected"))
 	m.spinner = widget.NewProgressBarInfinite()
 	m.spinner.Hide()
+	m.uptimeLabel = widget.NewLabel("")
+	m.uptimeLabel.Hide()
+	m.trafficLabel = widget.NewLabel("")
+	m.trafficLabel.Hide()
+	m.connectionLabel = widget.NewLabel("")
+	m.connectionLabel.Hide()
+	m.killSwitchIcon = widget.NewIcon(killSwitchOffIcon)
 
-	m.profileList = widget.NewList(
-		func() int { return len(m.profiles) },
-		func() fyne.CanvasObject { return widget.NewLabel("") },
-		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			label := obj.(*widget.Label)
-			if id < 0 || id >= len(m.profiles) {
-				label.SetText("-")
-				return
-			}
-			profile := m.profiles[id]
-			country := strings.ToUpper(strings.TrimSpace(profile.Country))
-			if country == "" {
-				country = "?"
-			}
-			label.SetText(fmt.Sprintf("%s (%s)", profile.Name, country))
-		},
-	)
-	m.profileList.OnSelected = m.handleProfileSelected
+	m.profileTree = m.buildProfileTree()
+
+	m.profileFilter = widget.NewEntry()
+	m.profileFilter.SetPlaceHolder(i18n.T("ui.profile_filter"))
+	m.profileFilter.OnChanged = func(string) { m.applyProfileFilter() }
 
-	profilesCard := widget.NewCard("Профили", "", container.NewMax(m.profileList))
+	profilesCard := widget.NewCard(i18n.T("ui.profiles"), "", container.NewBorder(m.profileFilter, nil, nil, nil, m.profileTree))
 
 	statusBar := container.NewHBox(
 		m.statusCircle,
-		widget.NewLabel("Статус:"),
+		widget.NewLabel(i18n.T("ui.status")),
 		m.mainStatus,
+		m.uptimeLabel,
+		m.trafficLabel,
+		m.connectionLabel,
 		layout.NewSpacer(),
+		m.killSwitchIcon,
+		widget.NewLabel("Kill Switch"),
 		m.spinner,
 	)
 
-	m.connectBtn = widget.NewButton("Подключиться", func() { m.sendSimpleEvent(state.EventUIClickConnect) })
-	m.disconnectBtn = widget.NewButton("Отключиться", func() { m.sendSimpleEvent(state.EventUIClickDisconnect) })
-	m.settingsBtn = widget.NewButton("Настройки", func() { m.sendSimpleEvent(state.EventUIOpenSettings) })
-	cleanupBtn := widget.NewButton("Починка", func() { m.sendSimpleEvent(state.EventUIClickCleanup) })
-	m.exitBtn = widget.NewButton("Выход", func() { m.sendSimpleEvent(state.EventUIExit) })
+	m.connectBtn = widget.NewButton(i18n.T("ui.connect"), func() { m.sendSimpleEvent(state.EventUIClickConnect) })
+	m.disconnectBtn = widget.NewButton(i18n.T("ui.disconnect"), func() { m.sendSessionEvent(state.EventUIClickDisconnect) })
+	m.settingsBtn = widget.NewButton(i18n.T("ui.settings"), func() { m.sendSimpleEvent(state.EventUIOpenSettings) })
+	m.refreshBtn = widget.NewButton(i18n.T("ui.refresh"), func() { m.sendSimpleEvent(state.EventUIClickRefresh) })
+	cleanupBtn := widget.NewButton(i18n.T("ui.repair"), func() { m.sendSimpleEvent(state.EventUIClickCleanup) })
+	logsBtn := widget.NewButton(i18n.T("ui.show_logs"), m.ShowCoreLogs)
+	historyBtn := widget.NewButton(i18n.T("ui.history"), m.ShowSessionHistory)
+	m.exitBtn = widget.NewButton(i18n.T("ui.exit"), func() { m.sendSessionEvent(state.EventUIExit) })
 
-	controls := container.NewGridWithColumns(5, m.connectBtn, m.disconnectBtn, m.settingsBtn, cleanupBtn, m.exitBtn)
+	controls := container.NewGridWithColumns(8, m.connectBtn, m.disconnectBtn, m.refreshBtn, m.settingsBtn, cleanupBtn, logsBtn, historyBtn, m.exitBtn)
 	mainContent := container.NewBorder(statusBar, controls, nil, nil, profilesCard)
 	win.SetContent(container.NewPadded(mainContent))
 	win.SetCloseIntercept(func() {
+		if !m.trayAvailable {
+			m.confirmExitWithoutTray()
+			return
+		}
 		m.sendSimpleEvent(state.EventTrayHideWindow)
 		win.Hide()
 		m.mainWinVisible = false
@@ -604,10 +1097,7 @@ func (m *Manager) handleLoginClicked() {
 		m.sendSimpleEvent(state.EventUIClickLogin)
 		return
 	}
-	payload := state.CredentialsPayload{
-		Login:    m.loginEntry.Text,
-		Password: m.passwordEntry.Text,
-	}
+	payload := m.credentialsPayload()
 	evt := state.Event{Type: state.EventUIClickLogin, Payload: payload, TS: time.Now()}
 	m.dispatchEvent(evt)
 }
@@ -616,29 +1106,35 @@ func (m *Manager) handleCredentialsEdited() {
 	if m.suppressCredEvents {
 		return
 	}
+	payload := m.credentialsPayload()
+	evt := state.Event{Type: state.EventUICredentialsChanged, Payload: payload, TS: time.Now()}
+	m.dispatchEvent(evt)
+}
+
+func (m *Manager) credentialsPayload() state.CredentialsPayload {
 	payload := state.CredentialsPayload{
 		Login:    m.loginEntry.Text,
 		Password: m.passwordEntry.Text,
 	}
-	evt := state.Event{Type: state.EventUICredentialsChanged, Payload: payload, TS: time.Now()}
-	m.dispatchEvent(evt)
+	if m.rememberCheck != nil {
+		payload.Remember = m.rememberCheck.Checked
+	}
+	return payload
 }
 
-func (m *Manager) handleProfileSelected(id widget.ListItemID) {
-	if m.suppressProfileSelect {
-		return
-	}
-	if id < 0 || int(id) >= len(m.profiles) {
+func (m *Manager) handleProfileSelected(id string) {
+	profile, ok := m.findVisibleProfile(id)
+	if !ok {
 		return
 	}
-	profile := m.profiles[id]
+	m.selectedProfileID = profile.ID
 	payload := state.SelectionPayload{ID: profile.ID}
 	evt := state.Event{Type: state.EventUISelectProfile, Payload: payload, TS: time.Now()}
 	m.dispatchEvent(evt)
 }
 
 func (m *Manager) handleExitRequested() {
-	m.sendSimpleEvent(state.EventUIExit)
+	m.sendSessionEvent(state.EventUIExit)
 }
 
 func (m *Manager) handleRetryPreflight() {
@@ -653,6 +1149,36 @@ func (m *Manager) sendSimpleEvent(t state.EventType) {
 	m.dispatchEvent(evt)
 }
 
+// SetConfirmDisconnect включает или выключает подтверждение разрыва подключения.
+func (m *Manager) SetConfirmDisconnect(enabled bool) {
+	m.callOnUI(func() {
+		m.confirmDisconnect = enabled
+	})
+}
+
+// sendSessionEvent отправляет событие, разрывающее подключение (отключение или выход).
+// Во время Connected/Connecting сначала запрашивается подтверждение, если оно включено.
+func (m *Manager) sendSessionEvent(t state.EventType) {
+	if m.headless || !m.confirmDisconnect || !m.sessionActive {
+		m.sendSimpleEvent(t)
+		return
+	}
+	if !m.loginWinVisible && !m.mainWinVisible && m.mainWin != nil {
+		// вызов из трея при скрытых окнах: диалогу нужно видимое окно
+		m.mainWin.Show()
+		m.mainWinVisible = true
+	}
+	// confirmDialog ждёт ответа пользователя, поэтому не блокируем goroutine UI
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("confirm disconnect")
+		if m.confirmDialog("CustomVPN", i18n.T("ui.confirm_disconnect")) {
+			m.sendSimpleEvent(t)
+		}
+	}()
+}
+
 func (m *Manager) dispatchEvent(evt state.Event) {
 	if m.dispatch == nil {
 		return
@@ -682,6 +1208,12 @@ func (m *Manager) callOnUI(fn func()) {
 	if m.app == nil || fn == nil {
 		return
 	}
+	select {
+	case <-m.loopDone:
+		// цикл Fyne завершён: показывать нечего, а ожидание очереди главного потока может не закончиться
+		return
+	default:
+	}
 	if drv := m.app.Driver(); drv != nil {
 		drv.DoFromGoroutine(fn, true)
 		return
@@ -697,7 +1229,7 @@ func (m *Manager) ensureCleanupDialog() {
 	if m.cleanupDialog != nil {
 		m.cleanupDialog.Hide()
 	}
-	label := widget.NewLabel("Очистка начата")
+	label := widget.NewLabel(i18n.T("ui.cleanup_begun"))
 	button := widget.NewButton("OK", func() {
 		if m.cleanupDialog != nil {
 			m.cleanupDialog.Hide()
@@ -705,13 +1237,73 @@ func (m *Manager) ensureCleanupDialog() {
 	})
 	button.Disable()
 	content := container.NewVBox(label, button)
-	dialog := dialog.NewCustomWithoutButtons("Починка", content, parent)
+	dialog := dialog.NewCustomWithoutButtons(i18n.T("ui.repair"), content, parent)
 	m.cleanupDialog = dialog
 	m.cleanupDialogLabel = label
 	m.cleanupDialogButton = button
 	m.cleanupDialogParent = parent
 }
 
+func (m *Manager) showSettingsDialog(current config.Settings) {
+	parent := m.activeWindow()
+	if parent == nil {
+		return
+	}
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(current.ControlServerURL)
+	coreEntry := widget.NewEntry()
+	coreEntry.SetText(current.CorePath)
+	levelSelect := widget.NewSelect([]string{"debug", "info", "error"}, nil)
+	levelSelect.SetSelected(current.LogLevel)
+	// тема применяется сразу при выборе; «Отмена» возвращает прежнюю
+	appliedTheme := m.theme
+	themeSelect := widget.NewSelect(themeLabels(), func(label string) {
+		m.applyTheme(themeByLabel(label))
+	})
+	themeSelect.SetSelected(themeLabel(appliedTheme))
+	errorLabel := widget.NewLabel("")
+	errorLabel.Wrapping = fyne.TextWrapWord
+	errorLabel.Importance = widget.DangerImportance
+	errorLabel.Hide()
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("ui.settings_control_server"), urlEntry),
+		widget.NewFormItem(i18n.T("ui.settings_log_level"), levelSelect),
+		widget.NewFormItem(i18n.T("ui.settings_core_path"), coreEntry),
+		widget.NewFormItem(i18n.T("ui.settings_theme"), themeSelect),
+	)
+	var dlg *dialog.CustomDialog
+	saveBtn := widget.NewButton(i18n.T("ui.save"), func() {
+		settings := config.Settings{
+			ControlServerURL: strings.TrimSpace(urlEntry.Text),
+			LogLevel:         levelSelect.Selected,
+			CorePath:         strings.TrimSpace(coreEntry.Text),
+			Theme:            themeByLabel(themeSelect.Selected),
+		}
+		if m.saveSettings == nil {
+			errorLabel.SetText(i18n.T("ui.settings_save_unavailable"))
+			errorLabel.Show()
+			return
+		}
+		if err := m.saveSettings(settings); err != nil {
+			errorLabel.SetText(i18n.Tf("ui.settings_save_failed", err))
+			errorLabel.Show()
+			return
+		}
+		dlg.Hide()
+		dialog.ShowInformation(i18n.T("ui.settings"), i18n.T("ui.settings_saved"), parent)
+	})
+	saveBtn.Importance = widget.HighImportance
+	cancelBtn := widget.NewButton(i18n.T("ui.cancel"), func() {
+		m.applyTheme(appliedTheme)
+		dlg.Hide()
+	})
+	content := container.NewVBox(form, errorLabel, container.NewHBox(layout.NewSpacer(), cancelBtn, saveBtn))
+	dlg = dialog.NewCustomWithoutButtons(i18n.T("ui.settings"), content, parent)
+	dlg.Resize(fyne.NewSize(520, 0))
+	dlg.Show()
+}
+
 func (m *Manager) confirmDialog(title, message string) bool {
 	if m == nil || m.app == nil {
 		return false
@@ -734,18 +1326,206 @@ func (m *Manager) setupTray() {
 	if m.app == nil {
 		return
 	}
-	showItem := fyne.NewMenuItem("Показать", func() { m.sendSimpleEvent(state.EventTrayShowWindow) })
-	hideItem := fyne.NewMenuItem("Скрыть", func() { m.sendSimpleEvent(state.EventTrayHideWindow) })
-	quitItem := fyne.NewMenuItem(lang.L("Quit"), func() { m.sendSimpleEvent(state.EventTrayExit) })
+	showItem := fyne.NewMenuItem(i18n.T("ui.show"), func() { m.sendSimpleEvent(state.EventTrayShowWindow) })
+	hideItem := fyne.NewMenuItem(i18n.T("ui.hide"), func() { m.sendSimpleEvent(state.EventTrayHideWindow) })
+	quitItem := fyne.NewMenuItem(i18n.T("ui.exit"), func() { m.sendSessionEvent(state.EventTrayExit) })
 	quitItem.IsQuit = true
-	menu := fyne.NewMenu(m.appName, showItem, hideItem, fyne.NewMenuItemSeparator(), quitItem)
+	diagnosticsItem := fyne.NewMenuItem(i18n.T("ui.collect_diagnostics"), func() { m.runDiagnostics() })
+	menu := fyne.NewMenu(m.appName, showItem, hideItem, fyne.NewMenuItemSeparator(), diagnosticsItem, fyne.NewMenuItemSeparator(), quitItem)
+	if m.headless {
+		menu = m.headlessTrayMenu(diagnosticsItem, quitItem)
+	}
+	// отладочные пункты добавляются перед «Собрать диагностику»
+	var debugItems []*fyne.MenuItem
+	if m.previewConnect != nil {
+		debugItems = append(debugItems, fyne.NewMenuItem(i18n.T("ui.preview_connect"), func() { m.runPreviewConnect() }))
+	}
+	if m.coreConfig != nil {
+		debugItems = append(debugItems, fyne.NewMenuItem(i18n.T("ui.copy_core_config"), func() { m.runCopyCoreConfig() }))
+	}
+	// экспорт плана идёт сразу после «Собрать диагностику»
+	var exportItems []*fyne.MenuItem
+	if m.exportConnectPlan != nil {
+		exportItems = append(exportItems, fyne.NewMenuItem(i18n.T("ui.export_plan"), func() { m.runExportConnectPlan() }))
+	}
+	for i, item := range menu.Items {
+		if item == diagnosticsItem {
+			tail := append(append([]*fyne.MenuItem{diagnosticsItem}, exportItems...), menu.Items[i+1:]...)
+			menu.Items = append(append(menu.Items[:i:i], debugItems...), tail...)
+			break
+		}
+	}
 	tray := m.trayApp()
 	if tray == nil {
+		if m.logger != nil {
+			m.logger.Infof("system tray unavailable: closing the main window asks to quit instead of hiding it")
+		}
 		return
 	}
 	tray.SetSystemTrayMenu(menu)
-	tray.SetSystemTrayIcon(theme.FyneLogo())
-	systray.SetOnTapped(func() { m.toggleTrayWindow() })
+	m.trayAvailable = true
+	m.setTrayIcon(trayDisconnectedIcon)
+	if !m.headless {
+		systray.SetOnTapped(func() { m.toggleTrayWindow() })
+	}
+}
+
+// runDiagnostics собирает архив диагностики в фоне и сообщает результат.
+func (m *Manager) runDiagnostics() {
+	if m.collectDiagnostics == nil {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("diagnostics")
+		path, err := m.collectDiagnostics()
+		if m.headless {
+			m.logDiagnostics(path, err)
+			return
+		}
+		m.callOnUI(func() {
+			win := m.activeWindow()
+			if win == nil {
+				return
+			}
+			if !m.loginWinVisible && !m.mainWinVisible {
+				win.Show()
+				if win == m.mainWin {
+					m.mainWinVisible = true
+				} else {
+					m.loginWinVisible = true
+				}
+			}
+			if err != nil {
+				dialog.ShowError(errors.New(i18n.Tf("ui.diagnostics_failed", err)), win)
+				return
+			}
+			dialog.ShowInformation(i18n.T("ui.diagnostics_title"), i18n.Tf("ui.diagnostics_saved", path), win)
+		})
+	}()
+}
+
+// runPreviewConnect строит план подключения в фоне и показывает его в окне.
+func (m *Manager) runPreviewConnect() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("connect preview")
+		plan, err := m.previewConnect()
+		if m.headless {
+			// план уже записан в лог приложением
+			if err != nil && m.logger != nil {
+				m.logger.Errorf("connect preview failed: %v", err)
+			}
+			return
+		}
+		m.callOnUI(func() {
+			win := m.activeWindow()
+			if win == nil {
+				return
+			}
+			if err != nil {
+				dialog.ShowError(errors.New(i18n.Tf("ui.preview_failed", err)), win)
+				return
+			}
+			output := widget.NewMultiLineEntry()
+			output.SetText(plan)
+			output.Wrapping = fyne.TextWrapOff
+			output.TextStyle = fyne.TextStyle{Monospace: true}
+			output.Disable()
+			planDialog := dialog.NewCustom(i18n.T("ui.preview_connect"), i18n.T("ui.close"), output, win)
+			planDialog.Resize(fyne.NewSize(720, 420))
+			planDialog.Show()
+		})
+	}()
+}
+
+// runCopyCoreConfig получает конфигурацию Core выбранного профиля в фоне и копирует её в буфер обмена.
+func (m *Manager) runCopyCoreConfig() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("core config export")
+		text, err := m.coreConfig()
+		if m.headless {
+			// буфера обмена без окон нет: конфигурация пишется в лог
+			if m.logger != nil {
+				if err != nil {
+					m.logger.Errorf("core config export failed: %v", err)
+				} else {
+					m.logger.Infof("core config of the selected profile:\n%s", text)
+				}
+			}
+			return
+		}
+		m.callOnUI(func() {
+			win := m.activeWindow()
+			if win == nil {
+				return
+			}
+			if err != nil {
+				dialog.ShowError(errors.New(i18n.Tf("ui.core_config_failed", err)), win)
+				return
+			}
+			m.app.Clipboard().SetContent(text)
+			dialog.ShowInformation("CustomVPN", i18n.T("ui.core_config_copied"), win)
+		})
+	}()
+}
+
+// runExportConnectPlan строит план подключения в фоне и сохраняет его в выбранный пользователем файл.
+func (m *Manager) runExportConnectPlan() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.logPanic("connect plan export")
+		data, err := m.exportConnectPlan()
+		if m.headless {
+			// без окон файл не выбрать: план пишется в лог
+			if m.logger != nil {
+				if err != nil {
+					m.logger.Errorf("connect plan export failed: %v", err)
+				} else {
+					m.logger.Infof("connect plan:\n%s", data)
+				}
+			}
+			return
+		}
+		m.callOnUI(func() {
+			win := m.activeWindow()
+			if win == nil {
+				return
+			}
+			if err != nil {
+				dialog.ShowError(errors.New(i18n.Tf("ui.export_plan_failed", err)), win)
+				return
+			}
+			saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil {
+					dialog.ShowError(errors.New(i18n.Tf("ui.export_plan_failed", err)), win)
+					return
+				}
+				if writer == nil {
+					return
+				}
+				_, writeErr := writer.Write(data)
+				if closeErr := writer.Close(); writeErr == nil {
+					writeErr = closeErr
+				}
+				if writeErr != nil {
+					dialog.ShowError(errors.New(i18n.Tf("ui.export_plan_failed", writeErr)), win)
+					return
+				}
+				if m.logger != nil {
+					m.logger.Infof("connect plan exported to %s", writer.URI().Path())
+				}
+				dialog.ShowInformation(i18n.T("ui.export_plan"), i18n.Tf("ui.export_plan_saved", writer.URI().Path()), win)
+			}, win)
+			saveDialog.SetFileName("customvpn_plan.json")
+			saveDialog.Show()
+		})
+	}()
 }
 
 func (m *Manager) trayApp() interface {
@@ -783,9 +1563,7 @@ func (m *Manager) toggleTrayWindow() {
 		}
 		if m.lastShownLogin && m.loginWin != nil {
 			m.loginWin.Show()
-			if canvas := m.loginWin.Canvas(); canvas != nil && m.loginEntry != nil {
-				canvas.Focus(m.loginEntry)
-			}
+			m.focusLoginForm()
 			m.loginWinVisible = true
 			return
 		}
@@ -797,29 +1575,69 @@ func (m *Manager) toggleTrayWindow() {
 	})
 }
 
-func normalizeUserText(message string) string {
-	message = strings.TrimSpace(message)
-	if message == "" {
-		return message
+// focusLoginForm ставит фокус на пароль, если логин уже заполнен (запомненный), иначе на логин.
+func (m *Manager) focusLoginForm() {
+	if m.loginWin == nil || m.loginEntry == nil {
+		return
+	}
+	canvas := m.loginWin.Canvas()
+	if canvas == nil {
+		return
+	}
+	if strings.TrimSpace(m.loginEntry.Text) != "" && m.passwordEntry != nil {
+		canvas.Focus(m.passwordEntry)
+		return
+	}
+	canvas.Focus(m.loginEntry)
+}
+
+func formatUptime(d time.Duration) string {
+	if d < 0 {
+		d = 0
 	}
-	encoded, err := charmap.Windows1251.NewEncoder().Bytes([]byte(message))
-	if err != nil {
-		return message
+	total := int(d / time.Second)
+	return i18n.Tf("ui.connected_for", total/3600, (total/60)%60, total%60)
+}
+
+// formatTraffic показывает скорость приёма и передачи туннеля.
+func formatTraffic(stats state.TrafficStats) string {
+	return fmt.Sprintf("↓ %s ↑ %s", formatRate(stats.RateIn), formatRate(stats.RateOut))
+}
+
+// connectionDetails показывает, куда установлен туннель: «сервер через интерфейс».
+func connectionDetails(snap uiSnapshot) string {
+	switch {
+	case snap.ConnectedServer != "" && snap.TunnelName != "":
+		return i18n.Tf("ui.server_via", snap.ConnectedServer, snap.TunnelName)
+	case snap.ConnectedServer != "":
+		return snap.ConnectedServer
+	default:
+		return snap.TunnelName
 	}
-	if utf8.Valid(encoded) {
-		fixed := string(encoded)
-		if fixed != "" {
-			return fixed
-		}
+}
+
+func formatRate(bytesPerSec float64) string {
+	switch {
+	case bytesPerSec >= 1<<20:
+		return i18n.Tf("ui.rate_mb", bytesPerSec/(1<<20))
+	case bytesPerSec >= 1<<10:
+		return i18n.Tf("ui.rate_kb", bytesPerSec/(1<<10))
+	default:
+		return i18n.Tf("ui.rate_b", bytesPerSec)
 	}
-	return message
 }
 
-func findProfileIndex(list []state.Profile, id string) int {
-	for i, profile := range list {
-		if profile.ID == id {
-			return i
+// filterProfiles возвращает профили, у которых название или страна содержат query без учёта регистра.
+func filterProfiles(list []state.Profile, query string) []state.Profile {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return list
+	}
+	filtered := make([]state.Profile, 0, len(list))
+	for _, profile := range list {
+		if strings.Contains(strings.ToLower(profile.Name), query) || strings.Contains(strings.ToLower(profile.Country), query) {
+			filtered = append(filtered, profile)
 		}
 	}
-	return -1
+	return filtered
 }
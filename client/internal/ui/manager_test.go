@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/state"
+)
+
+func newUpdateTestManager() *Manager {
+	return &Manager{
+		updateCh: make(chan uiSnapshot, 16),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+func statusContext(status string) *state.AppContext {
+	ctx := state.NewAppContext(&config.Config{})
+	ctx.UI.StatusText = status
+	return ctx
+}
+
+func TestUpdateUIBurstKeepsLastSnapshot(t *testing.T) {
+	m := newUpdateTestManager()
+	const total = 200
+	for i := 0; i < total; i++ {
+		m.UpdateUI(statusContext(fmt.Sprintf("status %d", i)))
+	}
+
+	snap := m.latestSnapshot(<-m.updateCh)
+	if want := fmt.Sprintf("status %d", total-1); snap.StatusText != want {
+		t.Fatalf("applied snapshot = %q, want %q", snap.StatusText, want)
+	}
+	if len(m.updateCh) != 0 {
+		t.Fatalf("queue not drained: %d snapshots left", len(m.updateCh))
+	}
+}
+
+func TestUpdateUIConcurrentBurstEndsWithLastSnapshot(t *testing.T) {
+	m := newUpdateTestManager()
+	const total = 1000
+	want := fmt.Sprintf("status %d", total-1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < total; i++ {
+			m.UpdateUI(statusContext(fmt.Sprintf("status %d", i)))
+		}
+	}()
+
+	// потребитель повторяет цикл processUpdates, но вместо отрисовки запоминает снимок
+	var last uiSnapshot
+	for last.StatusText != want {
+		last = m.latestSnapshot(<-m.updateCh)
+	}
+	wg.Wait()
+	if len(m.updateCh) != 0 {
+		t.Fatalf("snapshot queued after the final one: %q", m.latestSnapshot(<-m.updateCh).StatusText)
+	}
+}
@@ -0,0 +1,333 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// profileOtherGroup — группа для профилей без страны; всегда последняя. В дереве показывается как ui.profile_other.
+const profileOtherGroup = "Прочее"
+
+// favoritesNodeID — раздел «Избранное» над странами.
+const favoritesNodeID = "favorites"
+
+// Префиксы идентификаторов узлов дерева профилей: страна, профиль или профиль в «Избранном».
+// Избранный профиль виден в дереве дважды, поэтому его лист в «Избранном» имеет отдельный ID.
+const (
+	groupNodePrefix    = "country:"
+	profileNodePrefix  = "profile:"
+	favoriteNodePrefix = "favorite:"
+)
+
+// profileGroup — профили одной страны в дереве.
+type profileGroup struct {
+	Country  string
+	Profiles []state.Profile
+}
+
+// groupProfiles раскладывает профили по странам: группы и профили внутри них отсортированы по алфавиту.
+func groupProfiles(list []state.Profile) []profileGroup {
+	byCountry := make(map[string][]state.Profile)
+	for _, profile := range list {
+		country := profileCountry(profile)
+		byCountry[country] = append(byCountry[country], profile)
+	}
+	groups := make([]profileGroup, 0, len(byCountry))
+	for country, profiles := range byCountry {
+		sort.SliceStable(profiles, func(i, j int) bool {
+			return strings.ToLower(profiles[i].Name) < strings.ToLower(profiles[j].Name)
+		})
+		groups = append(groups, profileGroup{Country: country, Profiles: profiles})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Country == profileOtherGroup) != (groups[j].Country == profileOtherGroup) {
+			return groups[j].Country == profileOtherGroup
+		}
+		return groups[i].Country < groups[j].Country
+	})
+	return groups
+}
+
+func profileCountry(profile state.Profile) string {
+	country := strings.ToUpper(strings.TrimSpace(profile.Country))
+	if country == "" {
+		return profileOtherGroup
+	}
+	return country
+}
+
+// favoriteProfilesOf возвращает избранные профили из list, отсортированные по названию.
+func favoriteProfilesOf(list []state.Profile, favorites []string) []state.Profile {
+	if len(favorites) == 0 {
+		return nil
+	}
+	ids := make(map[string]struct{}, len(favorites))
+	for _, id := range favorites {
+		ids[id] = struct{}{}
+	}
+	var result []state.Profile
+	for _, profile := range list {
+		if _, ok := ids[profile.ID]; ok {
+			result = append(result, profile)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+	return result
+}
+
+// profileIDFromNode возвращает ID профиля листа дерева из любого раздела.
+func profileIDFromNode(uid widget.TreeNodeID) (string, bool) {
+	if id, ok := strings.CutPrefix(uid, favoriteNodePrefix); ok {
+		return id, true
+	}
+	return strings.CutPrefix(uid, profileNodePrefix)
+}
+
+func groupNodeID(country string) widget.TreeNodeID {
+	return groupNodePrefix + country
+}
+
+func profileNodeID(id string) widget.TreeNodeID {
+	return profileNodePrefix + id
+}
+
+func favoriteNodeID(id string) widget.TreeNodeID {
+	return favoriteNodePrefix + id
+}
+
+// profileNodeLabel — подпись узла дерева с контекстным меню по правому клику.
+type profileNodeLabel struct {
+	widget.Label
+	uid         widget.TreeNodeID
+	onSecondary func(uid widget.TreeNodeID, ev *fyne.PointEvent)
+}
+
+func newProfileNodeLabel(onSecondary func(widget.TreeNodeID, *fyne.PointEvent)) *profileNodeLabel {
+	label := &profileNodeLabel{onSecondary: onSecondary}
+	label.ExtendBaseWidget(label)
+	return label
+}
+
+// TappedSecondary открывает контекстное меню узла; левый клик обрабатывает само дерево.
+func (l *profileNodeLabel) TappedSecondary(ev *fyne.PointEvent) {
+	if l.onSecondary != nil {
+		l.onSecondary(l.uid, ev)
+	}
+}
+
+// buildProfileTree создаёт дерево «страна → профили» поверх m.profileGroups.
+func (m *Manager) buildProfileTree() *widget.Tree {
+	tree := widget.NewTree(
+		m.profileTreeChildren,
+		func(uid widget.TreeNodeID) bool {
+			return uid == "" || uid == favoritesNodeID || strings.HasPrefix(uid, groupNodePrefix)
+		},
+		func(bool) fyne.CanvasObject { return newProfileNodeLabel(m.showProfileNodeMenu) },
+		m.updateProfileTreeNode,
+	)
+	tree.OnSelected = m.handleProfileNodeSelected
+	return tree
+}
+
+func (m *Manager) profileTreeChildren(uid widget.TreeNodeID) []widget.TreeNodeID {
+	if uid == "" {
+		ids := make([]widget.TreeNodeID, 0, len(m.profileGroups)+1)
+		if len(m.profileFavorites) > 0 {
+			ids = append(ids, favoritesNodeID)
+		}
+		for _, group := range m.profileGroups {
+			ids = append(ids, groupNodeID(group.Country))
+		}
+		return ids
+	}
+	if uid == favoritesNodeID {
+		ids := make([]widget.TreeNodeID, 0, len(m.profileFavorites))
+		for _, profile := range m.profileFavorites {
+			ids = append(ids, favoriteNodeID(profile.ID))
+		}
+		return ids
+	}
+	group := m.findProfileGroup(strings.TrimPrefix(uid, groupNodePrefix))
+	if group == nil {
+		return nil
+	}
+	ids := make([]widget.TreeNodeID, 0, len(group.Profiles))
+	for _, profile := range group.Profiles {
+		ids = append(ids, profileNodeID(profile.ID))
+	}
+	return ids
+}
+
+func (m *Manager) updateProfileTreeNode(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+	label := obj.(*profileNodeLabel)
+	label.uid = uid
+	// узлы переиспользуются деревом: серый цвет отключённого профиля сбрасывается явно
+	label.Importance = widget.MediumImportance
+	if uid == favoritesNodeID {
+		label.SetText(i18n.Tf("ui.favorites_count", len(m.profileFavorites)))
+		return
+	}
+	if branch {
+		country := strings.TrimPrefix(uid, groupNodePrefix)
+		count := 0
+		if group := m.findProfileGroup(country); group != nil {
+			count = len(group.Profiles)
+		}
+		title := country
+		if country == profileOtherGroup {
+			title = i18n.T("ui.profile_other")
+		}
+		label.SetText(fmt.Sprintf("%s (%d)", title, count))
+		return
+	}
+	id, _ := profileIDFromNode(uid)
+	profile, ok := m.findVisibleProfile(id)
+	if !ok {
+		label.SetText("-")
+		return
+	}
+	text := profile.Name
+	if strings.HasPrefix(uid, profileNodePrefix) && m.isFavorite(profile.ID) {
+		text = "★ " + text
+	}
+	if !profile.Enabled() {
+		label.Importance = widget.LowImportance
+		text += i18n.T("ui.profile_disabled")
+	}
+	label.SetText(text)
+}
+
+// handleProfileNodeSelected выбирает профиль по листу; выбор страны лишь раскрывает
+// или сворачивает группу и возвращает выделение выбранному профилю.
+func (m *Manager) handleProfileNodeSelected(uid widget.TreeNodeID) {
+	if m.suppressProfileSelect {
+		return
+	}
+	if uid == favoritesNodeID || strings.HasPrefix(uid, groupNodePrefix) {
+		m.profileTree.ToggleBranch(uid)
+		m.selectProfileNode()
+		return
+	}
+	id, _ := profileIDFromNode(uid)
+	if profile, ok := m.findVisibleProfile(id); ok && !profile.Enabled() {
+		// отключённый профиль не выбирается: выделение возвращается к текущему
+		m.selectProfileNode()
+		return
+	}
+	m.handleProfileSelected(id)
+	// выделение переносится в «Избранное», если профиль есть там
+	m.selectProfileNode()
+}
+
+// selectProfileNode выделяет выбранный профиль, если он виден: в «Избранном», если он там есть,
+// иначе в раскрытой группе его страны. Вызывается только из UI goroutine.
+func (m *Manager) selectProfileNode() {
+	if m.profileTree == nil {
+		return
+	}
+	m.suppressProfileSelect = true
+	defer func() { m.suppressProfileSelect = false }()
+	profile, ok := m.findVisibleProfile(m.selectedProfileID)
+	if m.selectedProfileID == "" || !ok {
+		m.profileTree.UnselectAll()
+		return
+	}
+	node := profileNodeID(profile.ID)
+	if m.isVisibleFavorite(profile.ID) && m.profileTree.IsBranchOpen(favoritesNodeID) {
+		node = favoriteNodeID(profile.ID)
+	} else {
+		m.profileTree.OpenBranch(groupNodeID(profileCountry(profile)))
+	}
+	m.profileTree.Select(node)
+	m.profileTree.ScrollTo(node)
+}
+
+// showProfileNodeMenu показывает меню профиля: добавить в избранное или убрать из него.
+func (m *Manager) showProfileNodeMenu(uid widget.TreeNodeID, ev *fyne.PointEvent) {
+	id, ok := profileIDFromNode(uid)
+	if !ok || m.mainWin == nil {
+		return
+	}
+	title := i18n.T("ui.favorite_add")
+	if m.isFavorite(id) {
+		title = i18n.T("ui.favorite_remove")
+	}
+	menu := fyne.NewMenu("", fyne.NewMenuItem(title, func() { m.toggleFavorite(id) }))
+	widget.ShowPopUpMenuAtPosition(menu, m.mainWin.Canvas(), ev.AbsolutePosition)
+}
+
+// toggleFavorite добавляет профиль в избранное или убирает из него и сохраняет список.
+// Вызывается только из UI goroutine.
+func (m *Manager) toggleFavorite(id string) {
+	favorites := make([]string, 0, len(m.favorites)+1)
+	removed := false
+	for _, existing := range m.favorites {
+		if existing == id {
+			removed = true
+			continue
+		}
+		favorites = append(favorites, existing)
+	}
+	if !removed {
+		favorites = append(favorites, id)
+	}
+	m.favorites = favorites
+	if m.saveFavorites != nil {
+		if err := m.saveFavorites(favorites); err != nil {
+			dialog.ShowInformation(i18n.T("ui.favorites"), i18n.Tf("ui.favorites_save_failed", err), m.mainWin)
+		}
+	}
+	m.applyProfileFilter()
+	if !removed {
+		m.profileTree.OpenBranch(favoritesNodeID)
+		m.selectProfileNode()
+	}
+}
+
+func (m *Manager) isFavorite(id string) bool {
+	for _, favorite := range m.favorites {
+		if favorite == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) isVisibleFavorite(id string) bool {
+	for _, profile := range m.profileFavorites {
+		if profile.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) findProfileGroup(country string) *profileGroup {
+	for i := range m.profileGroups {
+		if m.profileGroups[i].Country == country {
+			return &m.profileGroups[i]
+		}
+	}
+	return nil
+}
+
+func (m *Manager) findVisibleProfile(id string) (state.Profile, bool) {
+	for _, group := range m.profileGroups {
+		for _, profile := range group.Profiles {
+			if profile.ID == id {
+				return profile, true
+			}
+		}
+	}
+	return state.Profile{}, false
+}
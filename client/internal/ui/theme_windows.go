@@ -3,20 +3,35 @@ package ui
 import (
 	"image/color"
 
+	"customvpn/client/internal/config"
+	"customvpn/client/internal/i18n"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
 )
 
 // windows11Theme задает мягкую палитру и скругления в духе Windows 11.
+// mode — значение theme из config.yaml: light, dark или system (вариант, который сообщает Fyne).
 type windows11Theme struct {
 	base fyne.Theme
+	mode string
 }
 
-func newWindows11Theme() fyne.Theme {
-	return &windows11Theme{base: theme.LightTheme()}
+func newWindows11Theme(mode string) fyne.Theme {
+	return &windows11Theme{base: theme.DefaultTheme(), mode: mode}
 }
 
 func (t *windows11Theme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch t.mode {
+	case config.ThemeDark:
+		variant = theme.VariantDark
+	case config.ThemeSystem:
+	default:
+		variant = theme.VariantLight
+	}
+	if variant == theme.VariantDark {
+		return t.darkColor(name, variant)
+	}
 	switch name {
 	case theme.ColorNameBackground:
 		return color.NRGBA{R: 244, G: 244, B: 249, A: 255}
@@ -33,6 +48,24 @@ func (t *windows11Theme) Color(name fyne.ThemeColorName, variant fyne.ThemeVaria
 	}
 }
 
+// darkColor — тёмная палитра: тот же синий акцент, фон и текст поменяны местами.
+func (t *windows11Theme) darkColor(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch name {
+	case theme.ColorNameBackground:
+		return color.NRGBA{R: 28, G: 30, B: 38, A: 255}
+	case theme.ColorNameButton, theme.ColorNamePrimary:
+		return color.NRGBA{R: 37, G: 99, B: 235, A: 255}
+	case theme.ColorNameForeground:
+		return color.NRGBA{R: 236, G: 237, B: 243, A: 255}
+	case theme.ColorNameInputBackground:
+		return color.NRGBA{R: 40, G: 43, B: 54, A: 255}
+	case theme.ColorNameDisabled:
+		return color.NRGBA{R: 98, G: 103, B: 116, A: 255}
+	default:
+		return t.base.Color(name, variant)
+	}
+}
+
 func (t *windows11Theme) Font(style fyne.TextStyle) fyne.Resource {
 	return t.base.Font(style)
 }
@@ -44,3 +77,48 @@ func (t *windows11Theme) Icon(name fyne.ThemeIconName) fyne.Resource {
 func (t *windows11Theme) Size(name fyne.ThemeSizeName) float32 {
 	return t.base.Size(name)
 }
+
+// themeOptions — пункты выбора оформления в окне настроек; label — ключ каталога.
+var themeOptions = []struct {
+	mode  string
+	label string
+}{
+	{config.ThemeLight, "ui.theme_light"},
+	{config.ThemeDark, "ui.theme_dark"},
+	{config.ThemeSystem, "ui.theme_system"},
+}
+
+func themeLabels() []string {
+	labels := make([]string, 0, len(themeOptions))
+	for _, option := range themeOptions {
+		labels = append(labels, i18n.T(option.label))
+	}
+	return labels
+}
+
+func themeLabel(mode string) string {
+	for _, option := range themeOptions {
+		if option.mode == mode {
+			return i18n.T(option.label)
+		}
+	}
+	return i18n.T(themeOptions[0].label)
+}
+
+func themeByLabel(label string) string {
+	for _, option := range themeOptions {
+		if i18n.T(option.label) == label {
+			return option.mode
+		}
+	}
+	return config.ThemeLight
+}
+
+// applyTheme переключает оформление без перезапуска. Вызывается в goroutine UI.
+func (m *Manager) applyTheme(mode string) {
+	if m.app == nil || mode == m.theme {
+		return
+	}
+	m.theme = mode
+	m.app.Settings().SetTheme(newWindows11Theme(mode))
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	_ "embed"
+	"time"
+
+	"customvpn/client/internal/i18n"
+	"customvpn/client/internal/state"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/systray"
+)
+
+// Значки трея — PNG: на Windows значок конвертируется в ICO, SVG там не поддерживается.
+var (
+	//go:embed icons/tray_disconnected.png
+	trayDisconnectedPNG []byte
+	//go:embed icons/tray_connected.png
+	trayConnectedPNG []byte
+	//go:embed icons/tray_connecting.png
+	trayConnectingPNG []byte
+	//go:embed icons/tray_connecting_dim.png
+	trayConnectingDimPNG []byte
+)
+
+var (
+	trayDisconnectedIcon  = fyne.NewStaticResource("tray_disconnected.png", trayDisconnectedPNG)
+	trayConnectedIcon     = fyne.NewStaticResource("tray_connected.png", trayConnectedPNG)
+	trayConnectingIcon    = fyne.NewStaticResource("tray_connecting.png", trayConnectingPNG)
+	trayConnectingDimIcon = fyne.NewStaticResource("tray_connecting_dim.png", trayConnectingDimPNG)
+)
+
+// trayStateTitles — ключи каталога с названиями состояний для подсказки значка в трее.
+var trayStateTitles = map[state.State]string{
+	state.StateAppStarting:        "state.app_starting",
+	state.StatePreflightCheck:     "state.preflight",
+	state.StateWaitingLogin:       "state.waiting_login",
+	state.StateAuthInProgress:     "state.authenticating",
+	state.StateSyncInProgress:     "state.syncing",
+	state.StatePreparingEnv:       "state.preparing_env",
+	state.StateReadyDisconnected:  "state.ready",
+	state.StateRefreshingProfiles: "state.refreshing_profiles",
+	state.StateConnecting:         "state.connecting",
+	state.StateConnected:          "state.connected",
+	state.StateDisconnecting:      "state.disconnecting",
+	state.StateReconnecting:       "state.reconnecting",
+	state.StateError:              "state.error",
+	state.StateExiting:            "state.exiting",
+}
+
+// OnStateChanged обновляет подсказку значка в трее при смене состояния.
+// Вызывается из goroutine state machine.
+func (m *Manager) OnStateChanged(_, next state.State) {
+	if m.app == nil {
+		return
+	}
+	title := string(next)
+	if key, ok := trayStateTitles[next]; ok {
+		title = i18n.T(key)
+	}
+	m.callOnUI(func() {
+		m.trayStateTitle = title
+		m.updateTrayTooltip()
+	})
+}
+
+// updateTray меняет значок трея по снимку: серый — отключено, зелёный — подключено,
+// мигающий жёлтый — подключение или переподключение. Вызывается только из UI goroutine.
+func (m *Manager) updateTray(snap uiSnapshot) {
+	m.trayStatusText = snap.StatusText
+	m.updateTrayTooltip()
+	switch {
+	case snap.IsConnected:
+		m.stopTrayPulse()
+		m.setTrayIcon(trayConnectedIcon)
+	case snap.IsConnecting || snap.IsReconnecting:
+		m.startTrayPulse()
+	default:
+		m.stopTrayPulse()
+		m.setTrayIcon(trayDisconnectedIcon)
+	}
+}
+
+// updateTrayTooltip показывает в подсказке состояние и текст статуса, если он добавляет подробности.
+func (m *Manager) updateTrayTooltip() {
+	tooltip := m.appName
+	if m.trayStateTitle != "" {
+		tooltip += ": " + m.trayStateTitle
+	}
+	if m.trayStatusText != "" && m.trayStatusText != m.trayStateTitle {
+		tooltip += "\n" + m.trayStatusText
+	}
+	if !m.trayAvailable || tooltip == m.trayTooltip {
+		return
+	}
+	m.trayTooltip = tooltip
+	systray.SetTooltip(tooltip)
+}
+
+// setTrayIcon устанавливает значок, только если он изменился.
+func (m *Manager) setTrayIcon(icon fyne.Resource) {
+	if !m.trayAvailable || icon == m.trayIcon {
+		return
+	}
+	tray := m.trayApp()
+	if tray == nil {
+		return
+	}
+	m.trayIcon = icon
+	tray.SetSystemTrayIcon(icon)
+}
+
+// confirmExitWithoutTray спрашивает о выходе при закрытии главного окна, когда трея нет:
+// скрытое окно вернуть было бы нечем. Вызывается только из UI goroutine.
+func (m *Manager) confirmExitWithoutTray() {
+	if m.mainWin == nil {
+		return
+	}
+	message := i18n.Tf("ui.tray_unavailable", m.appName)
+	dialog.ShowConfirm(i18n.T("ui.exit"), message, func(ok bool) {
+		if ok {
+			m.handleExitRequested()
+		}
+	}, m.mainWin)
+}
+
+// startTrayPulse запускает мигание значка подключения. Вызывается только из UI goroutine.
+func (m *Manager) startTrayPulse() {
+	if m.trayPulse != nil {
+		return
+	}
+	m.setTrayIcon(trayConnectingIcon)
+	pulse := fyne.NewAnimation(700*time.Millisecond, func(progress float32) {
+		if progress < 0.5 {
+			m.setTrayIcon(trayConnectingIcon)
+		} else {
+			m.setTrayIcon(trayConnectingDimIcon)
+		}
+	})
+	pulse.AutoReverse = true
+	pulse.RepeatCount = fyne.AnimationRepeatForever
+	m.trayPulse = pulse
+	pulse.Start()
+}
+
+// stopTrayPulse останавливает мигание значка. Вызывается только из UI goroutine.
+func (m *Manager) stopTrayPulse() {
+	if m.trayPulse == nil {
+		return
+	}
+	m.trayPulse.Stop()
+	m.trayPulse = nil
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -20,6 +22,11 @@ type AuthResponse struct {
 	AuthToken string `json:"authToken"`
 }
 
+// AuthErrorResponse explains why /auth rejected the request
+type AuthErrorResponse struct {
+	Error string `json:"error"`
+}
+
 // authHandler handles POST /auth
 func authHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -34,33 +41,43 @@ func authHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metrics.authAttempts.Add(1)
+	now := time.Now()
+	if wait := loginLimiter.retryAfter(req.Login, now); wait > 0 {
+		metrics.authThrottled.Add(1)
+		log.Printf("Auth throttled for login: %s, retry in %s", req.Login, wait.Round(time.Second))
+		writeTooManyAttempts(w, wait)
+		return
+	}
+
 	// Check credentials
-	user, exists := users[req.Login]
+	user, exists := store.GetUser(req.Login)
 	if !exists || user.Password != req.Password {
 		log.Printf("Auth failed for login: %s", req.Login)
-		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(`"Auth Failed"`))
+		metrics.authFailures.Add(1)
+		if loginLimiter.fail(req.Login, now) {
+			log.Printf("Too many failed attempts for login: %s, locked for %s", req.Login, loginLimiter.cooldown)
+			writeTooManyAttempts(w, loginLimiter.cooldown)
+			return
+		}
+		writeAuthError(w, http.StatusUnauthorized, "bad_credentials")
+		return
+	}
+	loginLimiter.succeed(req.Login)
+	if user.Locked {
+		log.Printf("Auth rejected for locked login: %s", req.Login)
+		metrics.authFailures.Add(1)
+		writeAuthError(w, http.StatusForbidden, "locked")
 		return
 	}
 
-	// Generate token
-	token, err := generateToken()
+	token, err := issueToken(req.Login)
 	if err != nil {
 		log.Printf("Failed to generate token: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Store token
-	authToken := &AuthToken{
-		Value:     token,
-		UserLogin: req.Login,
-		IssuedAt:  time.Now(),
-		ExpiresAt: nil, // long-lived
-	}
-	tokens[token] = authToken
-
 	log.Printf("Auth successful for login: %s, token: %s", req.Login, token)
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -68,6 +85,94 @@ func authHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(AuthResponse{AuthToken: token})
 }
 
+// writeAuthError writes an /auth rejection as {"error":"<code>"}
+func writeAuthError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(AuthErrorResponse{Error: code})
+}
+
+// writeTooManyAttempts answers 429 {"error":"too_many_attempts"} with Retry-After in seconds
+func writeTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeAuthError(w, http.StatusTooManyRequests, "too_many_attempts")
+}
+
+// logoutHandler handles POST /logout and revokes the caller's token
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, _ := bearerToken(r)
+	authToken, exists := store.RevokeToken(token)
+
+	if exists {
+		log.Printf("Logout for login: %s, token: %s", authToken.UserLogin, token)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refreshHandler handles POST /refresh: exchanges a valid token for a new one
+func refreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	oldToken, _ := bearerToken(r)
+	value, err := generateToken()
+	if err != nil {
+		log.Printf("Failed to generate token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// the old token is revoked in the same step so only one of them stays usable
+	token, err := store.ExchangeToken(oldToken, value, time.Now())
+	if err != nil {
+		metrics.tokenRejections.Add(1)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`"Auth Failed"`))
+		return
+	}
+	metrics.tokenRefreshes.Add(1)
+
+	log.Printf("Token refreshed for login: %s, token: %s", token.UserLogin, token.Value)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(AuthResponse{AuthToken: token.Value})
+}
+
+// issueToken generates and stores a new token for the user, applying tokenTTL
+func issueToken(login string) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	store.AddToken(newAuthToken(token, login, time.Now()))
+	return token, nil
+}
+
+// newAuthToken builds a token issued at now, applying tokenTTL
+func newAuthToken(value, login string, now time.Time) *AuthToken {
+	authToken := &AuthToken{
+		Value:     value,
+		UserLogin: login,
+		IssuedAt:  now,
+		ExpiresAt: nil, // long-lived
+	}
+	if tokenTTL > 0 {
+		expiresAt := now.Add(tokenTTL)
+		authToken.ExpiresAt = &expiresAt
+	}
+	return authToken
+}
+
 // generateToken generates a random token
 func generateToken() (string, error) {
 	bytes := make([]byte, 32)
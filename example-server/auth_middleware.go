@@ -1,9 +1,11 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // authMiddleware checks for valid Bearer token
@@ -11,6 +13,7 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
+			metrics.tokenRejections.Add(1)
 			log.Printf("Missing Authorization header")
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -18,8 +21,9 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		token, ok := bearerToken(r)
+		if !ok {
+			metrics.tokenRejections.Add(1)
 			log.Printf("Invalid Authorization header format")
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -27,9 +31,13 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		token := parts[1]
-		if _, exists := tokens[token]; !exists {
-			log.Printf("Invalid token: %s", token)
+		if _, err := store.ValidateToken(token, time.Now()); err != nil {
+			metrics.tokenRejections.Add(1)
+			if errors.Is(err, errExpiredToken) {
+				log.Printf("Expired token: %s", token)
+			} else {
+				log.Printf("Invalid token: %s", token)
+			}
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte(`"Auth Failed"`))
@@ -39,4 +47,13 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		// Token is valid, proceed
 		next(w, r)
 	}
+}
+
+// bearerToken extracts the token from the "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, bool) {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+	return parts[1], true
 }
\ No newline at end of file
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupAuth replaces the global store with one user and the given token TTL for the test
+func setupAuth(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	prevStore, prevTTL, prevLimiter := store, tokenTTL, loginLimiter
+	t.Cleanup(func() {
+		store, tokenTTL, loginLimiter = prevStore, prevTTL, prevLimiter
+	})
+	store = NewStore()
+	store.AddUser(&User{ID: "user", Login: "user", Password: "secret"})
+	tokenTTL = ttl
+	loginLimiter = newAuthLimiter(5, time.Minute, time.Minute)
+}
+
+func authedRequest(method, path, token string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestIssueTokenAppliesTTL(t *testing.T) {
+	setupAuth(t, 30*time.Minute)
+	before := time.Now()
+	value, err := issueToken("user")
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	token, err := store.ValidateToken(value, time.Now())
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if token.ExpiresAt == nil || token.ExpiresAt.Before(before.Add(30*time.Minute)) {
+		t.Fatalf("ExpiresAt = %v, want about 30m from now", token.ExpiresAt)
+	}
+
+	tokenTTL = 0
+	value, err = issueToken("user")
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	if token, _ := store.ValidateToken(value, time.Now()); token.ExpiresAt != nil {
+		t.Fatalf("ExpiresAt = %v with token_ttl 0, want long-lived", token.ExpiresAt)
+	}
+}
+
+func TestAuthMiddlewareRejectsExpiredToken(t *testing.T) {
+	setupAuth(t, 0)
+	now := time.Now()
+	expired := now.Add(-time.Second)
+	valid := now.Add(time.Hour)
+	store.AddToken(&AuthToken{Value: "expired", UserLogin: "user", IssuedAt: now.Add(-time.Hour), ExpiresAt: &expired})
+	store.AddToken(&AuthToken{Value: "valid", UserLogin: "user", IssuedAt: now, ExpiresAt: &valid})
+	handler := authMiddleware(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "valid", token: "valid", want: http.StatusNoContent},
+		{name: "expired", token: "expired", want: http.StatusUnauthorized},
+		{name: "unknown", token: "unknown", want: http.StatusUnauthorized},
+		{name: "missing", token: "", want: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, authedRequest(http.MethodGet, "/sync/profiles", tc.token))
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+	// expired tokens are dropped from the store once seen
+	if _, ok := store.RevokeToken("expired"); ok {
+		t.Fatalf("expired token is still stored")
+	}
+}
+
+func TestRefreshExchangesToken(t *testing.T) {
+	setupAuth(t, time.Hour)
+	old, err := issueToken("user")
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	handler := authMiddleware(refreshHandler)
+
+	rec := httptest.NewRecorder()
+	handler(rec, authedRequest(http.MethodPost, "/refresh", old))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp AuthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.AuthToken == "" || resp.AuthToken == old {
+		t.Fatalf("refresh response = %+v, %v; want a new token", resp, err)
+	}
+	if _, err := store.ValidateToken(resp.AuthToken, time.Now()); err != nil {
+		t.Fatalf("new token is not valid: %v", err)
+	}
+
+	// the old token is revoked by the exchange
+	rec = httptest.NewRecorder()
+	handler(rec, authedRequest(http.MethodPost, "/refresh", old))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("second refresh with the old token: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, authedRequest(http.MethodGet, "/refresh", resp.AuthToken))
+	if rec.Code != http.StatusMethodNotAllowed || !strings.Contains(rec.Body.String(), "Method not allowed") {
+		t.Fatalf("GET /refresh: status = %d, want 405", rec.Code)
+	}
+}
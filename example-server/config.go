@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,7 +13,18 @@ type ServerConfig struct {
 	ListenAddr  string `yaml:"listen_addr"`
 	UserLogin   string `yaml:"user_login"`
 	UserPassword string `yaml:"user_password"`
+	// UserLocked makes /auth reject the test user with 403 {"error":"locked"}
+	UserLocked  bool   `yaml:"user_locked"`
 	ProfilesDir string `yaml:"profiles_dir"`
+	// TokenTTL limits the lifetime of issued tokens ("30m", "12h"); zero means long-lived
+	TokenTTL time.Duration `yaml:"token_ttl"`
+	// AuthMaxFailures failed /auth attempts within AuthFailureWindow lock the login
+	// out for AuthLockout with 429; zero disables rate limiting
+	AuthMaxFailures   int           `yaml:"auth_max_failures"`
+	AuthFailureWindow time.Duration `yaml:"auth_failure_window"`
+	AuthLockout       time.Duration `yaml:"auth_lockout"`
+	// GzipMinSize is the smallest response body compressed with gzip; zero means 1024, negative disables gzip
+	GzipMinSize int `yaml:"gzip_min_size"`
 }
 
 // LoadServerConfig loads the server configuration from server-config.yaml
@@ -29,5 +41,21 @@ func LoadServerConfig(configPath string) (*ServerConfig, error) {
 		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
+	if config.TokenTTL < 0 {
+		return nil, fmt.Errorf("token_ttl must not be negative")
+	}
+	if config.AuthMaxFailures < 0 || config.AuthFailureWindow < 0 || config.AuthLockout < 0 {
+		return nil, fmt.Errorf("auth rate limit settings must not be negative")
+	}
+	if config.AuthFailureWindow == 0 {
+		config.AuthFailureWindow = time.Minute
+	}
+	if config.AuthLockout == 0 {
+		config.AuthLockout = time.Minute
+	}
+	if config.GzipMinSize == 0 {
+		config.GzipMinSize = defaultGzipMinSize
+	}
+
 	return &config, nil
 }
@@ -11,6 +11,34 @@ type ProfileDTO struct {
 	DirectRoutes []string    `json:"direct_routes"`
 	TunnelRoutes []string    `json:"tunnel_routes"`
 	KillSwitch  bool        `json:"kill_switch"`
+	EnableIPv6   bool        `json:"enable_ipv6"`
+	TunnelDNS    []string    `json:"tunnel_dns,omitempty"`
+	// PreConnectCmd and PostDisconnectCmd run on the client only when it allows profile hooks
+	PreConnectCmd     string `json:"pre_connect_cmd,omitempty"`
+	PostDisconnectCmd string `json:"post_disconnect_cmd,omitempty"`
+	// Status is "active" (the default when empty) or "disabled": a disabled profile stays listed
+	// but clients refuse to connect to it
+	Status string `json:"status,omitempty"`
+	// RouteMetric, when set (1..MaxRouteMetric), replaces the metric of every route the client adds for the profile
+	RouteMetric int `json:"route_metric,omitempty"`
+}
+
+// Profile status values
+const (
+	ProfileStatusActive   = "active"
+	ProfileStatusDisabled = "disabled"
+)
+
+// MaxRouteMetric is the largest route_metric accepted (route.exe limit on Windows)
+const MaxRouteMetric = 9999
+
+// APIVersion is the control API version reported by /health.
+const APIVersion = "1.0"
+
+// HealthDTO represents the /health response.
+type HealthDTO struct {
+	Status     string `json:"status"`
+	APIVersion string `json:"api_version"`
 }
 
 // ProfileSummaryDTO represents a minimal profile list item.
@@ -18,4 +46,5 @@ type ProfileSummaryDTO struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
 	Country string `json:"country"`
+	Status  string `json:"status"`
 }
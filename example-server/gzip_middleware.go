@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// defaultGzipMinSize is used when gzip_min_size is not set
+const defaultGzipMinSize = 1024
+
+// gzipMiddleware compresses responses larger than minSize for clients that accept gzip.
+// Smaller responses are sent as is with their original headers. A negative minSize disables compression.
+func gzipMiddleware(minSize int, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if minSize < 0 || !acceptsGzip(r) {
+			next(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize, statusCode: http.StatusOK}
+		defer gw.finish()
+		next(gw, r)
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses gzip
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the response until it grows past minSize, then switches
+// to a streamed gzip body without Content-Length (chunked transfer encoding).
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize     int
+	statusCode  int
+	wroteHeader bool
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	g.statusCode = code
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	g.wroteHeader = true
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	g.buf.Write(p)
+	if g.buf.Len() < g.minSize || !bodyAllowed(g.statusCode) {
+		return len(p), nil
+	}
+	header := g.Header()
+	if header.Get("Content-Encoding") != "" {
+		// the handler encoded the body itself
+		return len(p), nil
+	}
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	g.ResponseWriter.WriteHeader(g.statusCode)
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+	if _, err := g.gz.Write(g.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	g.buf.Reset()
+	return len(p), nil
+}
+
+// finish sends a buffered small response as is or closes the gzip stream
+func (g *gzipResponseWriter) finish() {
+	if g.gz != nil {
+		g.gz.Close()
+		return
+	}
+	g.ResponseWriter.WriteHeader(g.statusCode)
+	if g.buf.Len() > 0 {
+		g.ResponseWriter.Write(g.buf.Bytes())
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	large := strings.Repeat("profile ", 512)
+	cases := []struct {
+		name           string
+		minSize        int
+		acceptEncoding string
+		status         int
+		body           string
+		wantGzip       bool
+		wantVary       bool
+	}{
+		{name: "below min size", minSize: 1024, acceptEncoding: "gzip", status: http.StatusOK, body: "small", wantVary: true},
+		{name: "above min size", minSize: 1024, acceptEncoding: "gzip", status: http.StatusOK, body: large, wantGzip: true, wantVary: true},
+		{name: "among other codings", minSize: 1024, acceptEncoding: "br, GZIP ; q=0.8", status: http.StatusOK, body: large, wantGzip: true, wantVary: true},
+		{name: "gzip refused", minSize: 1024, acceptEncoding: "gzip;q=0", status: http.StatusOK, body: large},
+		{name: "gzip refused with spaces", minSize: 1024, acceptEncoding: "gzip; q=0", status: http.StatusOK, body: large},
+		{name: "not accepted", minSize: 1024, acceptEncoding: "", status: http.StatusOK, body: large},
+		{name: "disabled", minSize: -1, acceptEncoding: "gzip", status: http.StatusOK, body: large},
+		{name: "no content", minSize: 0, acceptEncoding: "gzip", status: http.StatusNoContent, wantVary: true},
+		{name: "error status above min size", minSize: 16, acceptEncoding: "gzip", status: http.StatusNotFound, body: large, wantGzip: true, wantVary: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := gzipMiddleware(tc.minSize, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(tc.status)
+				// the body arrives in two writes, so buffering crosses minSize mid-response
+				half := len(tc.body) / 2
+				io.WriteString(w, tc.body[:half])
+				io.WriteString(w, tc.body[half:])
+			})
+			r := httptest.NewRequest(http.MethodGet, "/profiles/p1", nil)
+			if tc.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tc.status {
+				t.Fatalf("status = %d, want %d", w.Code, tc.status)
+			}
+			if got := w.Header().Get("Vary") == "Accept-Encoding"; got != tc.wantVary {
+				t.Fatalf("Vary = %q, want set %v", w.Header().Get("Vary"), tc.wantVary)
+			}
+			body := w.Body.Bytes()
+			if tc.wantGzip {
+				if w.Header().Get("Content-Encoding") != "gzip" {
+					t.Fatalf("Content-Encoding = %q, want gzip", w.Header().Get("Content-Encoding"))
+				}
+				if w.Header().Get("Content-Length") != "" {
+					t.Fatalf("compressed response has Content-Length %s", w.Header().Get("Content-Length"))
+				}
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("gzip reader: %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("decompress: %v", err)
+				}
+			} else if enc := w.Header().Get("Content-Encoding"); enc != "" {
+				t.Fatalf("Content-Encoding = %q, want none", enc)
+			}
+			if string(body) != tc.body {
+				t.Fatalf("body = %d bytes, want %d", len(body), len(tc.body))
+			}
+		})
+	}
+}
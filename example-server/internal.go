@@ -11,4 +11,13 @@ type Profile struct {
 	DirectRoutes []string
 	TunnelRoutes []string
 	KillSwitch  bool
+	EnableIPv6   bool
+	TunnelDNS    []string
+	// client-side hook commands, passed through as is
+	PreConnectCmd     string
+	PostDisconnectCmd string
+	// Status is ProfileStatusActive or ProfileStatusDisabled
+	Status string
+	// RouteMetric overrides client route metrics; 0 keeps the gateway metric
+	RouteMetric int
 }
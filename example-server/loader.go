@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // LoadProfiles loads all profile JSON files from the specified directory.
@@ -60,6 +62,50 @@ func validateProfileDTO(dto ProfileDTO) error {
 	if dto.Port <= 0 || dto.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", dto.Port)
 	}
-	// Additional validation for CIDR can be added if needed
+	if err := validateCIDRs("direct_routes", dto.DirectRoutes); err != nil {
+		return err
+	}
+	if err := validateCIDRs("tunnel_routes", dto.TunnelRoutes); err != nil {
+		return err
+	}
+	if err := validateIPs("tunnel_dns", dto.TunnelDNS); err != nil {
+		return err
+	}
+	switch dto.Status {
+	case "", ProfileStatusActive, ProfileStatusDisabled:
+	default:
+		return fmt.Errorf("invalid status %q: expected %q or %q", dto.Status, ProfileStatusActive, ProfileStatusDisabled)
+	}
+	if dto.RouteMetric < 0 || dto.RouteMetric > MaxRouteMetric {
+		return fmt.Errorf("invalid route_metric %d: expected 0..%d", dto.RouteMetric, MaxRouteMetric)
+	}
+	return nil
+}
+
+// validateCIDRs checks that every non-empty entry of a route list is a valid CIDR.
+func validateCIDRs(field string, cidrs []string) error {
+	for i, cidr := range cidrs {
+		value := strings.TrimSpace(cidr)
+		if value == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			return fmt.Errorf("%s[%d]: invalid CIDR %q: %w", field, i, cidr, err)
+		}
+	}
+	return nil
+}
+
+// validateIPs checks that every non-empty entry of a list is a valid IP address.
+func validateIPs(field string, ips []string) error {
+	for i, ip := range ips {
+		value := strings.TrimSpace(ip)
+		if value == "" {
+			continue
+		}
+		if net.ParseIP(value) == nil {
+			return fmt.Errorf("%s[%d]: invalid IP address %q", field, i, ip)
+		}
+	}
 	return nil
 }
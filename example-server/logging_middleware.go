@@ -6,18 +6,45 @@ import (
 	"time"
 )
 
-// loggingMiddleware logs each request
+// requestIDHeader carries the client's per-request ID used to match client and server logs
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the echoed ID so a client cannot bloat responses and log lines
+const maxRequestIDLength = 64
+
+// loggingMiddleware logs each request and echoes its X-Request-ID
 func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		requestID := sanitizeRequestID(r.Header.Get(requestIDHeader))
+		if requestID != "" {
+			w.Header().Set(requestIDHeader, requestID)
+		} else {
+			requestID = "-"
+		}
 		// Wrap ResponseWriter to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
 		next(wrapped, r)
 
 		duration := time.Since(start)
-		log.Printf("Request: %s %s %d %v", r.Method, r.URL.Path, wrapped.statusCode, duration)
+		log.Printf("Request: %s %s %d %v request_id=%s", r.Method, r.URL.Path, wrapped.statusCode, duration, requestID)
+	}
+}
+
+// sanitizeRequestID returns id if it is a short token of letters, digits, '-' and '_', and "" otherwise
+func sanitizeRequestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return ""
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return ""
+		}
 	}
+	return id
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code
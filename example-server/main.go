@@ -27,6 +27,11 @@ func main() {
 	// Initialize storage
 	InitStorage(config, profileDTOs)
 
+	// Reload profiles on change, so clients can test refresh without a restart
+	if err := WatchProfiles(config.ProfilesDir); err != nil {
+		log.Printf("Profiles watcher disabled: %v", err)
+	}
+
 	// Start server
 	StartServer(config)
 }
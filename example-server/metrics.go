@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency histogram
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// Metrics holds the counters exposed by GET /metrics in the Prometheus text format.
+// Counters are updated by handlers concurrently, so they are atomics or guarded by mu.
+type Metrics struct {
+	authAttempts       atomic.Int64
+	authFailures       atomic.Int64
+	authThrottled      atomic.Int64
+	tokenRejections    atomic.Int64
+	tokenRefreshes     atomic.Int64
+	profileListFetches atomic.Int64
+	profileFetches     atomic.Int64
+
+	mu        sync.Mutex
+	latencies map[string]*latencyHistogram
+	responses map[responseKey]int64
+}
+
+// responseKey identifies a requests_total series
+type responseKey struct {
+	route  string
+	status int
+}
+
+// latencyHistogram is a cumulative histogram of one route's request durations
+type latencyHistogram struct {
+	buckets []int64
+	count   int64
+	sum     float64
+}
+
+// NewMetrics creates empty metrics
+func NewMetrics() *Metrics {
+	return &Metrics{
+		latencies: make(map[string]*latencyHistogram),
+		responses: make(map[responseKey]int64),
+	}
+}
+
+var metrics = NewMetrics()
+
+// observeRequest records one handled request of the given route
+func (m *Metrics) observeRequest(route string, status int, duration time.Duration) {
+	seconds := duration.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses[responseKey{route: route, status: status}]++
+	histogram, ok := m.latencies[route]
+	if !ok {
+		histogram = &latencyHistogram{buckets: make([]int64, len(latencyBuckets))}
+		m.latencies[route] = histogram
+	}
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// metricsMiddleware records the status and latency of requests to route.
+// route is the registered pattern, so /profiles/{id} stays a single series.
+func metricsMiddleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(wrapped, r)
+		metrics.observeRequest(route, wrapped.statusCode, time.Since(start))
+	}
+}
+
+// metricsHandler handles GET /metrics
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	metrics.write(w, store, time.Now())
+}
+
+// write renders all metrics; store gauges are read at the time of the request
+func (m *Metrics) write(w io.Writer, s *Store, now time.Time) {
+	writeMetric(w, "customvpn_auth_attempts_total", "counter", "Login attempts received by /auth.", m.authAttempts.Load())
+	writeMetric(w, "customvpn_auth_failures_total", "counter", "Logins rejected for bad credentials or a locked account.", m.authFailures.Load())
+	writeMetric(w, "customvpn_auth_throttled_total", "counter", "Logins rejected by the failed attempts limiter.", m.authThrottled.Load())
+	writeMetric(w, "customvpn_token_rejections_total", "counter", "Requests rejected for a missing, unknown or expired token.", m.tokenRejections.Load())
+	writeMetric(w, "customvpn_token_refreshes_total", "counter", "Tokens exchanged by /refresh.", m.tokenRefreshes.Load())
+	writeMetric(w, "customvpn_profile_list_fetches_total", "counter", "Profile lists served by /sync/profiles.", m.profileListFetches.Load())
+	writeMetric(w, "customvpn_profile_fetches_total", "counter", "Profiles served by /profiles/{id}.", m.profileFetches.Load())
+	writeMetric(w, "customvpn_active_tokens", "gauge", "Issued tokens that have not expired or been revoked.", int64(s.ActiveTokens(now)))
+	writeMetric(w, "customvpn_profiles", "gauge", "Profiles currently loaded.", int64(s.ProfileCount()))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]responseKey, 0, len(m.responses))
+	for key := range m.responses {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].status < keys[j].status
+	})
+	fmt.Fprintln(w, "# HELP customvpn_http_requests_total HTTP requests by route and status code.")
+	fmt.Fprintln(w, "# TYPE customvpn_http_requests_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(w, "customvpn_http_requests_total{route=%q,code=\"%d\"} %d\n", key.route, key.status, m.responses[key])
+	}
+
+	routes := make([]string, 0, len(m.latencies))
+	for route := range m.latencies {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	fmt.Fprintln(w, "# HELP customvpn_http_request_duration_seconds HTTP request latencies by route.")
+	fmt.Fprintln(w, "# TYPE customvpn_http_request_duration_seconds histogram")
+	for _, route := range routes {
+		histogram := m.latencies[route]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(w, "customvpn_http_request_duration_seconds_bucket{route=%q,le=\"%s\"} %d\n", route, strconv.FormatFloat(bound, 'g', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(w, "customvpn_http_request_duration_seconds_bucket{route=%q,le=\"+Inf\"} %d\n", route, histogram.count)
+		fmt.Fprintf(w, "customvpn_http_request_duration_seconds_sum{route=%q} %s\n", route, strconv.FormatFloat(histogram.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "customvpn_http_request_duration_seconds_count{route=%q} %d\n", route, histogram.count)
+	}
+}
+
+func writeMetric(w io.Writer, name, kind, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
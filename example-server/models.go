@@ -7,6 +7,7 @@ type User struct {
 	ID       string
 	Login    string
 	Password string
+	Locked   bool
 }
 
 // AuthToken represents an authentication token
@@ -15,4 +16,9 @@ type AuthToken struct {
 	UserLogin string
 	IssuedAt  time.Time
 	ExpiresAt *time.Time // null for long-lived
+}
+
+// Expired reports whether the token is past its expiry time
+func (t *AuthToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// authLimiter counts failed /auth attempts per login in a sliding window and
+// locks the login out for a cooldown once maxFailures is reached
+type authLimiter struct {
+	mu          sync.Mutex
+	maxFailures int
+	window      time.Duration
+	cooldown    time.Duration
+	failures    map[string][]time.Time
+	lockedUntil map[string]time.Time
+	// lastPrune is when stale entries of logins that never hit the limit were last dropped
+	lastPrune time.Time
+}
+
+// loginLimiter is nil when auth_max_failures is not set
+var loginLimiter *authLimiter
+
+func newAuthLimiter(maxFailures int, window, cooldown time.Duration) *authLimiter {
+	if maxFailures <= 0 {
+		return nil
+	}
+	return &authLimiter{
+		maxFailures: maxFailures,
+		window:      window,
+		cooldown:    cooldown,
+		failures:    make(map[string][]time.Time),
+		lockedUntil: make(map[string]time.Time),
+	}
+}
+
+// retryAfter reports how long the login stays locked out; zero means attempts are allowed
+func (l *authLimiter) retryAfter(login string, now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.lockedUntil[login]
+	if !ok {
+		return 0
+	}
+	if !now.Before(until) {
+		delete(l.lockedUntil, login)
+		return 0
+	}
+	return until.Sub(now)
+}
+
+// fail records a failed attempt and reports whether the login is now locked out
+func (l *authLimiter) fail(login string, now time.Time) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	recent := l.failures[login][:0]
+	for _, at := range l.failures[login] {
+		if now.Sub(at) < l.window {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	if len(recent) < l.maxFailures {
+		l.failures[login] = recent
+		return false
+	}
+	delete(l.failures, login)
+	l.lockedUntil[login] = now.Add(l.cooldown)
+	return true
+}
+
+// prune drops failures that slid out of the window and expired lockouts, at most once
+// per window, so logins that never reach the limit do not pile up in memory
+func (l *authLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	l.lastPrune = now
+	for login, times := range l.failures {
+		// failures are appended in order: the last one is the newest
+		if now.Sub(times[len(times)-1]) >= l.window {
+			delete(l.failures, login)
+		}
+	}
+	for login, until := range l.lockedUntil {
+		if !now.Before(until) {
+			delete(l.lockedUntil, login)
+		}
+	}
+}
+
+// succeed forgets earlier failures after a successful login
+func (l *authLimiter) succeed(login string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	delete(l.failures, login)
+	l.mu.Unlock()
+}
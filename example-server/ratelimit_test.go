@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthLimiter(t *testing.T) {
+	type step struct {
+		at      time.Duration // offset from the start
+		op      string        // "fail", "succeed" or "check"
+		locked  bool          // fail: the login got locked by this attempt
+		waiting time.Duration // check: expected retryAfter
+	}
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "failures within the window lock the login",
+			steps: []step{
+				{at: 0, op: "fail"},
+				{at: 10 * time.Second, op: "fail"},
+				{at: 20 * time.Second, op: "fail", locked: true},
+				{at: 30 * time.Second, op: "check", waiting: 50 * time.Second},
+			},
+		},
+		{
+			name: "failures outside the window do not add up",
+			steps: []step{
+				{at: 0, op: "fail"},
+				{at: 10 * time.Second, op: "fail"},
+				{at: 70 * time.Second, op: "fail"},
+				{at: 75 * time.Second, op: "check"},
+			},
+		},
+		{
+			name: "lock expires after the cooldown",
+			steps: []step{
+				{at: 0, op: "fail"},
+				{at: time.Second, op: "fail"},
+				{at: 2 * time.Second, op: "fail", locked: true},
+				{at: 61 * time.Second, op: "check", waiting: time.Second},
+				{at: 62 * time.Second, op: "check"},
+				// the lock started a fresh count
+				{at: 63 * time.Second, op: "fail"},
+				{at: 64 * time.Second, op: "check"},
+			},
+		},
+		{
+			name: "success clears failures",
+			steps: []step{
+				{at: 0, op: "fail"},
+				{at: time.Second, op: "fail"},
+				{at: 2 * time.Second, op: "succeed"},
+				{at: 3 * time.Second, op: "fail"},
+				{at: 4 * time.Second, op: "fail"},
+				{at: 5 * time.Second, op: "check"},
+			},
+		},
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := newAuthLimiter(3, time.Minute, time.Minute)
+			for i, s := range tc.steps {
+				now := start.Add(s.at)
+				switch s.op {
+				case "fail":
+					if locked := limiter.fail("alice", now); locked != s.locked {
+						t.Fatalf("step %d: fail locked = %v, want %v", i, locked, s.locked)
+					}
+				case "succeed":
+					limiter.succeed("alice")
+				case "check":
+					if wait := limiter.retryAfter("alice", now); wait != s.waiting {
+						t.Fatalf("step %d: retryAfter = %s, want %s", i, wait, s.waiting)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAuthLimiterPrunesStaleLogins(t *testing.T) {
+	limiter := newAuthLimiter(3, time.Minute, time.Minute)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, login := range []string{"a", "b", "c"} {
+		limiter.fail(login, start)
+	}
+	for range 3 {
+		limiter.fail("locked", start)
+	}
+
+	// a failure of another login a window later drops the stale entries
+	limiter.fail("d", start.Add(2*time.Minute))
+	if len(limiter.failures) != 1 || limiter.failures["d"] == nil {
+		t.Fatalf("failures kept for %d logins, want only d", len(limiter.failures))
+	}
+	if len(limiter.lockedUntil) != 0 {
+		t.Fatalf("expired lockouts kept: %v", limiter.lockedUntil)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
@@ -12,10 +13,21 @@ import (
 
 // StartServer starts the HTTP server with graceful shutdown
 func StartServer(config *ServerConfig) {
-	http.HandleFunc("/health", loggingMiddleware(healthHandler))
-	http.HandleFunc("/auth", loggingMiddleware(authHandler))
-	http.HandleFunc("/sync/profiles", loggingMiddleware(authMiddleware(syncProfilesListHandler)))
-	http.HandleFunc("/profiles/", loggingMiddleware(authMiddleware(syncProfileHandler)))
+	// profiles with embedded Core configs are large, so sync responses are compressed
+	gzipped := func(next http.HandlerFunc) http.HandlerFunc {
+		return gzipMiddleware(config.GzipMinSize, next)
+	}
+	// every API route is counted in /metrics under its pattern
+	handle := func(pattern string, handler http.HandlerFunc) {
+		http.HandleFunc(pattern, loggingMiddleware(metricsMiddleware(pattern, handler)))
+	}
+	handle("/health", healthHandler)
+	handle("/auth", authHandler)
+	handle("/refresh", authMiddleware(refreshHandler))
+	handle("/logout", authMiddleware(logoutHandler))
+	handle("/sync/profiles", gzipped(authMiddleware(syncProfilesListHandler)))
+	handle("/profiles/", gzipped(authMiddleware(syncProfileHandler)))
+	http.HandleFunc("/metrics", loggingMiddleware(metricsHandler))
 
 	server := &http.Server{
 		Addr:    config.ListenAddr,
@@ -57,5 +69,5 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`"OK"`))
+	json.NewEncoder(w).Encode(HealthDTO{Status: "OK", APIVersion: APIVersion})
 }
@@ -1,14 +1,44 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
 )
 
+// Token validation errors returned by Store.ValidateToken
+var (
+	errUnknownToken = errors.New("unknown token")
+	errExpiredToken = errors.New("expired token")
+)
+
+// Store is the in-memory storage of users, tokens and profiles.
+// Handlers run concurrently and the profiles watcher writes in the background,
+// so every access goes through the methods below.
+type Store struct {
+	mu       sync.RWMutex
+	users    map[string]*User
+	tokens   map[string]*AuthToken
+	profiles map[string]*Profile
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		users:    make(map[string]*User),
+		tokens:   make(map[string]*AuthToken),
+		profiles: make(map[string]*Profile),
+	}
+}
+
 // In-memory storage
 var (
-	users   = make(map[string]*User)
-	tokens  = make(map[string]*AuthToken)
-	profiles = make(map[string]*Profile)
+	store = NewStore()
+	// tokenTTL is the lifetime of new tokens; zero means tokens never expire
+	tokenTTL time.Duration
 )
 
 // InitStorage initializes the storage with config data
@@ -18,24 +48,179 @@ func InitStorage(config *ServerConfig, profileDTOs []ProfileDTO) {
 		ID:       config.UserLogin,
 		Login:    config.UserLogin,
 		Password: config.UserPassword,
+		Locked:   config.UserLocked,
 	}
-	users[user.Login] = user
+	store.AddUser(user)
+	tokenTTL = config.TokenTTL
+	loginLimiter = newAuthLimiter(config.AuthMaxFailures, config.AuthFailureWindow, config.AuthLockout)
 
 	// Add profiles
+	store.ReplaceProfiles(profileDTOs)
+
+	log.Printf("Loaded user %s, %d profiles", user.Login, len(profileDTOs))
+}
+
+// AddUser adds or replaces a user
+func (s *Store) AddUser(user *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.Login] = user
+}
+
+// GetUser returns the user with the given login
+func (s *Store) GetUser(login string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[login]
+	return user, ok
+}
+
+// AddToken stores an issued token
+func (s *Store) AddToken(token *AuthToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token.Value] = token
+}
+
+// ValidateToken returns the token if it is known and not expired; expired tokens are removed
+func (s *Store) ValidateToken(value string, now time.Time) (*AuthToken, error) {
+	s.mu.RLock()
+	token, ok := s.tokens[value]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, errUnknownToken
+	}
+	if token.Expired(now) {
+		s.RevokeToken(value)
+		return nil, errExpiredToken
+	}
+	return token, nil
+}
+
+// RevokeToken removes a token and returns it if it existed
+func (s *Store) RevokeToken(value string) (*AuthToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[value]
+	delete(s.tokens, value)
+	return token, ok
+}
+
+// ExchangeToken replaces a valid token with a new one carrying value.
+// The old token is checked and revoked under one lock, so concurrent
+// exchanges of the same token produce exactly one successor.
+func (s *Store) ExchangeToken(old, value string, now time.Time) (*AuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.tokens[old]
+	if !ok {
+		return nil, errUnknownToken
+	}
+	delete(s.tokens, old)
+	if current.Expired(now) {
+		return nil, errExpiredToken
+	}
+	token := newAuthToken(value, current.UserLogin, now)
+	s.tokens[token.Value] = token
+	return token, nil
+}
+
+// ActiveTokens counts tokens that are not expired at now
+func (s *Store) ActiveTokens(now time.Time) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	active := 0
+	for _, token := range s.tokens {
+		if !token.Expired(now) {
+			active++
+		}
+	}
+	return active
+}
+
+// ProfileCount returns the number of loaded profiles
+func (s *Store) ProfileCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.profiles)
+}
+
+// GetProfile returns the profile with the given ID
+func (s *Store) GetProfile(id string) (*Profile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profile, ok := s.profiles[id]
+	return profile, ok
+}
+
+// ListProfiles returns a snapshot of all profiles; entries are never modified in place
+func (s *Store) ListProfiles() []*Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]*Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		list = append(list, profile)
+	}
+	return list
+}
+
+// ReplaceProfiles swaps the profiles for the given set and reports
+// which profile IDs were added, changed and removed
+func (s *Store) ReplaceProfiles(profileDTOs []ProfileDTO) (added, changed, removed []string) {
+	next := make(map[string]*Profile, len(profileDTOs))
 	for _, dto := range profileDTOs {
-		profile := &Profile{
-			ID:           dto.ID,
-			Name:         dto.Name,
-			Country:      dto.Country,
-			Host:         dto.Host,
-			Port:         dto.Port,
-			CoreConfig:   dto.CoreConfig,
-			DirectRoutes: dto.DirectRoutes,
-			TunnelRoutes: dto.TunnelRoutes,
-			KillSwitch:  dto.KillSwitch,
+		next[dto.ID] = profileFromDTO(dto)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, profile := range next {
+		old, ok := s.profiles[id]
+		switch {
+		case !ok:
+			added = append(added, id)
+		case !reflect.DeepEqual(old, profile):
+			changed = append(changed, id)
+		}
+	}
+	for id := range s.profiles {
+		if _, ok := next[id]; !ok {
+			removed = append(removed, id)
 		}
-		profiles[profile.ID] = profile
 	}
+	s.profiles = next
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return added, changed, removed
+}
+
+// profileFromDTO converts a loaded profile file into the internal profile
+func profileFromDTO(dto ProfileDTO) *Profile {
+	return &Profile{
+		ID:           dto.ID,
+		Name:         dto.Name,
+		Country:      dto.Country,
+		Host:         dto.Host,
+		Port:         dto.Port,
+		CoreConfig:   dto.CoreConfig,
+		DirectRoutes: dto.DirectRoutes,
+		TunnelRoutes: dto.TunnelRoutes,
+		KillSwitch:   dto.KillSwitch,
+		EnableIPv6:   dto.EnableIPv6,
+		TunnelDNS:    dto.TunnelDNS,
+
+		PreConnectCmd:     dto.PreConnectCmd,
+		PostDisconnectCmd: dto.PostDisconnectCmd,
+		Status:            profileStatus(dto.Status),
+		RouteMetric:       dto.RouteMetric,
+	}
+}
 
-	log.Printf("Loaded %d users, %d profiles", len(users), len(profiles))
+// profileStatus applies the default to an omitted status
+func profileStatus(status string) string {
+	if status == "" {
+		return ProfileStatusActive
+	}
+	return status
 }
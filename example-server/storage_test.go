@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExchangeTokenConcurrent(t *testing.T) {
+	s := NewStore()
+	now := time.Now()
+	s.AddToken(newAuthToken("old", "user", now))
+
+	const workers = 16
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		winners  []string
+		rejected int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := s.ExchangeToken("old", fmt.Sprintf("new-%d", i), now)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				rejected++
+				return
+			}
+			winners = append(winners, token.Value)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(winners) != 1 || rejected != workers-1 {
+		t.Fatalf("winners = %v, rejected = %d; want exactly one exchange", winners, rejected)
+	}
+	if _, err := s.ValidateToken("old", now); err != errUnknownToken {
+		t.Fatalf("old token error = %v, want %v", err, errUnknownToken)
+	}
+	token, err := s.ValidateToken(winners[0], now)
+	if err != nil || token.UserLogin != "user" {
+		t.Fatalf("new token = %+v, %v; want valid token for user", token, err)
+	}
+	if active := s.ActiveTokens(now); active != 1 {
+		t.Fatalf("active tokens = %d, want 1", active)
+	}
+}
+
+func TestExchangeTokenRejectsExpired(t *testing.T) {
+	s := NewStore()
+	issued := time.Now()
+	expiresAt := issued.Add(time.Minute)
+	s.AddToken(&AuthToken{Value: "old", UserLogin: "user", IssuedAt: issued, ExpiresAt: &expiresAt})
+
+	if _, err := s.ExchangeToken("old", "new", issued.Add(2*time.Minute)); err != errExpiredToken {
+		t.Fatalf("error = %v, want %v", err, errExpiredToken)
+	}
+	if active := s.ActiveTokens(issued); active != 0 {
+		t.Fatalf("expired token was not removed, active = %d", active)
+	}
+}
@@ -14,12 +14,14 @@ func syncProfilesListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metrics.profileListFetches.Add(1)
 	var profileDTOs []ProfileSummaryDTO
-	for _, profile := range profiles {
+	for _, profile := range store.ListProfiles() {
 		dto := ProfileSummaryDTO{
 			ID:      profile.ID,
 			Name:    profile.Name,
 			Country: profile.Country,
+			Status:  profile.Status,
 		}
 		profileDTOs = append(profileDTOs, dto)
 	}
@@ -45,7 +47,7 @@ func syncProfileHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "profile id is required", http.StatusBadRequest)
 		return
 	}
-	profile, ok := profiles[id]
+	profile, ok := store.GetProfile(id)
 	if !ok {
 		http.Error(w, "profile not found", http.StatusNotFound)
 		return
@@ -60,7 +62,15 @@ func syncProfileHandler(w http.ResponseWriter, r *http.Request) {
 		DirectRoutes: profile.DirectRoutes,
 		TunnelRoutes: profile.TunnelRoutes,
 		KillSwitch:  profile.KillSwitch,
+		EnableIPv6:   profile.EnableIPv6,
+		TunnelDNS:    profile.TunnelDNS,
+
+		PreConnectCmd:     profile.PreConnectCmd,
+		PostDisconnectCmd: profile.PostDisconnectCmd,
+		Status:            profile.Status,
+		RouteMetric:       profile.RouteMetric,
 	}
+	metrics.profileFetches.Add(1)
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(dto); err != nil {
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// profilesReloadDelay collapses the burst of events an editor produces when saving a file
+const profilesReloadDelay = 300 * time.Millisecond
+
+// WatchProfiles reloads profiles whenever a JSON file in dir is created, changed or removed.
+// Clients see the changes on their next /sync/profiles without a server restart.
+func WatchProfiles(dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watchTree(watcher, dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		var reload *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Create) && isDir(event.Name) {
+					// a new or moved-in directory is watched too and may already hold profiles
+					if err := watchTree(watcher, event.Name); err != nil {
+						log.Printf("Profiles watcher: watch %s: %v", event.Name, err)
+					}
+				} else if filepath.Ext(event.Name) != ".json" || event.Op == fsnotify.Chmod {
+					continue
+				}
+				log.Printf("Profiles watcher: %s %s", event.Op, event.Name)
+				if reload != nil {
+					reload.Stop()
+				}
+				reload = time.AfterFunc(profilesReloadDelay, func() { reloadProfiles(dir) })
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Profiles watcher error: %v", err)
+			}
+		}
+	}()
+	log.Printf("Watching %s for profile changes", dir)
+	return nil
+}
+
+// watchTree adds root and every directory below it: fsnotify is not recursive,
+// so every directory LoadProfiles walks is watched
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// reloadProfiles re-reads the profiles directory; on error the current profiles are kept,
+// so a half-written file does not wipe them
+func reloadProfiles(dir string) {
+	profileDTOs, err := LoadProfiles(dir)
+	if err != nil {
+		log.Printf("Profiles reload failed, keeping current profiles: %v", err)
+		return
+	}
+	added, changed, removed := store.ReplaceProfiles(profileDTOs)
+	for _, id := range added {
+		log.Printf("Profile added: %s", id)
+	}
+	for _, id := range changed {
+		log.Printf("Profile changed: %s", id)
+	}
+	for _, id := range removed {
+		log.Printf("Profile removed: %s", id)
+	}
+	log.Printf("Profiles reloaded: %d total", len(profileDTOs))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// setupProfiles replaces the global store with an empty one for the test
+func setupProfiles(t *testing.T) {
+	t.Helper()
+	prevStore := store
+	t.Cleanup(func() { store = prevStore })
+	store = NewStore()
+}
+
+func writeProfile(t *testing.T, dir string, dto ProfileDTO) {
+	t.Helper()
+	data, err := json.Marshal(dto)
+	if err != nil {
+		t.Fatalf("marshal %s: %v", dto.ID, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, dto.ID+".json"), data, 0o644); err != nil {
+		t.Fatalf("write %s: %v", dto.ID, err)
+	}
+}
+
+func testProfile(id string) ProfileDTO {
+	return ProfileDTO{ID: id, Name: "Profile " + id, Host: "203.0.113.10", Port: 443}
+}
+
+func storedProfileIDs() []string {
+	var ids []string
+	for _, profile := range store.ListProfiles() {
+		ids = append(ids, profile.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestReplaceProfilesReportsChanges(t *testing.T) {
+	setupProfiles(t)
+	store.ReplaceProfiles([]ProfileDTO{testProfile("keep"), testProfile("edit"), testProfile("drop")})
+
+	edited := testProfile("edit")
+	edited.Port = 8443
+	added, changed, removed := store.ReplaceProfiles([]ProfileDTO{testProfile("keep"), edited, testProfile("new")})
+
+	if !slices.Equal(added, []string{"new"}) || !slices.Equal(changed, []string{"edit"}) || !slices.Equal(removed, []string{"drop"}) {
+		t.Fatalf("added %q, changed %q, removed %q; want [new], [edit], [drop]", added, changed, removed)
+	}
+	if got := storedProfileIDs(); !slices.Equal(got, []string{"edit", "keep", "new"}) {
+		t.Fatalf("stored profiles = %q", got)
+	}
+	if profile, _ := store.GetProfile("edit"); profile.Port != 8443 {
+		t.Fatalf("edited profile port = %d, want 8443", profile.Port)
+	}
+}
+
+func TestReloadProfilesKeepsCurrentOnLoadFailure(t *testing.T) {
+	setupProfiles(t)
+	dir := t.TempDir()
+	writeProfile(t, dir, testProfile("p1"))
+	writeProfile(t, dir, testProfile("p2"))
+	reloadProfiles(dir)
+	if got := storedProfileIDs(); !slices.Equal(got, []string{"p1", "p2"}) {
+		t.Fatalf("profiles after reload = %q, want [p1 p2]", got)
+	}
+
+	// a half-written file fails the whole load: the profiles loaded earlier stay
+	if err := os.Remove(filepath.Join(dir, "p2.json")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "p3.json"), []byte(`{"id": "p3",`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	reloadProfiles(dir)
+	if got := storedProfileIDs(); !slices.Equal(got, []string{"p1", "p2"}) {
+		t.Fatalf("profiles after failed reload = %q, want [p1 p2]", got)
+	}
+
+	writeProfile(t, dir, testProfile("p3"))
+	reloadProfiles(dir)
+	if got := storedProfileIDs(); !slices.Equal(got, []string{"p1", "p3"}) {
+		t.Fatalf("profiles after fixed reload = %q, want [p1 p3]", got)
+	}
+}
+
+func TestWatchProfilesPicksUpNewDirectory(t *testing.T) {
+	setupProfiles(t)
+	dir := t.TempDir()
+	if err := WatchProfiles(dir); err != nil {
+		t.Fatalf("WatchProfiles: %v", err)
+	}
+
+	sub := filepath.Join(dir, "eu")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeProfile(t, sub, testProfile("p1"))
+	waitForProfiles(t, []string{"p1"})
+
+	// a file created later in the new directory is seen only if the directory is watched
+	writeProfile(t, sub, testProfile("p2"))
+	waitForProfiles(t, []string{"p1", "p2"})
+}
+
+func waitForProfiles(t *testing.T, want []string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(storedProfileIDs(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("profiles = %q, want %q", storedProfileIDs(), want)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}